@@ -1,22 +1,66 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/MauricioGiaconia/uala_backend_challenge/internal/routes"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/services"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
 	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/db"
 	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/factory"
+	redisdb "github.com/MauricioGiaconia/uala_backend_challenge/pkg/redis_db"
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
 
+	// Subcomando para administrar las migraciones del esquema: api migrate [flags] up|down|status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
+	// Subcomando para copiar una db SQLite en disco a otro archivo: api snapshot -sqlite-path <db> <archivo>
+	if len(os.Args) > 1 && os.Args[1] == "snapshot" {
+		runSnapshot(os.Args[2:])
+		return
+	}
+
 	//Obtengo el tipo de db y port a utilizar por linea de comandos, usando la flag -db y -port
 	dbType := flag.String("db", "sqlite", "Tipo de base de datos a usar (postgres, sqlite)")
+	cacheType := flag.String("cache", "memory", "Tipo de cache a usar (redis, memory, none)")
+	cacheSize := flag.Int("cache-size", cache.DefaultMemoryCacheSize, "Cantidad maxima de claves del cache en memoria")
 	port := flag.String("port", "8080", "Puerto a utilizar") //Por defecto se usa el puerto 8080
+	sqlitePath := flag.String("sqlite-path", "", "Archivo de la db SQLite (modo WAL), vacio para usar una db en memoria")
+	sqliteRestore := flag.String("sqlite-restore", "", "Snapshot de SQLite a cargar al iniciar la API, reemplazando el contenido de la db")
+	sqliteSnapshotDir := flag.String("sqlite-snapshot-dir", services.SQLiteSnapshotDir, "Directorio donde se guardan y se buscan los snapshots de SQLite de /admin/db")
+	likeFlushInterval := flag.Duration("like-flush-interval", services.LikeCountFlushInterval, "Cada cuanto se vuelcan a la db sql los likes acumulados en cache")
+	fanOutThreshold := flag.Int64("fanout-threshold", services.FanOutFollowersThreshold, "Cantidad de seguidores a partir de la cual los tweets de un usuario no se distribuyen en los feeds de sus seguidores")
+	wsPingInterval := flag.Duration("ws-ping-interval", services.RealtimePingInterval, "Cada cuanto se envia un ping a las conexiones WebSocket")
+	wsReadTimeout := flag.Duration("ws-read-timeout", services.RealtimeReadTimeout, "Tiempo maximo sin recibir mensajes de una conexion WebSocket antes de cerrarla")
+	wsSendQueueSize := flag.Int("ws-send-queue-size", services.RealtimeSendQueueSize, "Cantidad de eventos encolados por conexion WebSocket antes de cerrarla por no consumirlos")
+	wsMaxSubscriptions := flag.Int("ws-max-subscriptions", services.RealtimeMaxSubscriptions, "Cantidad maxima de canales por conexion WebSocket")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 10*time.Second, "Tiempo maximo para cerrar las conexiones abiertas al apagar el servidor")
+	migrateOnStart := flag.Bool("migrate-on-start", false, "Aplica las migraciones pendientes de PostgreSQL al iniciar la API (SQLite siempre las aplica al conectar)")
+
+	// La conexion a Redis se toma de las variables de entorno REDIS_* y se puede sobreescribir con las flags -redis-*
+	redisConfig, err := redisdb.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("[x] Invalid Redis configuration: %v", err)
+	}
+	redisdb.BindFlags(flag.CommandLine, &redisConfig)
+
 	flag.Parse()
 
 	portNum, err := strconv.Atoi(*port)
@@ -28,7 +72,50 @@ func main() {
 		log.Fatalf("[x] Invalid dbType")
 	}
 
-	dbInstance, err := factory.GetDatabase(*dbType)
+	if *cacheType != "redis" && *cacheType != "memory" && *cacheType != "none" {
+		log.Fatalf("[x] Invalid cache type")
+	}
+
+	if *cacheSize < 1 {
+		log.Fatalf("[x] Invalid cache-size")
+	}
+
+	if *fanOutThreshold < 0 {
+		log.Fatalf("[x] Invalid fanout-threshold")
+	}
+	services.FanOutFollowersThreshold = *fanOutThreshold
+
+	if *likeFlushInterval <= 0 {
+		log.Fatalf("[x] Invalid like-flush-interval")
+	}
+
+	// El read timeout debe superar al intervalo de ping para que el cliente alcance a responder el pong
+	if *wsPingInterval <= 0 || *wsReadTimeout <= *wsPingInterval {
+		log.Fatalf("[x] Invalid ws-ping-interval or ws-read-timeout")
+	}
+	services.RealtimePingInterval = *wsPingInterval
+	services.RealtimeReadTimeout = *wsReadTimeout
+
+	if *wsSendQueueSize < 1 {
+		log.Fatalf("[x] Invalid ws-send-queue-size")
+	}
+	services.RealtimeSendQueueSize = *wsSendQueueSize
+
+	if *wsMaxSubscriptions < 1 {
+		log.Fatalf("[x] Invalid ws-max-subscriptions")
+	}
+	services.RealtimeMaxSubscriptions = *wsMaxSubscriptions
+
+	if *shutdownTimeout <= 0 {
+		log.Fatalf("[x] Invalid shutdown-timeout")
+	}
+
+	if *dbType != "sqlite" && (*sqlitePath != "" || *sqliteRestore != "") {
+		log.Fatalf("[x] sqlite-path and sqlite-restore can only be used with SQLite")
+	}
+	services.SQLiteSnapshotDir = *sqliteSnapshotDir
+
+	dbInstance, err := factory.GetDatabase(*dbType, factory.DatabaseOptions{SQLitePath: *sqlitePath})
 
 	if err != nil {
 		log.Fatalf("[x] Error getting database instance: %v", err)
@@ -40,24 +127,203 @@ func main() {
 		log.Fatalf("[x] Error connecting to database: %v", err)
 	}
 
-	redisClient, err := factory.GetCache("redis")
-	if err != nil {
-		fmt.Println("API working without redis: %v", err.Error())
-		redisClient = nil
-	} else {
-		fmt.Println("API working with redis!")
-		defer redisClient.Close()
+	if *sqliteRestore != "" {
+		if err := db.RestoreSQLite(dbConn, *sqliteRestore); err != nil {
+			log.Fatalf("[x] Error restoring SQLite snapshot: %v", err)
+		}
+		fmt.Printf("[x] SQLite snapshot %s restored\n", *sqliteRestore)
+	}
+
+	// SQLite aplica las migraciones al conectar. Las de PostgreSQL se aplican con el subcomando "migrate"
+	// o al iniciar con -migrate-on-start; con cualquiera de los dos la API no inicia si quedan migraciones pendientes
+	checkMigrations(dbConn, dbInstance.Dialect(), *migrateOnStart)
+
+	// Con -cache none la API funciona sin cache, consultando siempre la db sql
+	var cacheInstance cache.Cache
+	if *cacheType != "none" {
+		cacheInstance, err = factory.GetCache(*cacheType, factory.CacheOptions{Redis: redisConfig, MemoryMaxEntries: *cacheSize})
+		if err != nil {
+			log.Fatalf("[x] Error getting cache instance: %v", err)
+		}
+		defer cacheInstance.Close()
+
+		// Los likes se acumulan en cache y se vuelcan de forma periodica a la db sql
+		stopLikeFlusher := services.StartLikeCountFlusher(dbConn, cacheInstance, *likeFlushInterval)
+		defer stopLikeFlusher()
 	}
+	fmt.Printf("API working with cache: %s\n", *cacheType)
 
 	router := gin.Default()
 
 	// Configurar las rutas
-	routes.SetupRoutes(router, dbConn, redisClient)
+	shutdownRealtime := routes.SetupRoutes(router, dbConn, cacheInstance)
 
 	defer db.CloseDatabase(dbConn)
 
-	address := fmt.Sprintf(":%s", *port)
-	if err := router.Run(address); err != nil {
+	server := &http.Server{Addr: fmt.Sprintf(":%s", *port), Handler: router}
+
+	// Con SIGINT o SIGTERM se dejan de aceptar conexiones y se espera a que terminen las requests en curso,
+	// para que se ejecuten los defer que vuelcan los likes y cierran el cache y la db
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
 		log.Fatalf("[x] Failed to start server: %v", err)
+	case <-stop:
+	}
+
+	fmt.Println("[x] Shutting down server")
+
+	ctx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+
+	// Las conexiones WebSocket no las cierra server.Shutdown, se les avisa a los clientes que el servidor se apaga
+	if err := shutdownRealtime(ctx); err != nil {
+		fmt.Printf("Error closing WebSocket connections: %v\n", err)
+	}
+
+	if err := server.Shutdown(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Printf("Error shutting down server: %v\n", err)
+	}
+}
+
+// checkMigrations aplica las migraciones pendientes si se indico -migrate-on-start y detiene la API si quedan pendientes,
+// para no atender requests con un esquema desactualizado
+func checkMigrations(dbConn *sql.DB, dialect string, migrateOnStart bool) {
+	if migrateOnStart {
+		applied, err := db.MigrateUp(dbConn, dialect, 0)
+		if err != nil {
+			log.Fatalf("[x] Error applying migrations: %v", err)
+		}
+		printMigrations("Applied", applied)
+	}
+
+	pending, err := db.CountPendingMigrations(dbConn, dialect)
+	if err != nil {
+		log.Fatalf("[x] Cannot check database migrations: %v", err)
 	}
+
+	if pending > 0 {
+		log.Fatalf("[x] %d pending database migrations, run \"api migrate -db %s up\" or start the API with -migrate-on-start", pending, dialect)
+	}
+}
+
+// runMigrate implementa el subcomando "api migrate [-db postgres] [-sqlite-path archivo] [-steps N] up|down|status"
+func runMigrate(args []string) {
+	migrateFlags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dbType := migrateFlags.String("db", "sqlite", "Tipo de base de datos a migrar (postgres, sqlite)")
+	sqlitePath := migrateFlags.String("sqlite-path", "", "Archivo de la db SQLite a migrar")
+	steps := migrateFlags.Int("steps", 0, "Cantidad de migraciones a aplicar o revertir (up: todas por defecto, down: 1 por defecto)")
+	migrateFlags.Usage = func() {
+		fmt.Fprintf(migrateFlags.Output(), "Uso: api migrate [flags] up|down|status\n")
+		migrateFlags.PrintDefaults()
+	}
+	migrateFlags.Parse(args)
+
+	if migrateFlags.NArg() != 1 {
+		migrateFlags.Usage()
+		os.Exit(2)
+	}
+
+	command := migrateFlags.Arg(0)
+	if command != "up" && command != "down" && command != "status" {
+		log.Fatalf("[x] Invalid migrate command: %s", command)
+	}
+
+	if *steps < 0 {
+		log.Fatalf("[x] Invalid steps")
+	}
+
+	// La conexion no aplica las migraciones de SQLite: el subcomando decide cuales se aplican o revierten y status solo las lee
+	dbInstance, err := factory.GetDatabase(*dbType, factory.DatabaseOptions{SQLitePath: *sqlitePath, SQLiteSkipMigrations: true})
+	if err != nil {
+		log.Fatalf("[x] Error getting database instance: %v", err)
+	}
+
+	dbConn, err := dbInstance.Connect()
+	if err != nil {
+		log.Fatalf("[x] Error connecting to database: %v", err)
+	}
+	defer db.CloseDatabase(dbConn)
+
+	switch command {
+	case "up":
+		applied, err := db.MigrateUp(dbConn, dbInstance.Dialect(), *steps)
+		printMigrations("Applied", applied)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+	case "down":
+		if *steps == 0 {
+			*steps = 1
+		}
+		reverted, err := db.MigrateDown(dbConn, dbInstance.Dialect(), *steps)
+		printMigrations("Reverted", reverted)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+	case "status":
+		statuses, err := db.GetMigrationsStatus(dbConn, dbInstance.Dialect())
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = fmt.Sprintf("applied at %s", status.AppliedAt.Format("2006-01-02 15:04:05"))
+			}
+			fmt.Printf("%04d_%s: %s\n", status.Version, status.Name, state)
+		}
+	}
+}
+
+func printMigrations(action string, migrations []db.Migration) {
+	if len(migrations) == 0 {
+		fmt.Printf("[x] %s 0 migrations\n", action)
+		return
+	}
+
+	for _, migration := range migrations {
+		fmt.Printf("[x] %s %04d_%s\n", action, migration.Version, migration.Name)
+	}
+}
+
+// runSnapshot implementa el subcomando "api snapshot -sqlite-path <db> <archivo>". Sirve para respaldar una db SQLite
+// en disco; la db en memoria solo existe dentro del proceso de la API, por lo que se respalda con POST /admin/db/snapshot
+func runSnapshot(args []string) {
+	snapshotFlags := flag.NewFlagSet("snapshot", flag.ExitOnError)
+	sqlitePath := snapshotFlags.String("sqlite-path", "", "Archivo de la db SQLite a respaldar")
+	snapshotFlags.Usage = func() {
+		fmt.Fprintf(snapshotFlags.Output(), "Uso: api snapshot -sqlite-path <db> <archivo>\n")
+		snapshotFlags.PrintDefaults()
+	}
+	snapshotFlags.Parse(args)
+
+	if *sqlitePath == "" || snapshotFlags.NArg() != 1 {
+		snapshotFlags.Usage()
+		os.Exit(2)
+	}
+
+	dbInstance, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{SQLitePath: *sqlitePath})
+	if err != nil {
+		log.Fatalf("[x] Error getting database instance: %v", err)
+	}
+
+	dbConn, err := dbInstance.Connect()
+	if err != nil {
+		log.Fatalf("[x] Error connecting to database: %v", err)
+	}
+	defer db.CloseDatabase(dbConn)
+
+	if err := db.SnapshotSQLite(dbConn, snapshotFlags.Arg(0)); err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	fmt.Printf("[x] SQLite snapshot saved to %s\n", snapshotFlags.Arg(0))
 }
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/services"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type AdminController struct {
+	AdminService *services.AdminService
+}
+
+func NewAdminController(db *sql.DB, cacheClient cache.Cache) *AdminController {
+	adminService := services.NewAdminService(db, cacheClient)
+	return &AdminController{AdminService: adminService}
+}
+
+// CreateSnapshotHandler maneja la solicitud de guardar un snapshot de la db SQLite en un archivo
+func (ac *AdminController) CreateSnapshotHandler(c *gin.Context) {
+	var request models.SnapshotRequest
+
+	// El body es opcional, sin nombre se genera uno a partir de la fecha
+	if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Error decoding body", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	snapshot, err := ac.AdminService.CreateSnapshot(request.Name)
+
+	if err != nil {
+		if err.Error() == "Snapshots are only supported with SQLite" || err.Error() == "Invalid snapshot name" {
+			badResponse := utils.ResponseToApi(http.StatusBadRequest, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusBadRequest, badResponse)
+			return
+		}
+
+		if err.Error() == "Snapshot already exists" {
+			conflictResponse := utils.ResponseToApi(http.StatusConflict, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusConflict, conflictResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	response := utils.ResponseToApi(http.StatusCreated, snapshot, false, 0, 0, 0)
+	c.JSON(http.StatusCreated, response)
+}
+
+// RestoreSnapshotHandler maneja la solicitud de reemplazar la db SQLite por un snapshot guardado
+func (ac *AdminController) RestoreSnapshotHandler(c *gin.Context) {
+	var request models.SnapshotRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil || request.Name == "" {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Snapshot name is required", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	snapshot, err := ac.AdminService.RestoreSnapshot(request.Name)
+
+	if err != nil {
+		if err.Error() == "Snapshots are only supported with SQLite" || err.Error() == "Invalid snapshot name" {
+			badResponse := utils.ResponseToApi(http.StatusBadRequest, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusBadRequest, badResponse)
+			return
+		}
+
+		if err.Error() == "Snapshot not found" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusNotFound, notFoundResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	response := utils.ResponseToApi(http.StatusOK, snapshot, false, 0, 0, 0)
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/services"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type AuthController struct {
+	AuthService *services.AuthService
+}
+
+func NewAuthController(db *sql.DB) *AuthController {
+	authService := services.NewAuthService(db)
+	return &AuthController{AuthService: authService}
+}
+
+// LoginHandler maneja la solicitud de login, retornando los tokens de acceso del usuario
+func (ac *AuthController) LoginHandler(c *gin.Context) {
+	var credentials models.LoginRequest
+
+	if err := c.ShouldBindJSON(&credentials); err != nil {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Error decoding body", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	if credentials.Email == "" || credentials.Password == "" {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Email and password are required", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	tokens, err := ac.AuthService.Login(&credentials)
+
+	if err != nil {
+		if err.Error() == "Invalid email or password" {
+			unauthorizedResponse := utils.ResponseToApi(http.StatusUnauthorized, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusUnauthorized, unauthorizedResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	response := utils.ResponseToApi(http.StatusOK, tokens, false, 0, 0, 0)
+	c.JSON(http.StatusOK, response)
+}
+
+// RefreshHandler maneja la solicitud de renovar los tokens a partir de un refresh token
+func (ac *AuthController) RefreshHandler(c *gin.Context) {
+	var request models.RefreshRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil || request.RefreshToken == "" {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Refresh token is required", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	tokens, err := ac.AuthService.RefreshTokens(request.RefreshToken)
+
+	if err != nil {
+		if err.Error() == "Invalid or expired token" {
+			unauthorizedResponse := utils.ResponseToApi(http.StatusUnauthorized, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusUnauthorized, unauthorizedResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	response := utils.ResponseToApi(http.StatusOK, tokens, false, 0, 0, 0)
+	c.JSON(http.StatusOK, response)
+}
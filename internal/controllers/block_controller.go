@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/middlewares"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/services"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type BlockController struct {
+	BlockService *services.BlockService
+}
+
+func NewBlockController(db *sql.DB, cacheClient cache.Cache, broadcaster cache.Broadcaster) *BlockController {
+	blockService := services.NewBlockService(db, cacheClient)
+	blockService.Broadcaster = broadcaster
+	return &BlockController{BlockService: blockService}
+}
+
+// BlockUserHandler maneja la solicitud del usuario autenticado de bloquear a otro usuario
+func (bc *BlockController) BlockUserHandler(c *gin.Context) {
+	userId, targetId, ok := getTargetUserParams(c, "block")
+
+	if !ok {
+		return
+	}
+
+	_, err := bc.BlockService.BlockUser(userId, targetId)
+
+	if err != nil {
+		if err.Error() == "Nonexistent user" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusNotFound, notFoundResponse)
+			return
+		}
+
+		if err.Error() == "User already blocked" {
+			conflictResponse := utils.ResponseToApi(http.StatusConflict, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusConflict, conflictResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	response := utils.ResponseToApi(http.StatusCreated, "User blocked", false, 0, 0, 0)
+	c.JSON(http.StatusCreated, response)
+}
+
+// UnblockUserHandler maneja la solicitud del usuario autenticado de desbloquear a otro usuario
+func (bc *BlockController) UnblockUserHandler(c *gin.Context) {
+	userId, targetId, ok := getTargetUserParams(c, "unblock")
+
+	if !ok {
+		return
+	}
+
+	_, err := bc.BlockService.UnblockUser(userId, targetId)
+
+	if err != nil {
+		if err.Error() == "Block not found" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusNotFound, notFoundResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	response := utils.ResponseToApi(http.StatusOK, "User unblocked", false, 0, 0, 0)
+	c.JSON(http.StatusOK, response)
+}
+
+// getTargetUserParams obtiene el usuario (:id) y el usuario sobre el que actua (:target_id) de las rutas de bloqueos y silenciamientos.
+// El usuario debe ser el autenticado y no puede actuar sobre si mismo. Si los parametros no son validos responde el error y retorna false
+func getTargetUserParams(c *gin.Context, action string) (int64, int64, bool) {
+	userId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	if err != nil || userId <= 0 {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid user or target ID", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return 0, 0, false
+	}
+
+	targetId, err := strconv.ParseInt(c.Param("target_id"), 10, 64)
+
+	if err != nil || targetId <= 0 {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid user or target ID", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return 0, 0, false
+	}
+
+	authUserId, ok := middlewares.GetAuthUserId(c)
+
+	if !ok || authUserId != userId {
+		forbiddenResponse := utils.ResponseToApi(http.StatusForbidden, fmt.Sprintf("Cannot %s on behalf of another user", action), false, 0, 0, 0)
+		c.JSON(http.StatusForbidden, forbiddenResponse)
+		return 0, 0, false
+	}
+
+	if userId == targetId {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, fmt.Sprintf("Cannot %s yourself", action), false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return 0, 0, false
+	}
+
+	return userId, targetId, true
+}
@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/middlewares"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/services"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type BookmarkController struct {
+	BookmarkService *services.BookmarkService
+}
+
+func NewBookmarkController(db *sql.DB, cacheClient cache.Cache) *BookmarkController {
+	bookmarkService := services.NewBookmarkService(db, cacheClient)
+	return &BookmarkController{BookmarkService: bookmarkService}
+}
+
+// BookmarkTweetHandler maneja la solicitud de guardar un tweet en los bookmarks del usuario autenticado
+func (bc *BookmarkController) BookmarkTweetHandler(c *gin.Context) {
+	tweetId, err := strconv.ParseInt(c.Param("tweet_id"), 10, 64)
+
+	if err != nil || tweetId <= 0 {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid tweet ID", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	authUserId, ok := middlewares.GetAuthUserId(c)
+
+	if !ok {
+		unauthorizedResponse := utils.ResponseToApi(http.StatusUnauthorized, "Missing authorization token", false, 0, 0, 0)
+		c.JSON(http.StatusUnauthorized, unauthorizedResponse)
+		return
+	}
+
+	_, err = bc.BookmarkService.BookmarkTweet(tweetId, authUserId)
+
+	if err != nil {
+		if err.Error() == "This account is private" {
+			forbiddenResponse := utils.ResponseToApi(http.StatusForbidden, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusForbidden, forbiddenResponse)
+			return
+		}
+
+		if err.Error() == "Nonexistent tweet" || err.Error() == "Nonexistent user" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusNotFound, notFoundResponse)
+			return
+		}
+
+		if err.Error() == "Tweet already bookmarked" {
+			conflictResponse := utils.ResponseToApi(http.StatusConflict, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusConflict, conflictResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	response := utils.ResponseToApi(http.StatusCreated, "Tweet bookmarked", false, 0, 0, 0)
+	c.JSON(http.StatusCreated, response)
+}
+
+// DeleteBookmarkHandler maneja la solicitud de quitar un tweet de los bookmarks del usuario autenticado
+func (bc *BookmarkController) DeleteBookmarkHandler(c *gin.Context) {
+	tweetId, err := strconv.ParseInt(c.Param("tweet_id"), 10, 64)
+
+	if err != nil || tweetId <= 0 {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid tweet ID", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	authUserId, ok := middlewares.GetAuthUserId(c)
+
+	if !ok {
+		unauthorizedResponse := utils.ResponseToApi(http.StatusUnauthorized, "Missing authorization token", false, 0, 0, 0)
+		c.JSON(http.StatusUnauthorized, unauthorizedResponse)
+		return
+	}
+
+	_, err = bc.BookmarkService.DeleteBookmark(tweetId, authUserId)
+
+	if err != nil {
+		if err.Error() == "Nonexistent tweet" || err.Error() == "Bookmark not found" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusNotFound, notFoundResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	response := utils.ResponseToApi(http.StatusOK, "Bookmark deleted", false, 0, 0, 0)
+	c.JSON(http.StatusOK, response)
+}
+
+// GetBookmarksHandler maneja la solicitud de obtener los bookmarks de un usuario. Los bookmarks son privados,
+// por lo que solo los puede consultar el propio usuario autenticado
+func (bc *BookmarkController) GetBookmarksHandler(c *gin.Context) {
+	userId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	if err != nil || userId <= 0 {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid user ID", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	authUserId, ok := middlewares.GetAuthUserId(c)
+
+	if !ok {
+		unauthorizedResponse := utils.ResponseToApi(http.StatusUnauthorized, "Missing authorization token", false, 0, 0, 0)
+		c.JSON(http.StatusUnauthorized, unauthorizedResponse)
+		return
+	}
+
+	if authUserId != userId {
+		forbiddenResponse := utils.ResponseToApi(http.StatusForbidden, "Cannot get the bookmarks of another user", false, 0, 0, 0)
+		c.JSON(http.StatusForbidden, forbiddenResponse)
+		return
+	}
+
+	limit, offset, cursor, err := getPaginationParams(c)
+
+	if err != nil {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	bookmarks, totalBookmarks, err := bc.BookmarkService.GetBookmarks(userId, limit, offset, cursor)
+
+	if err != nil {
+		if err.Error() == "Nonexistent user" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusNotFound, notFoundResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	response := utils.ResponseCursorListToApi(http.StatusOK, bookmarks, totalBookmarks, limit, offset, c.Query("cursor"), nextBookmarksCursor(bookmarks, limit))
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/middlewares"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/services"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type LikeController struct {
+	LikeService *services.LikeService
+}
+
+func NewLikeController(db *sql.DB, cacheClient cache.Cache, broadcaster cache.Broadcaster) *LikeController {
+	likeService := services.NewLikeService(db, cacheClient)
+	likeService.Broadcaster = broadcaster
+	return &LikeController{LikeService: likeService}
+}
+
+// LikeTweetHandler maneja la solicitud de dar like a un tweet en nombre del usuario autenticado
+func (lc *LikeController) LikeTweetHandler(c *gin.Context) {
+	tweetId, err := strconv.ParseInt(c.Param("tweet_id"), 10, 64)
+
+	if err != nil || tweetId <= 0 {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid tweet ID", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	authUserId, ok := middlewares.GetAuthUserId(c)
+
+	if !ok {
+		unauthorizedResponse := utils.ResponseToApi(http.StatusUnauthorized, "Missing authorization token", false, 0, 0, 0)
+		c.JSON(http.StatusUnauthorized, unauthorizedResponse)
+		return
+	}
+
+	_, err = lc.LikeService.LikeTweet(tweetId, authUserId)
+
+	if err != nil {
+		if err.Error() == "This account is private" {
+			forbiddenResponse := utils.ResponseToApi(http.StatusForbidden, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusForbidden, forbiddenResponse)
+			return
+		}
+
+		if err.Error() == "Nonexistent tweet" || err.Error() == "Nonexistent user" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusNotFound, notFoundResponse)
+			return
+		}
+
+		if err.Error() == "Tweet already liked" {
+			conflictResponse := utils.ResponseToApi(http.StatusConflict, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusConflict, conflictResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	response := utils.ResponseToApi(http.StatusCreated, "Tweet liked", false, 0, 0, 0)
+	c.JSON(http.StatusCreated, response)
+}
+
+// UnlikeTweetHandler maneja la solicitud de quitar el like del usuario autenticado a un tweet
+func (lc *LikeController) UnlikeTweetHandler(c *gin.Context) {
+	tweetId, err := strconv.ParseInt(c.Param("tweet_id"), 10, 64)
+
+	if err != nil || tweetId <= 0 {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid tweet ID", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	authUserId, ok := middlewares.GetAuthUserId(c)
+
+	if !ok {
+		unauthorizedResponse := utils.ResponseToApi(http.StatusUnauthorized, "Missing authorization token", false, 0, 0, 0)
+		c.JSON(http.StatusUnauthorized, unauthorizedResponse)
+		return
+	}
+
+	_, err = lc.LikeService.UnlikeTweet(tweetId, authUserId)
+
+	if err != nil {
+		if err.Error() == "Nonexistent tweet" || err.Error() == "Like not found" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusNotFound, notFoundResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	response := utils.ResponseToApi(http.StatusOK, "Like deleted", false, 0, 0, 0)
+	c.JSON(http.StatusOK, response)
+}
+
+// GetTweetLikesHandler maneja la solicitud de obtener los usuarios que dieron like a un tweet, paginados por offset
+func (lc *LikeController) GetTweetLikesHandler(c *gin.Context) {
+	tweetId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	if err != nil || tweetId <= 0 {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid tweet ID", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	limit, offset, err := getOffsetPaginationParams(c)
+
+	if err != nil {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	viewerId, _ := middlewares.GetAuthUserId(c)
+
+	likes, totalLikes, err := lc.LikeService.GetTweetLikes(tweetId, viewerId, limit, offset)
+
+	if err != nil {
+		if err.Error() == "This account is private" {
+			forbiddenResponse := utils.ResponseToApi(http.StatusForbidden, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusForbidden, forbiddenResponse)
+			return
+		}
+
+		if err.Error() == "Nonexistent tweet" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusNotFound, notFoundResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	response := utils.ResponseToApi(http.StatusOK, likes, true, totalLikes, limit, offset)
+	c.JSON(http.StatusOK, response)
+}
+
+// GetUserLikesHandler maneja la solicitud de obtener los tweets a los que un usuario dio like, paginados por offset
+func (lc *LikeController) GetUserLikesHandler(c *gin.Context) {
+	userId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	if err != nil || userId <= 0 {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid user ID", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	limit, offset, err := getOffsetPaginationParams(c)
+
+	if err != nil {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	// Sin token se consulta como anonimo y solo se incluyen los tweets de cuentas publicas
+	viewerId, _ := middlewares.GetAuthUserId(c)
+
+	tweets, totalTweets, err := lc.LikeService.GetUserLikes(userId, viewerId, limit, offset)
+
+	if err != nil {
+		if err.Error() == "Nonexistent user" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusNotFound, notFoundResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	response := utils.ResponseToApi(http.StatusOK, tweets, true, totalTweets, limit, offset)
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/services"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type MuteController struct {
+	MuteService *services.MuteService
+}
+
+func NewMuteController(db *sql.DB, cacheClient cache.Cache) *MuteController {
+	muteService := services.NewMuteService(db, cacheClient)
+	return &MuteController{MuteService: muteService}
+}
+
+// MuteUserHandler maneja la solicitud del usuario autenticado de silenciar a otro usuario
+func (mc *MuteController) MuteUserHandler(c *gin.Context) {
+	userId, targetId, ok := getTargetUserParams(c, "mute")
+
+	if !ok {
+		return
+	}
+
+	_, err := mc.MuteService.MuteUser(userId, targetId)
+
+	if err != nil {
+		if err.Error() == "Nonexistent user" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusNotFound, notFoundResponse)
+			return
+		}
+
+		if err.Error() == "User already muted" {
+			conflictResponse := utils.ResponseToApi(http.StatusConflict, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusConflict, conflictResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	response := utils.ResponseToApi(http.StatusCreated, "User muted", false, 0, 0, 0)
+	c.JSON(http.StatusCreated, response)
+}
+
+// UnmuteUserHandler maneja la solicitud del usuario autenticado de dejar de silenciar a otro usuario
+func (mc *MuteController) UnmuteUserHandler(c *gin.Context) {
+	userId, targetId, ok := getTargetUserParams(c, "unmute")
+
+	if !ok {
+		return
+	}
+
+	_, err := mc.MuteService.UnmuteUser(userId, targetId)
+
+	if err != nil {
+		if err.Error() == "Mute not found" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusNotFound, notFoundResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	response := utils.ResponseToApi(http.StatusOK, "User unmuted", false, 0, 0, 0)
+	c.JSON(http.StatusOK, response)
+}
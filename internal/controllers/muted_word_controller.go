@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/middlewares"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/services"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type MutedWordController struct {
+	MutedWordService *services.MutedWordService
+}
+
+func NewMutedWordController(db *sql.DB, cacheClient cache.Cache) *MutedWordController {
+	mutedWordService := services.NewMutedWordService(db, cacheClient)
+	return &MutedWordController{MutedWordService: mutedWordService}
+}
+
+// AddMutedWordHandler maneja la solicitud de silenciar una palabra, frase o hashtag en el timeline del usuario autenticado
+func (mwc *MutedWordController) AddMutedWordHandler(c *gin.Context) {
+	userId, ok := getMutedWordsUserId(c)
+
+	if !ok {
+		return
+	}
+
+	var request models.MutedWordRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Error decoding body", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	mutedWord, err := mwc.MutedWordService.AddMutedWord(userId, request.Word, request.ExpiresAt)
+
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "Invalid ") {
+			badResponse := utils.ResponseToApi(http.StatusBadRequest, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusBadRequest, badResponse)
+			return
+		}
+
+		if err.Error() == "Nonexistent user" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusNotFound, notFoundResponse)
+			return
+		}
+
+		if err.Error() == "Muted word already exists" {
+			conflictResponse := utils.ResponseToApi(http.StatusConflict, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusConflict, conflictResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	response := utils.ResponseToApi(http.StatusCreated, mutedWord, false, 0, 0, 0)
+	c.JSON(http.StatusCreated, response)
+}
+
+// GetMutedWordsHandler maneja la solicitud de obtener las palabras silenciadas (no expiradas) del usuario autenticado
+func (mwc *MutedWordController) GetMutedWordsHandler(c *gin.Context) {
+	userId, ok := getMutedWordsUserId(c)
+
+	if !ok {
+		return
+	}
+
+	mutedWords, err := mwc.MutedWordService.GetMutedWords(userId)
+
+	if err != nil {
+		if err.Error() == "Nonexistent user" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusNotFound, notFoundResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	total := int64(len(mutedWords))
+	response := utils.ResponseToApi(http.StatusOK, mutedWords, true, total, total, 0)
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteMutedWordHandler maneja la solicitud de dejar de silenciar una palabra del usuario autenticado
+func (mwc *MutedWordController) DeleteMutedWordHandler(c *gin.Context) {
+	userId, ok := getMutedWordsUserId(c)
+
+	if !ok {
+		return
+	}
+
+	mutedWordId, err := strconv.ParseInt(c.Param("word_id"), 10, 64)
+
+	if err != nil || mutedWordId <= 0 {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid muted word ID", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	_, err = mwc.MutedWordService.DeleteMutedWord(userId, mutedWordId)
+
+	if err != nil {
+		if err.Error() == "Muted word not found" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusNotFound, notFoundResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	response := utils.ResponseToApi(http.StatusOK, "Muted word deleted", false, 0, 0, 0)
+	c.JSON(http.StatusOK, response)
+}
+
+// getMutedWordsUserId obtiene el usuario de la ruta, que debe ser el autenticado porque las palabras silenciadas son privadas.
+// Si no es valido responde el error y retorna false
+func getMutedWordsUserId(c *gin.Context) (int64, bool) {
+	userId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	if err != nil || userId <= 0 {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid user ID", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return 0, false
+	}
+
+	authUserId, ok := middlewares.GetAuthUserId(c)
+
+	if !ok || authUserId != userId {
+		forbiddenResponse := utils.ResponseToApi(http.StatusForbidden, "Cannot manage muted words of another user", false, 0, 0, 0)
+		c.JSON(http.StatusForbidden, forbiddenResponse)
+		return 0, false
+	}
+
+	return userId, true
+}
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/middlewares"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/services"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationController struct {
+	NotificationService *services.NotificationService
+}
+
+func NewNotificationController(db *sql.DB, cacheClient cache.Cache) *NotificationController {
+	notificationService := services.NewNotificationService(db, cacheClient)
+	return &NotificationController{NotificationService: notificationService}
+}
+
+// GetNotificationsHandler maneja la solicitud de obtener las notificaciones agrupadas del usuario autenticado, paginadas por offset
+func (nc *NotificationController) GetNotificationsHandler(c *gin.Context) {
+	userId, ok := getNotificationsUserId(c)
+
+	if !ok {
+		return
+	}
+
+	limit, offset, err := getOffsetPaginationParams(c)
+
+	if err != nil {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	notifications, totalGroups, err := nc.NotificationService.GetNotifications(userId, limit, offset)
+
+	if err != nil {
+		if err.Error() == "Nonexistent user" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusNotFound, notFoundResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	response := utils.ResponseToApi(http.StatusOK, notifications, true, totalGroups, limit, offset)
+	c.JSON(http.StatusOK, response)
+}
+
+// MarkNotificationsReadHandler maneja la solicitud de marcar como leidas todas las notificaciones del usuario autenticado
+func (nc *NotificationController) MarkNotificationsReadHandler(c *gin.Context) {
+	userId, ok := getNotificationsUserId(c)
+
+	if !ok {
+		return
+	}
+
+	_, err := nc.NotificationService.MarkNotificationsRead(userId)
+
+	if err != nil {
+		if err.Error() == "Nonexistent user" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusNotFound, notFoundResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	response := utils.ResponseToApi(http.StatusOK, "Notifications marked as read", false, 0, 0, 0)
+	c.JSON(http.StatusOK, response)
+}
+
+// getNotificationsUserId obtiene el usuario de la ruta, que debe ser el autenticado porque las notificaciones son privadas.
+// Si no es valido responde el error y retorna false
+func getNotificationsUserId(c *gin.Context) (int64, bool) {
+	userId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	if err != nil || userId <= 0 {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid user ID", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return 0, false
+	}
+
+	authUserId, ok := middlewares.GetAuthUserId(c)
+
+	if !ok || authUserId != userId {
+		forbiddenResponse := utils.ResponseToApi(http.StatusForbidden, "Cannot access notifications of another user", false, 0, 0, 0)
+		c.JSON(http.StatusForbidden, forbiddenResponse)
+		return 0, false
+	}
+
+	return userId, true
+}
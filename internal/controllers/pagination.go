@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const defaultLimit int64 = 25 // Por defecto, vendran 25 elementos por pagina
+const defaultOffset int64 = 0
+const maxLimit int64 = 100 // Limite máximo permitido
+
+// getPaginationParams obtiene y valida los parametros de paginado de la query (limit, offset y cursor).
+// El cursor y el offset son excluyentes: si viene el cursor se pagina por cursor (keyset), si no por offset
+func getPaginationParams(c *gin.Context) (int64, int64, *models.Cursor, error) {
+	limitStr := c.Query("limit")
+	offsetStr := c.Query("offset")
+	cursorStr := c.Query("cursor")
+
+	limit := defaultLimit
+	offset := defaultOffset
+	var cursor *models.Cursor
+
+	if limitStr != "" {
+		parsedLimit, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || parsedLimit <= 0 || parsedLimit > maxLimit {
+			return 0, 0, nil, fmt.Errorf("Invalid limit parameter")
+		}
+		limit = parsedLimit
+	}
+
+	if offsetStr != "" {
+		parsedOffset, err := strconv.ParseInt(offsetStr, 10, 64)
+		if err != nil || parsedOffset < 0 {
+			return 0, 0, nil, fmt.Errorf("Invalid offset parameter")
+		}
+		offset = parsedOffset
+	}
+
+	if cursorStr != "" {
+		if offsetStr != "" {
+			return 0, 0, nil, fmt.Errorf("Cannot use cursor and offset parameters together")
+		}
+
+		createdAt, id, err := utils.DecodeCursor(cursorStr)
+		if err != nil {
+			return 0, 0, nil, fmt.Errorf("Invalid cursor parameter")
+		}
+		cursor = &models.Cursor{CreatedAt: createdAt, ID: id}
+	}
+
+	return limit, offset, cursor, nil
+}
+
+// getOffsetPaginationParams obtiene los parametros de paginado de los listados que solo se paginan por offset
+func getOffsetPaginationParams(c *gin.Context) (int64, int64, error) {
+	if c.Query("cursor") != "" {
+		return 0, 0, fmt.Errorf("Cursor pagination is not supported")
+	}
+
+	limit, offset, _, err := getPaginationParams(c)
+
+	return limit, offset, err
+}
+
+// nextTimelineCursor genera el cursor de la pagina siguiente a partir del ultimo tweet. Si la pagina no esta completa no hay mas datos
+func nextTimelineCursor(timeline []models.Tweet, limit int64) string {
+	if len(timeline) == 0 || int64(len(timeline)) < limit {
+		return ""
+	}
+
+	lastTweet := timeline[len(timeline)-1]
+	return utils.EncodeCursor(lastTweet.CreatedAt, lastTweet.ID)
+}
+
+// nextRepliesCursor genera el cursor de la pagina siguiente de respuestas de un hilo a partir de la ultima respuesta directa
+func nextRepliesCursor(replies []models.TweetReply, limit int64) string {
+	if len(replies) == 0 || int64(len(replies)) < limit {
+		return ""
+	}
+
+	lastReply := replies[len(replies)-1]
+	return utils.EncodeCursor(lastReply.CreatedAt, lastReply.ID)
+}
+
+// nextFollowsCursor genera el cursor de la pagina siguiente a partir del ultimo seguidor/seguido
+func nextFollowsCursor(follows []models.UserFollowInfo, limit int64) string {
+	if len(follows) == 0 || int64(len(follows)) < limit {
+		return ""
+	}
+
+	lastFollow := follows[len(follows)-1]
+	return utils.EncodeCursor(lastFollow.FollowDate, int64(lastFollow.FollowUserData.ID))
+}
+
+// nextBookmarksCursor genera el cursor de la pagina siguiente a partir del ultimo bookmark
+func nextBookmarksCursor(bookmarks []models.BookmarkedTweet, limit int64) string {
+	if len(bookmarks) == 0 || int64(len(bookmarks)) < limit {
+		return ""
+	}
+
+	lastBookmark := bookmarks[len(bookmarks)-1]
+	return utils.EncodeCursor(lastBookmark.BookmarkDate, lastBookmark.Tweet.ID)
+}
@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/middlewares"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/services"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type SearchController struct {
+	SearchService *services.SearchService
+}
+
+func NewSearchController(db *sql.DB, cacheClient cache.Cache) *SearchController {
+	searchService := services.NewSearchService(db, cacheClient)
+	return &SearchController{SearchService: searchService}
+}
+
+// Errores de la busqueda que se deben a una query invalida
+var invalidSearchErrors = map[string]bool{
+	"The search query is required":                  true,
+	"Invalid from: user ID":                         true,
+	"Invalid since: date":                           true,
+	"Invalid until: date":                           true,
+	"Invalid hashtag":                               true,
+	"The until: date must be after the since: date": true,
+}
+
+// SearchTweetsHandler maneja la solicitud de buscar tweets (parametro q, ver services.parseSearchQuery)
+func (sc *SearchController) SearchTweetsHandler(c *gin.Context) {
+	limit, offset, cursor, err := getPaginationParams(c)
+
+	if err != nil {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	// Sin token se consulta como anonimo y solo se incluyen los tweets de cuentas publicas
+	viewerId, _ := middlewares.GetAuthUserId(c)
+
+	tweets, totalTweets, err := sc.SearchService.SearchTweets(c.Query("q"), viewerId, limit, offset, cursor)
+
+	if err != nil {
+		if invalidSearchErrors[err.Error()] {
+			badResponse := utils.ResponseToApi(http.StatusBadRequest, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusBadRequest, badResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	response := utils.ResponseCursorListToApi(http.StatusOK, tweets, totalTweets, limit, offset, c.Query("cursor"), nextTimelineCursor(tweets, limit))
+	c.JSON(http.StatusOK, response)
+}
+
+// SearchUsersHandler maneja la solicitud de buscar usuarios por el prefijo de su handle o nombre (parametro q)
+func (sc *SearchController) SearchUsersHandler(c *gin.Context) {
+	limit, offset, err := getOffsetPaginationParams(c)
+
+	if err != nil {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	users, totalUsers, err := sc.SearchService.SearchUsers(c.Query("q"), limit, offset)
+
+	if err != nil {
+		if err.Error() == "The search query is required" {
+			badResponse := utils.ResponseToApi(http.StatusBadRequest, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusBadRequest, badResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	response := utils.ResponseToApi(http.StatusOK, users, true, totalUsers, limit, offset)
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/services"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type TrendController struct {
+	TrendService *services.TrendService
+}
+
+func NewTrendController(db *sql.DB, cacheClient cache.Cache) *TrendController {
+	trendService := services.NewTrendService(db, cacheClient)
+	return &TrendController{TrendService: trendService}
+}
+
+// GetTrendsHandler maneja la solicitud de obtener los hashtags en tendencia dentro de la ventana indicada (1h por defecto)
+func (tc *TrendController) GetTrendsHandler(c *gin.Context) {
+	limit, offset, err := getOffsetPaginationParams(c)
+
+	if err != nil {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	trends, totalTrends, err := tc.TrendService.GetTrends(c.Query("window"), limit, offset)
+
+	if err != nil {
+		if err.Error() == "Invalid window" {
+			badResponse := utils.ResponseToApi(http.StatusBadRequest, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusBadRequest, badResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	response := utils.ResponseToApi(http.StatusOK, trends, true, totalTrends, limit, offset)
+	c.JSON(http.StatusOK, response)
+}
@@ -3,22 +3,30 @@ package controllers
 import (
 	"database/sql"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/middlewares"
 	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
 	"github.com/MauricioGiaconia/uala_backend_challenge/internal/services"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
 	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/utils"
+	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
-	"github.com/redis/go-redis/v9"
 )
 
+// streamHeartbeatInterval es cada cuanto se envia un comentario por los streams SSE sin eventos, para que los proxies no cierren la conexion
+const streamHeartbeatInterval = 15 * time.Second
+
 type TweetController struct {
 	TweetService *services.TweetService
 }
 
-func NewTweetController(db *sql.DB, rdb *redis.Client) *TweetController {
-	tweetService := services.NewTweetService(db, rdb)
+func NewTweetController(db *sql.DB, cacheClient cache.Cache, broadcaster cache.Broadcaster) *TweetController {
+	tweetService := services.NewTweetService(db, cacheClient)
+	tweetService.Broadcaster = broadcaster
 	return &TweetController{TweetService: tweetService}
 }
 
@@ -31,23 +39,42 @@ func (tc *TweetController) CreateTweetHandler(c *gin.Context) {
 		return
 	}
 
-	if tweet.UserID <= 0 {
-		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid user ID", false, 0, 0, 0)
-		c.JSON(http.StatusBadRequest, badResponse)
+	// El autor del tweet es siempre el usuario autenticado, se ignora el authorId que pueda venir en el body
+	authUserId, ok := middlewares.GetAuthUserId(c)
+
+	if !ok {
+		unauthorizedResponse := utils.ResponseToApi(http.StatusUnauthorized, "Missing authorization token", false, 0, 0, 0)
+		c.JSON(http.StatusUnauthorized, unauthorizedResponse)
 		return
 	}
 
+	tweet.UserID = authUserId
+
 	tweetPosted, err := tc.TweetService.PostTweet(&tweet)
 
 	if err != nil {
 
+		if err.Error() == "This account is private" {
+			forbiddenResponse := utils.ResponseToApi(http.StatusForbidden, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusForbidden, forbiddenResponse)
+			return
+		}
+
 		if err.Error() == "Nonexistent user" {
 			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
 			c.JSON(http.StatusNotFound, notFoundResponse)
 			return
 		}
 
-		if err.Error() == "The content of the tweet must not exceed 280 characters" {
+		if err.Error() == "Nonexistent replied tweet" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusNotFound, notFoundResponse)
+			return
+		}
+
+		if err.Error() == "The content of the tweet must not exceed 280 characters" ||
+			err.Error() == "The conversation can only be set on replies" ||
+			err.Error() == "The conversation does not match the replied tweet" {
 			badResponse := utils.ResponseToApi(http.StatusBadRequest, err.Error(), false, 0, 0, 0)
 			c.JSON(http.StatusBadRequest, badResponse)
 			return
@@ -69,50 +96,370 @@ func (tc *TweetController) CreateTweetHandler(c *gin.Context) {
 	c.JSON(http.StatusCreated, response)
 }
 
-func (tc *TweetController) GetTimelineHandler(c *gin.Context) {
-	idStr := c.Param("follower_id")
+// DeleteTweetHandler maneja la solicitud de eliminar un tweet, solo su autor puede eliminarlo
+func (tc *TweetController) DeleteTweetHandler(c *gin.Context) {
+	tweetId, err := strconv.ParseInt(c.Param("tweet_id"), 10, 64)
+
+	if err != nil || tweetId <= 0 {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid tweet ID", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
 
-	id, err := strconv.ParseInt(idStr, 10, 64)
+	authUserId, ok := middlewares.GetAuthUserId(c)
 
-	if err != nil || id <= 0 {
-		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid follower ID", false, 0, 0, 0)
+	if !ok {
+		unauthorizedResponse := utils.ResponseToApi(http.StatusUnauthorized, "Missing authorization token", false, 0, 0, 0)
+		c.JSON(http.StatusUnauthorized, unauthorizedResponse)
+		return
+	}
+
+	_, err = tc.TweetService.DeleteTweet(tweetId, authUserId)
+
+	if err != nil {
+		if err.Error() == "Nonexistent tweet" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusNotFound, notFoundResponse)
+			return
+		}
+
+		if err.Error() == "Only the author can delete the tweet" {
+			forbiddenResponse := utils.ResponseToApi(http.StatusForbidden, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusForbidden, forbiddenResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	response := utils.ResponseToApi(http.StatusOK, "Tweet deleted", false, 0, 0, 0)
+	c.JSON(http.StatusOK, response)
+}
+
+// RetweetHandler maneja la solicitud de retweetear un tweet en nombre del usuario autenticado
+func (tc *TweetController) RetweetHandler(c *gin.Context) {
+	tweetId, err := strconv.ParseInt(c.Param("tweet_id"), 10, 64)
+
+	if err != nil || tweetId <= 0 {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid tweet ID", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	authUserId, ok := middlewares.GetAuthUserId(c)
+
+	if !ok {
+		unauthorizedResponse := utils.ResponseToApi(http.StatusUnauthorized, "Missing authorization token", false, 0, 0, 0)
+		c.JSON(http.StatusUnauthorized, unauthorizedResponse)
+		return
+	}
+
+	retweet, err := tc.TweetService.Retweet(tweetId, authUserId)
+
+	if err != nil {
+		if err.Error() == "Cannot repost a tweet from a private account" {
+			forbiddenResponse := utils.ResponseToApi(http.StatusForbidden, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusForbidden, forbiddenResponse)
+			return
+		}
+
+		if err.Error() == "Nonexistent tweet" || err.Error() == "Nonexistent user" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusNotFound, notFoundResponse)
+			return
+		}
+
+		if err.Error() == "Tweet already retweeted" {
+			conflictResponse := utils.ResponseToApi(http.StatusConflict, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusConflict, conflictResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, "[X] Error retweeting: "+err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	response := utils.ResponseToApi(http.StatusCreated, retweet, false, 0, 0, 0)
+	c.JSON(http.StatusCreated, response)
+}
+
+// UndoRetweetHandler maneja la solicitud de deshacer el retweet del usuario autenticado
+func (tc *TweetController) UndoRetweetHandler(c *gin.Context) {
+	tweetId, err := strconv.ParseInt(c.Param("tweet_id"), 10, 64)
+
+	if err != nil || tweetId <= 0 {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid tweet ID", false, 0, 0, 0)
 		c.JSON(http.StatusBadRequest, badResponse)
 		return
 	}
 
-	limitStr := c.Query("limit")
-	offsetStr := c.Query("offset")
+	authUserId, ok := middlewares.GetAuthUserId(c)
+
+	if !ok {
+		unauthorizedResponse := utils.ResponseToApi(http.StatusUnauthorized, "Missing authorization token", false, 0, 0, 0)
+		c.JSON(http.StatusUnauthorized, unauthorizedResponse)
+		return
+	}
+
+	_, err = tc.TweetService.UndoRetweet(tweetId, authUserId)
+
+	if err != nil {
+		if err.Error() == "Nonexistent tweet" || err.Error() == "Retweet not found" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusNotFound, notFoundResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	response := utils.ResponseToApi(http.StatusOK, "Retweet deleted", false, 0, 0, 0)
+	c.JSON(http.StatusOK, response)
+}
 
-	const defaultLimit int64 = 25 // Por defecto, vendran 25 tweets por pagina
-	const defaultOffset int64 = 0
-	const maxLimit int64 = 100 // Limite máximo permitido
+// QuoteTweetHandler maneja la solicitud de citar un tweet con un comentario del usuario autenticado
+func (tc *TweetController) QuoteTweetHandler(c *gin.Context) {
+	tweetId, err := strconv.ParseInt(c.Param("tweet_id"), 10, 64)
+
+	if err != nil || tweetId <= 0 {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid tweet ID", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
 
-	var limit, offset int64
-	var paramError error
+	var quote models.Tweet
 
-	if limitStr != "" {
-		limit, paramError = strconv.ParseInt(limitStr, 10, 64)
-		if err != nil || limit <= 0 || limit > maxLimit {
-			badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid limit parameter", false, 0, 0, 0)
+	if err := c.ShouldBindJSON(&quote); err != nil {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	authUserId, ok := middlewares.GetAuthUserId(c)
+
+	if !ok {
+		unauthorizedResponse := utils.ResponseToApi(http.StatusUnauthorized, "Missing authorization token", false, 0, 0, 0)
+		c.JSON(http.StatusUnauthorized, unauthorizedResponse)
+		return
+	}
+
+	quote.UserID = authUserId
+
+	_, err = tc.TweetService.QuoteTweet(tweetId, &quote)
+
+	if err != nil {
+		if err.Error() == "Cannot repost a tweet from a private account" || err.Error() == "This account is private" {
+			forbiddenResponse := utils.ResponseToApi(http.StatusForbidden, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusForbidden, forbiddenResponse)
+			return
+		}
+
+		if err.Error() == "Nonexistent tweet" || err.Error() == "Nonexistent user" || err.Error() == "Nonexistent replied tweet" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusNotFound, notFoundResponse)
+			return
+		}
+
+		if err.Error() == "The content of the quote is required" ||
+			err.Error() == "The content of the tweet must not exceed 280 characters" ||
+			err.Error() == "The conversation can only be set on replies" ||
+			err.Error() == "The conversation does not match the replied tweet" {
+			badResponse := utils.ResponseToApi(http.StatusBadRequest, err.Error(), false, 0, 0, 0)
 			c.JSON(http.StatusBadRequest, badResponse)
 			return
 		}
-	} else {
-		limit = defaultLimit
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, "[X] Error posting quote: "+err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
 	}
 
-	if offsetStr != "" {
-		offset, paramError = strconv.ParseInt(offsetStr, 10, 64)
-		if paramError != nil || offset < 0 {
-			badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid offset parameter", false, 0, 0, 0)
+	response := utils.ResponseToApi(http.StatusCreated, "Quote posted", false, 0, 0, 0)
+	c.JSON(http.StatusCreated, response)
+}
+
+// StreamTimelineHandler mantiene abierta una conexion SSE que envia los tweets nuevos del timeline del usuario a medida que se crean.
+// El ID de cada evento es el ID del tweet: al reconectarse, el cliente envia el header Last-Event-ID y recibe primero los tweets
+// que se crearon mientras estuvo desconectado
+func (tc *TweetController) StreamTimelineHandler(c *gin.Context) {
+	id, ok := getTimelineUserId(c)
+
+	if !ok {
+		return
+	}
+
+	var lastEventId int64
+	var err error
+
+	if lastEventIdStr := c.GetHeader("Last-Event-ID"); lastEventIdStr != "" {
+		lastEventId, err = strconv.ParseInt(lastEventIdStr, 10, 64)
+
+		if err != nil || lastEventId < 0 {
+			badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid Last-Event-ID", false, 0, 0, 0)
 			c.JSON(http.StatusBadRequest, badResponse)
 			return
 		}
-	} else {
-		offset = defaultOffset
 	}
 
-	timeline, err := tc.TweetService.GetUserTimeline(&id, &limit, &offset)
+	stream, err := tc.TweetService.OpenTimelineStream(c.Request.Context(), id, lastEventId)
+
+	if err != nil {
+		if err.Error() == "Nonexistent user" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusNotFound, notFoundResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+	defer stream.Close()
+
+	// Los headers se envian de inmediato para que el cliente sepa que el stream ya esta suscripto
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Evita que nginx acumule los eventos antes de enviarlos
+	c.Status(http.StatusOK)
+	c.Writer.WriteHeaderNow()
+
+	for _, tweet := range stream.Replay {
+		renderTweetEvent(c, tweet)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-heartbeat.C:
+			// Un comentario SSE mantiene viva la conexion cuando no hay tweets nuevos
+			_, err := io.WriteString(w, ": heartbeat\n\n")
+			return err == nil
+		case message, ok := <-stream.Messages():
+			if !ok {
+				// Se perdieron mensajes, al cerrar el stream el cliente se reconecta con Last-Event-ID
+				return false
+			}
+
+			tweet, err := stream.TweetFromMessage(message)
+			if err != nil {
+				fmt.Println(err)
+				return true
+			}
+
+			if tweet != nil {
+				renderTweetEvent(c, *tweet)
+			}
+			return true
+		}
+	})
+}
+
+// renderTweetEvent escribe el tweet como un evento SSE "tweet" con el ID del tweet
+func renderTweetEvent(c *gin.Context, tweet models.Tweet) {
+	c.Render(-1, sse.Event{Id: strconv.FormatInt(tweet.ID, 10), Event: "tweet", Data: tweet})
+}
+
+// GetThreadHandler maneja la solicitud de obtener el hilo de un tweet: los tweets a los que responde y sus respuestas paginadas
+func (tc *TweetController) GetThreadHandler(c *gin.Context) {
+	tweetId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	if err != nil || tweetId <= 0 {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid tweet ID", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	limit, offset, cursor, err := getPaginationParams(c)
+
+	if err != nil {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	// Sin token se consulta como anonimo y solo se incluyen los tweets de cuentas publicas
+	viewerId, _ := middlewares.GetAuthUserId(c)
+
+	thread, totalReplies, err := tc.TweetService.GetThread(tweetId, viewerId, limit, offset, cursor)
+
+	if err != nil {
+		if err.Error() == "This account is private" {
+			forbiddenResponse := utils.ResponseToApi(http.StatusForbidden, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusForbidden, forbiddenResponse)
+			return
+		}
+
+		if err.Error() == "Nonexistent tweet" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusNotFound, notFoundResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	// El paginado (count, limit, offset y cursores) corresponde a las respuestas directas al tweet
+	response := utils.ResponseCursorListToApi(http.StatusOK, thread, totalReplies, limit, offset, c.Query("cursor"), nextRepliesCursor(thread.Replies, limit))
+	c.JSON(http.StatusOK, response)
+}
+
+// getTimelineUserId obtiene el ID del usuario cuyo timeline se solicita. Cada usuario solo puede consultar su propio timeline,
+// si no es el usuario autenticado responde el error y retorna false
+func getTimelineUserId(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	if err != nil || id <= 0 {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid follower ID", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return 0, false
+	}
+
+	authUserId, ok := middlewares.GetAuthUserId(c)
+
+	if !ok {
+		unauthorizedResponse := utils.ResponseToApi(http.StatusUnauthorized, "Missing authorization token", false, 0, 0, 0)
+		c.JSON(http.StatusUnauthorized, unauthorizedResponse)
+		return 0, false
+	}
+
+	if authUserId != id {
+		forbiddenResponse := utils.ResponseToApi(http.StatusForbidden, "Cannot access the timeline of another user", false, 0, 0, 0)
+		c.JSON(http.StatusForbidden, forbiddenResponse)
+		return 0, false
+	}
+
+	return id, true
+}
+
+func (tc *TweetController) GetTimelineHandler(c *gin.Context) {
+	id, ok := getTimelineUserId(c)
+
+	if !ok {
+		return
+	}
+
+	limit, offset, cursor, err := getPaginationParams(c)
+
+	if err != nil {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	timeline, err := tc.TweetService.GetUserTimeline(&id, &limit, &offset, cursor)
 
 	if err != nil {
 		if err.Error() == "Nonexistent user" {
@@ -134,59 +481,102 @@ func (tc *TweetController) GetTimelineHandler(c *gin.Context) {
 	}
 
 	//Por mas que el count rompa, retorno la informacion igual ya que cuento con el timeline
-	response := utils.ResponseToApi(http.StatusOK, timeline, true, totalTweets, limit, offset)
+	response := utils.ResponseCursorListToApi(http.StatusOK, timeline, totalTweets, limit, offset, c.Query("cursor"), nextTimelineCursor(timeline, limit))
 	c.JSON(http.StatusOK, response)
 }
 
 func (tc *TweetController) GetTimelineWithGoRoutineHandler(c *gin.Context) {
-	idStr := c.Param("follower_id")
+	id, ok := getTimelineUserId(c)
 
-	id, err := strconv.ParseInt(idStr, 10, 64)
-
-	if err != nil || id <= 0 {
-		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid follower ID", false, 0, 0, 0)
-		c.JSON(http.StatusBadRequest, badResponse)
+	if !ok {
 		return
 	}
 
-	limitStr := c.Query("limit")
-	offsetStr := c.Query("offset")
+	limit, offset, cursor, err := getPaginationParams(c)
 
-	const defaultLimit int64 = 25 // Por defecto, vendran 25 tweets por pagina
-	const defaultOffset int64 = 0
-	const maxLimit int64 = 100 // Limite máximo permitido
+	if err != nil {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
 
-	var limit, offset int64
-	var paramError error
+	timeline, totalTweets, err := tc.TweetService.GetUserTimelineDataWithRoutine(&id, &limit, &offset, cursor)
 
-	if limitStr != "" {
-		limit, paramError = strconv.ParseInt(limitStr, 10, 64)
-		if err != nil || limit <= 0 || limit > maxLimit {
-			badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid limit parameter", false, 0, 0, 0)
-			c.JSON(http.StatusBadRequest, badResponse)
+	if err != nil {
+		if err.Error() == "Nonexistent user" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusBadRequest, notFoundResponse)
 			return
 		}
-	} else {
-		limit = defaultLimit
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	//Por mas que el count rompa, retorno la informacion igual ya que cuento con el timeline
+	response := utils.ResponseCursorListToApi(http.StatusOK, timeline, totalTweets, limit, offset, c.Query("cursor"), nextTimelineCursor(timeline, limit))
+	c.JSON(http.StatusOK, response)
+}
+
+// GetHashtagTweetsHandler maneja la solicitud de obtener los tweets que usan un hashtag (con o sin '#', sin distinguir mayusculas)
+func (tc *TweetController) GetHashtagTweetsHandler(c *gin.Context) {
+	limit, offset, cursor, err := getPaginationParams(c)
+
+	if err != nil {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
 	}
 
-	if offsetStr != "" {
-		offset, paramError = strconv.ParseInt(offsetStr, 10, 64)
-		if paramError != nil || offset < 0 {
-			badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid offset parameter", false, 0, 0, 0)
+	// Sin token se consulta como anonimo y solo se incluyen los tweets de cuentas publicas
+	viewerId, _ := middlewares.GetAuthUserId(c)
+
+	tweets, totalTweets, err := tc.TweetService.GetHashtagTweets(c.Param("tag"), viewerId, limit, offset, cursor)
+
+	if err != nil {
+		if err.Error() == "Invalid hashtag" {
+			badResponse := utils.ResponseToApi(http.StatusBadRequest, err.Error(), false, 0, 0, 0)
 			c.JSON(http.StatusBadRequest, badResponse)
 			return
 		}
-	} else {
-		offset = defaultOffset
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
 	}
 
-	timeline, totalTweets, err := tc.TweetService.GetUserTimelineDataWithRoutine(&id, &limit, &offset)
+	response := utils.ResponseCursorListToApi(http.StatusOK, tweets, totalTweets, limit, offset, c.Query("cursor"), nextTimelineCursor(tweets, limit))
+	c.JSON(http.StatusOK, response)
+}
+
+// GetUserMentionsHandler maneja la solicitud de obtener los tweets que mencionan a un usuario
+func (tc *TweetController) GetUserMentionsHandler(c *gin.Context) {
+	userId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	if err != nil || userId <= 0 {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid user ID", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	limit, offset, cursor, err := getPaginationParams(c)
+
+	if err != nil {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	// Sin token se consulta como anonimo y solo se incluyen los tweets de cuentas publicas
+	viewerId, _ := middlewares.GetAuthUserId(c)
+
+	tweets, totalTweets, err := tc.TweetService.GetUserMentions(userId, viewerId, limit, offset, cursor)
 
 	if err != nil {
 		if err.Error() == "Nonexistent user" {
 			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
-			c.JSON(http.StatusBadRequest, notFoundResponse)
+			c.JSON(http.StatusNotFound, notFoundResponse)
 			return
 		}
 
@@ -195,7 +585,6 @@ func (tc *TweetController) GetTimelineWithGoRoutineHandler(c *gin.Context) {
 		return
 	}
 
-	//Por mas que el count rompa, retorno la informacion igual ya que cuento con el timeline
-	response := utils.ResponseToApi(http.StatusOK, timeline, true, totalTweets, limit, offset)
+	response := utils.ResponseCursorListToApi(http.StatusOK, tweets, totalTweets, limit, offset, c.Query("cursor"), nextTimelineCursor(tweets, limit))
 	c.JSON(http.StatusOK, response)
 }
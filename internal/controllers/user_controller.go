@@ -4,9 +4,12 @@ import (
 	"database/sql"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/middlewares"
 	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
 	"github.com/MauricioGiaconia/uala_backend_challenge/internal/services"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
 	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/utils"
 	"github.com/gin-gonic/gin"
 )
@@ -15,8 +18,9 @@ type UserController struct {
 	UserService *services.UserService
 }
 
-func NewUserController(db *sql.DB) *UserController {
-	userService := services.NewUserService(db)
+func NewUserController(db *sql.DB, cacheClient cache.Cache, broadcaster cache.Broadcaster) *UserController {
+	userService := services.NewUserService(db, cacheClient)
+	userService.Broadcaster = broadcaster
 	return &UserController{UserService: userService}
 }
 
@@ -34,6 +38,18 @@ func (uc *UserController) CreateUserHandler(c *gin.Context) {
 	// Llamamos al servicio para crear el usuario
 	userID, err := uc.UserService.CreateUser(user)
 	if err != nil {
+		if err.Error() == "Invalid handle" {
+			badResponse := utils.ResponseToApi(http.StatusBadRequest, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusBadRequest, badResponse)
+			return
+		}
+
+		if err.Error() == "Handle already in use" {
+			conflictResponse := utils.ResponseToApi(http.StatusConflict, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusConflict, conflictResponse)
+			return
+		}
+
 		serverErrorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
 		c.JSON(http.StatusInternalServerError, serverErrorResponse)
 		return
@@ -77,3 +93,134 @@ func (uc *UserController) GetUserByIdHandler(c *gin.Context) {
 	response := utils.ResponseToApi(http.StatusOK, user, false, 0, 0, 0)
 	c.JSON(http.StatusOK, response)
 }
+
+// GetUserByHandleHandler maneja la solicitud de obtener un usuario por su handle (con o sin '@', sin distinguir mayusculas).
+func (uc *UserController) GetUserByHandleHandler(c *gin.Context) {
+	user, err := uc.UserService.GetUserByHandle(c.Param("handle"))
+
+	if err != nil {
+		if err.Error() == "Invalid handle" {
+			badResponse := utils.ResponseToApi(http.StatusBadRequest, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusBadRequest, badResponse)
+			return
+		}
+
+		if err.Error() == "Error fetching user: user not found" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, "Not found", false, 0, 0, 0)
+			c.JSON(http.StatusNotFound, notFoundResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	response := utils.ResponseToApi(http.StatusOK, user, false, 0, 0, 0)
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateUserHandler maneja la solicitud de modificar el perfil del usuario autenticado. Solo se modifican los campos enviados
+func (uc *UserController) UpdateUserHandler(c *gin.Context) {
+	userId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	if err != nil || userId <= 0 {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid user ID", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	authUserId, ok := middlewares.GetAuthUserId(c)
+
+	if !ok {
+		unauthorizedResponse := utils.ResponseToApi(http.StatusUnauthorized, "Missing authorization token", false, 0, 0, 0)
+		c.JSON(http.StatusUnauthorized, unauthorizedResponse)
+		return
+	}
+
+	if authUserId != userId {
+		forbiddenResponse := utils.ResponseToApi(http.StatusForbidden, "Cannot update another user", false, 0, 0, 0)
+		c.JSON(http.StatusForbidden, forbiddenResponse)
+		return
+	}
+
+	var update models.UserUpdate
+
+	if err := c.ShouldBindJSON(&update); err != nil {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	user, err := uc.UserService.UpdateUser(userId, update)
+
+	if err != nil {
+		// Los errores de validacion indican el campo invalido
+		if strings.HasPrefix(err.Error(), "Invalid ") || err.Error() == "No fields to update" {
+			badResponse := utils.ResponseToApi(http.StatusBadRequest, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusBadRequest, badResponse)
+			return
+		}
+
+		if err.Error() == "Nonexistent user" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusNotFound, notFoundResponse)
+			return
+		}
+
+		if err.Error() == "Email already in use" {
+			conflictResponse := utils.ResponseToApi(http.StatusConflict, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusConflict, conflictResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	response := utils.ResponseToApi(http.StatusOK, user, false, 0, 0, 0)
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteUserHandler maneja la solicitud de eliminar la cuenta del usuario autenticado junto a sus tweets, follows, likes y bookmarks
+func (uc *UserController) DeleteUserHandler(c *gin.Context) {
+	userId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	if err != nil || userId <= 0 {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid user ID", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	authUserId, ok := middlewares.GetAuthUserId(c)
+
+	if !ok {
+		unauthorizedResponse := utils.ResponseToApi(http.StatusUnauthorized, "Missing authorization token", false, 0, 0, 0)
+		c.JSON(http.StatusUnauthorized, unauthorizedResponse)
+		return
+	}
+
+	if authUserId != userId {
+		forbiddenResponse := utils.ResponseToApi(http.StatusForbidden, "Cannot delete another user", false, 0, 0, 0)
+		c.JSON(http.StatusForbidden, forbiddenResponse)
+		return
+	}
+
+	_, err = uc.UserService.DeleteUser(userId)
+
+	if err != nil {
+		if err.Error() == "Nonexistent user" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusNotFound, notFoundResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	response := utils.ResponseToApi(http.StatusOK, "User deleted", false, 0, 0, 0)
+	c.JSON(http.StatusOK, response)
+}
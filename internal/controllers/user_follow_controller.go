@@ -6,19 +6,21 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/middlewares"
 	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
 	"github.com/MauricioGiaconia/uala_backend_challenge/internal/services"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
 	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/utils"
 	"github.com/gin-gonic/gin"
-	"github.com/redis/go-redis/v9"
 )
 
 type UserFollowController struct {
 	UserFollowService *services.FollowService
 }
 
-func NewUseFollowrController(db *sql.DB, rds *redis.Client) *UserFollowController {
-	userFollowService := services.NewFollowService(db, rds)
+func NewUseFollowrController(db *sql.DB, cacheClient cache.Cache, broadcaster cache.Broadcaster) *UserFollowController {
+	userFollowService := services.NewFollowService(db, cacheClient)
+	userFollowService.Broadcaster = broadcaster
 	return &UserFollowController{UserFollowService: userFollowService}
 }
 
@@ -33,6 +35,17 @@ func (ufc *UserFollowController) FollowUserHandler(c *gin.Context) {
 		return
 	}
 
+	// El seguidor es siempre el usuario autenticado, se ignora el followerId que pueda venir en el body
+	authUserId, ok := middlewares.GetAuthUserId(c)
+
+	if !ok {
+		unauthorizedResponse := utils.ResponseToApi(http.StatusUnauthorized, "Missing authorization token", false, 0, 0, 0)
+		c.JSON(http.StatusUnauthorized, unauthorizedResponse)
+		return
+	}
+
+	follow.FollowerID = authUserId
+
 	if follow.FollowerID == follow.FollowedID {
 		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Cannot follow yourself", false, 0, 0, 0)
 		c.JSON(http.StatusBadRequest, badResponse)
@@ -55,7 +68,13 @@ func (ufc *UserFollowController) FollowUserHandler(c *gin.Context) {
 			return
 		}
 
-		if err.Error() == "Follow already exists" {
+		if err.Error() == "Cannot follow this user" {
+			forbiddenResponse := utils.ResponseToApi(http.StatusForbidden, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusForbidden, forbiddenResponse)
+			return
+		}
+
+		if err.Error() == "Follow already exists" || err.Error() == "Follow request already exists" {
 			badResponse := utils.ResponseToApi(http.StatusBadRequest, err.Error(), false, 0, 0, 0)
 			c.JSON(http.StatusBadRequest, badResponse)
 			return
@@ -68,6 +87,12 @@ func (ufc *UserFollowController) FollowUserHandler(c *gin.Context) {
 
 	msgResponse := "Followed"
 	responseCode := http.StatusCreated
+	if follow.Pending {
+		// La cuenta es privada, el follow queda pendiente hasta que el usuario seguido lo apruebe
+		msgResponse = "Follow request sent"
+		responseCode = http.StatusAccepted
+	}
+
 	if !followResponse {
 		responseCode = http.StatusBadRequest
 		msgResponse = "Cannot follow the user"
@@ -78,6 +103,50 @@ func (ufc *UserFollowController) FollowUserHandler(c *gin.Context) {
 	c.JSON(responseCode, finalResponse)
 }
 
+// UnfollowUserHandler maneja la solicitud de dejar de seguir a un usuario
+func (ufc *UserFollowController) UnfollowUserHandler(c *gin.Context) {
+	followerId, err := strconv.ParseInt(c.Param("follower_id"), 10, 64)
+
+	if err != nil || followerId <= 0 {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid follower or followed ID", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	followedId, err := strconv.ParseInt(c.Param("followed_id"), 10, 64)
+
+	if err != nil || followedId <= 0 {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid follower or followed ID", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	authUserId, ok := middlewares.GetAuthUserId(c)
+
+	if !ok || authUserId != followerId {
+		forbiddenResponse := utils.ResponseToApi(http.StatusForbidden, "Cannot unfollow on behalf of another user", false, 0, 0, 0)
+		c.JSON(http.StatusForbidden, forbiddenResponse)
+		return
+	}
+
+	_, err = ufc.UserFollowService.UnfollowUser(&models.UserFollow{FollowerID: followerId, FollowedID: followedId})
+
+	if err != nil {
+		if err.Error() == "Follow not found" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusNotFound, notFoundResponse)
+			return
+		}
+
+		serverErrorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, serverErrorResponse)
+		return
+	}
+
+	response := utils.ResponseToApi(http.StatusOK, "Unfollowed", false, 0, 0, 0)
+	c.JSON(http.StatusOK, response)
+}
+
 // GetFollowersHandler maneja la solicitud de obtener un usuario por su ID.
 func (ufc *UserFollowController) GetFollowersHandler(c *gin.Context) {
 	idStr := c.Param("id")
@@ -91,40 +160,16 @@ func (ufc *UserFollowController) GetFollowersHandler(c *gin.Context) {
 		return
 	}
 
-	limitStr := c.Query("limit")
-	offsetStr := c.Query("offset")
-
-	const defaultLimit int64 = 25 // Por defecto, vendran 25 tweets por pagina
-	const defaultOffset int64 = 0
-	const maxLimit int64 = 100 // Limite máximo permitido
-
-	var limit, offset int64
-	var paramError error
-
-	if limitStr != "" {
-		limit, paramError = strconv.ParseInt(limitStr, 10, 64)
-		if err != nil || limit <= 0 || limit > maxLimit {
-			badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid limit parameter", false, 0, 0, 0)
-			c.JSON(http.StatusBadRequest, badResponse)
-			return
-		}
-	} else {
-		limit = defaultLimit
-	}
+	limit, offset, cursor, err := getPaginationParams(c)
 
-	if offsetStr != "" {
-		offset, paramError = strconv.ParseInt(offsetStr, 10, 64)
-		if paramError != nil || offset < 0 {
-			badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid offset parameter", false, 0, 0, 0)
-			c.JSON(http.StatusBadRequest, badResponse)
-			return
-		}
-	} else {
-		offset = defaultOffset
+	if err != nil {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
 	}
 
 	// Llamamos al servicio para obtener el usuario
-	userFollowInfo, err := ufc.UserFollowService.GetFollows(&id, &relationType, &limit, &offset)
+	userFollowInfo, err := ufc.UserFollowService.GetFollows(&id, &relationType, &limit, &offset, cursor)
 
 	if err != nil {
 
@@ -147,6 +192,104 @@ func (ufc *UserFollowController) GetFollowersHandler(c *gin.Context) {
 	}
 
 	// Respondemos con los seguidores/seguidos del usuario en formato JSON junto a la informacion del paginado
-	response := utils.ResponseToApi(http.StatusOK, userFollowInfo, true, totalFollows, limit, offset)
+	response := utils.ResponseCursorListToApi(http.StatusOK, userFollowInfo, totalFollows, limit, offset, c.Query("cursor"), nextFollowsCursor(userFollowInfo.Follows, limit))
+	c.JSON(http.StatusOK, response)
+}
+
+// GetFollowRequestsHandler obtiene las solicitudes de seguimiento pendientes del usuario autenticado
+func (ufc *UserFollowController) GetFollowRequestsHandler(c *gin.Context) {
+	userId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	if err != nil || userId <= 0 {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid user ID", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	authUserId, ok := middlewares.GetAuthUserId(c)
+
+	if !ok || authUserId != userId {
+		forbiddenResponse := utils.ResponseToApi(http.StatusForbidden, "Cannot view follow requests of another user", false, 0, 0, 0)
+		c.JSON(http.StatusForbidden, forbiddenResponse)
+		return
+	}
+
+	limit, offset, err := getOffsetPaginationParams(c)
+
+	if err != nil {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	requests, total, err := ufc.UserFollowService.GetFollowRequests(userId, limit, offset)
+
+	if err != nil {
+		if err.Error() == "Nonexistent ID user" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusNotFound, notFoundResponse)
+			return
+		}
+
+		errorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, errorResponse)
+		return
+	}
+
+	response := utils.ResponseToApi(http.StatusOK, requests, true, total, limit, offset)
+	c.JSON(http.StatusOK, response)
+}
+
+// ApproveFollowRequestHandler aprueba la solicitud de seguimiento de un usuario a la cuenta autenticada
+func (ufc *UserFollowController) ApproveFollowRequestHandler(c *gin.Context) {
+	ufc.resolveFollowRequest(c, ufc.UserFollowService.ApproveFollowRequest, "Follow request approved")
+}
+
+// RejectFollowRequestHandler rechaza la solicitud de seguimiento de un usuario a la cuenta autenticada
+func (ufc *UserFollowController) RejectFollowRequestHandler(c *gin.Context) {
+	ufc.resolveFollowRequest(c, ufc.UserFollowService.RejectFollowRequest, "Follow request rejected")
+}
+
+// resolveFollowRequest valida los parametros de la ruta y resuelve la solicitud con la accion recibida
+func (ufc *UserFollowController) resolveFollowRequest(c *gin.Context, resolve func(followerId int64, followedId int64) error, msgResponse string) {
+	followedId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	if err != nil || followedId <= 0 {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid follower or followed ID", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	followerId, err := strconv.ParseInt(c.Param("follower_id"), 10, 64)
+
+	if err != nil || followerId <= 0 {
+		badResponse := utils.ResponseToApi(http.StatusBadRequest, "Invalid follower or followed ID", false, 0, 0, 0)
+		c.JSON(http.StatusBadRequest, badResponse)
+		return
+	}
+
+	authUserId, ok := middlewares.GetAuthUserId(c)
+
+	if !ok || authUserId != followedId {
+		forbiddenResponse := utils.ResponseToApi(http.StatusForbidden, "Cannot resolve follow requests of another user", false, 0, 0, 0)
+		c.JSON(http.StatusForbidden, forbiddenResponse)
+		return
+	}
+
+	err = resolve(followerId, followedId)
+
+	if err != nil {
+		if err.Error() == "Follow request not found" {
+			notFoundResponse := utils.ResponseToApi(http.StatusNotFound, err.Error(), false, 0, 0, 0)
+			c.JSON(http.StatusNotFound, notFoundResponse)
+			return
+		}
+
+		serverErrorResponse := utils.ResponseToApi(http.StatusInternalServerError, err.Error(), false, 0, 0, 0)
+		c.JSON(http.StatusInternalServerError, serverErrorResponse)
+		return
+	}
+
+	response := utils.ResponseToApi(http.StatusOK, msgResponse, false, 0, 0, 0)
 	c.JSON(http.StatusOK, response)
 }
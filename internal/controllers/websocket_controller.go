@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/middlewares"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/services"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// websocketMaxMessageSize es el tamaño maximo (en bytes) de los mensajes que puede enviar el cliente
+const websocketMaxMessageSize = 4096
+
+type WebSocketController struct {
+	RealtimeService *services.RealtimeService
+}
+
+// NewWebSocketController recibe el RealtimeService compartido con SetupRoutes, que lo usa para cerrar las conexiones al apagar el servidor
+func NewWebSocketController(realtimeService *services.RealtimeService) *WebSocketController {
+	return &WebSocketController{RealtimeService: realtimeService}
+}
+
+// WebSocketHandler abre el WebSocket del usuario autenticado, por el que se suscribe a canales y recibe sus eventos en JSON
+func (wc *WebSocketController) WebSocketHandler(c *gin.Context) {
+	authUserId, ok := middlewares.GetAuthUserId(c)
+
+	if !ok {
+		unauthorizedResponse := utils.ResponseToApi(http.StatusUnauthorized, "Missing authorization token", false, 0, 0, 0)
+		c.JSON(http.StatusUnauthorized, unauthorizedResponse)
+		return
+	}
+
+	if wc.RealtimeService.IsClosing() {
+		unavailableResponse := utils.ResponseToApi(http.StatusServiceUnavailable, "Server is shutting down", false, 0, 0, 0)
+		c.JSON(http.StatusServiceUnavailable, unavailableResponse)
+		return
+	}
+
+	// El cliente se autentica con el header Authorization (no con cookies), por lo que no se valida el header Origin,
+	// que ademas los clientes mobile no envian
+	server := websocket.Server{
+		Handshake: func(config *websocket.Config, req *http.Request) error {
+			return nil
+		},
+		Handler: func(ws *websocket.Conn) {
+			ws.MaxPayloadBytes = websocketMaxMessageSize
+			wc.RealtimeService.Serve(&websocketConn{ws: ws}, authUserId)
+		},
+	}
+
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// websocketConn adapta la conexion de golang.org/x/net/websocket a services.RealtimeConn
+type websocketConn struct {
+	ws *websocket.Conn
+}
+
+func (conn *websocketConn) Receive() (string, error) {
+	var message string
+	err := websocket.Message.Receive(conn.ws, &message)
+	return message, err
+}
+
+func (conn *websocketConn) Send(message string) error {
+	return websocket.Message.Send(conn.ws, message)
+}
+
+func (conn *websocketConn) SetReadDeadline(deadline time.Time) error {
+	return conn.ws.SetReadDeadline(deadline)
+}
+
+func (conn *websocketConn) SetWriteDeadline(deadline time.Time) error {
+	return conn.ws.SetWriteDeadline(deadline)
+}
+
+// Close envia el frame de cierre y vence el deadline de lectura para desbloquear a Receive.
+// La conexion de red la cierra el servidor de websocket al terminar el Handler
+func (conn *websocketConn) Close(status int) error {
+	err := conn.ws.WriteClose(status)
+	conn.ws.SetReadDeadline(time.Now())
+	return err
+}
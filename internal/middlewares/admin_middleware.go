@@ -0,0 +1,32 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminRequired valida que el header X-Admin-Token coincida con la variable de entorno ADMIN_TOKEN.
+// Si ADMIN_TOKEN no esta definida los endpoints de administracion quedan deshabilitados
+func AdminRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminToken := os.Getenv("ADMIN_TOKEN")
+		if adminToken == "" {
+			forbiddenResponse := utils.ResponseToApi(http.StatusForbidden, "Admin endpoints are disabled", false, 0, 0, 0)
+			c.AbortWithStatusJSON(http.StatusForbidden, forbiddenResponse)
+			return
+		}
+
+		token := c.GetHeader("X-Admin-Token")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+			unauthorizedResponse := utils.ResponseToApi(http.StatusUnauthorized, "Invalid admin token", false, 0, 0, 0)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, unauthorizedResponse)
+			return
+		}
+
+		c.Next()
+	}
+}
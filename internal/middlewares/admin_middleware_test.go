@@ -0,0 +1,39 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminRequired(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", AdminRequired(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	makeAdminRequest := func(token string) int {
+		req := httptest.NewRequest("GET", "/", nil)
+		if token != "" {
+			req.Header.Set("X-Admin-Token", token)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Sin ADMIN_TOKEN los endpoints quedan deshabilitados, incluso enviando un token vacio
+	t.Setenv("ADMIN_TOKEN", "")
+	assert.Equal(t, http.StatusForbidden, makeAdminRequest(""))
+	assert.Equal(t, http.StatusForbidden, makeAdminRequest("secreto"))
+
+	t.Setenv("ADMIN_TOKEN", "secreto")
+	assert.Equal(t, http.StatusUnauthorized, makeAdminRequest(""))
+	assert.Equal(t, http.StatusUnauthorized, makeAdminRequest("otro"))
+	assert.Equal(t, http.StatusOK, makeAdminRequest("secreto"))
+}
@@ -0,0 +1,62 @@
+package middlewares
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/auth"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const authUserIdKey = "authUserId"
+
+// AuthRequired valida el token de acceso del header Authorization y guarda en el contexto el ID del usuario autenticado
+func AuthRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		tokenString, found := strings.CutPrefix(header, "Bearer ")
+
+		if !found || tokenString == "" {
+			unauthorizedResponse := utils.ResponseToApi(http.StatusUnauthorized, "Missing authorization token", false, 0, 0, 0)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, unauthorizedResponse)
+			return
+		}
+
+		userId, err := auth.ValidateToken(tokenString, auth.AccessTokenType)
+		if err != nil {
+			unauthorizedResponse := utils.ResponseToApi(http.StatusUnauthorized, err.Error(), false, 0, 0, 0)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, unauthorizedResponse)
+			return
+		}
+
+		c.Set(authUserIdKey, userId)
+		c.Next()
+	}
+}
+
+// GetAuthUserId obtiene el ID del usuario autenticado por AuthRequired
+func GetAuthUserId(c *gin.Context) (int64, bool) {
+	userId, ok := c.Get(authUserIdKey)
+	if !ok {
+		return 0, false
+	}
+
+	id, ok := userId.(int64)
+	return id, ok
+}
+
+// OptionalAuth valida el token de acceso solo si se envia el header Authorization, para las rutas publicas
+// cuyo contenido depende del usuario que consulta (por ejemplo, los tweets de cuentas privadas que sigue)
+func OptionalAuth() gin.HandlerFunc {
+	authRequired := AuthRequired()
+
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+
+		authRequired(c)
+	}
+}
@@ -0,0 +1,83 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/auth"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupAuthRouter retorna un router cuya ruta responde con el ID del usuario autenticado, o 0 si no hay
+func setupAuthRouter(middleware gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", middleware, func(c *gin.Context) {
+		userId, _ := GetAuthUserId(c)
+		c.String(http.StatusOK, strconv.FormatInt(userId, 10))
+	})
+
+	return router
+}
+
+func makeAuthRequest(router *gin.Engine, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/", nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthRequired(t *testing.T) {
+	accessToken, err := auth.GenerateToken(3, auth.AccessTokenType, auth.AccessTokenTTL)
+	assert.NoError(t, err)
+	refreshToken, err := auth.GenerateToken(3, auth.RefreshTokenType, auth.RefreshTokenTTL)
+	assert.NoError(t, err)
+
+	router := setupAuthRouter(AuthRequired())
+
+	requests := []struct {
+		authorization string
+		expected      int
+		body          string
+	}{
+		{"", http.StatusUnauthorized, "Missing authorization token"},
+		{accessToken, http.StatusUnauthorized, "Missing authorization token"},
+		{"Bearer ", http.StatusUnauthorized, "Missing authorization token"},
+		{"Bearer abc", http.StatusUnauthorized, "Invalid or expired token"},
+		{"Bearer " + refreshToken, http.StatusUnauthorized, "Invalid or expired token"},
+		{"Bearer " + accessToken, http.StatusOK, "3"},
+	}
+
+	for _, tc := range requests {
+		w := makeAuthRequest(router, tc.authorization)
+		assert.Equal(t, tc.expected, w.Code, tc.authorization)
+		assert.Contains(t, w.Body.String(), tc.body, tc.authorization)
+	}
+}
+
+func TestOptionalAuth(t *testing.T) {
+	accessToken, err := auth.GenerateToken(3, auth.AccessTokenType, auth.AccessTokenTTL)
+	assert.NoError(t, err)
+
+	router := setupAuthRouter(OptionalAuth())
+
+	// Sin header la ruta se ejecuta sin usuario autenticado
+	w := makeAuthRequest(router, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0", w.Body.String())
+
+	w = makeAuthRequest(router, "Bearer "+accessToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "3", w.Body.String())
+
+	// Si se envia un token invalido se rechaza, en lugar de tratar la request como anonima
+	w = makeAuthRequest(router, "Bearer abc")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,10 @@
+package models
+
+type SnapshotRequest struct {
+	Name string `json:"name"` // Nombre del archivo del snapshot dentro del directorio de snapshots, opcional al crear
+}
+
+type Snapshot struct {
+	Name string `json:"name"` // Nombre del archivo del snapshot
+	Path string `json:"path"` // Ruta del archivo en el servidor
+}
@@ -0,0 +1,17 @@
+package models
+
+type LoginRequest struct {
+	Email    string `json:"email"`    // Email del usuario
+	Password string `json:"password"` // Contraseña en texto plano, se compara contra el hash guardado
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"` // Token obtenido en el login para renovar el token de acceso
+}
+
+type AuthTokens struct {
+	AccessToken  string `json:"accessToken"`  // Token a enviar en el header Authorization: Bearer <token>
+	RefreshToken string `json:"refreshToken"` // Token para obtener un nuevo token de acceso
+	TokenType    string `json:"tokenType"`    // Siempre "Bearer"
+	ExpiresIn    int64  `json:"expiresIn"`    // Segundos de vida del token de acceso
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+type Bookmark struct {
+	UserID    int64      `json:"userId"`    // Usuario que guardo el tweet
+	TweetID   int64      `json:"tweetId"`   // Tweet guardado
+	CreatedAt *time.Time `json:"createdAt"` // Fecha en la que se guardo
+}
+
+type BookmarkedTweet struct {
+	Tweet        Tweet     `json:"tweet"`
+	BookmarkDate time.Time `json:"bookmarkDate"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+type Like struct {
+	UserID    int64      `json:"userId"`    // Usuario que dio like
+	TweetID   int64      `json:"tweetId"`   // Tweet likeado
+	CreatedAt *time.Time `json:"createdAt"` // Fecha del like
+}
+
+type LikeUserInfo struct {
+	LikeUserData User      `json:"likeUserData"`
+	LikeDate     time.Time `json:"likeDate"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// MutedWord es una palabra, frase o hashtag (con '#') que el usuario no quiere ver en su timeline
+type MutedWord struct {
+	ID        int64      `json:"id"`
+	UserID    int64      `json:"userId"`
+	Word      string     `json:"word"`      // Se guarda en minusculas y sin espacios en los extremos
+	ExpiresAt *time.Time `json:"expiresAt"` // Fecha desde la que deja de silenciarse, nil si no expira
+	CreatedAt *time.Time `json:"createdAt"`
+}
+
+// MutedWordRequest es el body para silenciar una palabra. expiresAt es opcional y se envia en formato RFC 3339
+type MutedWordRequest struct {
+	Word      string     `json:"word"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
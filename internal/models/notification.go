@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Notification avisa al usuario de una accion de otro usuario sobre su cuenta o sus tweets
+type Notification struct {
+	ID        int64      `json:"id"`
+	UserID    int64      `json:"userId"`            // Usuario notificado
+	ActorID   int64      `json:"actorId"`           // Usuario que realizo la accion
+	Type      string     `json:"type"`              // follow, like, reply o mention
+	TweetID   *int64     `json:"tweetId,omitempty"` // Tweet likeado, la respuesta o el tweet con la mencion
+	Read      bool       `json:"read"`
+	CreatedAt *time.Time `json:"createdAt"`
+}
+
+// NotificationGroup agrupa las notificaciones del mismo tipo sobre el mismo tweet (o los follows), por ejemplo "X and 4 others followed you"
+type NotificationGroup struct {
+	Type        string              `json:"type"`
+	TweetID     *int64              `json:"tweetId,omitempty"`
+	Actors      []NotificationActor `json:"actors"`      // Ultimos usuarios que realizaron la accion, del mas reciente al mas antiguo
+	ActorsCount int64               `json:"actorsCount"` // Cantidad de usuarios distintos que realizaron la accion
+	Message     string              `json:"message"`
+	Read        bool                `json:"read"`
+	CreatedAt   *time.Time          `json:"createdAt"` // Fecha de la notificacion mas reciente del grupo
+
+	LastID      int64 `json:"-"` // ID de la notificacion mas reciente del grupo
+	GroupBucket int64 `json:"-"` // Ventana de tiempo del grupo (solo en los follows)
+}
+
+// NotificationActor contiene los datos publicos del usuario que realizo la accion notificada
+type NotificationActor struct {
+	ID     int64  `json:"id"`
+	Name   string `json:"name"`
+	Handle string `json:"handle"`
+}
+
+type NotificationsPage struct {
+	UnreadCount   int64               `json:"unreadCount"` // Cantidad de grupos de notificaciones sin leer
+	Notifications []NotificationGroup `json:"notifications"`
+}
@@ -1,7 +1,17 @@
 package models
 
+import "time"
+
 type PaginationWithID struct {
 	ID     int64
 	Limit  int64
 	Offset int64
+	Cursor *Cursor // Si viene informado, se pagina por cursor y se ignora Offset
+}
+
+// Cursor indica el ultimo elemento de la pagina anterior en el paginado por cursor (keyset).
+// La siguiente pagina contiene los elementos con (CreatedAt, ID) estrictamente menores
+type Cursor struct {
+	CreatedAt time.Time
+	ID        int64
 }
@@ -0,0 +1,26 @@
+package models
+
+// RealtimeRequest es el mensaje que un cliente envia por el WebSocket
+type RealtimeRequest struct {
+	Action  string `json:"action"`  // subscribe, unsubscribe o pong (respuesta al ping del servidor)
+	Channel string `json:"channel"` // notifications, tweet:<tweet_id> o user:<user_id>
+}
+
+// RealtimeEvent es el mensaje que el servidor envia por el WebSocket
+type RealtimeEvent struct {
+	Type    string      `json:"type"`              // subscribed, unsubscribed, error, ping, notification, tweet_counters o followers_count
+	Channel string      `json:"channel,omitempty"` // Canal al que corresponde el evento
+	Data    interface{} `json:"data,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+type TweetCounters struct {
+	TweetID    int64 `json:"tweetId"`
+	LikeCount  int64 `json:"likeCount"`
+	ReplyCount int64 `json:"replyCount"` // Respuestas directas visibles para cualquier usuario
+}
+
+type FollowersCount struct {
+	UserID         int64 `json:"userId"`
+	FollowersCount int64 `json:"followersCount"`
+}
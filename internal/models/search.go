@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// TweetSearchQuery es una busqueda de tweets ya interpretada. Todas las condiciones deben cumplirse
+type TweetSearchQuery struct {
+	Terms      []string   // Palabras sueltas, en cualquier parte del contenido
+	Phrases    []string   // Frases ("entre comillas"), con sus palabras juntas y en orden
+	Hashtags   []string   // Hashtags normalizados en minusculas
+	FromUserID *int64     // Autor del tweet (from:userId)
+	Since      *time.Time // Fecha desde la que se busca, inclusive (since:YYYY-MM-DD)
+	Until      *time.Time // Fecha hasta la que se busca, exclusive (until:YYYY-MM-DD)
+	ViewerID   int64      // Usuario que busca, para incluir los tweets de las cuentas privadas que sigue (0 si no hay usuario autenticado)
+}
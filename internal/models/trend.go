@@ -0,0 +1,8 @@
+package models
+
+type Trend struct {
+	Tag           string  `json:"tag"`           // Hashtag normalizado en minusculas
+	Count         int64   `json:"count"`         // Usos del hashtag dentro de la ventana
+	BaselineCount float64 `json:"baselineCount"` // Usos esperados dentro de la ventana segun el periodo previo
+	Velocity      float64 `json:"velocity"`      // Cuantas veces supera el uso actual al esperado, define el ranking
+}
@@ -3,14 +3,52 @@ package models
 import "time"
 
 type Tweet struct {
-	ID         int64     `json:"tweetId"`    // Identificador unico del tweet
-	UserID     int64     `json:"authorId"`   // Identificador del usuario creador del tweet
-	AuthorName *string   `json:"authorName"` // Campo opcional: Nombre del usuario creador del tweet
-	Content    string    `json:"content"`    // Contenido del tweet
-	CreatedAt  time.Time `json:"createdAt"`  // Fecha de creación
+	ID               int64         `json:"tweetId"`                    // Identificador unico del tweet
+	UserID           int64         `json:"authorId"`                   // Identificador del usuario creador del tweet
+	AuthorName       *string       `json:"authorName"`                 // Campo opcional: Nombre del usuario creador del tweet
+	AuthorHandle     *string       `json:"authorHandle,omitempty"`     // Campo opcional: Handle del usuario creador del tweet
+	Content          string        `json:"content"`                    // Contenido del tweet
+	InReplyToTweetID *int64        `json:"inReplyToTweetId,omitempty"` // Campo opcional: Tweet al que responde, nil si no es una respuesta
+	InReplyToUserID  *int64        `json:"inReplyToUserId,omitempty"`  // Autor del tweet al que responde, lo completa la API
+	ConversationID   int64         `json:"conversationId"`             // ID del primer tweet de la conversacion (el propio ID si no es una respuesta)
+	RetweetOfTweetID *int64        `json:"retweetOfTweetId,omitempty"` // Si es un retweet, ID del tweet original. El autor del retweet es el usuario que lo reposteo
+	QuotedTweetID    *int64        `json:"quotedTweetId,omitempty"`    // Si es una cita, ID del tweet citado
+	OriginalTweet    *Tweet        `json:"originalTweet,omitempty"`    // Tweet retweeteado o citado, nil si no es un retweet/cita o si el tweet citado fue eliminado
+	LikeCount        int64         `json:"likeCount"`                  // Cantidad de likes del tweet
+	LikedByViewer    bool          `json:"likedByViewer"`              // Indica si el usuario que consulta le dio like al tweet
+	Entities         TweetEntities `json:"entities"`                   // Hashtags y menciones del contenido, los calcula la API
+	CreatedAt        time.Time     `json:"createdAt"`                  // Fecha de creación
 }
 
-type TimelineCache struct {
-	Tweets     []Tweet `json:"tweets"`
-	IsFullPage bool    `json:"isFullPage"`
+// TweetEntities son los hashtags y menciones del contenido de un tweet. Las posiciones se cuentan en caracteres (runes),
+// igual que el limite de caracteres del tweet: Start es la posicion del '#' o '@' y End la posicion siguiente al ultimo caracter
+type TweetEntities struct {
+	Hashtags []HashtagEntity `json:"hashtags"`
+	Mentions []MentionEntity `json:"mentions"`
+}
+
+type HashtagEntity struct {
+	Tag   string `json:"tag"` // Hashtag sin '#', tal como se escribio
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+type MentionEntity struct {
+	Username string `json:"username"` // Usuario mencionado sin '@', tal como se escribio
+	UserID   *int64 `json:"userId"`   // ID del usuario mencionado, nil si no corresponde a ningun usuario
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+}
+
+// TweetReply es una respuesta dentro del arbol de un hilo, junto a sus propias respuestas
+type TweetReply struct {
+	Tweet
+	Replies []TweetReply `json:"replies"`
+}
+
+// TweetThread es la conversacion alrededor de un tweet: los tweets a los que responde y el arbol de sus respuestas
+type TweetThread struct {
+	Ancestors []Tweet      `json:"ancestors"` // Tweets a los que responde, desde el inicio de la conversacion hasta el tweet padre
+	Tweet     Tweet        `json:"tweet"`     // Tweet solicitado
+	Replies   []TweetReply `json:"replies"`   // Pagina de respuestas directas al tweet, cada una con sus respuestas anidadas
 }
@@ -5,7 +5,25 @@ import "time"
 type User struct {
 	ID        int       `json:"id"`                 // Identificador unico del usuario
 	Name      string    `json:"name"`               // Nombre
-	Email     string    `json:"email"`              // Email
-	Password  string    `json:"password,omitempty"` // Password - El ideal es que este hasheada, para efectos de esta prueba no se hará esa lógica - A su vez, es un campo opcional en el modelo por razones de seguridad
+	Email     string    `json:"email,omitempty"`    // Email - Solo se retorna al propio usuario, no se incluye en los perfiles publicos
+	Handle    string    `json:"handle"`             // Nombre de usuario unico (@handle), sin '@' y en minusculas. Si no se indica al crear el usuario se genera a partir del nombre
+	Bio       string    `json:"bio"`                // Descripcion del perfil
+	Location  string    `json:"location"`           // Ubicacion
+	Website   string    `json:"website"`            // Sitio web (URL http o https)
+	AvatarURL string    `json:"avatarUrl"`          // URL de la imagen de perfil
+	IsPrivate bool      `json:"isPrivate"`          // Si es true solo sus seguidores ven sus tweets y para seguirlo hay que enviar una solicitud
+	Password  string    `json:"password,omitempty"` // Password - Se guarda hasheada con bcrypt - A su vez, es un campo opcional en el modelo por razones de seguridad
 	CreatedAt time.Time `json:"createdAt"`          // Fecha de creación del usuario
 }
+
+// UserUpdate contiene los campos del perfil a modificar. Los campos no enviados (nil) no se modifican y
+// los textos vacios borran el dato (salvo el nombre y el email, que son obligatorios)
+type UserUpdate struct {
+	Name      *string `json:"name"`
+	Email     *string `json:"email"`
+	Bio       *string `json:"bio"`
+	Location  *string `json:"location"`
+	Website   *string `json:"website"`
+	AvatarURL *string `json:"avatarUrl"`
+	IsPrivate *bool   `json:"isPrivate"`
+}
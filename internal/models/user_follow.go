@@ -6,6 +6,7 @@ type UserFollow struct {
 	FollowerID int64      `json:"followerId"` // Usuario seguidor
 	FollowedID int64      `json:"followedId"` // Usuario seguido
 	CreatedAt  *time.Time `json:"createdAt"`  // Fecha de seguimiento
+	Pending    bool       `json:"-"`          // Si es true el usuario seguido es privado y se creo una solicitud de seguimiento en lugar del follow
 }
 
 type UserFollows struct {
@@ -19,6 +20,12 @@ type UserFollowInfo struct {
 	FollowDate     time.Time `json:"followDate"`
 }
 
+// FollowRequestInfo es una solicitud pendiente para seguir a una cuenta privada
+type FollowRequestInfo struct {
+	RequesterUserData User      `json:"requesterUserData"`
+	RequestDate       time.Time `json:"requestDate"`
+}
+
 type FollowsCache struct {
 	Follows    UserFollows `json:"follows"`
 	IsFullPage bool        `json:"isFullPage"`
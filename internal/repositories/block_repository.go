@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// BlockUser guarda el bloqueo y elimina los follows y las solicitudes de seguimiento entre ambos usuarios.
+// Retorna false si el usuario ya estaba bloqueado
+func BlockUser(db *sql.DB, blockerId int64, blockedId int64) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("Error starting BlockUser transaction: %v", err)
+	}
+
+	result, err := tx.Exec(`INSERT INTO blocks (blocker_id, blocked_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, blockerId, blockedId)
+	if err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("[x] Error to create block: %v", err)
+	}
+
+	insertedRows, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("[x] Error to create block: %v", err)
+	}
+
+	if insertedRows == 0 {
+		tx.Rollback()
+		return false, nil
+	}
+
+	for _, table := range []string{"follows", "follow_requests"} {
+		_, err = tx.Exec(fmt.Sprintf(`DELETE FROM %s WHERE (follower_id = $1 AND followed_id = $2) OR (follower_id = $2 AND followed_id = $1)`, table), blockerId, blockedId)
+		if err != nil {
+			tx.Rollback()
+			return false, fmt.Errorf("[x] Error to delete %s: %v", table, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, fmt.Errorf("Error committing BlockUser transaction: %v", err)
+	}
+
+	return true, nil
+}
+
+// UnblockUser elimina el bloqueo. Retorna false si el usuario no estaba bloqueado
+func UnblockUser(db *sql.DB, blockerId int64, blockedId int64) (bool, error) {
+	result, err := db.Exec(`DELETE FROM blocks WHERE blocker_id = $1 AND blocked_id = $2`, blockerId, blockedId)
+	if err != nil {
+		return false, fmt.Errorf("[x] Error to delete block: %v", err)
+	}
+
+	deletedRows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("[x] Error to delete block: %v", err)
+	}
+
+	return deletedRows > 0, nil
+}
+
+// IsBlockedBetween indica si alguno de los dos usuarios bloqueo al otro
+func IsBlockedBetween(db *sql.DB, userId int64, otherUserId int64) (bool, error) {
+	var blocked bool
+	err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM blocks WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1))`, userId, otherUserId).Scan(&blocked)
+	if err != nil {
+		return false, fmt.Errorf("Error checking block: %v", err)
+	}
+
+	return blocked, nil
+}
@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+)
+
+// PostBookmark guarda el tweet en los bookmarks del usuario. Retorna false si ya estaba guardado
+func PostBookmark(db *sql.DB, bookmark *models.Bookmark) (bool, error) {
+	result, err := db.Exec(`INSERT INTO bookmarks (user_id, tweet_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, bookmark.UserID, bookmark.TweetID)
+	if err != nil {
+		return false, fmt.Errorf("[x] Error to create bookmark: %v", err)
+	}
+
+	insertedRows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("[x] Error to create bookmark: %v", err)
+	}
+
+	return insertedRows > 0, nil
+}
+
+// DeleteBookmark quita el tweet de los bookmarks del usuario. Retorna false si no estaba guardado
+func DeleteBookmark(db *sql.DB, userId int64, tweetId int64) (bool, error) {
+	result, err := db.Exec(`DELETE FROM bookmarks WHERE user_id = $1 AND tweet_id = $2`, userId, tweetId)
+	if err != nil {
+		return false, fmt.Errorf("[x] Error to delete bookmark: %v", err)
+	}
+
+	deletedRows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("[x] Error to delete bookmark: %v", err)
+	}
+
+	return deletedRows > 0, nil
+}
+
+// GetBookmarks obtiene los tweets guardados por el usuario, del bookmark mas reciente al mas antiguo. No incluye los tweets de
+// las cuentas que pasaron a ser privadas y que el usuario no sigue (por ejemplo, si dejo de seguirlas despues de guardarlos).
+// Si se recibe un cursor se pagina por cursor a partir de la fecha del bookmark y el ID del tweet, y se ignora el offset
+func GetBookmarks(db *sql.DB, userId int64, limit int64, offset int64, cursor *models.Cursor) ([]models.BookmarkedTweet, error) {
+	query := `SELECT ` + tweetColumns + `, bk.created_at
+				FROM bookmarks AS bk
+				INNER JOIN tweets AS tw ON tw.id = bk.tweet_id
+				INNER JOIN users AS us ON us.id = tw.user_id
+				` + tweetOriginalJoins + `
+				WHERE bk.user_id = $1 AND ` + visibleTweetCondition(1) + ` `
+
+	args := []interface{}{userId}
+
+	if cursor != nil {
+		query += `AND (bk.created_at < $2 OR (bk.created_at = $2 AND bk.tweet_id < $3))
+				ORDER BY bk.created_at DESC, bk.tweet_id DESC
+				LIMIT $4;`
+		args = append(args, cursorTimestamp(cursor), cursor.ID, limit)
+	} else {
+		query += `ORDER BY bk.created_at DESC, bk.tweet_id DESC
+				LIMIT $2
+				OFFSET $3;`
+		args = append(args, limit, offset)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching bookmarks: %v", err)
+	}
+	defer rows.Close()
+
+	bookmarks := []models.BookmarkedTweet{}
+	for rows.Next() {
+		var bookmarkDate time.Time
+		tweet, err := scanTweet(rows, &bookmarkDate)
+		if err != nil {
+			return nil, fmt.Errorf("Error scanning row: %v", err)
+		}
+
+		bookmarks = append(bookmarks, models.BookmarkedTweet{Tweet: tweet, BookmarkDate: bookmarkDate})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Error iterating rows: %v", err)
+	}
+
+	return bookmarks, nil
+}
+
+func CountBookmarks(db *sql.DB, userId int64) (int64, error) {
+	var total int64
+	err := db.QueryRow(`SELECT COUNT(*)
+				FROM bookmarks AS bk
+				INNER JOIN tweets AS tw ON tw.id = bk.tweet_id
+				INNER JOIN users AS us ON us.id = tw.user_id
+				WHERE bk.user_id = $1 AND `+visibleTweetCondition(1), userId).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("Error counting bookmarks: %v", err)
+	}
+
+	return total, nil
+}
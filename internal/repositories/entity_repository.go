@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+)
+
+// GetTweetsByHashtag obtiene los tweets que usan el hashtag (normalizado en minusculas) y que el usuario que consulta puede ver,
+// del mas reciente al mas antiguo. Si se recibe un cursor se pagina por cursor y se ignora el offset
+func GetTweetsByHashtag(db *sql.DB, tag string, viewerId int64, limit int64, offset int64, cursor *models.Cursor) ([]models.Tweet, error) {
+	return getTweetsByEntity(db, "tweet_hashtags", "tag", tag, viewerId, limit, offset, cursor)
+}
+
+func CountTweetsByHashtag(db *sql.DB, tag string, viewerId int64) (int64, error) {
+	return countTweetsByEntity(db, "tweet_hashtags", "tag", tag, viewerId)
+}
+
+// GetMentionTweets obtiene los tweets que mencionan al usuario y que el usuario que consulta puede ver, del mas reciente al mas antiguo.
+// Si se recibe un cursor se pagina por cursor y se ignora el offset
+func GetMentionTweets(db *sql.DB, userId int64, viewerId int64, limit int64, offset int64, cursor *models.Cursor) ([]models.Tweet, error) {
+	return getTweetsByEntity(db, "tweet_mentions", "user_id", userId, viewerId, limit, offset, cursor)
+}
+
+func CountMentionTweets(db *sql.DB, userId int64, viewerId int64) (int64, error) {
+	return countTweetsByEntity(db, "tweet_mentions", "user_id", userId, viewerId)
+}
+
+// getTweetsByEntity recorre el indice (valor, created_at, tweet_id) de la tabla de hashtags o de menciones
+func getTweetsByEntity(db *sql.DB, table string, column string, value interface{}, viewerId int64, limit int64, offset int64, cursor *models.Cursor) ([]models.Tweet, error) {
+	query := fmt.Sprintf(`SELECT %s
+				FROM %s AS ent
+				INNER JOIN tweets AS tw ON tw.id = ent.tweet_id
+				INNER JOIN users AS us ON us.id = tw.user_id
+				`+tweetOriginalJoins+`
+				WHERE ent.%s = $1 AND %s `, tweetColumns, table, column, visibleTweetCondition(2))
+
+	args := []interface{}{value, viewerId}
+
+	if cursor != nil {
+		query += `AND (ent.created_at < $3 OR (ent.created_at = $3 AND ent.tweet_id < $4))
+				ORDER BY ent.created_at DESC, ent.tweet_id DESC
+				LIMIT $5;`
+		args = append(args, cursorTimestamp(cursor), cursor.ID, limit)
+	} else {
+		query += `ORDER BY ent.created_at DESC, ent.tweet_id DESC
+				LIMIT $3
+				OFFSET $4;`
+		args = append(args, limit, offset)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching tweets from %s: %v", table, err)
+	}
+	defer rows.Close()
+
+	return scanTweets(rows)
+}
+
+func countTweetsByEntity(db *sql.DB, table string, column string, value interface{}, viewerId int64) (int64, error) {
+	query := fmt.Sprintf(`SELECT COUNT(*)
+				FROM %s AS ent
+				INNER JOIN tweets AS tw ON tw.id = ent.tweet_id
+				INNER JOIN users AS us ON us.id = tw.user_id
+				WHERE ent.%s = $1 AND %s`, table, column, visibleTweetCondition(2))
+
+	var total int64
+	err := db.QueryRow(query, value, viewerId).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("Error counting tweets from %s: %v", table, err)
+	}
+
+	return total, nil
+}
+
+// GetUserIdsByHandles obtiene el ID del usuario que corresponde a cada handle (en minusculas).
+// Los handles que no corresponden a ningun usuario no se incluyen
+func GetUserIdsByHandles(db *sql.DB, handles []string) (map[string]int64, error) {
+	userIds := map[string]int64{}
+
+	if len(handles) == 0 {
+		return userIds, nil
+	}
+
+	args := make([]interface{}, len(handles))
+	placeholders := make([]string, len(handles))
+	for i, handle := range handles {
+		args[i] = handle
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+
+	rows, err := db.Query(fmt.Sprintf(`SELECT id, handle FROM users WHERE handle IN (%s)`, strings.Join(placeholders, ", ")), args...)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching mentioned users: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var handle string
+		if err := rows.Scan(&id, &handle); err != nil {
+			return nil, fmt.Errorf("Error scanning row: %v", err)
+		}
+		userIds[handle] = id
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Error iterating rows: %v", err)
+	}
+
+	return userIds, nil
+}
@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+)
+
+// CreateFollowRequest guarda la solicitud del seguidor para seguir a una cuenta privada
+func CreateFollowRequest(db *sql.DB, request *models.UserFollow) (bool, error) {
+	_, err := db.Exec(`INSERT INTO follow_requests (follower_id, followed_id) VALUES ($1, $2)`, request.FollowerID, request.FollowedID)
+	if err != nil {
+		return false, fmt.Errorf("[x] Error to create follow request: %v", err)
+	}
+
+	return true, nil
+}
+
+func FollowRequestExists(db *sql.DB, followerId int64, followedId int64) (bool, error) {
+	var exists bool
+	err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM follow_requests WHERE follower_id = $1 AND followed_id = $2)`, followerId, followedId).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("Error checking follow request: %v", err)
+	}
+
+	return exists, nil
+}
+
+// DeleteFollowRequest elimina la solicitud (se rechaza o el seguidor la cancela). Retorna false si la solicitud no existia
+func DeleteFollowRequest(db *sql.DB, followerId int64, followedId int64) (bool, error) {
+	result, err := db.Exec(`DELETE FROM follow_requests WHERE follower_id = $1 AND followed_id = $2`, followerId, followedId)
+	if err != nil {
+		return false, fmt.Errorf("[x] Error to delete follow request: %v", err)
+	}
+
+	deletedRows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("[x] Error to delete follow request: %v", err)
+	}
+
+	return deletedRows > 0, nil
+}
+
+// ApproveFollowRequest reemplaza la solicitud por el follow. Retorna false si la solicitud no existia
+func ApproveFollowRequest(db *sql.DB, followerId int64, followedId int64) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("Error starting ApproveFollowRequest transaction: %v", err)
+	}
+
+	result, err := tx.Exec(`DELETE FROM follow_requests WHERE follower_id = $1 AND followed_id = $2`, followerId, followedId)
+	if err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("[x] Error to delete follow request: %v", err)
+	}
+
+	deletedRows, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("[x] Error to delete follow request: %v", err)
+	}
+
+	if deletedRows == 0 {
+		tx.Rollback()
+		return false, nil
+	}
+
+	_, err = tx.Exec(`INSERT INTO follows (follower_id, followed_id) VALUES ($1, $2)`, followerId, followedId)
+	if err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("[x] Error to create follow: %v", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, fmt.Errorf("Error committing ApproveFollowRequest transaction: %v", err)
+	}
+
+	return true, nil
+}
+
+// ApproveAllFollowRequests reemplaza todas las solicitudes pendientes de la cuenta por follows. Se utiliza cuando la cuenta
+// deja de ser privada. Retorna los IDs de los nuevos seguidores
+func ApproveAllFollowRequests(db *sql.DB, followedId int64) ([]int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("Error starting ApproveAllFollowRequests transaction: %v", err)
+	}
+
+	rows, err := tx.Query(`SELECT follower_id FROM follow_requests WHERE followed_id = $1`, followedId)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("Error fetching follow requests: %v", err)
+	}
+
+	followerIds, err := scanFollowerIds(rows)
+	rows.Close()
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	_, err = tx.Exec(`INSERT INTO follows (follower_id, followed_id) SELECT follower_id, followed_id FROM follow_requests WHERE followed_id = $1`, followedId)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("[x] Error to create follows: %v", err)
+	}
+
+	_, err = tx.Exec(`DELETE FROM follow_requests WHERE followed_id = $1`, followedId)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("[x] Error to delete follow requests: %v", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("Error committing ApproveAllFollowRequests transaction: %v", err)
+	}
+
+	return followerIds, nil
+}
+
+// GetFollowRequests obtiene una pagina de las solicitudes pendientes de la cuenta, de la mas reciente a la mas antigua
+func GetFollowRequests(db *sql.DB, userId int64, limit int64, offset int64) ([]models.FollowRequestInfo, error) {
+	query := `SELECT ` + prefixedUserColumns + `, fr.created_at
+				FROM follow_requests AS fr
+				INNER JOIN users AS u ON u.id = fr.follower_id
+				WHERE fr.followed_id = $1
+				ORDER BY fr.created_at DESC, u.id DESC
+				LIMIT $2
+				OFFSET $3;`
+
+	rows, err := db.Query(query, userId, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching follow requests: %v", err)
+	}
+	defer rows.Close()
+
+	requests := []models.FollowRequestInfo{}
+	for rows.Next() {
+		var requestDate time.Time
+		user, err := scanUser(rows, &requestDate)
+		if err != nil {
+			return nil, fmt.Errorf("Error scanning row: %v", err)
+		}
+
+		requests = append(requests, models.FollowRequestInfo{RequesterUserData: user, RequestDate: requestDate})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Error iterating rows: %v", err)
+	}
+
+	return requests, nil
+}
+
+func CountFollowRequests(db *sql.DB, userId int64) (int64, error) {
+	var total int64
+	err := db.QueryRow(`SELECT COUNT(*) FROM follow_requests WHERE followed_id = $1`, userId).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("Error counting follow requests: %v", err)
+	}
+
+	return total, nil
+}
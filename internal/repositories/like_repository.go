@@ -0,0 +1,461 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+)
+
+// PostLike guarda el like de un usuario a un tweet. Retorna false si el usuario ya le habia dado like.
+// Si updateCount es true tambien se incrementa el contador del tweet; con cache el contador se vuelca a la db sql de forma periodica
+func PostLike(db *sql.DB, like *models.Like, updateCount bool) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("Error starting PostLike transaction: %v", err)
+	}
+
+	result, err := tx.Exec(`INSERT INTO likes (user_id, tweet_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, like.UserID, like.TweetID)
+	if err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("[x] Error to create like: %v", err)
+	}
+
+	insertedRows, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("[x] Error to create like: %v", err)
+	}
+
+	if insertedRows > 0 && updateCount {
+		_, err = tx.Exec(`UPDATE tweets SET like_count = like_count + 1 WHERE id = $1`, like.TweetID)
+		if err != nil {
+			tx.Rollback()
+			return false, fmt.Errorf("[x] Error to update like count: %v", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, fmt.Errorf("Error committing PostLike transaction: %v", err)
+	}
+
+	return insertedRows > 0, nil
+}
+
+// DeleteLike elimina el like de un usuario a un tweet. Retorna false si el like no existia.
+// Si updateCount es true tambien se decrementa el contador del tweet
+func DeleteLike(db *sql.DB, userId int64, tweetId int64, updateCount bool) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("Error starting DeleteLike transaction: %v", err)
+	}
+
+	result, err := tx.Exec(`DELETE FROM likes WHERE user_id = $1 AND tweet_id = $2`, userId, tweetId)
+	if err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("[x] Error to delete like: %v", err)
+	}
+
+	deletedRows, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("[x] Error to delete like: %v", err)
+	}
+
+	if deletedRows > 0 && updateCount {
+		_, err = tx.Exec(`UPDATE tweets SET like_count = like_count - 1 WHERE id = $1`, tweetId)
+		if err != nil {
+			tx.Rollback()
+			return false, fmt.Errorf("[x] Error to update like count: %v", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, fmt.Errorf("Error committing DeleteLike transaction: %v", err)
+	}
+
+	return deletedRows > 0, nil
+}
+
+// GetTweetLikes obtiene los usuarios que dieron like a un tweet, del like mas reciente al mas antiguo
+func GetTweetLikes(db *sql.DB, tweetId int64, limit int64, offset int64) ([]models.LikeUserInfo, error) {
+	query := `SELECT u.id, u.name, u.handle, u.created_at, lk.created_at AS like_date
+				FROM likes AS lk
+				INNER JOIN users AS u ON u.id = lk.user_id
+				WHERE lk.tweet_id = $1
+				ORDER BY like_date DESC, u.id DESC
+				LIMIT $2
+				OFFSET $3;`
+
+	rows, err := db.Query(query, tweetId, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching likes: %v", err)
+	}
+	defer rows.Close()
+
+	likes := []models.LikeUserInfo{}
+	for rows.Next() {
+		var user models.User
+		var likeDate time.Time
+		err := rows.Scan(&user.ID, &user.Name, &user.Handle, &user.CreatedAt, &likeDate)
+		if err != nil {
+			return nil, fmt.Errorf("Error scanning row: %v", err)
+		}
+
+		likes = append(likes, models.LikeUserInfo{LikeUserData: user, LikeDate: likeDate})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Error iterating rows: %v", err)
+	}
+
+	return likes, nil
+}
+
+func CountTweetLikes(db *sql.DB, tweetId int64) (int64, error) {
+	var total int64
+	err := db.QueryRow(`SELECT COUNT(*) FROM likes WHERE tweet_id = $1`, tweetId).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("Error counting likes: %v", err)
+	}
+
+	return total, nil
+}
+
+// GetLikedTweets obtiene los tweets a los que un usuario dio like y que el usuario que consulta puede ver, del like mas reciente al mas antiguo
+func GetLikedTweets(db *sql.DB, userId int64, viewerId int64, limit int64, offset int64) ([]models.Tweet, error) {
+	query := `SELECT ` + tweetColumns + `
+				FROM likes AS lk
+				INNER JOIN tweets AS tw ON tw.id = lk.tweet_id
+				INNER JOIN users AS us ON us.id = tw.user_id
+				` + tweetOriginalJoins + `
+				WHERE lk.user_id = $1 AND ` + visibleTweetCondition(2) + `
+				ORDER BY lk.created_at DESC, lk.tweet_id DESC
+				LIMIT $3
+				OFFSET $4;`
+
+	rows, err := db.Query(query, userId, viewerId, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching liked tweets: %v", err)
+	}
+	defer rows.Close()
+
+	return scanTweets(rows)
+}
+
+func CountLikedTweets(db *sql.DB, userId int64, viewerId int64) (int64, error) {
+	var total int64
+	err := db.QueryRow(`SELECT COUNT(*)
+				FROM likes AS lk
+				INNER JOIN tweets AS tw ON tw.id = lk.tweet_id
+				INNER JOIN users AS us ON us.id = tw.user_id
+				WHERE lk.user_id = $1 AND `+visibleTweetCondition(2), userId, viewerId).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("Error counting liked tweets: %v", err)
+	}
+
+	return total, nil
+}
+
+// GetLikedTweetIds obtiene los IDs de todos los tweets a los que el usuario dio like
+func GetLikedTweetIds(db *sql.DB, userId int64) ([]int64, error) {
+	rows, err := db.Query(`SELECT tweet_id FROM likes WHERE user_id = $1`, userId)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching liked tweet ids: %v", err)
+	}
+	defer rows.Close()
+
+	tweetIds := []int64{}
+	for rows.Next() {
+		var tweetId int64
+		if err := rows.Scan(&tweetId); err != nil {
+			return nil, fmt.Errorf("Error scanning row: %v", err)
+		}
+		tweetIds = append(tweetIds, tweetId)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Error iterating rows: %v", err)
+	}
+
+	return tweetIds, nil
+}
+
+// GetTweetsLikeData obtiene el contador de likes guardado en la db sql de cada tweet y si el usuario le dio like.
+// Un viewerId igual a 0 indica que no hay usuario que consulta
+func GetTweetsLikeData(db *sql.DB, viewerId int64, tweetIds []int64) (map[int64]int64, map[int64]bool, error) {
+	likeCounts := map[int64]int64{}
+	likedTweets := map[int64]bool{}
+
+	if len(tweetIds) == 0 {
+		return likeCounts, likedTweets, nil
+	}
+
+	args := []interface{}{viewerId}
+	placeholders := make([]string, len(tweetIds))
+	for i, id := range tweetIds {
+		args = append(args, id)
+		placeholders[i] = fmt.Sprintf("$%d", len(args))
+	}
+
+	query := fmt.Sprintf(`SELECT tw.id, tw.like_count,
+				EXISTS (SELECT 1 FROM likes AS lk WHERE lk.user_id = $1 AND lk.tweet_id = tw.id)
+				FROM tweets AS tw
+				WHERE tw.id IN (%s);`, strings.Join(placeholders, ", "))
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error fetching likes data: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, likeCount int64
+		var liked bool
+		if err := rows.Scan(&id, &likeCount, &liked); err != nil {
+			return nil, nil, fmt.Errorf("Error scanning row: %v", err)
+		}
+
+		likeCounts[id] = likeCount
+		likedTweets[id] = liked
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("Error iterating rows: %v", err)
+	}
+
+	return likeCounts, likedTweets, nil
+}
+
+// AddToLikeCount suma delta al contador de likes de un tweet guardado en la db sql
+func AddToLikeCount(db *sql.DB, tweetId int64, delta int64) error {
+	_, err := db.Exec(`UPDATE tweets SET like_count = like_count + $1 WHERE id = $2`, delta, tweetId)
+	if err != nil {
+		return fmt.Errorf("Error updating like count: %v", err)
+	}
+
+	return nil
+}
+
+// RecalculateLikeCounts recalcula desde la tabla likes el contador de los tweets indicados cuyo like_count no coincide con sus likes
+func RecalculateLikeCounts(db *sql.DB, tweetIds []int64) error {
+	if len(tweetIds) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, len(tweetIds))
+	placeholders := make([]string, len(tweetIds))
+	for i, id := range tweetIds {
+		args[i] = id
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+
+	query := fmt.Sprintf(`UPDATE tweets SET like_count = (SELECT COUNT(*) FROM likes WHERE likes.tweet_id = tweets.id)
+				WHERE tweets.id IN (%s) AND like_count <> (SELECT COUNT(*) FROM likes WHERE likes.tweet_id = tweets.id);`, strings.Join(placeholders, ", "))
+
+	_, err := db.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("Error recalculating like counts: %v", err)
+	}
+
+	return nil
+}
+
+// RecalculateAllLikeCounts recalcula desde la tabla likes el contador de todos los tweets. Recorre la tabla tweets completa,
+// por lo que solo se usa al restaurar un snapshot
+func RecalculateAllLikeCounts(db *sql.DB) error {
+	_, err := db.Exec(`UPDATE tweets SET like_count = (SELECT COUNT(*) FROM likes WHERE likes.tweet_id = tweets.id)
+				WHERE like_count <> (SELECT COUNT(*) FROM likes WHERE likes.tweet_id = tweets.id);`)
+	if err != nil {
+		return fmt.Errorf("Error recalculating like counts: %v", err)
+	}
+
+	return nil
+}
+
+// Los likes que todavia no se volcaron a la db sql se acumulan en un contador por tweet (la diferencia con like_count),
+// y los tweets con likes pendientes se registran en un set para saber que contadores volcar.
+// Los tweets con likes nuevos tambien se registran en likesToReconcileKey, que vacia el recalculo de contadores
+const (
+	pendingLikesKey      = "pending_likes"
+	likesToReconcileKey  = "likes_to_reconcile"
+	likeReconcileLockKey = "locks:like_reconcile"
+)
+
+func PendingLikesKey(tweetId int64) string {
+	return fmt.Sprintf("pending_likes:%d", tweetId)
+}
+
+// AddPendingLike suma delta al contador de likes pendientes del tweet
+func AddPendingLike(cacheClient cache.Cache, tweetId int64, delta int64) error {
+	var ctx = context.Background()
+
+	_, err := cacheClient.IncrBy(ctx, PendingLikesKey(tweetId), delta)
+	if err != nil {
+		return fmt.Errorf("Error updating like count in cache: %v", err)
+	}
+
+	err = cacheClient.SAdd(ctx, pendingLikesKey, strconv.FormatInt(tweetId, 10))
+	if err != nil {
+		return fmt.Errorf("Error updating pending likes: %v", err)
+	}
+
+	return AddTweetsToReconcile(cacheClient, []int64{tweetId})
+}
+
+// DiscountPendingLikes resta del contador de likes pendientes del tweet los likes que ya se volcaron a la db sql
+func DiscountPendingLikes(cacheClient cache.Cache, tweetId int64, flushed int64) error {
+	var ctx = context.Background()
+
+	_, err := cacheClient.IncrBy(ctx, PendingLikesKey(tweetId), -flushed)
+	if err != nil {
+		return fmt.Errorf("Error updating like count in cache: %v", err)
+	}
+
+	return nil
+}
+
+// DeletePendingLikesIfUnchanged elimina los contadores de likes pendientes que todavia tienen el valor leido en pendingLikes.
+// Retorna los tweets cuyo contador cambio (por ejemplo por un like nuevo), que no se eliminan
+func DeletePendingLikesIfUnchanged(cacheClient cache.Cache, pendingLikes map[int64]int64) ([]int64, error) {
+	var ctx = context.Background()
+
+	changedIds := []int64{}
+	for tweetId, pending := range pendingLikes {
+		deleted, err := cacheClient.DeleteIfEquals(ctx, PendingLikesKey(tweetId), strconv.FormatInt(pending, 10))
+		if err != nil {
+			return nil, fmt.Errorf("Error deleting pending likes: %v", err)
+		}
+
+		if !deleted {
+			changedIds = append(changedIds, tweetId)
+		}
+	}
+
+	return changedIds, nil
+}
+
+// GetPendingLikes obtiene los likes que todavia no se volcaron a la db sql de cada tweet
+func GetPendingLikes(cacheClient cache.Cache, tweetIds []int64) (map[int64]int64, error) {
+	var ctx = context.Background()
+
+	keys := make([]string, len(tweetIds))
+	for i, id := range tweetIds {
+		keys[i] = PendingLikesKey(id)
+	}
+
+	values, err := cacheClient.MGet(ctx, keys...)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting like counts from cache: %v", err)
+	}
+
+	pending := map[int64]int64{}
+	for i, id := range tweetIds {
+		value, ok := values[keys[i]]
+		if !ok {
+			continue
+		}
+
+		delta, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid like count in cache: %v", err)
+		}
+		pending[id] = delta
+	}
+
+	return pending, nil
+}
+
+// GetTweetsWithPendingLikes obtiene los tweets cuyos contadores de likes tienen cambios sin volcar a la db sql
+func GetTweetsWithPendingLikes(cacheClient cache.Cache) ([]int64, error) {
+	return getTweetIdsSet(cacheClient, pendingLikesKey)
+}
+
+// GetTweetsToReconcile obtiene los tweets con likes nuevos desde el ultimo recalculo de contadores
+func GetTweetsToReconcile(cacheClient cache.Cache) ([]int64, error) {
+	return getTweetIdsSet(cacheClient, likesToReconcileKey)
+}
+
+// AddTweetsToReconcile registra los tweets para que el proximo recalculo revise sus contadores
+func AddTweetsToReconcile(cacheClient cache.Cache, tweetIds []int64) error {
+	if len(tweetIds) == 0 {
+		return nil
+	}
+
+	err := cacheClient.SAdd(context.Background(), likesToReconcileKey, formatTweetIds(tweetIds)...)
+	if err != nil {
+		return fmt.Errorf("Error updating likes to reconcile: %v", err)
+	}
+
+	return nil
+}
+
+// RemoveTweetsToReconcile quita los tweets del set de tweets a revisar en el proximo recalculo
+func RemoveTweetsToReconcile(cacheClient cache.Cache, tweetIds []int64) error {
+	if len(tweetIds) == 0 {
+		return nil
+	}
+
+	err := cacheClient.SRem(context.Background(), likesToReconcileKey, formatTweetIds(tweetIds)...)
+	if err != nil {
+		return fmt.Errorf("Error updating likes to reconcile: %v", err)
+	}
+
+	return nil
+}
+
+// AcquireLikeReconcileLock reserva el recalculo de contadores durante ttl. Retorna false si otra instancia de la API ya lo reservo
+func AcquireLikeReconcileLock(cacheClient cache.Cache, ttl time.Duration) (bool, error) {
+	acquired, err := cacheClient.SetNX(context.Background(), likeReconcileLockKey, "1", ttl)
+	if err != nil {
+		return false, fmt.Errorf("Error acquiring like reconcile lock: %v", err)
+	}
+
+	return acquired, nil
+}
+
+func getTweetIdsSet(cacheClient cache.Cache, key string) ([]int64, error) {
+	var ctx = context.Background()
+	members, err := cacheClient.SMembers(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting %s: %v", key, err)
+	}
+
+	ids := make([]int64, 0, len(members))
+	for _, member := range members {
+		id, err := strconv.ParseInt(member, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid tweet ID in %s: %v", key, err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func formatTweetIds(tweetIds []int64) []string {
+	members := make([]string, len(tweetIds))
+	for i, id := range tweetIds {
+		members[i] = strconv.FormatInt(id, 10)
+	}
+
+	return members
+}
+
+// RemoveTweetFromPendingLikes quita el tweet del set de tweets con likes pendientes
+func RemoveTweetFromPendingLikes(cacheClient cache.Cache, tweetId int64) error {
+	var ctx = context.Background()
+	err := cacheClient.SRem(ctx, pendingLikesKey, strconv.FormatInt(tweetId, 10))
+	if err != nil {
+		return fmt.Errorf("Error updating pending likes: %v", err)
+	}
+
+	return nil
+}
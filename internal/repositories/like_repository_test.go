@@ -0,0 +1,157 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupTestDB conecta una db SQLite en memoria con las migraciones aplicadas, que se elimina al terminar el test
+func setupTestDB(t *testing.T) *sql.DB {
+	database := &db.SQLiteDatabase{}
+	conn, err := database.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+// createTestUsers crea count usuarios y retorna sus IDs
+func createTestUsers(t *testing.T, conn *sql.DB, count int) []int64 {
+	ids := []int64{}
+	for i := 1; i <= count; i++ {
+		id, err := CreateUser(conn, models.User{
+			Name:     fmt.Sprintf("Usuario %d", i),
+			Email:    fmt.Sprintf("usuario%d@hotmail.com", i),
+			Password: "1234",
+			Handle:   fmt.Sprintf("usuario%d", i),
+		})
+		if err != nil {
+			t.Fatalf("failed to create user: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+func createTestTweet(t *testing.T, conn *sql.DB, userId int64, content string) int64 {
+	tweet := models.Tweet{UserID: userId, Content: content}
+	_, err := PostTweet(conn, &tweet)
+	if err != nil {
+		t.Fatalf("failed to create tweet: %v", err)
+	}
+
+	return tweet.ID
+}
+
+func getLikeCount(t *testing.T, conn *sql.DB, tweetId int64) int64 {
+	tweet, err := GetTweetById(conn, tweetId)
+	assert.NoError(t, err)
+
+	return tweet.LikeCount
+}
+
+func TestGetTweetsLikeData(t *testing.T) {
+	conn := setupTestDB(t)
+	userIds := createTestUsers(t, conn, 3)
+
+	likedId := createTestTweet(t, conn, userIds[0], "Tweet likeado")
+	otherId := createTestTweet(t, conn, userIds[0], "Tweet sin likes")
+
+	_, err := PostLike(conn, &models.Like{UserID: userIds[1], TweetID: likedId}, true)
+	assert.NoError(t, err)
+
+	// El like es del usuario que consulta, de otro usuario o no hay usuario que consulta (viewerId 0)
+	for _, viewerId := range []int64{userIds[1], userIds[2], 0} {
+		likeCounts, likedTweets, err := GetTweetsLikeData(conn, viewerId, []int64{likedId, otherId})
+		assert.NoError(t, err)
+		assert.Equal(t, map[int64]int64{likedId: 1, otherId: 0}, likeCounts)
+		assert.Equal(t, map[int64]bool{likedId: viewerId == userIds[1], otherId: false}, likedTweets, viewerId)
+	}
+}
+
+func TestRecalculateLikeCounts(t *testing.T) {
+	conn := setupTestDB(t)
+	userIds := createTestUsers(t, conn, 2)
+
+	firstId := createTestTweet(t, conn, userIds[0], "Primer tweet")
+	secondId := createTestTweet(t, conn, userIds[0], "Segundo tweet")
+
+	for _, tweetId := range []int64{firstId, secondId} {
+		_, err := PostLike(conn, &models.Like{UserID: userIds[1], TweetID: tweetId}, false)
+		assert.NoError(t, err)
+		assert.NoError(t, AddToLikeCount(conn, tweetId, 5))
+	}
+
+	// Solo se corrigen los tweets indicados
+	assert.NoError(t, RecalculateLikeCounts(conn, []int64{firstId}))
+	assert.Equal(t, int64(1), getLikeCount(t, conn, firstId))
+	assert.Equal(t, int64(5), getLikeCount(t, conn, secondId))
+
+	assert.NoError(t, RecalculateLikeCounts(conn, []int64{}))
+
+	assert.NoError(t, RecalculateAllLikeCounts(conn))
+	assert.Equal(t, int64(1), getLikeCount(t, conn, firstId))
+	assert.Equal(t, int64(1), getLikeCount(t, conn, secondId))
+}
+
+func TestPendingLikesToReconcile(t *testing.T) {
+	cacheClient := cache.NewMemoryCache(cache.DefaultMemoryCacheSize)
+
+	// Los likes pendientes registran el tweet para el proximo recalculo
+	assert.NoError(t, AddPendingLike(cacheClient, 1, 1))
+	assert.NoError(t, AddPendingLike(cacheClient, 2, 1))
+
+	tweetIds, err := GetTweetsToReconcile(cacheClient)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []int64{1, 2}, tweetIds)
+
+	assert.NoError(t, RemoveTweetsToReconcile(cacheClient, []int64{1, 2}))
+	tweetIds, err = GetTweetsToReconcile(cacheClient)
+	assert.NoError(t, err)
+	assert.Empty(t, tweetIds)
+
+	// Solo se eliminan los contadores que no cambiaron desde que se leyeron
+	pendingLikes, err := GetPendingLikes(cacheClient, []int64{1, 2})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64]int64{1: 1, 2: 1}, pendingLikes)
+
+	assert.NoError(t, AddPendingLike(cacheClient, 2, 1))
+
+	changedIds, err := DeletePendingLikesIfUnchanged(cacheClient, pendingLikes)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{2}, changedIds)
+
+	pendingLikes, err = GetPendingLikes(cacheClient, []int64{1, 2})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64]int64{2: 2}, pendingLikes)
+}
+
+func TestAcquireLikeReconcileLock(t *testing.T) {
+	cacheClient := cache.NewMemoryCache(cache.DefaultMemoryCacheSize)
+
+	acquired, err := AcquireLikeReconcileLock(cacheClient, 50*time.Millisecond)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	// Otra instancia no puede reservar el recalculo hasta que expira el lock
+	acquired, err = AcquireLikeReconcileLock(cacheClient, 50*time.Millisecond)
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+
+	time.Sleep(60 * time.Millisecond)
+
+	acquired, err = AcquireLikeReconcileLock(cacheClient, 50*time.Millisecond)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+}
@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// MuteUser guarda el silenciamiento. Retorna false si el usuario ya estaba silenciado
+func MuteUser(db *sql.DB, muterId int64, mutedId int64) (bool, error) {
+	result, err := db.Exec(`INSERT INTO mutes (muter_id, muted_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, muterId, mutedId)
+	if err != nil {
+		return false, fmt.Errorf("[x] Error to create mute: %v", err)
+	}
+
+	insertedRows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("[x] Error to create mute: %v", err)
+	}
+
+	return insertedRows > 0, nil
+}
+
+// UnmuteUser elimina el silenciamiento. Retorna false si el usuario no estaba silenciado
+func UnmuteUser(db *sql.DB, muterId int64, mutedId int64) (bool, error) {
+	result, err := db.Exec(`DELETE FROM mutes WHERE muter_id = $1 AND muted_id = $2`, muterId, mutedId)
+	if err != nil {
+		return false, fmt.Errorf("[x] Error to delete mute: %v", err)
+	}
+
+	deletedRows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("[x] Error to delete mute: %v", err)
+	}
+
+	return deletedRows > 0, nil
+}
+
+// GetMuterIds obtiene los IDs de los usuarios que silenciaron al usuario
+func GetMuterIds(db *sql.DB, userId int64) ([]int64, error) {
+	rows, err := db.Query(`SELECT muter_id FROM mutes WHERE muted_id = $1`, userId)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching muter ids: %v", err)
+	}
+	defer rows.Close()
+
+	return scanFollowerIds(rows)
+}
+
+// IsMuted indica si el usuario silencio al otro usuario
+func IsMuted(db *sql.DB, muterId int64, mutedId int64) (bool, error) {
+	var muted bool
+	err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM mutes WHERE muter_id = $1 AND muted_id = $2)`, muterId, mutedId).Scan(&muted)
+	if err != nil {
+		return false, fmt.Errorf("Error checking mute: %v", err)
+	}
+
+	return muted, nil
+}
@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+)
+
+// CreateMutedWord guarda la palabra silenciada y completa su ID y fecha de creacion. Retorna false si el usuario ya la habia silenciado
+func CreateMutedWord(db *sql.DB, mutedWord *models.MutedWord) (bool, error) {
+	var expiresAt *string
+	if mutedWord.ExpiresAt != nil {
+		timestamp := sqlTimestamp(*mutedWord.ExpiresAt)
+		expiresAt = &timestamp
+	}
+
+	err := db.QueryRow(`INSERT INTO muted_words (user_id, word, expires_at) VALUES ($1, $2, $3)
+				ON CONFLICT DO NOTHING
+				RETURNING id, created_at`, mutedWord.UserID, mutedWord.Word, expiresAt).Scan(&mutedWord.ID, &mutedWord.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("[x] Error to create muted word: %v", err)
+	}
+
+	return true, nil
+}
+
+// DeleteMutedWord elimina la palabra silenciada del usuario. Retorna false si no existia
+func DeleteMutedWord(db *sql.DB, userId int64, mutedWordId int64) (bool, error) {
+	result, err := db.Exec(`DELETE FROM muted_words WHERE id = $1 AND user_id = $2`, mutedWordId, userId)
+	if err != nil {
+		return false, fmt.Errorf("[x] Error to delete muted word: %v", err)
+	}
+
+	deletedRows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("[x] Error to delete muted word: %v", err)
+	}
+
+	return deletedRows > 0, nil
+}
+
+// DeleteExpiredMutedWords elimina las palabras silenciadas del usuario que ya expiraron
+func DeleteExpiredMutedWords(db *sql.DB, userId int64, now time.Time) error {
+	_, err := db.Exec(`DELETE FROM muted_words WHERE user_id = $1 AND expires_at <= $2`, userId, sqlTimestamp(now))
+	if err != nil {
+		return fmt.Errorf("[x] Error to delete expired muted words: %v", err)
+	}
+
+	return nil
+}
+
+// GetActiveMutedWords obtiene las palabras silenciadas del usuario que no expiraron, de la mas reciente a la mas antigua
+func GetActiveMutedWords(db *sql.DB, userId int64, now time.Time) ([]models.MutedWord, error) {
+	rows, err := db.Query(`SELECT id, user_id, word, expires_at, created_at
+				FROM muted_words
+				WHERE user_id = $1 AND (expires_at IS NULL OR expires_at > $2)
+				ORDER BY created_at DESC, id DESC`, userId, sqlTimestamp(now))
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching muted words: %v", err)
+	}
+	defer rows.Close()
+
+	mutedWords := []models.MutedWord{}
+	for rows.Next() {
+		var mutedWord models.MutedWord
+		var expiresAt sql.NullTime
+		if err := rows.Scan(&mutedWord.ID, &mutedWord.UserID, &mutedWord.Word, &expiresAt, &mutedWord.CreatedAt); err != nil {
+			return nil, fmt.Errorf("Error scanning row: %v", err)
+		}
+
+		if expiresAt.Valid {
+			mutedWord.ExpiresAt = &expiresAt.Time
+		}
+
+		mutedWords = append(mutedWords, mutedWord)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Error iterating rows: %v", err)
+	}
+
+	return mutedWords, nil
+}
@@ -0,0 +1,156 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+)
+
+// visibleNotificationCondition excluye las notificaciones de los usuarios que el usuario notificado bloqueo o silencio
+const visibleNotificationCondition = `NOT EXISTS (SELECT 1 FROM blocks AS nt_bl WHERE nt_bl.blocker_id = nt.user_id AND nt_bl.blocked_id = nt.actor_id)
+				AND NOT EXISTS (SELECT 1 FROM mutes AS nt_mu WHERE nt_mu.muter_id = nt.user_id AND nt_mu.muted_id = nt.actor_id)`
+
+// FollowNotificationGroupWindow es la ventana de tiempo en la que se agrupan los follows: los de distintas ventanas forman grupos distintos
+const FollowNotificationGroupWindow = 24 * time.Hour
+
+// notificationGroupsQuery agrupa las notificaciones visibles del usuario por tipo, tweet, ventana de tiempo y estado de lectura.
+// Las respuestas y menciones tienen como tweet_id el tweet que las genero, por lo que no se agrupan entre si
+const notificationGroupsQuery = `SELECT nt.type, nt.tweet_id, nt.group_bucket, nt.is_read, COUNT(DISTINCT nt.actor_id) AS actors_count, MAX(nt.id) AS last_id
+				FROM notifications AS nt
+				WHERE nt.user_id = $1 AND ` + visibleNotificationCondition + `
+				GROUP BY nt.type, nt.tweet_id, nt.group_bucket, nt.is_read`
+
+// notificationGroupBucket retorna la ventana de tiempo de la notificacion. Solo los follows (que no tienen tweet) se separan por ventana
+func notificationGroupBucket(notificationType string, now time.Time) int64 {
+	if notificationType != "follow" {
+		return 0
+	}
+
+	return now.Unix() / int64(FollowNotificationGroupWindow/time.Second)
+}
+
+// CreateNotification guarda la notificacion y completa su ID y fecha de creacion
+func CreateNotification(db *sql.DB, notification *models.Notification) error {
+	groupBucket := notificationGroupBucket(notification.Type, time.Now())
+
+	err := db.QueryRow(`INSERT INTO notifications (user_id, actor_id, type, tweet_id, group_bucket) VALUES ($1, $2, $3, $4, $5)
+				RETURNING id, created_at`, notification.UserID, notification.ActorID, notification.Type, notification.TweetID, groupBucket).Scan(&notification.ID, &notification.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("[x] Error to create notification: %v", err)
+	}
+
+	return nil
+}
+
+// GetNotificationGroups obtiene los grupos de notificaciones del usuario, del mas reciente al mas antiguo. Los actores de cada grupo se obtienen con GetNotificationGroupActors
+func GetNotificationGroups(db *sql.DB, userId int64, limit int64, offset int64) ([]models.NotificationGroup, error) {
+	query := `SELECT gr.type, gr.tweet_id, gr.group_bucket, gr.is_read, gr.actors_count, gr.last_id, nt.created_at
+				FROM (` + notificationGroupsQuery + `) AS gr
+				INNER JOIN notifications AS nt ON nt.id = gr.last_id
+				ORDER BY gr.last_id DESC
+				LIMIT $2
+				OFFSET $3;`
+
+	rows, err := db.Query(query, userId, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching notifications: %v", err)
+	}
+	defer rows.Close()
+
+	groups := []models.NotificationGroup{}
+	for rows.Next() {
+		var group models.NotificationGroup
+		var tweetId sql.NullInt64
+		err := rows.Scan(&group.Type, &tweetId, &group.GroupBucket, &group.Read, &group.ActorsCount, &group.LastID, &group.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("Error scanning row: %v", err)
+		}
+
+		if tweetId.Valid {
+			group.TweetID = &tweetId.Int64
+		}
+
+		groups = append(groups, group)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Error iterating rows: %v", err)
+	}
+
+	return groups, nil
+}
+
+// GetNotificationGroupActors obtiene los ultimos usuarios distintos que realizaron la accion del grupo, del mas reciente al mas antiguo.
+// De cada usuario solo se obtienen los datos publicos que se muestran en la notificacion (ID, nombre y handle)
+func GetNotificationGroupActors(db *sql.DB, userId int64, group models.NotificationGroup, limit int64) ([]models.NotificationActor, error) {
+	// Los follows no tienen tweet, se compara con 0 para no depender de IS NOT DISTINCT FROM
+	var tweetId int64
+	if group.TweetID != nil {
+		tweetId = *group.TweetID
+	}
+
+	query := `SELECT u.id, u.name, u.handle, MAX(nt.id) AS last_id
+				FROM notifications AS nt
+				INNER JOIN users AS u ON u.id = nt.actor_id
+				WHERE nt.user_id = $1 AND nt.type = $2 AND COALESCE(nt.tweet_id, 0) = $3 AND nt.group_bucket = $4 AND nt.is_read = $5 AND ` + visibleNotificationCondition + `
+				GROUP BY u.id, u.name, u.handle
+				ORDER BY last_id DESC
+				LIMIT $6;`
+
+	rows, err := db.Query(query, userId, group.Type, tweetId, group.GroupBucket, group.Read, limit)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching notification actors: %v", err)
+	}
+	defer rows.Close()
+
+	actors := []models.NotificationActor{}
+	for rows.Next() {
+		var actor models.NotificationActor
+		var lastId int64
+		err := rows.Scan(&actor.ID, &actor.Name, &actor.Handle, &lastId)
+		if err != nil {
+			return nil, fmt.Errorf("Error scanning row: %v", err)
+		}
+
+		actors = append(actors, actor)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Error iterating rows: %v", err)
+	}
+
+	return actors, nil
+}
+
+// CountNotificationGroups cuenta los grupos de notificaciones del usuario. Si onlyUnread es true solo cuenta los grupos sin leer
+func CountNotificationGroups(db *sql.DB, userId int64, onlyUnread bool) (int64, error) {
+	query := `SELECT COUNT(*) FROM (` + notificationGroupsQuery + `) AS gr`
+	if onlyUnread {
+		query += ` WHERE NOT gr.is_read`
+	}
+
+	var total int64
+	err := db.QueryRow(query, userId).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("Error counting notifications: %v", err)
+	}
+
+	return total, nil
+}
+
+// MarkNotificationsRead marca como leidas todas las notificaciones del usuario y retorna cuantas estaban sin leer
+func MarkNotificationsRead(db *sql.DB, userId int64) (int64, error) {
+	result, err := db.Exec(`UPDATE notifications SET is_read = TRUE WHERE user_id = $1 AND NOT is_read`, userId)
+	if err != nil {
+		return 0, fmt.Errorf("[x] Error to mark notifications as read: %v", err)
+	}
+
+	updatedRows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("[x] Error to mark notifications as read: %v", err)
+	}
+
+	return updatedRows, nil
+}
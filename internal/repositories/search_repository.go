@@ -0,0 +1,176 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/db"
+)
+
+// TweetSearchRepository busca tweets por su contenido. Cada db tiene su propio indice de texto (FTS4 en SQLite,
+// tsvector en PostgreSQL), por lo que hay una implementacion por db
+type TweetSearchRepository interface {
+	// SearchTweets obtiene los tweets que cumplen la busqueda, del mas reciente al mas antiguo.
+	// Si se recibe un cursor se pagina por cursor y se ignora el offset
+	SearchTweets(query models.TweetSearchQuery, limit int64, offset int64, cursor *models.Cursor) ([]models.Tweet, error)
+	CountTweets(query models.TweetSearchQuery) (int64, error)
+}
+
+// NewTweetSearchRepository retorna la implementacion que corresponde a la db de la conexion
+func NewTweetSearchRepository(conn *sql.DB) TweetSearchRepository {
+	if db.IsSQLite(conn) {
+		return &SQLiteTweetSearchRepository{DB: conn}
+	}
+
+	return &PostgresTweetSearchRepository{DB: conn}
+}
+
+// SQLiteTweetSearchRepository busca el texto con la tabla FTS4 tweets_search
+type SQLiteTweetSearchRepository struct {
+	DB *sql.DB
+}
+
+func (sr *SQLiteTweetSearchRepository) SearchTweets(query models.TweetSearchQuery, limit int64, offset int64, cursor *models.Cursor) ([]models.Tweet, error) {
+	return searchTweets(sr.DB, query, sr.textConditions, limit, offset, cursor)
+}
+
+func (sr *SQLiteTweetSearchRepository) CountTweets(query models.TweetSearchQuery) (int64, error) {
+	return countSearchTweets(sr.DB, query, sr.textConditions)
+}
+
+func (sr *SQLiteTweetSearchRepository) textConditions(query models.TweetSearchQuery, args *[]interface{}) []string {
+	texts := append(append([]string{}, query.Terms...), query.Phrases...)
+	if len(texts) == 0 {
+		return nil
+	}
+
+	// Cada palabra o frase va entre comillas, asi su contenido no se interpreta como operadores de FTS4. Las comillas y
+	// los asteriscos (prefijos) se reemplazan por espacios, que el tokenizer ignora igual que al resto de la puntuacion.
+	// Una frase entre comillas solo coincide con esas palabras juntas y en orden
+	quoted := make([]string, len(texts))
+	for i, text := range texts {
+		quoted[i] = `"` + strings.NewReplacer(`"`, " ", "*", " ").Replace(text) + `"`
+	}
+
+	*args = append(*args, strings.Join(quoted, " "))
+	return []string{fmt.Sprintf(`tw.id IN (SELECT docid FROM tweets_search WHERE tweets_search MATCH $%d)`, len(*args))}
+}
+
+// PostgresTweetSearchRepository busca el texto con la columna search_vector (tsvector con indice GIN)
+type PostgresTweetSearchRepository struct {
+	DB *sql.DB
+}
+
+func (sr *PostgresTweetSearchRepository) SearchTweets(query models.TweetSearchQuery, limit int64, offset int64, cursor *models.Cursor) ([]models.Tweet, error) {
+	return searchTweets(sr.DB, query, sr.textConditions, limit, offset, cursor)
+}
+
+func (sr *PostgresTweetSearchRepository) CountTweets(query models.TweetSearchQuery) (int64, error) {
+	return countSearchTweets(sr.DB, query, sr.textConditions)
+}
+
+func (sr *PostgresTweetSearchRepository) textConditions(query models.TweetSearchQuery, args *[]interface{}) []string {
+	conditions := []string{}
+
+	if len(query.Terms) > 0 {
+		*args = append(*args, strings.Join(query.Terms, " "))
+		conditions = append(conditions, fmt.Sprintf(`tw.search_vector @@ plainto_tsquery('simple', $%d)`, len(*args)))
+	}
+
+	for _, phrase := range query.Phrases {
+		*args = append(*args, phrase)
+		conditions = append(conditions, fmt.Sprintf(`tw.search_vector @@ phraseto_tsquery('simple', $%d)`, len(*args)))
+	}
+
+	return conditions
+}
+
+// textConditionsFunc agrega a args los parametros de las condiciones de texto de la busqueda y retorna las condiciones
+type textConditionsFunc func(query models.TweetSearchQuery, args *[]interface{}) []string
+
+// searchConditions arma el WHERE de la busqueda: las condiciones de texto de cada db y los filtros comunes a ambas.
+// Los retweets no tienen contenido propio, por lo que no se incluyen (el tweet original ya aparece en la busqueda),
+// y tampoco los tweets de cuentas privadas que el usuario que consulta no sigue
+func searchConditions(query models.TweetSearchQuery, textConditions textConditionsFunc, args *[]interface{}) string {
+	conditions := []string{"tw.retweet_of_tweet_id IS NULL"}
+	conditions = append(conditions, textConditions(query, args)...)
+
+	*args = append(*args, query.ViewerID)
+	conditions = append(conditions, visibleTweetCondition(len(*args)))
+
+	for _, tag := range query.Hashtags {
+		*args = append(*args, tag)
+		conditions = append(conditions, fmt.Sprintf(`EXISTS (SELECT 1 FROM tweet_hashtags AS ht WHERE ht.tweet_id = tw.id AND ht.tag = $%d)`, len(*args)))
+	}
+
+	if query.FromUserID != nil {
+		*args = append(*args, *query.FromUserID)
+		conditions = append(conditions, fmt.Sprintf(`tw.user_id = $%d`, len(*args)))
+	}
+
+	if query.Since != nil {
+		*args = append(*args, sqlTimestamp(*query.Since))
+		conditions = append(conditions, fmt.Sprintf(`tw.created_at >= $%d`, len(*args)))
+	}
+
+	if query.Until != nil {
+		*args = append(*args, sqlTimestamp(*query.Until))
+		conditions = append(conditions, fmt.Sprintf(`tw.created_at < $%d`, len(*args)))
+	}
+
+	return strings.Join(conditions, " AND ")
+}
+
+func searchTweets(db *sql.DB, query models.TweetSearchQuery, textConditions textConditionsFunc, limit int64, offset int64, cursor *models.Cursor) ([]models.Tweet, error) {
+	args := []interface{}{}
+
+	sqlQuery := `SELECT ` + tweetColumns + `
+				FROM tweets AS tw
+				INNER JOIN users AS us ON us.id = tw.user_id
+				` + tweetOriginalJoins + `
+				WHERE ` + searchConditions(query, textConditions, &args)
+
+	if cursor != nil {
+		args = append(args, cursorTimestamp(cursor), cursor.ID, limit)
+		sqlQuery += fmt.Sprintf(`
+				AND (tw.created_at < $%d OR (tw.created_at = $%d AND tw.id < $%d))
+				ORDER BY tw.created_at DESC, tw.id DESC
+				LIMIT $%d;`, len(args)-2, len(args)-2, len(args)-1, len(args))
+	} else {
+		args = append(args, limit, offset)
+		sqlQuery += fmt.Sprintf(`
+				ORDER BY tw.created_at DESC, tw.id DESC
+				LIMIT $%d
+				OFFSET $%d;`, len(args)-1, len(args))
+	}
+
+	rows, err := db.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Error searching tweets: %v", err)
+	}
+	defer rows.Close()
+
+	return scanTweets(rows)
+}
+
+func countSearchTweets(db *sql.DB, query models.TweetSearchQuery, textConditions textConditionsFunc) (int64, error) {
+	args := []interface{}{}
+
+	var total int64
+	err := db.QueryRow(`SELECT COUNT(*)
+				FROM tweets AS tw
+				INNER JOIN users AS us ON us.id = tw.user_id
+				WHERE `+searchConditions(query, textConditions, &args), args...).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("Error counting searched tweets: %v", err)
+	}
+
+	return total, nil
+}
+
+// escapeLike escapa los comodines de LIKE para buscar el texto de forma literal (con ESCAPE '\')
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
@@ -0,0 +1,117 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+)
+
+// Los usos de cada hashtag se cuentan en un sorted set por minuto (el score es la cantidad de usos del hashtag en ese minuto)
+func TrendBucketKey(minute time.Time) string {
+	return fmt.Sprintf("trends:%d", minute.Unix()/60)
+}
+
+// Ademas se acumulan en un sorted set por hora, para que las ventanas largas no tengan que leer un bucket por minuto
+func TrendHourBucketKey(hour time.Time) string {
+	return fmt.Sprintf("trends:hour:%d", hour.Unix()/3600)
+}
+
+// AddHashtagUsage suma delta a los usos de cada hashtag en el minuto y la hora indicados. Los buckets expiran luego de ttl,
+// contado desde el final de la hora en el caso de los buckets por hora
+func AddHashtagUsage(cacheClient cache.Cache, tags []string, at time.Time, delta int64, ttl time.Duration) error {
+	var ctx = context.Background()
+	key := TrendBucketKey(at)
+	hourKey := TrendHourBucketKey(at)
+
+	for _, tag := range tags {
+		err := cacheClient.ZIncrBy(ctx, key, tag, float64(delta), ttl)
+		if err != nil {
+			return fmt.Errorf("Error updating hashtag usage in cache: %v", err)
+		}
+
+		err = cacheClient.ZIncrBy(ctx, hourKey, tag, float64(delta), ttl+time.Hour)
+		if err != nil {
+			return fmt.Errorf("Error updating hashtag usage in cache: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// GetHashtagUsage obtiene los usos de cada hashtag entre from (inclusive) y to (exclusive), redondeados al minuto. Las horas
+// completas del rango se leen de los buckets por hora y los minutos restantes de los extremos de los buckets por minuto
+func GetHashtagUsage(cacheClient cache.Cache, from time.Time, to time.Time) (map[string]int64, error) {
+	var ctx = context.Background()
+
+	keys := []string{}
+	minute := from.Truncate(time.Minute)
+	for minute.Before(to) {
+		if minute.Truncate(time.Hour).Equal(minute) && !minute.Add(time.Hour).After(to) {
+			keys = append(keys, TrendHourBucketKey(minute))
+			minute = minute.Add(time.Hour)
+			continue
+		}
+
+		keys = append(keys, TrendBucketKey(minute))
+		minute = minute.Add(time.Minute)
+	}
+
+	scores, err := cacheClient.ZSumScores(ctx, keys...)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting hashtag usage from cache: %v", err)
+	}
+
+	usage := map[string]int64{}
+	for tag, score := range scores {
+		// Si se elimino un tweet el uso de su hashtag puede quedar en 0
+		if count := int64(math.Round(score)); count > 0 {
+			usage[tag] = count
+		}
+	}
+
+	return usage, nil
+}
+
+// GetHashtagUsageFromDB obtiene, agregando los hashtags de los tweets, los usos de cada hashtag desde windowStart (usage)
+// y entre baselineStart y windowStart (baseline). Solo incluye los hashtags usados desde baselineStart
+func GetHashtagUsageFromDB(db *sql.DB, baselineStart time.Time, windowStart time.Time) (map[string]int64, map[string]int64, error) {
+	query := `SELECT tag,
+				SUM(CASE WHEN created_at >= $1 THEN 1 ELSE 0 END) AS usage_count,
+				SUM(CASE WHEN created_at < $1 THEN 1 ELSE 0 END) AS baseline_count
+				FROM tweet_hashtags
+				WHERE created_at >= $2
+				GROUP BY tag;`
+
+	rows, err := db.Query(query, sqlTimestamp(windowStart), sqlTimestamp(baselineStart))
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error fetching hashtag usage: %v", err)
+	}
+	defer rows.Close()
+
+	usage := map[string]int64{}
+	baseline := map[string]int64{}
+	for rows.Next() {
+		var tag string
+		var usageCount, baselineCount int64
+		if err := rows.Scan(&tag, &usageCount, &baselineCount); err != nil {
+			return nil, nil, fmt.Errorf("Error scanning row: %v", err)
+		}
+
+		if usageCount > 0 {
+			usage[tag] = usageCount
+		}
+		if baselineCount > 0 {
+			baseline[tag] = baselineCount
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("Error iterating rows: %v", err)
+	}
+
+	return usage, baseline, nil
+}
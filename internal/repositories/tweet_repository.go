@@ -5,24 +5,86 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
-	"github.com/redis/go-redis/v9"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
 )
 
 // Funciones para interactura con db SQL
+
+// PostTweet inserta el tweet junto a sus hashtags y menciones, y completa en el struct recibido el ID, la conversacion y la fecha de creacion asignados por la db.
+// Si el tweet no es una respuesta inicia su propia conversacion, por lo que su conversation_id es su propio ID
 func PostTweet(db *sql.DB, tweet *models.Tweet) (bool, error) {
 	tx, err := db.Begin() //Se inicia transaccion para ejecutar Rollback si algo sale mal
-	query := `INSERT INTO tweets (user_id, content) VALUES ($1, $2)`
+	if err != nil {
+		return false, fmt.Errorf("Error starting PostTweet transaction: %v", err)
+	}
+
+	var conversationId *int64
+	if tweet.InReplyToTweetID != nil {
+		conversationId = &tweet.ConversationID
+	}
+
+	entities, err := json.Marshal(tweet.Entities)
+	if err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("Error encoding tweet entities: %v", err)
+	}
 
-	_, err = tx.Exec(query, tweet.UserID, tweet.Content)
+	query := `INSERT INTO tweets (user_id, content, in_reply_to_tweet_id, in_reply_to_user_id, conversation_id, retweet_of_tweet_id, quoted_tweet_id, entities)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
+
+	err = tx.QueryRow(query, tweet.UserID, tweet.Content, tweet.InReplyToTweetID, tweet.InReplyToUserID, conversationId, tweet.RetweetOfTweetID, tweet.QuotedTweetID, string(entities)).Scan(&tweet.ID)
 	if err != nil {
 		tx.Rollback()
-		fmt.Println("[x] Error to create Tweet: %v", err)
+		fmt.Printf("[x] Error to create Tweet: %v\n", err)
 		return false, fmt.Errorf(err.Error())
 	}
 
+	if conversationId == nil {
+		_, err = tx.Exec(`UPDATE tweets SET conversation_id = id WHERE id = $1`, tweet.ID)
+		if err != nil {
+			tx.Rollback()
+			return false, fmt.Errorf("Error setting tweet conversation: %v", err)
+		}
+		tweet.ConversationID = tweet.ID
+	}
+
+	err = tx.QueryRow(`SELECT created_at FROM tweets WHERE id = $1`, tweet.ID).Scan(&tweet.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("Error getting tweet creation date: %v", err)
+	}
+
+	// Los hashtags se guardan normalizados y las menciones solo si corresponden a un usuario, para listar los tweets por hashtag o mencion
+	for _, hashtag := range tweet.Entities.Hashtags {
+		_, err = tx.Exec(`INSERT INTO tweet_hashtags (tweet_id, tag, created_at)
+					SELECT id, $1, created_at FROM tweets WHERE id = $2
+					ON CONFLICT DO NOTHING`, strings.ToLower(hashtag.Tag), tweet.ID)
+		if err != nil {
+			tx.Rollback()
+			return false, fmt.Errorf("Error saving tweet hashtags: %v", err)
+		}
+	}
+
+	for _, mention := range tweet.Entities.Mentions {
+		if mention.UserID == nil {
+			continue
+		}
+
+		_, err = tx.Exec(`INSERT INTO tweet_mentions (tweet_id, user_id, created_at)
+					SELECT id, $1, created_at FROM tweets WHERE id = $2
+					ON CONFLICT DO NOTHING`, *mention.UserID, tweet.ID)
+		if err != nil {
+			tx.Rollback()
+			return false, fmt.Errorf("Error saving tweet mentions: %v", err)
+		}
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		return false, fmt.Errorf("Error committing PostTweet transaction: %v", err)
@@ -31,45 +93,190 @@ func PostTweet(db *sql.DB, tweet *models.Tweet) (bool, error) {
 	return true, nil
 }
 
-func GetTweetsByUserId(db *sql.DB, userId *int64) ([]models.Tweet, error) {
-	query := `SELECT * FROM tweets WHERE user_id = $1`
+// GetTweetById obtiene un tweet junto al nombre de su autor
+func GetTweetById(db *sql.DB, tweetId int64) (models.Tweet, error) {
+	tweet, err := scanTweet(db.QueryRow(`SELECT `+tweetColumns+`
+				FROM tweets AS tw
+				INNER JOIN users AS us ON us.id = tw.user_id
+				`+tweetOriginalJoins+`
+				WHERE tw.id = $1`, tweetId))
 
-	rows, err := db.Query(query, userId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Tweet{}, fmt.Errorf("tweet not found")
+		}
+		return models.Tweet{}, fmt.Errorf("[x] Error to get tweet: %v", err)
+	}
 
+	return tweet, nil
+}
+
+// DeleteTweet elimina un tweet junto a sus retweets, likes, bookmarks, hashtags, menciones y notificaciones, y quita la referencia de las citas que lo citaban.
+// Retorna false si el tweet no existia
+func DeleteTweet(db *sql.DB, tweetId int64) (bool, error) {
+	tx, err := db.Begin()
 	if err != nil {
-		return nil, fmt.Errorf("Error fetching tweets: %v", err)
+		return false, fmt.Errorf("Error starting DeleteTweet transaction: %v", err)
+	}
+
+	// Se hace de forma explicita porque SQLite no aplica las acciones de las foreign keys
+	_, err = tx.Exec(`DELETE FROM tweets WHERE retweet_of_tweet_id = $1`, tweetId)
+	if err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("[x] Error to delete retweets: %v", err)
+	}
+
+	_, err = tx.Exec(`DELETE FROM likes WHERE tweet_id = $1`, tweetId)
+	if err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("[x] Error to delete likes: %v", err)
+	}
+
+	_, err = tx.Exec(`DELETE FROM bookmarks WHERE tweet_id = $1`, tweetId)
+	if err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("[x] Error to delete bookmarks: %v", err)
+	}
+
+	_, err = tx.Exec(`DELETE FROM tweet_hashtags WHERE tweet_id = $1`, tweetId)
+	if err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("[x] Error to delete hashtags: %v", err)
+	}
+
+	_, err = tx.Exec(`DELETE FROM tweet_mentions WHERE tweet_id = $1`, tweetId)
+	if err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("[x] Error to delete mentions: %v", err)
+	}
+
+	_, err = tx.Exec(`DELETE FROM notifications WHERE tweet_id = $1`, tweetId)
+	if err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("[x] Error to delete notifications: %v", err)
+	}
+
+	_, err = tx.Exec(`UPDATE tweets SET quoted_tweet_id = NULL WHERE quoted_tweet_id = $1`, tweetId)
+	if err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("[x] Error to update quotes: %v", err)
+	}
+
+	result, err := tx.Exec(`DELETE FROM tweets WHERE id = $1`, tweetId)
+	if err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("[x] Error to delete tweet: %v", err)
+	}
+
+	deletedRows, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("[x] Error to delete tweet: %v", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, fmt.Errorf("Error committing DeleteTweet transaction: %v", err)
+	}
+
+	return deletedRows > 0, nil
+}
+
+// GetRetweetByUser obtiene el retweet que hizo un usuario de un tweet
+func GetRetweetByUser(db *sql.DB, userId int64, tweetId int64) (models.Tweet, error) {
+	tweet, err := scanTweet(db.QueryRow(`SELECT `+tweetColumns+`
+				FROM tweets AS tw
+				INNER JOIN users AS us ON us.id = tw.user_id
+				`+tweetOriginalJoins+`
+				WHERE tw.user_id = $1 AND tw.retweet_of_tweet_id = $2`, userId, tweetId))
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Tweet{}, fmt.Errorf("retweet not found")
+		}
+		return models.Tweet{}, fmt.Errorf("[x] Error to get retweet: %v", err)
+	}
+
+	return tweet, nil
+}
+
+// GetTweetReferences obtiene los retweets (ID y autor) y los IDs de las citas de un tweet.
+// Se utiliza para actualizar el cache cuando se elimina el tweet
+func GetTweetReferences(db *sql.DB, tweetId int64) ([]models.Tweet, []int64, error) {
+	rows, err := db.Query(`SELECT id, user_id, retweet_of_tweet_id FROM tweets WHERE retweet_of_tweet_id = $1 OR quoted_tweet_id = $1`, tweetId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error fetching tweet references: %v", err)
 	}
 	defer rows.Close()
 
-	tweets := []models.Tweet{}
+	return scanTweetReferences(rows)
+}
 
-	for rows.Next() {
-		var tweet models.Tweet
+// GetUserTweetReferences obtiene los retweets (ID y autor) y los IDs de las citas que otros usuarios hicieron de los tweets del usuario.
+// Se utiliza para actualizar el cache cuando se modifica o elimina el usuario
+func GetUserTweetReferences(db *sql.DB, userId int64) ([]models.Tweet, []int64, error) {
+	rows, err := db.Query(`SELECT ref.id, ref.user_id, ref.retweet_of_tweet_id
+				FROM tweets AS ref
+				INNER JOIN tweets AS tw ON tw.id = COALESCE(ref.retweet_of_tweet_id, ref.quoted_tweet_id)
+				WHERE tw.user_id = $1 AND ref.user_id <> $1`, userId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error fetching tweet references: %v", err)
+	}
+	defer rows.Close()
 
-		err := rows.Scan(&tweet.ID, &tweet.UserID, &tweet.Content, &tweet.CreatedAt)
+	return scanTweetReferences(rows)
+}
 
-		if err != nil {
-			return nil, fmt.Errorf("Error scanning row: %v", err)
+func scanTweetReferences(rows *sql.Rows) ([]models.Tweet, []int64, error) {
+	retweets := []models.Tweet{}
+	quoteIds := []int64{}
+	for rows.Next() {
+		var tweet models.Tweet
+		if err := rows.Scan(&tweet.ID, &tweet.UserID, &tweet.RetweetOfTweetID); err != nil {
+			return nil, nil, fmt.Errorf("Error scanning row: %v", err)
 		}
 
-		tweets = append(tweets, tweet)
+		if tweet.RetweetOfTweetID != nil {
+			retweets = append(retweets, tweet)
+		} else {
+			quoteIds = append(quoteIds, tweet.ID)
+		}
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("Error iterating rows: %v", err)
+		return nil, nil, fmt.Errorf("Error iterating rows: %v", err)
 	}
 
-	return tweets, nil
+	return retweets, quoteIds, nil
+}
+
+func GetTweetsByUserId(db *sql.DB, userId *int64) ([]models.Tweet, error) {
+	query := `SELECT ` + tweetColumns + `
+				FROM tweets AS tw
+				INNER JOIN users AS us ON us.id = tw.user_id
+				` + tweetOriginalJoins + `
+				WHERE tw.user_id = $1
+				ORDER BY tw.created_at DESC, tw.id DESC`
+
+	rows, err := db.Query(query, userId)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching tweets: %v", err)
+	}
+	defer rows.Close()
+
+	return scanTweets(rows)
 }
 
 // Funcion para obtener el timeline de los usuarios a los que se sigue
 func GetTweetsFromDB(db *sql.DB, userId *int64, limit *int64, offset *int64) ([]models.Tweet, error) {
-	query := `SELECT tw.id as tw_id, tw.user_id, us.name, tw.content, tw.created_at as tweet_date
+	query := `SELECT ` + tweetColumns + `
               FROM tweets AS tw
               INNER JOIN follows AS fol ON fol.followed_id = tw.user_id
               INNER JOIN users AS us ON us.id = tw.user_id
-              WHERE fol.follower_id = $1
-              ORDER BY tweet_date DESC
+              ` + tweetOriginalJoins + `
+              WHERE fol.follower_id = $1 AND ` + timelineReplyCondition + ` AND ` + timelineMuteCondition + `
+              ORDER BY tw.created_at DESC, tw.id DESC
               LIMIT $2
               OFFSET $3;`
 	rows, err := db.Query(query, userId, limit, offset)
@@ -79,21 +286,425 @@ func GetTweetsFromDB(db *sql.DB, userId *int64, limit *int64, offset *int64) ([]
 	}
 	defer rows.Close()
 
-	var timeline []models.Tweet
+	return scanTweets(rows)
+}
+
+// GetTweetsFromDBByCursor obtiene el timeline paginando por cursor (keyset): trae los tweets anteriores
+// al (created_at, id) del cursor, evitando recorrer las filas previas como ocurre con OFFSET
+func GetTweetsFromDBByCursor(db *sql.DB, userId int64, limit int64, cursor *models.Cursor) ([]models.Tweet, error) {
+	query := `SELECT ` + tweetColumns + `
+              FROM tweets AS tw
+              INNER JOIN follows AS fol ON fol.followed_id = tw.user_id
+              INNER JOIN users AS us ON us.id = tw.user_id
+              ` + tweetOriginalJoins + `
+              WHERE fol.follower_id = $1 AND ` + timelineReplyCondition + ` AND ` + timelineMuteCondition + ` AND (tw.created_at < $2 OR (tw.created_at = $2 AND tw.id < $3))
+              ORDER BY tw.created_at DESC, tw.id DESC
+              LIMIT $4;`
+	rows, err := db.Query(query, userId, cursorTimestamp(cursor), cursor.ID, limit)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching timeline from DB: %v", err)
+	}
+	defer rows.Close()
+
+	return scanTweets(rows)
+}
+
+// GetTimelineTweet obtiene el tweet solo si forma parte del timeline del usuario, con las mismas condiciones que GetTweetsFromDB.
+// Retorna sql.ErrNoRows si el tweet no existe o no corresponde a su timeline
+func GetTimelineTweet(db *sql.DB, userId int64, tweetId int64) (models.Tweet, error) {
+	query := `SELECT ` + tweetColumns + `
+              FROM tweets AS tw
+              INNER JOIN follows AS fol ON fol.followed_id = tw.user_id
+              INNER JOIN users AS us ON us.id = tw.user_id
+              ` + tweetOriginalJoins + `
+              WHERE fol.follower_id = $1 AND ` + timelineReplyCondition + ` AND ` + timelineMuteCondition + ` AND tw.id = $2;`
+
+	return scanTweet(db.QueryRow(query, userId, tweetId))
+}
+
+// GetTimelineTweetsAfter obtiene los limit tweets mas recientes del timeline con ID mayor a afterId, del mas reciente al mas antiguo.
+// Los IDs de los tweets son crecientes, por lo que son los tweets creados despues del tweet afterId
+func GetTimelineTweetsAfter(db *sql.DB, userId int64, afterId int64, limit int64) ([]models.Tweet, error) {
+	query := `SELECT ` + tweetColumns + `
+              FROM tweets AS tw
+              INNER JOIN follows AS fol ON fol.followed_id = tw.user_id
+              INNER JOIN users AS us ON us.id = tw.user_id
+              ` + tweetOriginalJoins + `
+              WHERE fol.follower_id = $1 AND ` + timelineReplyCondition + ` AND ` + timelineMuteCondition + ` AND tw.id > $2
+              ORDER BY tw.id DESC
+              LIMIT $3;`
+	rows, err := db.Query(query, userId, afterId, limit)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching timeline from DB: %v", err)
+	}
+	defer rows.Close()
+
+	return scanTweets(rows)
+}
+
+// cursorTimestamp formatea la fecha del cursor con el mismo formato en que SQLite guarda CURRENT_TIMESTAMP,
+// para que la comparacion sea correcta tanto en SQLite (texto) como en PostgreSQL (timestamp)
+func cursorTimestamp(cursor *models.Cursor) string {
+	return sqlTimestamp(cursor.CreatedAt)
+}
+
+// sqlTimestamp aplica el mismo formato a cualquier fecha que se compare con las fechas guardadas en la db
+func sqlTimestamp(date time.Time) string {
+	return date.UTC().Format("2006-01-02 15:04:05.999999")
+}
+
+// Columnas que retornan las consultas de tweets (con el alias tw para tweets y us para users), en el orden que espera scanTweet.
+// Tambien incluyen el tweet retweeteado o citado (alias orig y orig_us, ver tweetOriginalJoins), nulas si no corresponde
+const tweetColumns = `tw.id, tw.user_id, us.name, us.handle, tw.content, tw.in_reply_to_tweet_id, tw.in_reply_to_user_id, tw.conversation_id,
+				tw.retweet_of_tweet_id, tw.quoted_tweet_id, tw.like_count, tw.entities, tw.created_at,
+				orig.id, orig.user_id, orig_us.name, orig_us.handle, orig.content, orig.in_reply_to_tweet_id, orig.in_reply_to_user_id, orig.conversation_id, orig.like_count, orig.entities, orig.created_at`
+
+// tweetOriginalJoins agrega a las consultas de tweets el tweet original de los retweets y el tweet citado de las citas
+const tweetOriginalJoins = `LEFT JOIN tweets AS orig ON orig.id = COALESCE(tw.retweet_of_tweet_id, tw.quoted_tweet_id)
+				LEFT JOIN users AS orig_us ON orig_us.id = orig.user_id`
+
+// timelineReplyCondition define que respuestas aparecen en el timeline del usuario $1. Un tweet que no es respuesta
+// aparece siempre; una respuesta solo si responde a un tweet del mismo autor (un hilo), a un tweet del propio usuario,
+// o si el usuario tambien sigue al autor del tweet respondido (es decir, sigue a ambas partes de la conversacion)
+const timelineReplyCondition = `(tw.in_reply_to_user_id IS NULL
+              OR tw.in_reply_to_user_id = tw.user_id
+              OR tw.in_reply_to_user_id = $1
+              OR EXISTS (SELECT 1 FROM follows AS reply_fol WHERE reply_fol.follower_id = $1 AND reply_fol.followed_id = tw.in_reply_to_user_id))`
+
+// timelineMuteCondition excluye del timeline del usuario $1 los tweets de los usuarios que silencio
+const timelineMuteCondition = `NOT EXISTS (SELECT 1 FROM mutes AS mut WHERE mut.muter_id = $1 AND mut.muted_id = tw.user_id)`
+
+// visibleTweetCondition filtra los tweets de las cuentas privadas (alias us) que el usuario que consulta no puede ver: solo los ven
+// el propio autor y sus seguidores. Recibe el numero del parametro con el ID del usuario que consulta (0 si no hay usuario que consulta)
+func visibleTweetCondition(viewerParam int) string {
+	return fmt.Sprintf(`(NOT us.is_private OR us.id = $%[1]d OR EXISTS (SELECT 1 FROM follows AS vis_fol WHERE vis_fol.follower_id = $%[1]d AND vis_fol.followed_id = us.id))`, viewerParam)
+}
+
+// CanViewUserTweets indica si el usuario que consulta (0 si no hay usuario que consulta) puede ver los tweets del autor
+func CanViewUserTweets(db *sql.DB, authorId int64, viewerId int64) (bool, error) {
+	var visible bool
+	err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM users AS us WHERE us.id = $1 AND `+visibleTweetCondition(2)+`)`, authorId, viewerId).Scan(&visible)
+	if err != nil {
+		return false, fmt.Errorf("Error checking tweets visibility: %v", err)
+	}
+
+	return visible, nil
+}
+
+// GetVisibleUserIds obtiene, de los usuarios recibidos, aquellos cuyos tweets puede ver el usuario que consulta
+// (0 si no hay usuario que consulta), con la misma condicion que CanViewUserTweets
+func GetVisibleUserIds(db *sql.DB, viewerId int64, userIds []int64) (map[int64]bool, error) {
+	visible := map[int64]bool{}
+	if len(userIds) == 0 {
+		return visible, nil
+	}
+
+	args := []interface{}{viewerId}
+	placeholders := make([]string, len(userIds))
+	for i, id := range userIds {
+		args = append(args, id)
+		placeholders[i] = fmt.Sprintf("$%d", len(args))
+	}
+
+	query := fmt.Sprintf(`SELECT us.id FROM users AS us WHERE %s AND us.id IN (%s);`, visibleTweetCondition(1), strings.Join(placeholders, ", "))
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Error checking tweets visibility: %v", err)
+	}
+	defer rows.Close()
+
 	for rows.Next() {
-		var tweet models.Tweet
-		err := rows.Scan(&tweet.ID, &tweet.UserID, &tweet.AuthorName, &tweet.Content, &tweet.CreatedAt)
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("Error scanning row: %v", err)
+		}
+		visible[id] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Error iterating rows: %v", err)
+	}
+
+	return visible, nil
+}
+
+// rowScanner permite escanear tanto un *sql.Row como un *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanTweet escanea una fila con las columnas de tweetColumns, embebiendo el tweet original si existe.
+// En extra se reciben los destinos de las columnas que la consulta agrega despues de tweetColumns
+func scanTweet(row rowScanner, extra ...any) (models.Tweet, error) {
+	var tweet models.Tweet
+	var originalId, originalUserId, originalConversationId, originalLikeCount sql.NullInt64
+	var originalAuthorName, originalAuthorHandle, originalContent, entities, originalEntities sql.NullString
+	var originalInReplyToTweetId, originalInReplyToUserId *int64
+	var originalCreatedAt sql.NullTime
+
+	dest := []any{&tweet.ID, &tweet.UserID, &tweet.AuthorName, &tweet.AuthorHandle, &tweet.Content, &tweet.InReplyToTweetID, &tweet.InReplyToUserID, &tweet.ConversationID,
+		&tweet.RetweetOfTweetID, &tweet.QuotedTweetID, &tweet.LikeCount, &entities, &tweet.CreatedAt,
+		&originalId, &originalUserId, &originalAuthorName, &originalAuthorHandle, &originalContent, &originalInReplyToTweetId, &originalInReplyToUserId, &originalConversationId, &originalLikeCount, &originalEntities, &originalCreatedAt}
+
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return models.Tweet{}, err
+	}
+
+	tweet.Entities, err = unmarshalEntities(entities)
+	if err != nil {
+		return models.Tweet{}, err
+	}
+
+	if originalId.Valid {
+		tweet.OriginalTweet = &models.Tweet{
+			ID:               originalId.Int64,
+			UserID:           originalUserId.Int64,
+			AuthorName:       &originalAuthorName.String,
+			AuthorHandle:     &originalAuthorHandle.String,
+			Content:          originalContent.String,
+			InReplyToTweetID: originalInReplyToTweetId,
+			InReplyToUserID:  originalInReplyToUserId,
+			ConversationID:   originalConversationId.Int64,
+			LikeCount:        originalLikeCount.Int64,
+			CreatedAt:        originalCreatedAt.Time,
+		}
+
+		tweet.OriginalTweet.Entities, err = unmarshalEntities(originalEntities)
+		if err != nil {
+			return models.Tweet{}, err
+		}
+	}
+
+	return tweet, nil
+}
+
+// unmarshalEntities obtiene los hashtags y menciones guardados en la columna entities.
+// Los tweets creados antes de que se guardaran las entidades no tienen hashtags ni menciones
+func unmarshalEntities(stored sql.NullString) (models.TweetEntities, error) {
+	entities := models.TweetEntities{Hashtags: []models.HashtagEntity{}, Mentions: []models.MentionEntity{}}
+	if !stored.Valid || stored.String == "" {
+		return entities, nil
+	}
+
+	err := json.Unmarshal([]byte(stored.String), &entities)
+	if err != nil {
+		return models.TweetEntities{}, fmt.Errorf("Error decoding tweet entities: %v", err)
+	}
+
+	return entities, nil
+}
+
+// scanTweets recorre las filas de una consulta que retorna las columnas de tweetColumns
+func scanTweets(rows *sql.Rows) ([]models.Tweet, error) {
+	tweets := []models.Tweet{}
+	for rows.Next() {
+		tweet, err := scanTweet(rows)
 		if err != nil {
 			return nil, fmt.Errorf("Error scanning row: %v", err)
 		}
-		timeline = append(timeline, tweet)
+		tweets = append(tweets, tweet)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("Error iterating rows: %v", err)
 	}
 
-	return timeline, nil
+	return tweets, nil
+}
+
+// GetTweetsFromDBByAuthors obtiene los tweets mas recientes de los autores indicados que el usuario sigue.
+// Se utiliza para mezclar al leer el timeline los tweets de las cuentas que no se distribuyen en los feeds
+// Si se recibe un cursor, solo se obtienen los tweets anteriores al mismo
+func GetTweetsFromDBByAuthors(db *sql.DB, followerId int64, authorIds []int64, limit int64, cursor *models.Cursor) ([]models.Tweet, error) {
+	if len(authorIds) == 0 {
+		return []models.Tweet{}, nil
+	}
+
+	// SQLite numera los parametros $N segun el orden en que aparecen en la consulta (no segun N),
+	// por eso los argumentos se agregan en el mismo orden en que se usan
+	args := []interface{}{followerId}
+	placeholders := make([]string, len(authorIds))
+	for i, id := range authorIds {
+		args = append(args, id)
+		placeholders[i] = fmt.Sprintf("$%d", len(args))
+	}
+
+	cursorCondition := ""
+	if cursor != nil {
+		args = append(args, cursorTimestamp(cursor), cursor.ID)
+		cursorCondition = fmt.Sprintf("AND (tw.created_at < $%d OR (tw.created_at = $%d AND tw.id < $%d))", len(args)-1, len(args)-1, len(args))
+	}
+
+	args = append(args, limit)
+
+	query := fmt.Sprintf(`SELECT %s
+				FROM tweets AS tw
+				INNER JOIN follows AS fol ON fol.followed_id = tw.user_id
+				INNER JOIN users AS us ON us.id = tw.user_id
+				`+tweetOriginalJoins+`
+				WHERE fol.follower_id = $1 AND tw.user_id IN (%s) AND %s AND %s %s
+				ORDER BY tw.created_at DESC, tw.id DESC
+				LIMIT $%d;`, tweetColumns, strings.Join(placeholders, ", "), timelineReplyCondition, timelineMuteCondition, cursorCondition, len(args))
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching tweets by authors: %v", err)
+	}
+	defer rows.Close()
+
+	return scanTweets(rows)
+}
+
+// GetTweetsByIds obtiene los tweets (junto al nombre de su autor) de los IDs recibidos.
+// Se utiliza para completar el contenido de los tweets del feed que no estan en cache
+func GetTweetsByIds(db *sql.DB, ids []int64) ([]models.Tweet, error) {
+	if len(ids) == 0 {
+		return []models.Tweet{}, nil
+	}
+
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = id
+	}
+
+	query := fmt.Sprintf(`SELECT %s
+				FROM tweets AS tw
+				INNER JOIN users AS us ON us.id = tw.user_id
+				`+tweetOriginalJoins+`
+				WHERE tw.id IN (%s);`, tweetColumns, strings.Join(placeholders, ", "))
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching tweets by ids: %v", err)
+	}
+	defer rows.Close()
+
+	return scanTweets(rows)
+}
+
+// GetTweetAncestors obtiene los tweets a los que responde un tweet, desde el inicio de la conversacion hasta el tweet padre.
+// maxDepth limita la cantidad de tweets a recorrer; si un tweet de la cadena fue eliminado, la cadena termina en su respuesta
+func GetTweetAncestors(db *sql.DB, tweetId int64, viewerId int64, maxDepth int64) ([]models.Tweet, error) {
+	query := `WITH RECURSIVE ancestors (id, parent_id, depth) AS (
+					SELECT id, in_reply_to_tweet_id, 0 FROM tweets WHERE id = $1
+					UNION ALL
+					SELECT parent.id, parent.in_reply_to_tweet_id, anc.depth + 1
+					FROM tweets AS parent
+					INNER JOIN ancestors AS anc ON parent.id = anc.parent_id
+					WHERE anc.depth < $2
+				)
+				SELECT ` + tweetColumns + `
+				FROM ancestors AS anc
+				INNER JOIN tweets AS tw ON tw.id = anc.id
+				INNER JOIN users AS us ON us.id = tw.user_id
+				` + tweetOriginalJoins + `
+				WHERE anc.depth > 0 AND ` + visibleTweetCondition(3) + `
+				ORDER BY anc.depth DESC;`
+
+	rows, err := db.Query(query, tweetId, maxDepth, viewerId)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching tweet ancestors: %v", err)
+	}
+	defer rows.Close()
+
+	return scanTweets(rows)
+}
+
+// GetTweetReplies obtiene una pagina de las respuestas directas a un tweet, de la mas nueva a la mas vieja.
+// Si se recibe un cursor se pagina por cursor y se ignora el offset
+func GetTweetReplies(db *sql.DB, tweetId int64, viewerId int64, limit int64, offset int64, cursor *models.Cursor) ([]models.Tweet, error) {
+	// Los parametros se numeran en el orden en que aparecen en la consulta (ver GetTweetsFromDBByAuthors)
+	var query string
+	var args []interface{}
+	if cursor != nil {
+		query = `SELECT ` + tweetColumns + `
+				FROM tweets AS tw
+				INNER JOIN users AS us ON us.id = tw.user_id
+				` + tweetOriginalJoins + `
+				WHERE tw.in_reply_to_tweet_id = $1 AND ` + visibleTweetCondition(2) + ` AND (tw.created_at < $3 OR (tw.created_at = $3 AND tw.id < $4))
+				ORDER BY tw.created_at DESC, tw.id DESC
+				LIMIT $5;`
+		args = []interface{}{tweetId, viewerId, cursorTimestamp(cursor), cursor.ID, limit}
+	} else {
+		query = `SELECT ` + tweetColumns + `
+				FROM tweets AS tw
+				INNER JOIN users AS us ON us.id = tw.user_id
+				` + tweetOriginalJoins + `
+				WHERE tw.in_reply_to_tweet_id = $1 AND ` + visibleTweetCondition(2) + `
+				ORDER BY tw.created_at DESC, tw.id DESC
+				LIMIT $3
+				OFFSET $4;`
+		args = []interface{}{tweetId, viewerId, limit, offset}
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching tweet replies: %v", err)
+	}
+	defer rows.Close()
+
+	return scanTweets(rows)
+}
+
+// GetTweetRepliesDescendants obtiene las respuestas anidadas a los tweets indicados, hasta maxDepth niveles por debajo
+// de ellos y como maximo limit tweets (los mas nuevos). Se utiliza para armar el arbol de respuestas de un hilo
+func GetTweetRepliesDescendants(db *sql.DB, tweetIds []int64, viewerId int64, maxDepth int64, limit int64) ([]models.Tweet, error) {
+	if len(tweetIds) == 0 || maxDepth <= 0 {
+		return []models.Tweet{}, nil
+	}
+
+	// Los parametros se numeran en el orden en que aparecen en la consulta (ver GetTweetsFromDBByAuthors)
+	args := []interface{}{}
+	placeholders := make([]string, len(tweetIds))
+	for i, id := range tweetIds {
+		args = append(args, id)
+		placeholders[i] = fmt.Sprintf("$%d", len(args))
+	}
+	args = append(args, maxDepth, viewerId, limit)
+
+	query := fmt.Sprintf(`WITH RECURSIVE descendants (id, depth) AS (
+					SELECT id, 1 FROM tweets WHERE in_reply_to_tweet_id IN (%s)
+					UNION ALL
+					SELECT reply.id, des.depth + 1
+					FROM tweets AS reply
+					INNER JOIN descendants AS des ON reply.in_reply_to_tweet_id = des.id
+					WHERE des.depth < $%d
+				)
+				SELECT %s
+				FROM descendants AS des
+				INNER JOIN tweets AS tw ON tw.id = des.id
+				INNER JOIN users AS us ON us.id = tw.user_id
+				`+tweetOriginalJoins+`
+				WHERE %s
+				ORDER BY tw.created_at DESC, tw.id DESC
+				LIMIT $%d;`, strings.Join(placeholders, ", "), len(args)-2, tweetColumns, visibleTweetCondition(len(args)-1), len(args))
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching tweet replies: %v", err)
+	}
+	defer rows.Close()
+
+	return scanTweets(rows)
+}
+
+func CountTweetReplies(db *sql.DB, tweetId int64, viewerId int64) (int64, error) {
+	var totalReplies int64
+	err := db.QueryRow(`SELECT COUNT(*)
+				FROM tweets AS tw
+				INNER JOIN users AS us ON us.id = tw.user_id
+				WHERE tw.in_reply_to_tweet_id = $1 AND `+visibleTweetCondition(2), tweetId, viewerId).Scan(&totalReplies)
+	if err != nil {
+		return 0, fmt.Errorf("Error counting tweet replies: %v", err)
+	}
+
+	return totalReplies, nil
 }
 
 func CountTweetsTimeline(db *sql.DB, userId *int64) (int64, error) {
@@ -101,7 +712,7 @@ func CountTweetsTimeline(db *sql.DB, userId *int64) (int64, error) {
 				FROM tweets AS tw
 				INNER JOIN follows AS fol ON fol.followed_id = tw.user_id
 				INNER JOIN users AS us ON us.id = tw.user_id
-				WHERE fol.follower_id = $1;`
+				WHERE fol.follower_id = $1 AND ` + timelineReplyCondition + ` AND ` + timelineMuteCondition + `;`
 
 	rows, err := db.Query(query, userId)
 	if err != nil {
@@ -124,36 +735,345 @@ func CountTweetsTimeline(db *sql.DB, userId *int64) (int64, error) {
 	return totalTweets, nil
 }
 
-//Funciones para interactuar con redis respecto a los Tweets
+//Funciones para interactuar con el cache respecto a los Tweets
+
+// El timeline de cada usuario se guarda precalculado en un sorted set (feed) cuyos miembros son los IDs
+// de los tweets y el score es la fecha de creacion. El contenido de cada tweet se guarda aparte,
+// de esta forma un tweet se escribe una unica vez sin importar en cuantos feeds este.
+
+// Set con los IDs de los usuarios cuyos tweets no se distribuyen en los feeds por tener demasiados seguidores.
+// Siempre contiene celebritiesLoadedMember (no existe un usuario con ID 0), asi un set vacio indica que la clave
+// no existe o fue desalojada y debe volver a leerse de la db sql (columna users.is_celebrity)
+const celebritiesKey = "celebrities"
+const celebritiesLoadedMember = "0"
+
+const feedKeyPrefix = "timeline:"
+
+func FeedKey(userId int64) string {
+	return fmt.Sprintf("%s%d", feedKeyPrefix, userId)
+}
+
+func TweetKey(tweetId int64) string {
+	return fmt.Sprintf("tweet:%d", tweetId)
+}
+
+func feedScore(createdAt time.Time) float64 {
+	return float64(createdAt.UnixMilli())
+}
+
+// feedMember completa el ID con ceros a la izquierda: el cache ordena los tweets con el mismo score de forma
+// lexicografica, y de esta forma ese orden coincide con el orden numerico de los IDs (igual que en la db sql)
+func feedMember(tweetId int64) string {
+	return fmt.Sprintf("%019d", tweetId)
+}
+
+// PushTweetToFeeds agrega el tweet al feed de cada uno de los usuarios recibidos (fan-out on write),
+// manteniendo como maximo maxSize tweets por feed. Los feeds que no existen no se crean:
+// se reconstruyen desde la db sql la proxima vez que se consulte el timeline
+func PushTweetToFeeds(cacheClient cache.Cache, tweet *models.Tweet, userIds []int64, maxSize int64) error {
+	var ctx = context.Background()
+
+	if len(userIds) == 0 {
+		return nil
+	}
+
+	feedKeys := make([]string, len(userIds))
+	for i, userId := range userIds {
+		feedKeys[i] = FeedKey(userId)
+	}
+
+	err := cacheClient.ZAddIfExists(ctx, feedKeys, cache.Z{Score: feedScore(tweet.CreatedAt), Member: feedMember(tweet.ID)}, maxSize)
+	if err != nil {
+		return fmt.Errorf("Error pushing tweet to feeds: %v", err)
+	}
+
+	return nil
+}
+
+// SaveFeed reemplaza el feed de un usuario con los tweets recibidos
+func SaveFeed(cacheClient cache.Cache, userId int64, tweets []models.Tweet, ttl time.Duration) error {
+	var ctx = context.Background()
+
+	members := make([]cache.Z, len(tweets))
+	for i := range tweets {
+		members[i] = cache.Z{Score: feedScore(tweets[i].CreatedAt), Member: feedMember(tweets[i].ID)}
+	}
+
+	err := cacheClient.ZReplace(ctx, FeedKey(userId), members, ttl)
+	if err != nil {
+		return fmt.Errorf("Error saving feed: %v", err)
+	}
+
+	return nil
+}
 
-func GetTweetsFromCache(redisClient *redis.Client, cacheKey string) (*models.TimelineCache, error) {
+func GetFeedSize(cacheClient cache.Cache, userId int64) (int64, error) {
 	var ctx = context.Background()
-	cachedTimelineData, err := redisClient.Get(ctx, cacheKey).Result()
-	if err == redis.Nil {
-		return nil, nil // No hay datos en cache
-	} else if err != nil {
-		return nil, fmt.Errorf("Error getting data from Redis: %v", err)
+	size, err := cacheClient.ZCard(ctx, FeedKey(userId))
+	if err != nil {
+		return 0, fmt.Errorf("Error getting feed size: %v", err)
 	}
 
-	var cachedTimeline models.TimelineCache
-	err = json.Unmarshal([]byte(cachedTimelineData), &cachedTimeline)
+	return size, nil
+}
+
+// GetFeedTweetIds obtiene los IDs de los tweets del feed ordenados del mas nuevo al mas viejo
+func GetFeedTweetIds(cacheClient cache.Cache, userId int64, limit int64, offset int64) ([]int64, error) {
+	var ctx = context.Background()
+	members, err := cacheClient.ZRevRange(ctx, FeedKey(userId), offset, offset+limit-1)
 	if err != nil {
-		return nil, fmt.Errorf("Error deserializing Redis data: %v", err)
+		return nil, fmt.Errorf("Error getting feed: %v", err)
 	}
 
-	return &cachedTimeline, nil
+	return parseFeedMembers(members)
 }
 
-func SaveTweetsToCache(redisClient *redis.Client, cacheKey string, timeline *models.TimelineCache, ttl time.Duration) error {
+// GetFeedTweetIdsByCursor obtiene los IDs de los tweets del feed anteriores al cursor, del mas nuevo al mas viejo
+func GetFeedTweetIdsByCursor(cacheClient cache.Cache, userId int64, limit int64, cursor *models.Cursor) ([]int64, error) {
 	var ctx = context.Background()
-	timelineJSON, err := json.Marshal(timeline)
+	feedKey := FeedKey(userId)
+	score := feedScore(cursor.CreatedAt)
+
+	// Primero los tweets con el mismo score que el cursor pero con un ID menor
+	tiedMembers, err := cacheClient.ZRevRangeByScore(ctx, feedKey, cache.ScoreRange{Min: score, Max: score})
+	if err != nil {
+		return nil, fmt.Errorf("Error getting feed: %v", err)
+	}
+
+	tiedIds, err := parseFeedMembers(tiedMembers)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []int64{}
+	for _, id := range tiedIds {
+		if id < cursor.ID {
+			ids = append(ids, id)
+		}
+	}
+
+	if int64(len(ids)) >= limit {
+		return ids[:limit], nil
+	}
+
+	// Luego se completa la pagina con los tweets de score menor
+	olderMembers, err := cacheClient.ZRevRangeByScore(ctx, feedKey, cache.ScoreRange{
+		Min:          math.Inf(-1),
+		Max:          score,
+		MaxExclusive: true,
+		Count:        limit - int64(len(ids)),
+	})
 	if err != nil {
-		return fmt.Errorf("Error serializing data for Redis: %v", err)
+		return nil, fmt.Errorf("Error getting feed: %v", err)
+	}
+
+	olderIds, err := parseFeedMembers(olderMembers)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(ids, olderIds...), nil
+}
+
+// parseFeedMembers convierte los miembros del feed en IDs de tweets
+func parseFeedMembers(members []string) ([]int64, error) {
+	ids := make([]int64, 0, len(members))
+	for _, member := range members {
+		id, err := strconv.ParseInt(member, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid tweet ID in feed: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// RemoveTweetFromFeeds quita el tweet del feed de cada uno de los usuarios recibidos
+func RemoveTweetFromFeeds(cacheClient cache.Cache, tweetId int64, userIds []int64) error {
+	var ctx = context.Background()
+
+	if len(userIds) == 0 {
+		return nil
+	}
+
+	feedKeys := make([]string, len(userIds))
+	for i, userId := range userIds {
+		feedKeys[i] = FeedKey(userId)
+	}
+
+	err := cacheClient.ZRemFromAll(ctx, feedKeys, feedMember(tweetId))
+	if err != nil {
+		return fmt.Errorf("Error removing tweet from feeds: %v", err)
+	}
+
+	return nil
+}
+
+func DeleteFeed(cacheClient cache.Cache, userId int64) error {
+	var ctx = context.Background()
+	err := cacheClient.Delete(ctx, FeedKey(userId))
+	if err != nil {
+		return fmt.Errorf("Error deleting feed: %v", err)
+	}
+
+	return nil
+}
+
+// DeleteFeeds elimina los feeds de los usuarios indicados, se reconstruyen desde la db sql al consultarlos
+func DeleteFeeds(cacheClient cache.Cache, userIds []int64) error {
+	if len(userIds) == 0 {
+		return nil
+	}
+
+	var ctx = context.Background()
+	feedKeys := make([]string, 0, len(userIds))
+	for _, userId := range userIds {
+		feedKeys = append(feedKeys, FeedKey(userId))
+	}
+
+	err := cacheClient.Delete(ctx, feedKeys...)
+	if err != nil {
+		return fmt.Errorf("Error deleting feeds: %v", err)
+	}
+
+	return nil
+}
+
+// GetTweetsFromCache obtiene el contenido de los tweets solicitados. El mapa retornado solo contiene los tweets encontrados en cache
+func GetTweetsFromCache(cacheClient cache.Cache, ids []int64) (map[int64]models.Tweet, error) {
+	var ctx = context.Background()
+	tweets := map[int64]models.Tweet{}
+
+	if len(ids) == 0 {
+		return tweets, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = TweetKey(id)
+	}
+
+	cachedTweets, err := cacheClient.MGet(ctx, keys...)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting tweets from cache: %v", err)
+	}
+
+	for _, data := range cachedTweets {
+		var tweet models.Tweet
+		err = json.Unmarshal([]byte(data), &tweet)
+		if err != nil {
+			return nil, fmt.Errorf("Error deserializing cached data: %v", err)
+		}
+		tweets[tweet.ID] = tweet
+	}
+
+	return tweets, nil
+}
+
+func SaveTweetsToCache(cacheClient cache.Cache, tweets []models.Tweet, ttl time.Duration) error {
+	var ctx = context.Background()
+
+	if len(tweets) == 0 {
+		return nil
+	}
+
+	values := make(map[string]string, len(tweets))
+	for i := range tweets {
+		tweetJSON, err := json.Marshal(tweets[i])
+		if err != nil {
+			return fmt.Errorf("Error serializing data for cache: %v", err)
+		}
+		values[TweetKey(tweets[i].ID)] = string(tweetJSON)
+	}
+
+	err := cacheClient.MSet(ctx, values, ttl)
+	if err != nil {
+		return fmt.Errorf("Error saving tweets to cache: %v", err)
+	}
+
+	return nil
+}
+
+// SetCelebrity agrega o quita al usuario del set de cuentas cuyos tweets se mezclan al leer el timeline
+func SetCelebrity(cacheClient cache.Cache, userId int64, isCelebrity bool) error {
+	var ctx = context.Background()
+	var err error
+
+	member := strconv.FormatInt(userId, 10)
+	if isCelebrity {
+		err = cacheClient.SAdd(ctx, celebritiesKey, member)
+	} else {
+		err = cacheClient.SRem(ctx, celebritiesKey, member)
+	}
+
+	if err != nil {
+		return fmt.Errorf("Error updating celebrities: %v", err)
+	}
+
+	return nil
+}
+
+// GetCelebrities obtiene los IDs del set de cuentas grandes. Retorna cache.ErrCacheMiss si el set no existe
+func GetCelebrities(cacheClient cache.Cache) ([]int64, error) {
+	var ctx = context.Background()
+	members, err := cacheClient.SMembers(ctx, celebritiesKey)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting celebrities: %v", err)
+	}
+
+	if len(members) == 0 {
+		return nil, cache.ErrCacheMiss
+	}
+
+	ids := make([]int64, 0, len(members))
+	for _, member := range members {
+		if member == celebritiesLoadedMember {
+			continue
+		}
+
+		id, err := strconv.ParseInt(member, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid user ID in celebrities: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// SaveCelebrities guarda el set de cuentas grandes leido de la db sql
+func SaveCelebrities(cacheClient cache.Cache, userIds []int64) error {
+	var ctx = context.Background()
+
+	members := []string{celebritiesLoadedMember}
+	for _, userId := range userIds {
+		members = append(members, strconv.FormatInt(userId, 10))
+	}
+
+	err := cacheClient.SAdd(ctx, celebritiesKey, members...)
+	if err != nil {
+		return fmt.Errorf("Error saving celebrities: %v", err)
+	}
+
+	return nil
+}
+
+func DeleteTweetFromCache(cacheClient cache.Cache, tweetIds ...int64) error {
+	var ctx = context.Background()
+
+	if len(tweetIds) == 0 {
+		return nil
+	}
+
+	keys := make([]string, len(tweetIds))
+	for i, tweetId := range tweetIds {
+		keys[i] = TweetKey(tweetId)
 	}
 
-	err = redisClient.Set(ctx, cacheKey, timelineJSON, ttl).Err()
+	err := cacheClient.Delete(ctx, keys...)
 	if err != nil {
-		return fmt.Errorf("Error setting value in Redis: %v", err)
+		return fmt.Errorf("Error deleting tweet from cache: %v", err)
 	}
 
 	return nil
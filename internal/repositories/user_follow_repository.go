@@ -5,10 +5,11 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
-	"github.com/redis/go-redis/v9"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
 )
 
 func FollowUser(db *sql.DB, userFollow *models.UserFollow) (bool, error) {
@@ -18,7 +19,7 @@ func FollowUser(db *sql.DB, userFollow *models.UserFollow) (bool, error) {
 	_, err = tx.Exec(query, userFollow.FollowerID, userFollow.FollowedID)
 	if err != nil {
 		tx.Rollback()
-		fmt.Println("[x] Error to create follow: %v", err)
+		fmt.Printf("[x] Error to create follow: %v\n", err)
 		return false, fmt.Errorf("[x] Error to create follow: %v", err)
 	}
 
@@ -30,8 +31,37 @@ func FollowUser(db *sql.DB, userFollow *models.UserFollow) (bool, error) {
 	return true, nil
 }
 
-func GetFollows(db *sql.DB, userId int64, relationType string, limit *int64, offset *int64) (*models.UserFollows, error) {
-	query := `SELECT u.id, u.name, u.email, u.created_at, f.created_at AS follow_date
+// UnfollowUser elimina la relacion de seguimiento. Retorna false si la relacion no existia
+func UnfollowUser(db *sql.DB, followerId int64, followedId int64) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("Error starting UnfollowUser transaction: %v", err)
+	}
+
+	result, err := tx.Exec(`DELETE FROM follows WHERE follower_id = $1 AND followed_id = $2`, followerId, followedId)
+	if err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("[x] Error to delete follow: %v", err)
+	}
+
+	deletedRows, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("[x] Error to delete follow: %v", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, fmt.Errorf("Error committing UnfollowUser transaction: %v", err)
+	}
+
+	return deletedRows > 0, nil
+}
+
+// GetFollows obtiene los seguidores o seguidos de un usuario. Si se recibe un cursor se pagina por cursor (keyset)
+// a partir de la fecha de seguimiento y el ID del usuario, y se ignora el offset
+func GetFollows(db *sql.DB, userId int64, relationType string, limit *int64, offset *int64, cursor *models.Cursor) (*models.UserFollows, error) {
+	query := `SELECT u.id, u.name, u.handle, u.created_at, f.created_at AS follow_date
 				FROM users u `
 
 	// Determinar la consulta según el tipo de relación
@@ -45,11 +75,22 @@ func GetFollows(db *sql.DB, userId int64, relationType string, limit *int64, off
 		return nil, fmt.Errorf("Invalid relationType: %s", relationType)
 	}
 
-	query += `ORDER BY follow_date DESC
+	args := []interface{}{userId}
+
+	if cursor != nil {
+		query += `AND (f.created_at < $2 OR (f.created_at = $2 AND u.id < $3))
+			  ORDER BY follow_date DESC, u.id DESC
+			  LIMIT $4;`
+		args = append(args, cursorTimestamp(cursor), cursor.ID, limit)
+	} else {
+		query += `ORDER BY follow_date DESC, u.id DESC
 			  LIMIT $2
 			  OFFSET $3;`
+		args = append(args, limit, offset)
+	}
+
 	follows := []models.UserFollowInfo{}
-	rows, err := db.Query(query, userId, limit, offset)
+	rows, err := db.Query(query, args...)
 
 	if err != nil {
 		return nil, fmt.Errorf("Error fetching follows: %v", err)
@@ -59,7 +100,7 @@ func GetFollows(db *sql.DB, userId int64, relationType string, limit *int64, off
 	for rows.Next() {
 		var user models.User
 		var followDate time.Time
-		err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.CreatedAt, &followDate)
+		err := rows.Scan(&user.ID, &user.Name, &user.Handle, &user.CreatedAt, &followDate)
 
 		if err != nil {
 			return nil, fmt.Errorf("Error scanning row: %v", err)
@@ -121,6 +162,62 @@ func CountFollows(db *sql.DB, userId int64, relationType string) (int64, error)
 
 	return totalFollows, nil
 }
+
+// GetFollowerIds obtiene los IDs de todos los seguidores de un usuario
+func GetFollowerIds(db *sql.DB, userId int64) ([]int64, error) {
+	rows, err := db.Query(`SELECT follower_id FROM follows WHERE followed_id = $1`, userId)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching follower ids: %v", err)
+	}
+	defer rows.Close()
+
+	return scanFollowerIds(rows)
+}
+
+// GetFollowedIds obtiene los IDs de todos los usuarios que sigue un usuario
+func GetFollowedIds(db *sql.DB, userId int64) ([]int64, error) {
+	rows, err := db.Query(`SELECT followed_id FROM follows WHERE follower_id = $1`, userId)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching followed ids: %v", err)
+	}
+	defer rows.Close()
+
+	return scanFollowerIds(rows)
+}
+
+// GetFollowerIdsForReply obtiene los seguidores del autor de una respuesta que la ven en su timeline:
+// los que tambien siguen al autor del tweet respondido, y el autor respondido si sigue a quien le responde
+func GetFollowerIdsForReply(db *sql.DB, authorId int64, repliedUserId int64) ([]int64, error) {
+	rows, err := db.Query(`SELECT fol.follower_id
+				FROM follows AS fol
+				WHERE fol.followed_id = $1 AND (fol.follower_id = $2
+					OR EXISTS (SELECT 1 FROM follows AS replied_fol WHERE replied_fol.follower_id = fol.follower_id AND replied_fol.followed_id = $2))`,
+		authorId, repliedUserId)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching follower ids: %v", err)
+	}
+	defer rows.Close()
+
+	return scanFollowerIds(rows)
+}
+
+func scanFollowerIds(rows *sql.Rows) ([]int64, error) {
+	followerIds := []int64{}
+	for rows.Next() {
+		var followerId int64
+		if err := rows.Scan(&followerId); err != nil {
+			return nil, fmt.Errorf("Error scanning row: %v", err)
+		}
+		followerIds = append(followerIds, followerId)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Error iterating rows: %v", err)
+	}
+
+	return followerIds, nil
+}
+
 func GetFollowByFollowerAndFollowed(db *sql.DB, followerId int64, followedId int64) (models.UserFollow, error) {
 	var follow models.UserFollow
 	err := db.QueryRow(`SELECT follower_id, followed_id, created_at FROM follows WHERE follower_id = $1 AND followed_id = $2`, followerId, followedId).
@@ -134,36 +231,87 @@ func GetFollowByFollowerAndFollowed(db *sql.DB, followerId int64, followedId int
 	return follow, nil
 }
 
-//Funciones para interactuar con redis respecto a los Follows
+//Funciones para interactuar con el cache respecto a los Follows
 
-func GetFollowsFromCache(redisClient *redis.Client, cacheKey string) (*models.FollowsCache, error) {
+func GetFollowsFromCache(cacheClient cache.Cache, cacheKey string) (*models.FollowsCache, error) {
 	var ctx = context.Background()
-	cachedFollowsData, err := redisClient.Get(ctx, cacheKey).Result()
-	if err == redis.Nil {
+	cachedFollowsData, err := cacheClient.Get(ctx, cacheKey)
+	if err == cache.ErrCacheMiss {
 		return nil, nil // No hay datos en cache
 	} else if err != nil {
-		return nil, fmt.Errorf("Error getting data from Redis: %v", err)
+		return nil, fmt.Errorf("Error getting follows from cache: %v", err)
 	}
 
 	var cachedFollows models.FollowsCache
 	err = json.Unmarshal([]byte(cachedFollowsData), &cachedFollows)
 	if err != nil {
-		return nil, fmt.Errorf("Error deserializing Redis data: %v", err)
+		return nil, fmt.Errorf("Error deserializing cached data: %v", err)
 	}
 
 	return &cachedFollows, nil
 }
 
-func SaveFollowsToCache(redisClient *redis.Client, cacheKey string, follows *models.FollowsCache, ttl time.Duration) error {
+func SaveFollowsToCache(cacheClient cache.Cache, cacheKey string, follows *models.FollowsCache, ttl time.Duration) error {
 	var ctx = context.Background()
 	followsJSON, err := json.Marshal(follows)
 	if err != nil {
-		return fmt.Errorf("Error serializing data for Redis: %v", err)
+		return fmt.Errorf("Error serializing data for cache: %v", err)
+	}
+
+	err = cacheClient.Set(ctx, cacheKey, string(followsJSON), ttl)
+	if err != nil {
+		return fmt.Errorf("Error saving follows to cache: %v", err)
+	}
+
+	return nil
+}
+
+// followsVersionTTL es el tiempo de vida de la version de las paginas de follows de un usuario. Si expira se genera una nueva y las paginas viejas quedan huerfanas hasta su propio TTL
+const followsVersionTTL = 24 * time.Hour
+
+func followsVersionKey(userId int64) string {
+	return fmt.Sprintf("follows_version:%d", userId)
+}
+
+// newFollowsVersion genera una version que nunca se uso antes, asi una version expulsada o expirada no vuelve a coincidir con paginas viejas
+func newFollowsVersion() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 10)
+}
+
+// GetFollowsCacheVersion retorna la version actual de las paginas de follows cacheadas de un usuario, que forma parte de la clave de cada pagina
+func GetFollowsCacheVersion(cacheClient cache.Cache, userId int64) (string, error) {
+	var ctx = context.Background()
+	key := followsVersionKey(userId)
+
+	version, err := cacheClient.Get(ctx, key)
+	if err == nil {
+		return version, nil
+	} else if err != cache.ErrCacheMiss {
+		return "", fmt.Errorf("Error getting follows version from cache: %v", err)
+	}
+
+	// SetNX para que dos requests concurrentes no pisen la version que genero la otra
+	_, err = cacheClient.SetNX(ctx, key, newFollowsVersion(), followsVersionTTL)
+	if err != nil {
+		return "", fmt.Errorf("Error saving follows version to cache: %v", err)
+	}
+
+	version, err = cacheClient.Get(ctx, key)
+	if err != nil {
+		return "", fmt.Errorf("Error getting follows version from cache: %v", err)
 	}
 
-	err = redisClient.Set(ctx, cacheKey, followsJSON, ttl).Err()
+	return version, nil
+}
+
+// InvalidateFollowsCache invalida todas las paginas de seguidores y seguidos cacheadas de un usuario cambiando su version.
+// No se recorre el keyspace: las paginas de la version anterior dejan de leerse y expiran por su TTL
+func InvalidateFollowsCache(cacheClient cache.Cache, userId int64) error {
+	var ctx = context.Background()
+
+	err := cacheClient.Set(ctx, followsVersionKey(userId), newFollowsVersion(), followsVersionTTL)
 	if err != nil {
-		return fmt.Errorf("Error setting value in Redis: %v", err)
+		return fmt.Errorf("Error invalidating follows cache: %v", err)
 	}
 
 	return nil
@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFollowsCacheVersion(t *testing.T) {
+	cacheClient := cache.NewMemoryCache(cache.DefaultMemoryCacheSize)
+
+	version, err := GetFollowsCacheVersion(cacheClient, 1)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, version)
+
+	// La version se mantiene hasta que se invalidan las paginas del usuario, y no afecta a otros usuarios
+	sameVersion, err := GetFollowsCacheVersion(cacheClient, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, version, sameVersion)
+
+	otherUserVersion, err := GetFollowsCacheVersion(cacheClient, 2)
+	assert.NoError(t, err)
+
+	assert.NoError(t, InvalidateFollowsCache(cacheClient, 1))
+
+	invalidatedVersion, err := GetFollowsCacheVersion(cacheClient, 1)
+	assert.NoError(t, err)
+	assert.NotEqual(t, version, invalidatedVersion)
+
+	sameOtherUserVersion, err := GetFollowsCacheVersion(cacheClient, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, otherUserVersion, sameOtherUserVersion)
+
+	// Si la version se desaloja, la nueva no coincide con ninguna anterior
+	assert.NoError(t, cacheClient.Delete(context.Background(), followsVersionKey(1)))
+
+	newVersion, err := GetFollowsCacheVersion(cacheClient, 1)
+	assert.NoError(t, err)
+	assert.NotContains(t, []string{version, invalidatedVersion}, newVersion)
+}
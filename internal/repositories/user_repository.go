@@ -4,16 +4,23 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/auth"
 )
 
-// CreateUser crea un nuevo usuario en la base de datos.
+// CreateUser crea un nuevo usuario en la base de datos. La contraseña se guarda hasheada con bcrypt.
 func CreateUser(db *sql.DB, user models.User) (int64, error) {
+	passwordHash, err := auth.HashPassword(user.Password)
+	if err != nil {
+		return 0, err
+	}
+
 	tx, err := db.Begin()
-	query := `INSERT INTO users (name, email, password) VALUES ($1, $2, $3) RETURNING id`
+	query := `INSERT INTO users (name, email, password, handle, is_private) VALUES ($1, $2, $3, $4, $5) RETURNING id`
 	var id int64
-	err = tx.QueryRow(query, user.Name, user.Email, user.Password).Scan(&id)
+	err = tx.QueryRow(query, user.Name, user.Email, passwordHash, user.Handle, user.IsPrivate).Scan(&id)
 	if err != nil {
 		tx.Rollback()
 		log.Printf("[x] Error to create user: %v", err)
@@ -31,9 +38,24 @@ func CreateUser(db *sql.DB, user models.User) (int64, error) {
 // GetUserById obtiene un usuario por su ID desde la base de datos.
 func GetUserById(db *sql.DB, id int64) (models.User, error) {
 
-	var user models.User
-	err := db.QueryRow(`SELECT id, name, email, created_at FROM users WHERE id = $1`, id).
-		Scan(&user.ID, &user.Name, &user.Email, &user.CreatedAt)
+	user, err := scanUser(db.QueryRow(`SELECT `+userColumns+` FROM users WHERE id = $1`, id))
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.User{}, fmt.Errorf("user not found")
+		}
+		return models.User{}, fmt.Errorf("[x] Error to get user: %v", err)
+	}
+
+	return user, nil
+}
+
+// GetUserWithEmailById obtiene un usuario por su ID incluyendo su email, que solo se retorna al propio usuario
+func GetUserWithEmailById(db *sql.DB, id int64) (models.User, error) {
+
+	var email string
+	user, err := scanUser(db.QueryRow(`SELECT `+userColumns+`, email FROM users WHERE id = $1`, id), &email)
+	user.Email = email
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -44,3 +66,250 @@ func GetUserById(db *sql.DB, id int64) (models.User, error) {
 
 	return user, nil
 }
+
+// GetUserByEmail obtiene un usuario por su email, incluyendo el hash de su contraseña para validar el login.
+func GetUserByEmail(db *sql.DB, email string) (models.User, error) {
+
+	var storedEmail, password string
+	user, err := scanUser(db.QueryRow(`SELECT `+userColumns+`, email, password FROM users WHERE email = $1`, email), &storedEmail, &password)
+	user.Email = storedEmail
+	user.Password = password
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.User{}, fmt.Errorf("user not found")
+		}
+		return models.User{}, fmt.Errorf("[x] Error to get user: %v", err)
+	}
+
+	return user, nil
+}
+
+// GetUserByHandle obtiene un usuario por su handle (sin '@' y en minusculas)
+func GetUserByHandle(db *sql.DB, handle string) (models.User, error) {
+
+	user, err := scanUser(db.QueryRow(`SELECT `+userColumns+` FROM users WHERE handle = $1`, handle))
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.User{}, fmt.Errorf("user not found")
+		}
+		return models.User{}, fmt.Errorf("[x] Error to get user: %v", err)
+	}
+
+	return user, nil
+}
+
+// HandleExists indica si algun usuario tiene el handle
+func HandleExists(db *sql.DB, handle string) (bool, error) {
+	var exists bool
+	err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM users WHERE handle = $1)`, handle).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("Error checking handle: %v", err)
+	}
+
+	return exists, nil
+}
+
+// GetCelebrityUserIds obtiene los IDs de los usuarios cuyos tweets no se distribuyen en los feeds por tener demasiados seguidores
+func GetCelebrityUserIds(db *sql.DB) ([]int64, error) {
+	rows, err := db.Query(`SELECT id FROM users WHERE is_celebrity = TRUE`)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching celebrity ids: %v", err)
+	}
+	defer rows.Close()
+
+	return scanFollowerIds(rows)
+}
+
+// SetUserCelebrity actualiza si los tweets del usuario se distribuyen en los feeds de sus seguidores
+func SetUserCelebrity(db *sql.DB, userId int64, isCelebrity bool) error {
+	_, err := db.Exec(`UPDATE users SET is_celebrity = $1 WHERE id = $2`, isCelebrity, userId)
+	if err != nil {
+		return fmt.Errorf("Error updating celebrity: %v", err)
+	}
+
+	return nil
+}
+
+// SearchUsers obtiene los usuarios cuyo handle o alguna palabra de su nombre empieza con el prefijo (en minusculas).
+// Primero el usuario con ese handle exacto, luego los que coinciden por handle y por ultimo los que coinciden por nombre
+func SearchUsers(db *sql.DB, prefix string, limit int64, offset int64) ([]models.User, error) {
+	query := `SELECT ` + userColumns + `
+				FROM users
+				WHERE ` + searchUsersCondition + `
+				ORDER BY CASE WHEN handle = $3 THEN 0 WHEN handle LIKE $1 ESCAPE '\' THEN 1 ELSE 2 END, handle
+				LIMIT $4
+				OFFSET $5;`
+
+	rows, err := db.Query(query, escapeLike(prefix)+"%", "% "+escapeLike(prefix)+"%", prefix, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("Error searching users: %v", err)
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("Error scanning row: %v", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Error iterating rows: %v", err)
+	}
+
+	return users, nil
+}
+
+func CountSearchUsers(db *sql.DB, prefix string) (int64, error) {
+	var total int64
+	err := db.QueryRow(`SELECT COUNT(*) FROM users WHERE `+searchUsersCondition, escapeLike(prefix)+"%", "% "+escapeLike(prefix)+"%").Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("Error counting searched users: %v", err)
+	}
+
+	return total, nil
+}
+
+// El prefijo ($1) se compara con el inicio del handle y del nombre, y con el inicio de cada palabra del nombre ($2)
+const searchUsersCondition = `(handle LIKE $1 ESCAPE '\' OR LOWER(name) LIKE $1 ESCAPE '\' OR LOWER(name) LIKE $2 ESCAPE '\')`
+
+// UpdateUser modifica los campos del perfil recibidos (los nil no se modifican). Retorna false si el usuario no existe
+func UpdateUser(db *sql.DB, userId int64, update models.UserUpdate) (bool, error) {
+	fields := []struct {
+		column string
+		value  *string
+	}{
+		{"name", update.Name},
+		{"email", update.Email},
+		{"bio", update.Bio},
+		{"location", update.Location},
+		{"website", update.Website},
+		{"avatar_url", update.AvatarURL},
+	}
+
+	sets := []string{}
+	args := []interface{}{}
+	for _, field := range fields {
+		if field.value != nil {
+			args = append(args, *field.value)
+			sets = append(sets, fmt.Sprintf("%s = $%d", field.column, len(args)))
+		}
+	}
+
+	if update.IsPrivate != nil {
+		args = append(args, *update.IsPrivate)
+		sets = append(sets, fmt.Sprintf("is_private = $%d", len(args)))
+	}
+
+	if len(sets) == 0 {
+		return true, nil
+	}
+
+	args = append(args, userId)
+	result, err := db.Exec(fmt.Sprintf(`UPDATE users SET %s WHERE id = $%d`, strings.Join(sets, ", "), len(args)), args...)
+	if err != nil {
+		return false, fmt.Errorf("[x] Error to update user: %v", err)
+	}
+
+	updatedRows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("[x] Error to update user: %v", err)
+	}
+
+	return updatedRows > 0, nil
+}
+
+// DeleteUser elimina al usuario junto a sus tweets (con los retweets, likes, bookmarks, hashtags y menciones de esos tweets),
+// sus follows, bloqueos, silenciamientos, palabras silenciadas, likes, bookmarks, notificaciones y las menciones que lo referencian. Las citas de otros usuarios a sus tweets dejan de embeberlos.
+// Si updateCount es true se descuentan sus likes del contador de los tweets likeados; con cache se descuentan en el cache.
+// Retorna false si el usuario no existia
+func DeleteUser(db *sql.DB, userId int64, updateCount bool) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("Error starting DeleteUser transaction: %v", err)
+	}
+
+	// Tweets del usuario y retweets de otros usuarios a esos tweets
+	const userTweets = `SELECT id FROM tweets WHERE user_id = $1 OR retweet_of_tweet_id IN (SELECT id FROM tweets WHERE user_id = $1)`
+
+	type statement struct {
+		query string
+		name  string
+	}
+
+	statements := []statement{}
+	if updateCount {
+		// Antes de eliminar los likes del usuario se descuentan de los tweets que likeo
+		statements = append(statements, statement{`UPDATE tweets SET like_count = like_count - 1 WHERE id IN (SELECT tweet_id FROM likes WHERE user_id = $1)`, "like counts"})
+	}
+
+	// Se hace de forma explicita porque SQLite no aplica las acciones de las foreign keys
+	statements = append(statements,
+		statement{`DELETE FROM likes WHERE tweet_id IN (` + userTweets + `)`, "tweet likes"},
+		statement{`DELETE FROM bookmarks WHERE tweet_id IN (` + userTweets + `)`, "tweet bookmarks"},
+		statement{`DELETE FROM tweet_hashtags WHERE tweet_id IN (` + userTweets + `)`, "hashtags"},
+		statement{`DELETE FROM tweet_mentions WHERE tweet_id IN (` + userTweets + `) OR user_id = $1`, "mentions"},
+		statement{`DELETE FROM notifications WHERE tweet_id IN (` + userTweets + `) OR user_id = $1 OR actor_id = $1`, "notifications"},
+		statement{`UPDATE tweets SET quoted_tweet_id = NULL WHERE user_id <> $1 AND quoted_tweet_id IN (SELECT id FROM tweets WHERE user_id = $1)`, "quotes"},
+		statement{`UPDATE tweets SET in_reply_to_tweet_id = NULL WHERE user_id <> $1 AND in_reply_to_tweet_id IN (` + userTweets + `)`, "replies"},
+		statement{`UPDATE tweets SET in_reply_to_user_id = NULL WHERE in_reply_to_user_id = $1`, "replies to the user"},
+		statement{`DELETE FROM tweets WHERE retweet_of_tweet_id IN (SELECT id FROM tweets WHERE user_id = $1)`, "retweets"},
+		statement{`DELETE FROM tweets WHERE user_id = $1`, "tweets"},
+		statement{`DELETE FROM follows WHERE follower_id = $1 OR followed_id = $1`, "follows"},
+		statement{`DELETE FROM follow_requests WHERE follower_id = $1 OR followed_id = $1`, "follow requests"},
+		statement{`DELETE FROM blocks WHERE blocker_id = $1 OR blocked_id = $1`, "blocks"},
+		statement{`DELETE FROM mutes WHERE muter_id = $1 OR muted_id = $1`, "mutes"},
+		statement{`DELETE FROM muted_words WHERE user_id = $1`, "muted words"},
+		statement{`DELETE FROM bookmarks WHERE user_id = $1`, "bookmarks"},
+		statement{`DELETE FROM likes WHERE user_id = $1`, "likes"},
+	)
+
+	for _, statement := range statements {
+		_, err = tx.Exec(statement.query, userId)
+		if err != nil {
+			tx.Rollback()
+			return false, fmt.Errorf("[x] Error to delete %s: %v", statement.name, err)
+		}
+	}
+
+	result, err := tx.Exec(`DELETE FROM users WHERE id = $1`, userId)
+	if err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("[x] Error to delete user: %v", err)
+	}
+
+	deletedRows, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("[x] Error to delete user: %v", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, fmt.Errorf("Error committing DeleteUser transaction: %v", err)
+	}
+
+	return deletedRows > 0, nil
+}
+
+// userColumns son las columnas publicas del perfil. No incluyen el email, que solo se retorna al propio usuario (ver GetUserWithEmailById)
+const userColumns = `id, name, handle, bio, location, website, avatar_url, is_private, created_at`
+
+// prefixedUserColumns son las columnas de userColumns para consultas donde la tabla users tiene el alias u
+const prefixedUserColumns = `u.id, u.name, u.handle, u.bio, u.location, u.website, u.avatar_url, u.is_private, u.created_at`
+
+// scanUser escanea una fila con las columnas de userColumns. Las columnas adicionales de la consulta se escanean en extra
+func scanUser(row rowScanner, extra ...any) (models.User, error) {
+	var user models.User
+	dest := append([]any{&user.ID, &user.Name, &user.Handle, &user.Bio, &user.Location, &user.Website, &user.AvatarURL, &user.IsPrivate, &user.CreatedAt}, extra...)
+
+	if err := row.Scan(dest...); err != nil {
+		return models.User{}, err
+	}
+
+	return user, nil
+}
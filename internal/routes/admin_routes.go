@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"database/sql"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/controllers"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/middlewares"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupAdminRoutes configura las rutas de administracion, protegidas con el header X-Admin-Token
+func SetupAdminRoutes(router *gin.Engine, db *sql.DB, cacheClient cache.Cache) {
+
+	adminController := controllers.NewAdminController(db, cacheClient)
+
+	adminGroup := router.Group("/admin", middlewares.AdminRequired())
+	{
+		adminGroup.POST("/db/snapshot", adminController.CreateSnapshotHandler) // POST /admin/db/snapshot guarda una copia de la db SQLite en el directorio de snapshots
+		adminGroup.POST("/db/restore", adminController.RestoreSnapshotHandler) // POST /admin/db/restore reemplaza la db SQLite por un snapshot guardado
+	}
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"database/sql"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupAuthRoutes configura las rutas de autenticacion.
+func SetupAuthRoutes(router *gin.Engine, db *sql.DB) {
+
+	authController := controllers.NewAuthController(db)
+
+	authGroup := router.Group("/auth")
+	{
+		authGroup.POST("/login", authController.LoginHandler)     // POST /auth/login obtiene los tokens de acceso con email y contraseña
+		authGroup.POST("/refresh", authController.RefreshHandler) // POST /auth/refresh renueva los tokens a partir de un refresh token
+	}
+}
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"database/sql"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/controllers"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/middlewares"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupBlockRoutes configura las rutas de bloqueos y silenciamientos de usuarios y de palabras
+func SetupBlockRoutes(router *gin.Engine, db *sql.DB, cacheClient cache.Cache, broadcaster cache.Broadcaster) {
+
+	blockController := controllers.NewBlockController(db, cacheClient, broadcaster)
+	muteController := controllers.NewMuteController(db, cacheClient)
+	mutedWordController := controllers.NewMutedWordController(db, cacheClient)
+
+	userGroup := router.Group("/users")
+	{
+		userGroup.POST("/:id/blocks/:target_id", middlewares.AuthRequired(), blockController.BlockUserHandler)                // POST /users/:id/blocks/:target_id el usuario autenticado bloquea a un usuario
+		userGroup.DELETE("/:id/blocks/:target_id", middlewares.AuthRequired(), blockController.UnblockUserHandler)            // DELETE /users/:id/blocks/:target_id el usuario autenticado desbloquea a un usuario
+		userGroup.POST("/:id/mutes/:target_id", middlewares.AuthRequired(), muteController.MuteUserHandler)                   // POST /users/:id/mutes/:target_id el usuario autenticado silencia a un usuario
+		userGroup.DELETE("/:id/mutes/:target_id", middlewares.AuthRequired(), muteController.UnmuteUserHandler)               // DELETE /users/:id/mutes/:target_id el usuario autenticado deja de silenciar a un usuario
+		userGroup.GET("/:id/muted_words", middlewares.AuthRequired(), mutedWordController.GetMutedWordsHandler)               // GET /users/:id/muted_words obtengo las palabras silenciadas del usuario autenticado
+		userGroup.POST("/:id/muted_words", middlewares.AuthRequired(), mutedWordController.AddMutedWordHandler)               // POST /users/:id/muted_words silencia una palabra, frase o hashtag, con expiracion opcional
+		userGroup.DELETE("/:id/muted_words/:word_id", middlewares.AuthRequired(), mutedWordController.DeleteMutedWordHandler) // DELETE /users/:id/muted_words/:word_id deja de silenciar una palabra
+	}
+}
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"database/sql"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/controllers"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/middlewares"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupBookmarkRoutes configura las rutas de bookmarks, que cuelgan de los tweets y de los usuarios
+func SetupBookmarkRoutes(router *gin.Engine, db *sql.DB, cacheClient cache.Cache) {
+
+	bookmarkController := controllers.NewBookmarkController(db, cacheClient)
+
+	tweetGroup := router.Group("/tweets")
+	{
+		tweetGroup.POST("/:tweet_id/bookmark", middlewares.AuthRequired(), bookmarkController.BookmarkTweetHandler)    // POST /tweets/:tweet_id/bookmark guarda un tweet en los bookmarks del usuario autenticado
+		tweetGroup.DELETE("/:tweet_id/bookmark", middlewares.AuthRequired(), bookmarkController.DeleteBookmarkHandler) // DELETE /tweets/:tweet_id/bookmark quita un tweet de los bookmarks del usuario autenticado
+	}
+
+	userGroup := router.Group("/users")
+	{
+		userGroup.GET("/:id/bookmarks", middlewares.AuthRequired(), bookmarkController.GetBookmarksHandler) // GET /users/:id/bookmarks obtengo los bookmarks del usuario autenticado
+	}
+}
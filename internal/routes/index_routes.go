@@ -2,25 +2,60 @@
 package routes
 
 import (
+	"context"
 	"database/sql"
 	"net/http"
 
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/services"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
 	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/utils"
 	"github.com/gin-gonic/gin"
-	"github.com/redis/go-redis/v9"
 )
 
-// Todas las rutas estaran centralizadas en SetupRoutes
-func SetupRoutes(router *gin.Engine, db *sql.DB, redisClient *redis.Client) {
+// Todas las rutas estaran centralizadas en SetupRoutes. Retorna la funcion que cierra las conexiones WebSocket al apagar
+// el servidor, ya que http.Server.Shutdown no espera a las conexiones que dejaron de ser HTTP
+func SetupRoutes(router *gin.Engine, db *sql.DB, cacheClient cache.Cache) func(ctx context.Context) error {
+
+	// Los tweets creados y los eventos en vivo se publican con Redis Pub/Sub (entre todas las instancias de la API)
+	// o, con el cache en memoria o sin cache, dentro del proceso
+	broadcaster := cache.NewBroadcaster(cacheClient)
+	realtimeService := services.NewRealtimeService(db, cacheClient, broadcaster)
+
+	// Rutas de autenticacion
+	SetupAuthRoutes(router, db)
 
 	// Rutas relacionadas con usuarios
-	SetupUserRoutes(router, db)
+	SetupUserRoutes(router, db, cacheClient, broadcaster)
 
 	// Rutas relacionadas con seguidores
-	SetupUserFollowRoutes(router, db, redisClient)
+	SetupUserFollowRoutes(router, db, cacheClient, broadcaster)
+
+	// Rutas de bloqueos y silenciamientos (de usuarios y de palabras)
+	SetupBlockRoutes(router, db, cacheClient, broadcaster)
 
 	// Rutas relacionadas con tweets
-	SetupTweetRoutes(router, db, redisClient)
+	SetupTweetRoutes(router, db, cacheClient, broadcaster)
+
+	// Rutas relacionadas con likes
+	SetupLikeRoutes(router, db, cacheClient, broadcaster)
+
+	// Rutas de notificaciones
+	SetupNotificationRoutes(router, db, cacheClient)
+
+	// Rutas relacionadas con bookmarks
+	SetupBookmarkRoutes(router, db, cacheClient)
+
+	// Rutas de tendencias
+	SetupTrendRoutes(router, db, cacheClient)
+
+	// Rutas de busqueda
+	SetupSearchRoutes(router, db, cacheClient)
+
+	// Rutas de administracion
+	SetupAdminRoutes(router, db, cacheClient)
+
+	// Gateway WebSocket de notificaciones y contadores en vivo
+	SetupWebSocketRoutes(router, realtimeService)
 
 	//Endpoint ping para probar el funcionamiento de la API
 	router.GET("/ping", func(c *gin.Context) {
@@ -35,4 +70,5 @@ func SetupRoutes(router *gin.Engine, db *sql.DB, redisClient *redis.Client) {
 		c.JSON(http.StatusNotFound, notFoundResponse)
 	})
 
+	return realtimeService.Shutdown
 }
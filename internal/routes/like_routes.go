@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"database/sql"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/controllers"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/middlewares"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupLikeRoutes configura las rutas de likes, que cuelgan de los tweets y de los usuarios
+func SetupLikeRoutes(router *gin.Engine, db *sql.DB, cacheClient cache.Cache, broadcaster cache.Broadcaster) {
+
+	likeController := controllers.NewLikeController(db, cacheClient, broadcaster)
+
+	tweetGroup := router.Group("/tweets")
+	{
+		tweetGroup.POST("/:tweet_id/like", middlewares.AuthRequired(), likeController.LikeTweetHandler)     // POST /tweets/:tweet_id/like da like a un tweet en nombre del usuario autenticado
+		tweetGroup.DELETE("/:tweet_id/like", middlewares.AuthRequired(), likeController.UnlikeTweetHandler) // DELETE /tweets/:tweet_id/like quita el like del usuario autenticado
+		tweetGroup.GET("/:id/likes", middlewares.OptionalAuth(), likeController.GetTweetLikesHandler)       // GET /tweets/:tweet_id/likes obtengo los usuarios que dieron like a un tweet
+	}
+
+	userGroup := router.Group("/users")
+	{
+		userGroup.GET("/:id/likes", middlewares.OptionalAuth(), likeController.GetUserLikesHandler) // GET /users/:id/likes obtengo los tweets a los que un usuario dio like
+	}
+}
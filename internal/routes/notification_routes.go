@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"database/sql"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/controllers"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/middlewares"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupNotificationRoutes configura las rutas de las notificaciones del usuario autenticado
+func SetupNotificationRoutes(router *gin.Engine, db *sql.DB, cacheClient cache.Cache) {
+
+	notificationController := controllers.NewNotificationController(db, cacheClient)
+
+	userGroup := router.Group("/users")
+	{
+		userGroup.GET("/:id/notifications", middlewares.AuthRequired(), notificationController.GetNotificationsHandler)            // GET /users/:id/notifications obtengo las notificaciones agrupadas del usuario autenticado y cuantas no leyo
+		userGroup.POST("/:id/notifications/read", middlewares.AuthRequired(), notificationController.MarkNotificationsReadHandler) // POST /users/:id/notifications/read marca como leidas las notificaciones del usuario autenticado
+	}
+}
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"database/sql"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/controllers"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/middlewares"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupSearchRoutes configura las rutas de busqueda
+func SetupSearchRoutes(router *gin.Engine, db *sql.DB, cacheClient cache.Cache) {
+
+	searchController := controllers.NewSearchController(db, cacheClient)
+
+	searchGroup := router.Group("/search")
+	{
+		searchGroup.GET("/tweets", middlewares.OptionalAuth(), searchController.SearchTweetsHandler) // GET /search/tweets?q= busco tweets por texto, frases, from:userId, since:/until: y hashtags
+		searchGroup.GET("/users", searchController.SearchUsersHandler)                               // GET /search/users?q= busco usuarios por el prefijo de su handle o nombre
+	}
+}
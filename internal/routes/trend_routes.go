@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"database/sql"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/controllers"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupTrendRoutes configura las rutas de tendencias
+func SetupTrendRoutes(router *gin.Engine, db *sql.DB, cacheClient cache.Cache) {
+
+	trendController := controllers.NewTrendController(db, cacheClient)
+
+	router.GET("/trends", trendController.GetTrendsHandler) // GET /trends?window=1h|24h obtengo los hashtags en tendencia, ordenados por velocidad
+}
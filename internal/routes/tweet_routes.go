@@ -4,18 +4,37 @@ import (
 	"database/sql"
 
 	"github.com/MauricioGiaconia/uala_backend_challenge/internal/controllers"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/middlewares"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
 	"github.com/gin-gonic/gin"
-	"github.com/redis/go-redis/v9"
 )
 
-func SetupTweetRoutes(router *gin.Engine, db *sql.DB, rdb *redis.Client) {
+func SetupTweetRoutes(router *gin.Engine, db *sql.DB, cacheClient cache.Cache, broadcaster cache.Broadcaster) {
 
-	tweetController := controllers.NewTweetController(db, rdb)
+	tweetController := controllers.NewTweetController(db, cacheClient, broadcaster)
 
+	// Gin no permite parametros con distinto nombre en la misma posicion de rutas del mismo metodo,
+	// por eso las rutas GET usan :id tanto para el ID del seguidor como para el ID del tweet
 	tweetGroup := router.Group("/tweets")
 	{
-		tweetGroup.POST("/create", tweetController.CreateTweetHandler)                                    // POST /tweets/post crea un nuevo tweet
-		tweetGroup.GET("/:follower_id/timeline", tweetController.GetTimelineHandler)                      // GET /tweets/:follower_id/timeline obtengo el timeline de los usuarios seguidos
-		tweetGroup.GET("/:follower_id/routine_timeline", tweetController.GetTimelineWithGoRoutineHandler) // GET /tweets/:follower_id/routine_timeline obtengo el timeline de los usuarios seguidos usango go routines
+		tweetGroup.POST("/create", middlewares.AuthRequired(), tweetController.CreateTweetHandler)                           // POST /tweets/post crea un nuevo tweet del usuario autenticado
+		tweetGroup.GET("/:id/timeline", middlewares.AuthRequired(), tweetController.GetTimelineHandler)                      // GET /tweets/:follower_id/timeline obtengo el timeline de los usuarios seguidos (solo el propio usuario autenticado)
+		tweetGroup.GET("/:id/routine_timeline", middlewares.AuthRequired(), tweetController.GetTimelineWithGoRoutineHandler) // GET /tweets/:follower_id/routine_timeline obtengo el timeline de los usuarios seguidos usango go routines (solo el propio usuario autenticado)
+		tweetGroup.GET("/:id/stream", middlewares.AuthRequired(), tweetController.StreamTimelineHandler)                     // GET /tweets/:follower_id/stream envia por SSE los tweets nuevos del timeline (soporta Last-Event-ID, solo el propio usuario autenticado)
+		tweetGroup.GET("/:id/thread", middlewares.OptionalAuth(), tweetController.GetThreadHandler)                          // GET /tweets/:tweet_id/thread obtengo el hilo de un tweet (tweets a los que responde y sus respuestas paginadas)
+		tweetGroup.DELETE("/:tweet_id", middlewares.AuthRequired(), tweetController.DeleteTweetHandler)                      // DELETE /tweets/:tweet_id elimina un tweet (solo su autor autenticado)
+		tweetGroup.POST("/:tweet_id/retweet", middlewares.AuthRequired(), tweetController.RetweetHandler)                    // POST /tweets/:tweet_id/retweet retweetea un tweet en nombre del usuario autenticado
+		tweetGroup.DELETE("/:tweet_id/retweet", middlewares.AuthRequired(), tweetController.UndoRetweetHandler)              // DELETE /tweets/:tweet_id/retweet deshace el retweet del usuario autenticado
+		tweetGroup.POST("/:tweet_id/quote", middlewares.AuthRequired(), tweetController.QuoteTweetHandler)                   // POST /tweets/:tweet_id/quote cita un tweet con un comentario del usuario autenticado
+	}
+
+	hashtagGroup := router.Group("/hashtags")
+	{
+		hashtagGroup.GET("/:tag/tweets", middlewares.OptionalAuth(), tweetController.GetHashtagTweetsHandler) // GET /hashtags/:tag/tweets obtengo los tweets que usan un hashtag
+	}
+
+	userGroup := router.Group("/users")
+	{
+		userGroup.GET("/:id/mentions", middlewares.OptionalAuth(), tweetController.GetUserMentionsHandler) // GET /users/:id/mentions obtengo los tweets que mencionan a un usuario
 	}
 }
@@ -4,18 +4,27 @@ import (
 	"database/sql"
 
 	"github.com/MauricioGiaconia/uala_backend_challenge/internal/controllers"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/middlewares"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
 	"github.com/gin-gonic/gin"
-	"github.com/redis/go-redis/v9"
 )
 
 // SetupUserRoutes configura las rutas para manejar usuarios.
-func SetupUserFollowRoutes(router *gin.Engine, db *sql.DB, rds *redis.Client) {
+func SetupUserFollowRoutes(router *gin.Engine, db *sql.DB, cacheClient cache.Cache, broadcaster cache.Broadcaster) {
 
-	userFollowController := controllers.NewUseFollowrController(db, rds)
+	userFollowController := controllers.NewUseFollowrController(db, cacheClient, broadcaster)
 
 	userFollowGroup := router.Group("/users_follow")
 	{
-		userFollowGroup.POST("/create", userFollowController.FollowUserHandler)                    // POST /users_follow/create crea un nuevo usuario seguidor a un usuario
-		userFollowGroup.GET("/:id/follows/:follow_type", userFollowController.GetFollowersHandler) // GET /users_follow/:id/followers obtiene todos los seguidores de un usuario
+		userFollowGroup.POST("/create", middlewares.AuthRequired(), userFollowController.FollowUserHandler)                        // POST /users_follow/create el usuario autenticado pasa a seguir a un usuario
+		userFollowGroup.GET("/:id/follows/:follow_type", userFollowController.GetFollowersHandler)                                 // GET /users_follow/:id/followers obtiene todos los seguidores de un usuario
+		userFollowGroup.DELETE("/:follower_id/:followed_id", middlewares.AuthRequired(), userFollowController.UnfollowUserHandler) // DELETE /users_follow/:follower_id/:followed_id el seguidor deja de seguir al usuario (o cancela su solicitud pendiente)
+	}
+
+	userGroup := router.Group("/users")
+	{
+		userGroup.GET("/:id/follow_requests", middlewares.AuthRequired(), userFollowController.GetFollowRequestsHandler)                          // GET /users/:id/follow_requests obtengo las solicitudes de seguimiento pendientes del usuario autenticado
+		userGroup.POST("/:id/follow_requests/:follower_id/approve", middlewares.AuthRequired(), userFollowController.ApproveFollowRequestHandler) // POST /users/:id/follow_requests/:follower_id/approve aprueba una solicitud de seguimiento
+		userGroup.POST("/:id/follow_requests/:follower_id/reject", middlewares.AuthRequired(), userFollowController.RejectFollowRequestHandler)   // POST /users/:id/follow_requests/:follower_id/reject rechaza una solicitud de seguimiento
 	}
 }
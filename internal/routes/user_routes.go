@@ -4,17 +4,22 @@ import (
 	"database/sql"
 
 	"github.com/MauricioGiaconia/uala_backend_challenge/internal/controllers"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/middlewares"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
 	"github.com/gin-gonic/gin"
 )
 
 // SetupUserRoutes configura las rutas para manejar usuarios.
-func SetupUserRoutes(router *gin.Engine, db *sql.DB) {
+func SetupUserRoutes(router *gin.Engine, db *sql.DB, cacheClient cache.Cache, broadcaster cache.Broadcaster) {
 
-	userController := controllers.NewUserController(db)
+	userController := controllers.NewUserController(db, cacheClient, broadcaster)
 
 	userGroup := router.Group("/users")
 	{
-		userGroup.POST("/create", userController.CreateUserHandler) // POST /users crea un nuevo usuario
-		userGroup.GET("/:id", userController.GetUserByIdHandler)    // GET /:id obtengo un usuario dado un ID
+		userGroup.POST("/create", userController.CreateUserHandler)                            // POST /users crea un nuevo usuario
+		userGroup.GET("/:id", userController.GetUserByIdHandler)                               // GET /:id obtengo un usuario dado un ID
+		userGroup.GET("/by-handle/:handle", userController.GetUserByHandleHandler)             // GET /by-handle/:handle obtengo un usuario dado su handle
+		userGroup.PATCH("/:id", middlewares.AuthRequired(), userController.UpdateUserHandler)  // PATCH /:id modifica el perfil del usuario autenticado
+		userGroup.DELETE("/:id", middlewares.AuthRequired(), userController.DeleteUserHandler) // DELETE /:id elimina la cuenta del usuario autenticado
 	}
 }
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/controllers"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/middlewares"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupWebSocketRoutes configura el gateway WebSocket de notificaciones y contadores en vivo
+func SetupWebSocketRoutes(router *gin.Engine, realtimeService *services.RealtimeService) {
+
+	webSocketController := controllers.NewWebSocketController(realtimeService)
+
+	router.GET("/ws", middlewares.AuthRequired(), webSocketController.WebSocketHandler) // GET /ws abre el WebSocket del usuario autenticado para suscribirse a notificaciones y contadores
+}
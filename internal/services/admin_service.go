@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/repositories"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/db"
+)
+
+// Directorio donde se guardan los snapshots de SQLite, configurable con la flag -sqlite-snapshot-dir
+var SQLiteSnapshotDir = "snapshots"
+
+// Los snapshots solo se pueden nombrar con un nombre de archivo simple, para no escribir ni leer fuera del directorio
+var snapshotNameRegex = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9_.-]*$`)
+
+type AdminService struct {
+	DB    *sql.DB
+	Cache cache.Cache
+}
+
+func NewAdminService(db *sql.DB, cacheClient cache.Cache) *AdminService {
+	return &AdminService{DB: db, Cache: cacheClient}
+}
+
+// CreateSnapshot copia la db SQLite a un archivo del directorio de snapshots.
+// Si no se indica un nombre se genera uno con la fecha actual
+func (as *AdminService) CreateSnapshot(name string) (models.Snapshot, error) {
+	if !db.IsSQLite(as.DB) {
+		return models.Snapshot{}, fmt.Errorf("Snapshots are only supported with SQLite")
+	}
+
+	if name == "" {
+		name = fmt.Sprintf("snapshot-%s.db", time.Now().UTC().Format("20060102-150405"))
+	}
+
+	if !snapshotNameRegex.MatchString(name) {
+		return models.Snapshot{}, fmt.Errorf("Invalid snapshot name")
+	}
+
+	err := os.MkdirAll(SQLiteSnapshotDir, 0o755)
+	if err != nil {
+		return models.Snapshot{}, fmt.Errorf("Error creating snapshots directory: %v", err)
+	}
+
+	snapshot := models.Snapshot{Name: name, Path: filepath.Join(SQLiteSnapshotDir, name)}
+
+	err = db.SnapshotSQLite(as.DB, snapshot.Path)
+	if err != nil {
+		if errors.Is(err, db.ErrSnapshotExists) {
+			return models.Snapshot{}, err
+		}
+		return models.Snapshot{}, fmt.Errorf("Error creating snapshot: %v", err)
+	}
+
+	return snapshot, nil
+}
+
+// RestoreSnapshot reemplaza el contenido de la db SQLite por el del snapshot indicado.
+// Como los feeds y tweets cacheados dejan de corresponder con la db, se vacia el cache
+func (as *AdminService) RestoreSnapshot(name string) (models.Snapshot, error) {
+	if !db.IsSQLite(as.DB) {
+		return models.Snapshot{}, fmt.Errorf("Snapshots are only supported with SQLite")
+	}
+
+	if !snapshotNameRegex.MatchString(name) {
+		return models.Snapshot{}, fmt.Errorf("Invalid snapshot name")
+	}
+
+	snapshot := models.Snapshot{Name: name, Path: filepath.Join(SQLiteSnapshotDir, name)}
+
+	err := db.RestoreSQLite(as.DB, snapshot.Path)
+	if err != nil {
+		if errors.Is(err, db.ErrSnapshotNotFound) {
+			return models.Snapshot{}, err
+		}
+		return models.Snapshot{}, fmt.Errorf("Error restoring snapshot: %v", err)
+	}
+
+	if as.Cache != nil {
+		err = as.Cache.DeleteByPrefix(context.Background(), "")
+		if err != nil {
+			return models.Snapshot{}, fmt.Errorf("Error clearing cache after restore: %v", err)
+		}
+	}
+
+	// El snapshot pudo tomarse con likes pendientes en cache, que se acaban de descartar
+	err = repositories.RecalculateAllLikeCounts(as.DB)
+	if err != nil {
+		return models.Snapshot{}, fmt.Errorf("Error recalculating like counts after restore: %v", err)
+	}
+
+	return snapshot, nil
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/repositories"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/auth"
+)
+
+type AuthService struct {
+	DB *sql.DB
+}
+
+func NewAuthService(db *sql.DB) *AuthService {
+	return &AuthService{DB: db}
+}
+
+// Login valida las credenciales del usuario y genera sus tokens de acceso
+func (as *AuthService) Login(credentials *models.LoginRequest) (models.AuthTokens, error) {
+	user, err := repositories.GetUserByEmail(as.DB, credentials.Email)
+
+	if err != nil {
+		// No se distingue entre email inexistente y contraseña incorrecta para no revelar que emails estan registrados
+		return models.AuthTokens{}, fmt.Errorf("Invalid email or password")
+	}
+
+	if !auth.CheckPassword(user.Password, credentials.Password) {
+		return models.AuthTokens{}, fmt.Errorf("Invalid email or password")
+	}
+
+	return generateTokens(int64(user.ID))
+}
+
+// RefreshTokens genera nuevos tokens a partir de un refresh token valido
+func (as *AuthService) RefreshTokens(refreshToken string) (models.AuthTokens, error) {
+	userId, err := auth.ValidateToken(refreshToken, auth.RefreshTokenType)
+
+	if err != nil {
+		return models.AuthTokens{}, err
+	}
+
+	_, err = repositories.GetUserById(as.DB, userId)
+
+	if err != nil {
+		return models.AuthTokens{}, fmt.Errorf("Invalid or expired token")
+	}
+
+	return generateTokens(userId)
+}
+
+func generateTokens(userId int64) (models.AuthTokens, error) {
+	accessToken, err := auth.GenerateToken(userId, auth.AccessTokenType, auth.AccessTokenTTL)
+	if err != nil {
+		return models.AuthTokens{}, err
+	}
+
+	refreshToken, err := auth.GenerateToken(userId, auth.RefreshTokenType, auth.RefreshTokenTTL)
+	if err != nil {
+		return models.AuthTokens{}, err
+	}
+
+	return models.AuthTokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(auth.AccessTokenTTL.Seconds()),
+	}, nil
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/repositories"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+)
+
+type BlockService struct {
+	DB    *sql.DB
+	Cache cache.Cache
+
+	Broadcaster cache.Broadcaster // Publica la cantidad de seguidores del gateway WebSocket, nil para no publicarla
+}
+
+func NewBlockService(db *sql.DB, cacheClient cache.Cache) *BlockService {
+	return &BlockService{DB: db, Cache: cacheClient}
+}
+
+// BlockUser bloquea al usuario: se eliminan los follows entre ambos (en los dos sentidos) y ninguno puede volver a seguir al otro
+func (bs *BlockService) BlockUser(blockerId int64, blockedId int64) (bool, error) {
+	_, err := repositories.GetUserById(bs.DB, blockedId)
+
+	if err != nil {
+		return false, fmt.Errorf("Nonexistent user")
+	}
+
+	blocked, err := repositories.BlockUser(bs.DB, blockerId, blockedId)
+
+	if err != nil {
+		return false, fmt.Errorf("Error blocking user: %v", err)
+	}
+
+	if !blocked {
+		return false, fmt.Errorf("User already blocked")
+	}
+
+	// Los feeds de ambos usuarios pueden tener tweets del otro y sus follows cacheados ya no son validos
+	followService := NewFollowService(bs.DB, bs.Cache)
+	followService.invalidateFollowCache(blockerId, blockedId)
+	followService.invalidateFollowCache(blockedId, blockerId)
+
+	publishFollowersCount(bs.DB, bs.Broadcaster, blockerId, blockedId)
+
+	return true, nil
+}
+
+// UnblockUser quita el bloqueo. Los follows eliminados al bloquear no se restauran
+func (bs *BlockService) UnblockUser(blockerId int64, blockedId int64) (bool, error) {
+	unblocked, err := repositories.UnblockUser(bs.DB, blockerId, blockedId)
+
+	if err != nil {
+		return false, fmt.Errorf("Error unblocking user: %v", err)
+	}
+
+	if !unblocked {
+		return false, fmt.Errorf("Block not found")
+	}
+
+	return true, nil
+}
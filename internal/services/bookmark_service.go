@@ -0,0 +1,111 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/repositories"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+)
+
+// BookmarkService maneja los tweets guardados por cada usuario. Los bookmarks se guardan solo en la db sql,
+// por lo que no dependen del tiempo de vida de los feeds ni del contenido de los tweets en cache
+type BookmarkService struct {
+	DB    *sql.DB
+	Cache cache.Cache // Se utiliza para completar los likes pendientes de los tweets guardados
+}
+
+func NewBookmarkService(db *sql.DB, cacheClient cache.Cache) *BookmarkService {
+	return &BookmarkService{DB: db, Cache: cacheClient}
+}
+
+// BookmarkTweet guarda el tweet (o el tweet original, si es un retweet) en los bookmarks del usuario
+func (bs *BookmarkService) BookmarkTweet(tweetId int64, userId int64) (bool, error) {
+	tweet, err := getRetweetableTweet(bs.DB, tweetId)
+
+	if err != nil {
+		return false, err
+	}
+
+	_, err = repositories.GetUserById(bs.DB, userId)
+
+	if err != nil {
+		return false, fmt.Errorf("Nonexistent user")
+	}
+
+	err = checkTweetVisibility(bs.DB, tweet.UserID, userId)
+
+	if err != nil {
+		return false, err
+	}
+
+	bookmarked, err := repositories.PostBookmark(bs.DB, &models.Bookmark{UserID: userId, TweetID: tweet.ID})
+
+	if err != nil {
+		return false, fmt.Errorf("Error bookmarking tweet: %v", err)
+	}
+
+	if !bookmarked {
+		return false, fmt.Errorf("Tweet already bookmarked")
+	}
+
+	return true, nil
+}
+
+// DeleteBookmark quita el tweet (o el tweet original, si es un retweet) de los bookmarks del usuario
+func (bs *BookmarkService) DeleteBookmark(tweetId int64, userId int64) (bool, error) {
+	tweet, err := getRetweetableTweet(bs.DB, tweetId)
+
+	if err != nil {
+		return false, err
+	}
+
+	deleted, err := repositories.DeleteBookmark(bs.DB, userId, tweet.ID)
+
+	if err != nil {
+		return false, fmt.Errorf("Error deleting bookmark: %v", err)
+	}
+
+	if !deleted {
+		return false, fmt.Errorf("Bookmark not found")
+	}
+
+	return true, nil
+}
+
+// GetBookmarks obtiene una pagina de los tweets guardados por el usuario, del mas reciente al mas antiguo
+func (bs *BookmarkService) GetBookmarks(userId int64, limit int64, offset int64, cursor *models.Cursor) ([]models.BookmarkedTweet, int64, error) {
+	_, err := repositories.GetUserById(bs.DB, userId)
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("Nonexistent user")
+	}
+
+	bookmarks, err := repositories.GetBookmarks(bs.DB, userId, limit, offset, cursor)
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("Error getting bookmarks: %v", err)
+	}
+
+	tweets := make([]models.Tweet, len(bookmarks))
+	for i, bookmark := range bookmarks {
+		tweets[i] = bookmark.Tweet
+	}
+
+	setLikesData(bs.DB, bs.Cache, userId, tweets)
+	hideInvisibleOriginals(bs.DB, userId, tweets)
+
+	for i := range bookmarks {
+		bookmarks[i].Tweet = tweets[i]
+	}
+
+	total, err := repositories.CountBookmarks(bs.DB, userId)
+
+	if err != nil {
+		//Por mas que el count rompa, se retornan los bookmarks
+		fmt.Println(err)
+	}
+
+	return bookmarks, total, nil
+}
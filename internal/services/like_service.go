@@ -0,0 +1,371 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/repositories"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+)
+
+// LikeCountFlushInterval es cada cuanto se vuelcan a la db sql los likes acumulados en cache.
+// Se puede configurar con la flag -like-flush-interval
+var LikeCountFlushInterval = 10 * time.Second
+
+// LikeCountReconcileInterval es cada cuanto se recalculan los contadores de likes desde la tabla likes (ver ReconcileLikeCounts)
+var LikeCountReconcileInterval = 10 * time.Minute
+
+type LikeService struct {
+	DB    *sql.DB     // Conexion a db SQL
+	Cache cache.Cache // Cache donde se acumulan los likes, nil si la API funciona sin cache
+
+	Broadcaster cache.Broadcaster // Publica los contadores y las notificaciones del gateway WebSocket, nil para no publicarlos
+}
+
+func NewLikeService(db *sql.DB, cacheClient cache.Cache) *LikeService {
+	return &LikeService{DB: db, Cache: cacheClient}
+}
+
+// LikeTweet guarda el like del usuario al tweet (o al tweet original, si es un retweet).
+// Con cache el contador del tweet no se actualiza en la db sql en cada like, para no bloquear la fila de los tweets
+// con muchos likes, sino que se acumula en cache y se vuelca de forma periodica (ver FlushLikeCounts)
+func (ls *LikeService) LikeTweet(tweetId int64, userId int64) (bool, error) {
+	tweet, err := getRetweetableTweet(ls.DB, tweetId)
+
+	if err != nil {
+		return false, err
+	}
+
+	_, err = repositories.GetUserById(ls.DB, userId)
+
+	if err != nil {
+		return false, fmt.Errorf("Nonexistent user")
+	}
+
+	err = checkTweetVisibility(ls.DB, tweet.UserID, userId)
+
+	if err != nil {
+		return false, err
+	}
+
+	liked, err := repositories.PostLike(ls.DB, &models.Like{UserID: userId, TweetID: tweet.ID}, ls.Cache == nil)
+
+	if err != nil {
+		return false, fmt.Errorf("Error liking tweet: %v", err)
+	}
+
+	if !liked {
+		return false, fmt.Errorf("Tweet already liked")
+	}
+
+	if ls.Cache != nil {
+		ls.addPendingLike(tweet.ID, 1)
+	}
+
+	publishTweetCounters(ls.DB, ls.Cache, ls.Broadcaster, tweet.ID)
+	createNotification(ls.DB, ls.Broadcaster, models.Notification{UserID: tweet.UserID, ActorID: userId, Type: "like", TweetID: &tweet.ID})
+
+	return true, nil
+}
+
+// UnlikeTweet elimina el like del usuario al tweet (o al tweet original, si es un retweet)
+func (ls *LikeService) UnlikeTweet(tweetId int64, userId int64) (bool, error) {
+	tweet, err := getRetweetableTweet(ls.DB, tweetId)
+
+	if err != nil {
+		return false, err
+	}
+
+	unliked, err := repositories.DeleteLike(ls.DB, userId, tweet.ID, ls.Cache == nil)
+
+	if err != nil {
+		return false, fmt.Errorf("Error unliking tweet: %v", err)
+	}
+
+	if !unliked {
+		return false, fmt.Errorf("Like not found")
+	}
+
+	if ls.Cache != nil {
+		ls.addPendingLike(tweet.ID, -1)
+	}
+
+	publishTweetCounters(ls.DB, ls.Cache, ls.Broadcaster, tweet.ID)
+
+	return true, nil
+}
+
+// addPendingLike acumula el like en cache. Si el cache falla se actualiza directamente la db sql para no perder el like
+func (ls *LikeService) addPendingLike(tweetId int64, delta int64) {
+	err := repositories.AddPendingLike(ls.Cache, tweetId, delta)
+	if err == nil {
+		return
+	}
+
+	fmt.Printf("Error saving like in cache: %v\n", err)
+
+	err = repositories.AddToLikeCount(ls.DB, tweetId, delta)
+	if err != nil {
+		fmt.Printf("Error updating like count: %v\n", err)
+	}
+}
+
+// GetTweetLikes obtiene una pagina de los usuarios que dieron like al tweet, si el usuario que consulta puede verlo
+func (ls *LikeService) GetTweetLikes(tweetId int64, viewerId int64, limit int64, offset int64) ([]models.LikeUserInfo, int64, error) {
+	tweet, err := getRetweetableTweet(ls.DB, tweetId)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = checkTweetVisibility(ls.DB, tweet.UserID, viewerId)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	likes, err := repositories.GetTweetLikes(ls.DB, tweet.ID, limit, offset)
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("Error getting likes: %v", err)
+	}
+
+	total, err := repositories.CountTweetLikes(ls.DB, tweet.ID)
+
+	if err != nil {
+		//Por mas que el count rompa, se retornan los likes
+		fmt.Println(err)
+	}
+
+	return likes, total, nil
+}
+
+// GetUserLikes obtiene una pagina de los tweets a los que el usuario dio like y que el usuario que consulta puede ver
+func (ls *LikeService) GetUserLikes(userId int64, viewerId int64, limit int64, offset int64) ([]models.Tweet, int64, error) {
+	_, err := repositories.GetUserById(ls.DB, userId)
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("Nonexistent user")
+	}
+
+	tweets, err := repositories.GetLikedTweets(ls.DB, userId, viewerId, limit, offset)
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("Error getting liked tweets: %v", err)
+	}
+
+	setLikesData(ls.DB, ls.Cache, viewerId, tweets)
+	hideInvisibleOriginals(ls.DB, viewerId, tweets)
+
+	total, err := repositories.CountLikedTweets(ls.DB, userId, viewerId)
+
+	if err != nil {
+		//Por mas que el count rompa, se retornan los tweets
+		fmt.Println(err)
+	}
+
+	return tweets, total, nil
+}
+
+// setLikesData completa la cantidad de likes de los tweets (y de los tweets que retweetean o citan) y si el usuario que
+// consulta les dio like. La cantidad es la guardada en la db sql mas los likes acumulados en cache que todavia no se volcaron.
+// Si falla la consulta los tweets se retornan con los datos que ya tenian
+func setLikesData(db *sql.DB, cacheClient cache.Cache, viewerId int64, tweets []models.Tweet) {
+	tweetIds := []int64{}
+	for _, tweet := range tweets {
+		tweetIds = append(tweetIds, tweet.ID)
+		if tweet.OriginalTweet != nil {
+			tweetIds = append(tweetIds, tweet.OriginalTweet.ID)
+		}
+	}
+
+	if len(tweetIds) == 0 {
+		return
+	}
+
+	likeCounts, likedTweets, err := repositories.GetTweetsLikeData(db, viewerId, tweetIds)
+	if err != nil {
+		fmt.Printf("Error getting likes data: %v\n", err)
+		return
+	}
+
+	pendingLikes := map[int64]int64{}
+	if cacheClient != nil {
+		pendingLikes, err = repositories.GetPendingLikes(cacheClient, tweetIds)
+		if err != nil {
+			fmt.Printf("Error getting pending likes: %v\n", err)
+			pendingLikes = map[int64]int64{}
+		}
+	}
+
+	for i := range tweets {
+		tweets[i].LikeCount = likeCounts[tweets[i].ID] + pendingLikes[tweets[i].ID]
+		tweets[i].LikedByViewer = likedTweets[tweets[i].ID]
+
+		if tweets[i].OriginalTweet != nil {
+			original := *tweets[i].OriginalTweet
+			original.LikeCount = likeCounts[original.ID] + pendingLikes[original.ID]
+			original.LikedByViewer = likedTweets[original.ID]
+			tweets[i].OriginalTweet = &original
+		}
+	}
+}
+
+// FlushLikeCounts vuelca a la db sql los likes acumulados en cache. Los likes volcados se descuentan del contador
+// del cache antes de actualizar la db sql, de forma que la suma de ambos se mantiene aunque varias instancias de la API
+// vuelquen los contadores al mismo tiempo
+func FlushLikeCounts(db *sql.DB, cacheClient cache.Cache) error {
+	tweetIds, err := repositories.GetTweetsWithPendingLikes(cacheClient)
+	if err != nil {
+		return err
+	}
+
+	for _, tweetId := range tweetIds {
+		// Se quita del set antes de leer el contador: si llega un like mientras se vuelca, el tweet se vuelve a agregar
+		err = repositories.RemoveTweetFromPendingLikes(cacheClient, tweetId)
+		if err != nil {
+			return err
+		}
+
+		pendingLikes, err := repositories.GetPendingLikes(cacheClient, []int64{tweetId})
+		if err != nil {
+			return err
+		}
+
+		pending := pendingLikes[tweetId]
+		if pending == 0 {
+			continue
+		}
+
+		err = repositories.DiscountPendingLikes(cacheClient, tweetId, pending)
+		if err != nil {
+			return err
+		}
+
+		err = repositories.AddToLikeCount(db, tweetId, pending)
+		if err != nil {
+			// Se devuelven los likes al cache para volcarlos en el proximo intento
+			if restoreErr := repositories.AddPendingLike(cacheClient, tweetId, pending); restoreErr != nil {
+				fmt.Printf("Error restoring pending likes: %v\n", restoreErr)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReconcileLikeCounts corrige los contadores de likes de la db sql que no coinciden con la tabla likes. Los contadores
+// pendientes del cache pueden perderse (el cache descarta claves al llenarse), por lo que se recalculan los tweets con likes
+// nuevos desde el ultimo recalculo que ya no tienen likes pendientes, y se eliminan sus contadores huerfanos, que ya estan
+// incluidos en el recalculo. Los tweets con likes pendientes se revisan en el proximo recalculo, luego de volcarlos
+func ReconcileLikeCounts(db *sql.DB, cacheClient cache.Cache) error {
+	changedIds, err := repositories.GetTweetsToReconcile(cacheClient)
+	if err != nil || len(changedIds) == 0 {
+		return err
+	}
+
+	pendingIds, err := repositories.GetTweetsWithPendingLikes(cacheClient)
+	if err != nil {
+		return err
+	}
+
+	pending := map[int64]bool{}
+	for _, id := range pendingIds {
+		pending[id] = true
+	}
+
+	tweetIds := []int64{}
+	for _, id := range changedIds {
+		if !pending[id] {
+			tweetIds = append(tweetIds, id)
+		}
+	}
+
+	if len(tweetIds) == 0 {
+		return nil
+	}
+
+	// Se quitan del set antes de recalcular: si llega un like mientras tanto, el tweet se vuelve a agregar
+	err = repositories.RemoveTweetsToReconcile(cacheClient, tweetIds)
+	if err != nil {
+		return err
+	}
+
+	// Los contadores huerfanos se leen antes de recalcular, ya que el recalculo incluye esos likes
+	orphanLikes, err := repositories.GetPendingLikes(cacheClient, tweetIds)
+	if err != nil {
+		return err
+	}
+
+	err = repositories.RecalculateLikeCounts(db, tweetIds)
+	if err != nil {
+		if restoreErr := repositories.AddTweetsToReconcile(cacheClient, tweetIds); restoreErr != nil {
+			fmt.Printf("Error restoring likes to reconcile: %v\n", restoreErr)
+		}
+		return err
+	}
+
+	// Si un contador cambio durante el recalculo (un like nuevo o un volcado en otra instancia) no se elimina,
+	// ya que no se sabe si el recalculo incluyo ese cambio, y el tweet se vuelve a revisar en el proximo recalculo
+	changedCounters, err := repositories.DeletePendingLikesIfUnchanged(cacheClient, orphanLikes)
+	if err != nil {
+		return err
+	}
+
+	return repositories.AddTweetsToReconcile(cacheClient, changedCounters)
+}
+
+// reconcileLikeCountsOnce ejecuta ReconcileLikeCounts solo en la instancia de la API que reserva el recalculo
+// durante LikeCountReconcileInterval, de forma que se ejecuta una vez por intervalo aunque haya varias instancias
+func reconcileLikeCountsOnce(db *sql.DB, cacheClient cache.Cache) error {
+	acquired, err := repositories.AcquireLikeReconcileLock(cacheClient, LikeCountReconcileInterval)
+	if err != nil || !acquired {
+		return err
+	}
+
+	return ReconcileLikeCounts(db, cacheClient)
+}
+
+// StartLikeCountFlusher vuelca los likes acumulados en cache cada interval en segundo plano y cada
+// LikeCountReconcileInterval corrige los contadores que no coinciden con la tabla likes.
+// Retorna la funcion que detiene el volcado, haciendo un ultimo volcado antes de terminar
+func StartLikeCountFlusher(db *sql.DB, cacheClient cache.Cache, interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	reconcileTicker := time.NewTicker(LikeCountReconcileInterval)
+	done := make(chan struct{})
+	wg := &sync.WaitGroup{}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer ticker.Stop()
+		defer reconcileTicker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := FlushLikeCounts(db, cacheClient); err != nil {
+					fmt.Printf("Error flushing like counts: %v\n", err)
+				}
+			case <-reconcileTicker.C:
+				if err := reconcileLikeCountsOnce(db, cacheClient); err != nil {
+					fmt.Printf("Error reconciling like counts: %v\n", err)
+				}
+			case <-done:
+				if err := FlushLikeCounts(db, cacheClient); err != nil {
+					fmt.Printf("Error flushing like counts: %v\n", err)
+				}
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
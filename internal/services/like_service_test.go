@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/repositories"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupTestDB conecta una db SQLite en memoria con las migraciones aplicadas, que se elimina al terminar el test
+func setupTestDB(t *testing.T) *sql.DB {
+	database := &db.SQLiteDatabase{}
+	conn, err := database.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+// createTestUsers crea count usuarios y retorna sus IDs
+func createTestUsers(t *testing.T, conn *sql.DB, count int) []int64 {
+	ids := []int64{}
+	for i := 1; i <= count; i++ {
+		id, err := repositories.CreateUser(conn, models.User{
+			Name:     fmt.Sprintf("Usuario %d", i),
+			Email:    fmt.Sprintf("usuario%d@hotmail.com", i),
+			Password: "1234",
+			Handle:   fmt.Sprintf("usuario%d", i),
+		})
+		if err != nil {
+			t.Fatalf("failed to create user: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+func createTestTweet(t *testing.T, conn *sql.DB, tweet models.Tweet) models.Tweet {
+	_, err := repositories.PostTweet(conn, &tweet)
+	if err != nil {
+		t.Fatalf("failed to create tweet: %v", err)
+	}
+
+	return tweet
+}
+
+func TestSetLikesData(t *testing.T) {
+	conn := setupTestDB(t)
+	cacheClient := cache.NewMemoryCache(cache.DefaultMemoryCacheSize)
+	userIds := createTestUsers(t, conn, 3)
+	author, liker, other := userIds[0], userIds[1], userIds[2]
+
+	original := createTestTweet(t, conn, models.Tweet{UserID: author, Content: "Tweet original"})
+	retweet := createTestTweet(t, conn, models.Tweet{UserID: other, RetweetOfTweetID: &original.ID})
+
+	// El like todavia no se volco a la db sql, solo esta acumulado en cache
+	_, err := repositories.PostLike(conn, &models.Like{UserID: liker, TweetID: original.ID}, false)
+	assert.NoError(t, err)
+	assert.NoError(t, repositories.AddPendingLike(cacheClient, original.ID, 1))
+
+	testCases := []struct {
+		name          string
+		cacheClient   cache.Cache
+		viewerId      int64
+		likeCount     int64
+		likedByViewer bool
+	}{
+		{"viewer liked the tweet", cacheClient, liker, 1, true},
+		{"viewer did not like the tweet", cacheClient, other, 1, false},
+		{"without viewer", cacheClient, 0, 1, false},
+		{"without cache", nil, liker, 0, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			originalCopy := original
+			tweets := []models.Tweet{original, retweet}
+			tweets[1].OriginalTweet = &originalCopy
+
+			setLikesData(conn, tc.cacheClient, tc.viewerId, tweets)
+
+			assert.Equal(t, tc.likeCount, tweets[0].LikeCount)
+			assert.Equal(t, tc.likedByViewer, tweets[0].LikedByViewer)
+
+			// El retweet no tiene likes propios, pero el tweet original embebido si
+			assert.Equal(t, int64(0), tweets[1].LikeCount)
+			assert.False(t, tweets[1].LikedByViewer)
+			assert.Equal(t, tc.likeCount, tweets[1].OriginalTweet.LikeCount)
+			assert.Equal(t, tc.likedByViewer, tweets[1].OriginalTweet.LikedByViewer)
+
+			// No se modifica el tweet original recibido
+			assert.Equal(t, int64(0), originalCopy.LikeCount)
+		})
+	}
+}
+
+func TestGetUserLikesUsesViewer(t *testing.T) {
+	conn := setupTestDB(t)
+	userIds := createTestUsers(t, conn, 3)
+	author, liker, viewer := userIds[0], userIds[1], userIds[2]
+
+	tweet := createTestTweet(t, conn, models.Tweet{UserID: author, Content: "Tweet likeado"})
+
+	likeService := NewLikeService(conn, nil)
+	_, err := likeService.LikeTweet(tweet.ID, liker)
+	assert.NoError(t, err)
+
+	// LikedByViewer indica si le dio like el usuario que consulta, no el usuario cuyos likes se listan
+	tweets, total, err := likeService.GetUserLikes(liker, viewer, 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, tweets, 1)
+	assert.Equal(t, int64(1), tweets[0].LikeCount)
+	assert.False(t, tweets[0].LikedByViewer)
+
+	tweets, _, err = likeService.GetUserLikes(liker, liker, 10, 0)
+	assert.NoError(t, err)
+	assert.Len(t, tweets, 1)
+	assert.True(t, tweets[0].LikedByViewer)
+}
+
+func TestReconcileLikeCounts(t *testing.T) {
+	conn := setupTestDB(t)
+	cacheClient := cache.NewMemoryCache(cache.DefaultMemoryCacheSize)
+	userIds := createTestUsers(t, conn, 2)
+
+	orphan := createTestTweet(t, conn, models.Tweet{UserID: userIds[0], Content: "Tweet con contador huerfano"})
+	pending := createTestTweet(t, conn, models.Tweet{UserID: userIds[0], Content: "Tweet con likes pendientes"})
+
+	likeService := NewLikeService(conn, cacheClient)
+	for _, tweetId := range []int64{orphan.ID, pending.ID} {
+		_, err := likeService.LikeTweet(tweetId, userIds[1])
+		assert.NoError(t, err)
+	}
+
+	// El cache desalojo el set de likes pendientes: el contador del primer tweet ya no se vuelca y queda huerfano
+	assert.NoError(t, cacheClient.Delete(context.Background(), "pending_likes"))
+	// El segundo tweet se vuelve a registrar en el set, sus likes pendientes se vuelcan normalmente
+	assert.NoError(t, repositories.AddPendingLike(cacheClient, pending.ID, 0))
+
+	assert.NoError(t, ReconcileLikeCounts(conn, cacheClient))
+
+	// El tweet con el contador huerfano se recalcula y el contador se elimina para no contar el like dos veces
+	tweet, err := repositories.GetTweetById(conn, orphan.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), tweet.LikeCount)
+
+	pendingLikes, err := repositories.GetPendingLikes(cacheClient, []int64{orphan.ID, pending.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64]int64{pending.ID: 1}, pendingLikes)
+
+	// El tweet con likes pendientes no se recalcula, queda para el proximo recalculo luego de volcarlos
+	tweet, err = repositories.GetTweetById(conn, pending.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), tweet.LikeCount)
+
+	toReconcile, err := repositories.GetTweetsToReconcile(cacheClient)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{pending.ID}, toReconcile)
+
+	assert.NoError(t, FlushLikeCounts(conn, cacheClient))
+	assert.NoError(t, ReconcileLikeCounts(conn, cacheClient))
+
+	tweet, err = repositories.GetTweetById(conn, pending.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), tweet.LikeCount)
+
+	toReconcile, err = repositories.GetTweetsToReconcile(cacheClient)
+	assert.NoError(t, err)
+	assert.Empty(t, toReconcile)
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/repositories"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+)
+
+type MuteService struct {
+	DB    *sql.DB
+	Cache cache.Cache
+}
+
+func NewMuteService(db *sql.DB, cacheClient cache.Cache) *MuteService {
+	return &MuteService{DB: db, Cache: cacheClient}
+}
+
+// MuteUser silencia al usuario: sus tweets dejan de aparecer en el timeline sin dejar de seguirlo
+func (ms *MuteService) MuteUser(muterId int64, mutedId int64) (bool, error) {
+	_, err := repositories.GetUserById(ms.DB, mutedId)
+
+	if err != nil {
+		return false, fmt.Errorf("Nonexistent user")
+	}
+
+	muted, err := repositories.MuteUser(ms.DB, muterId, mutedId)
+
+	if err != nil {
+		return false, fmt.Errorf("Error muting user: %v", err)
+	}
+
+	if !muted {
+		return false, fmt.Errorf("User already muted")
+	}
+
+	ms.invalidateFeed(muterId)
+
+	return true, nil
+}
+
+// UnmuteUser quita el silenciamiento, los tweets del usuario vuelven a aparecer en el timeline
+func (ms *MuteService) UnmuteUser(muterId int64, mutedId int64) (bool, error) {
+	unmuted, err := repositories.UnmuteUser(ms.DB, muterId, mutedId)
+
+	if err != nil {
+		return false, fmt.Errorf("Error unmuting user: %v", err)
+	}
+
+	if !unmuted {
+		return false, fmt.Errorf("Mute not found")
+	}
+
+	ms.invalidateFeed(muterId)
+
+	return true, nil
+}
+
+// invalidateFeed elimina el feed precalculado del usuario para que se reconstruya desde la db sql, que ya aplica sus silenciamientos
+func (ms *MuteService) invalidateFeed(userId int64) {
+	if ms.Cache == nil {
+		return
+	}
+
+	err := repositories.DeleteFeed(ms.Cache, userId)
+	if err != nil {
+		fmt.Printf("Error deleting feed from cache: %v\n", err)
+	}
+}
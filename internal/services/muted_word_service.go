@@ -0,0 +1,168 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/repositories"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+)
+
+const maxMutedWordLength = 100
+
+type MutedWordService struct {
+	DB    *sql.DB
+	Cache cache.Cache
+}
+
+func NewMutedWordService(db *sql.DB, cacheClient cache.Cache) *MutedWordService {
+	return &MutedWordService{DB: db, Cache: cacheClient}
+}
+
+// AddMutedWord silencia una palabra, frase o hashtag (con '#') para el usuario. Si expiresAt es nil no expira
+func (mws *MutedWordService) AddMutedWord(userId int64, word string, expiresAt *time.Time) (models.MutedWord, error) {
+	_, err := repositories.GetUserById(mws.DB, userId)
+
+	if err != nil {
+		return models.MutedWord{}, fmt.Errorf("Nonexistent user")
+	}
+
+	word = normalizeMutedWord(word)
+
+	if word == "" || word == "#" || len([]rune(word)) > maxMutedWordLength {
+		return models.MutedWord{}, fmt.Errorf("Invalid word: must have between 1 and %d characters", maxMutedWordLength)
+	}
+
+	now := time.Now()
+
+	if expiresAt != nil && !expiresAt.After(now) {
+		return models.MutedWord{}, fmt.Errorf("Invalid expiresAt: must be a future date")
+	}
+
+	// Las palabras expiradas se eliminan para que puedan volver a silenciarse
+	err = repositories.DeleteExpiredMutedWords(mws.DB, userId, now)
+
+	if err != nil {
+		return models.MutedWord{}, err
+	}
+
+	mutedWord := models.MutedWord{UserID: userId, Word: word, ExpiresAt: expiresAt}
+	created, err := repositories.CreateMutedWord(mws.DB, &mutedWord)
+
+	if err != nil {
+		return models.MutedWord{}, fmt.Errorf("Error muting word: %v", err)
+	}
+
+	if !created {
+		return models.MutedWord{}, fmt.Errorf("Muted word already exists")
+	}
+
+	return mutedWord, nil
+}
+
+// GetMutedWords obtiene las palabras silenciadas del usuario que no expiraron
+func (mws *MutedWordService) GetMutedWords(userId int64) ([]models.MutedWord, error) {
+	_, err := repositories.GetUserById(mws.DB, userId)
+
+	if err != nil {
+		return nil, fmt.Errorf("Nonexistent user")
+	}
+
+	mutedWords, err := repositories.GetActiveMutedWords(mws.DB, userId, time.Now())
+
+	if err != nil {
+		return nil, fmt.Errorf("Error getting muted words: %v", err)
+	}
+
+	return mutedWords, nil
+}
+
+// DeleteMutedWord deja de silenciar la palabra
+func (mws *MutedWordService) DeleteMutedWord(userId int64, mutedWordId int64) (bool, error) {
+	deleted, err := repositories.DeleteMutedWord(mws.DB, userId, mutedWordId)
+
+	if err != nil {
+		return false, fmt.Errorf("Error deleting muted word: %v", err)
+	}
+
+	if !deleted {
+		return false, fmt.Errorf("Muted word not found")
+	}
+
+	return true, nil
+}
+
+// normalizeMutedWord pasa la palabra a minusculas y deja un unico espacio entre las palabras de una frase
+func normalizeMutedWord(word string) string {
+	return strings.Join(strings.Fields(strings.ToLower(word)), " ")
+}
+
+// mutedWordsFilter indica que tweets contienen alguna de las palabras silenciadas de un usuario
+type mutedWordsFilter struct {
+	hashtags map[string]bool // Hashtags silenciados (sin '#'), solo coinciden con los hashtags del tweet
+	phrases  []string        // Palabras y frases silenciadas, coinciden con palabras completas del contenido (incluidos los hashtags)
+}
+
+func newMutedWordsFilter(mutedWords []models.MutedWord) *mutedWordsFilter {
+	filter := &mutedWordsFilter{hashtags: map[string]bool{}}
+
+	for _, mutedWord := range mutedWords {
+		if tag, isHashtag := strings.CutPrefix(mutedWord.Word, "#"); isHashtag {
+			filter.hashtags[tag] = true
+			continue
+		}
+
+		if phrase := normalizeMutedText(mutedWord.Word); phrase != "" {
+			filter.phrases = append(filter.phrases, phrase)
+		}
+	}
+
+	return filter
+}
+
+// matches indica si el tweet, o el tweet que retweetea o cita, contiene alguna palabra silenciada
+func (f *mutedWordsFilter) matches(tweet models.Tweet) bool {
+	if f.matchesContent(tweet) {
+		return true
+	}
+
+	return tweet.OriginalTweet != nil && f.matchesContent(*tweet.OriginalTweet)
+}
+
+func (f *mutedWordsFilter) matchesContent(tweet models.Tweet) bool {
+	for _, hashtag := range tweet.Entities.Hashtags {
+		if f.hashtags[strings.ToLower(hashtag.Tag)] {
+			return true
+		}
+	}
+
+	if len(f.phrases) == 0 {
+		return false
+	}
+
+	// Se agregan espacios en los extremos para que las palabras solo coincidan completas
+	content := " " + normalizeMutedText(tweet.Content) + " "
+	for _, phrase := range f.phrases {
+		if strings.Contains(content, " "+phrase+" ") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeMutedText pasa el texto a minusculas y reemplaza por un espacio todo lo que no es una letra, un numero o '_'
+func normalizeMutedText(text string) string {
+	text = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, text)
+
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/repositories"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+)
+
+const notificationGroupMaxActors int64 = 3 // Cantidad de usuarios que se incluyen en cada grupo de notificaciones
+
+// Texto de cada tipo de notificacion, a continuacion de los usuarios que realizaron la accion
+var notificationActions = map[string]string{
+	"follow":  "followed you",
+	"like":    "liked your tweet",
+	"reply":   "replied to your tweet",
+	"mention": "mentioned you in a tweet",
+}
+
+type NotificationService struct {
+	DB    *sql.DB
+	Cache cache.Cache
+}
+
+func NewNotificationService(db *sql.DB, cacheClient cache.Cache) *NotificationService {
+	return &NotificationService{DB: db, Cache: cacheClient}
+}
+
+// GetNotifications obtiene los grupos de notificaciones del usuario (del mas reciente al mas antiguo), la cantidad de grupos sin leer y el total de grupos
+func (ns *NotificationService) GetNotifications(userId int64, limit int64, offset int64) (models.NotificationsPage, int64, error) {
+	_, err := repositories.GetUserById(ns.DB, userId)
+
+	if err != nil {
+		return models.NotificationsPage{}, 0, fmt.Errorf("Nonexistent user")
+	}
+
+	groups, err := repositories.GetNotificationGroups(ns.DB, userId, limit, offset)
+
+	if err != nil {
+		return models.NotificationsPage{}, 0, err
+	}
+
+	for i := range groups {
+		groups[i].Actors, err = repositories.GetNotificationGroupActors(ns.DB, userId, groups[i], notificationGroupMaxActors)
+
+		if err != nil {
+			return models.NotificationsPage{}, 0, err
+		}
+
+		groups[i].Message = notificationMessage(groups[i])
+	}
+
+	unreadCount, err := repositories.CountNotificationGroups(ns.DB, userId, true)
+
+	if err != nil {
+		return models.NotificationsPage{}, 0, err
+	}
+
+	total, err := repositories.CountNotificationGroups(ns.DB, userId, false)
+
+	if err != nil {
+		return models.NotificationsPage{}, 0, err
+	}
+
+	return models.NotificationsPage{UnreadCount: unreadCount, Notifications: groups}, total, nil
+}
+
+// MarkNotificationsRead marca como leidas todas las notificaciones del usuario y retorna cuantas estaban sin leer
+func (ns *NotificationService) MarkNotificationsRead(userId int64) (int64, error) {
+	_, err := repositories.GetUserById(ns.DB, userId)
+
+	if err != nil {
+		return 0, fmt.Errorf("Nonexistent user")
+	}
+
+	return repositories.MarkNotificationsRead(ns.DB, userId)
+}
+
+// notificationMessage arma el texto del grupo con el usuario mas reciente, por ejemplo "Juan Perez and 4 others followed you"
+func notificationMessage(group models.NotificationGroup) string {
+	actor := "Someone"
+	if len(group.Actors) > 0 {
+		actor = group.Actors[0].Name
+	}
+
+	switch others := group.ActorsCount - 1; {
+	case others == 1:
+		actor = fmt.Sprintf("%s and 1 other", actor)
+	case others > 1:
+		actor = fmt.Sprintf("%s and %d others", actor, others)
+	}
+
+	return fmt.Sprintf("%s %s", actor, notificationActions[group.Type])
+}
+
+// createNotification guarda la notificacion y la publica por el gateway WebSocket (si broadcaster no es nil), salvo que el usuario
+// haya silenciado al actor; en ese caso se guarda pero no se lista. Las acciones sobre la propia cuenta no se notifican.
+// Los errores solo se registran, ya que la accion que genero la notificacion ya se realizo
+func createNotification(db *sql.DB, broadcaster cache.Broadcaster, notification models.Notification) {
+	if notification.ActorID == notification.UserID {
+		return
+	}
+
+	err := repositories.CreateNotification(db, &notification)
+
+	if err != nil {
+		fmt.Printf("Error creating notification: %v\n", err)
+		return
+	}
+
+	if broadcaster == nil {
+		return
+	}
+
+	muted, err := repositories.IsMuted(db, notification.UserID, notification.ActorID)
+
+	if err != nil || muted {
+		return
+	}
+
+	publishRealtimeEvent(broadcaster, notificationsChannel(notification.UserID), models.RealtimeEvent{Type: "notification", Channel: "notifications", Data: notification})
+}
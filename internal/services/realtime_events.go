@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/repositories"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+)
+
+// Canales del Broadcaster a los que se publican los eventos del gateway WebSocket.
+// El canal de notificaciones es por usuario, pero el cliente se suscribe a "notifications" sin indicar su ID
+func notificationsChannel(userId int64) string {
+	return fmt.Sprintf("realtime:notifications:%d", userId)
+}
+
+func tweetChannel(tweetId int64) string {
+	return fmt.Sprintf("realtime:tweet:%d", tweetId)
+}
+
+func userChannel(userId int64) string {
+	return fmt.Sprintf("realtime:user:%d", userId)
+}
+
+// publishRealtimeEvent publica el evento a las conexiones WebSocket suscriptas al canal, en cualquier instancia de la API.
+// Los errores solo se registran, ya que la accion que genero el evento ya se realizo
+func publishRealtimeEvent(broadcaster cache.Broadcaster, channel string, event models.RealtimeEvent) {
+	message, err := json.Marshal(event)
+	if err != nil {
+		fmt.Printf("Error encoding realtime event: %v\n", err)
+		return
+	}
+
+	err = broadcaster.Publish(context.Background(), channel, string(message))
+	if err != nil {
+		fmt.Printf("Error publishing realtime event: %v\n", err)
+	}
+}
+
+// publishTweetCounters publica la cantidad actual de likes y respuestas del tweet
+func publishTweetCounters(db *sql.DB, cacheClient cache.Cache, broadcaster cache.Broadcaster, tweetId int64) {
+	if broadcaster == nil {
+		return
+	}
+
+	tweet, err := repositories.GetTweetById(db, tweetId)
+	if err != nil {
+		fmt.Printf("Error getting tweet counters: %v\n", err)
+		return
+	}
+
+	// La cantidad de likes incluye los acumulados en cache que todavia no se volcaron a la db sql
+	tweets := []models.Tweet{tweet}
+	setLikesData(db, cacheClient, 0, tweets)
+
+	replyCount, err := repositories.CountTweetReplies(db, tweetId, 0)
+	if err != nil {
+		fmt.Printf("Error getting tweet counters: %v\n", err)
+		return
+	}
+
+	counters := models.TweetCounters{TweetID: tweetId, LikeCount: tweets[0].LikeCount, ReplyCount: replyCount}
+	publishRealtimeEvent(broadcaster, tweetChannel(tweetId), models.RealtimeEvent{Type: "tweet_counters", Channel: fmt.Sprintf("tweet:%d", tweetId), Data: counters})
+}
+
+// publishFollowersCount publica la cantidad actual de seguidores de cada usuario
+func publishFollowersCount(db *sql.DB, broadcaster cache.Broadcaster, userIds ...int64) {
+	if broadcaster == nil {
+		return
+	}
+
+	for _, userId := range userIds {
+		followersCount, err := repositories.CountFollows(db, userId, "followers")
+		if err != nil {
+			fmt.Printf("Error getting followers count: %v\n", err)
+			continue
+		}
+
+		count := models.FollowersCount{UserID: userId, FollowersCount: followersCount}
+		publishRealtimeEvent(broadcaster, userChannel(userId), models.RealtimeEvent{Type: "followers_count", Channel: fmt.Sprintf("user:%d", userId), Data: count})
+	}
+}
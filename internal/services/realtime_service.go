@@ -0,0 +1,452 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/repositories"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+)
+
+// Parametros de las conexiones WebSocket. Se pueden configurar con las flags -ws-*
+var (
+	RealtimePingInterval     = 30 * time.Second // Cada cuanto se envia un ping a cada conexion
+	RealtimeReadTimeout      = 60 * time.Second // Tiempo maximo sin recibir mensajes del cliente (por ejemplo el pong) antes de cerrar la conexion
+	RealtimeSendQueueSize    = 64               // Cantidad de eventos que se encolan para una conexion; si el cliente no los consume a tiempo se cierra la conexion
+	RealtimeMaxSubscriptions = 100              // Cantidad maxima de canales a los que se puede suscribir una conexion
+)
+
+const realtimeWriteTimeout = 10 * time.Second // Tiempo maximo para escribir un mensaje en la conexion
+
+// Codigos de cierre de las conexiones WebSocket (RFC 6455)
+const (
+	RealtimeCloseNormal          = 1000
+	RealtimeCloseGoingAway       = 1001 // El servidor se esta apagando
+	RealtimeClosePolicyViolation = 1008 // El cliente no consume los eventos a tiempo
+)
+
+// RealtimeConn es la conexion WebSocket de un cliente, de esta forma el service no depende de la libreria de WebSocket
+type RealtimeConn interface {
+	Receive() (string, error) // Bloquea hasta recibir un mensaje, un error o el deadline de lectura
+	Send(message string) error
+	SetReadDeadline(deadline time.Time) error
+	SetWriteDeadline(deadline time.Time) error
+	Close(status int) error // Envia el frame de cierre y desbloquea a Receive
+}
+
+// RealtimeService administra las conexiones WebSocket de esta instancia de la API. Cada canal del Broadcaster tiene una unica
+// suscripcion por instancia, compartida por todas las conexiones suscriptas a ese canal
+type RealtimeService struct {
+	DB          *sql.DB
+	Cache       cache.Cache
+	Broadcaster cache.Broadcaster
+
+	mu       sync.Mutex
+	channels map[string]*realtimeChannel
+	sessions map[*realtimeSession]struct{}
+	closing  bool
+	wg       sync.WaitGroup
+}
+
+type realtimeChannel struct {
+	subscription cache.Subscription
+	sessions     map[*realtimeSession]struct{}
+}
+
+// realtimeSession es una conexion WebSocket abierta. Solo la goroutine de escritura escribe en la conexion,
+// el resto encola los eventos en send
+type realtimeSession struct {
+	conn       RealtimeConn
+	userId     int64
+	send       chan string
+	channels   map[string]string // Canal del Broadcaster -> canal solicitado por el cliente, protegido por el lock del service
+	done       chan struct{}
+	writerDone chan struct{}
+	closeOnce  sync.Once
+	status     int
+}
+
+// pingMessage es el heartbeat que se envia cada RealtimePingInterval, el cliente debe responder {"action": "pong"}
+const pingMessage = `{"type":"ping"}`
+
+func NewRealtimeService(db *sql.DB, cacheClient cache.Cache, broadcaster cache.Broadcaster) *RealtimeService {
+	return &RealtimeService{
+		DB:          db,
+		Cache:       cacheClient,
+		Broadcaster: broadcaster,
+		channels:    map[string]*realtimeChannel{},
+		sessions:    map[*realtimeSession]struct{}{},
+	}
+}
+
+// IsClosing indica si el service dejo de aceptar conexiones porque el servidor se esta apagando
+func (rs *RealtimeService) IsClosing() bool {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	return rs.closing
+}
+
+// Serve atiende la conexion del usuario autenticado hasta que se cierra
+func (rs *RealtimeService) Serve(conn RealtimeConn, userId int64) {
+	session, ok := rs.register(conn, userId)
+	if !ok {
+		conn.SetWriteDeadline(time.Now().Add(realtimeWriteTimeout))
+		conn.Close(RealtimeCloseGoingAway)
+		return
+	}
+	defer rs.unregister(session)
+
+	go session.writeLoop()
+
+	rs.readLoop(session)
+
+	session.close(RealtimeCloseNormal)
+	<-session.writerDone
+}
+
+// Shutdown cierra todas las conexiones (avisando a los clientes que el servidor se apaga) y espera a que terminen
+func (rs *RealtimeService) Shutdown(ctx context.Context) error {
+	rs.mu.Lock()
+	rs.closing = true
+	for session := range rs.sessions {
+		session.close(RealtimeCloseGoingAway)
+	}
+	rs.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		rs.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (rs *RealtimeService) register(conn RealtimeConn, userId int64) (*realtimeSession, bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if rs.closing {
+		return nil, false
+	}
+
+	session := &realtimeSession{
+		conn:       conn,
+		userId:     userId,
+		send:       make(chan string, RealtimeSendQueueSize),
+		channels:   map[string]string{},
+		done:       make(chan struct{}),
+		writerDone: make(chan struct{}),
+	}
+
+	rs.sessions[session] = struct{}{}
+	rs.wg.Add(1)
+
+	return session, true
+}
+
+// unregister quita la conexion de todos sus canales y cierra las suscripciones que quedan sin conexiones
+func (rs *RealtimeService) unregister(session *realtimeSession) {
+	rs.mu.Lock()
+	subscriptions := []cache.Subscription{}
+	for channelKey := range session.channels {
+		if subscription := rs.removeFromChannel(session, channelKey); subscription != nil {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	delete(rs.sessions, session)
+	rs.mu.Unlock()
+
+	for _, subscription := range subscriptions {
+		subscription.Close()
+	}
+
+	rs.wg.Done()
+}
+
+// readLoop procesa los mensajes del cliente. Cualquier mensaje renueva el deadline de lectura, si el cliente no envia
+// nada (ni siquiera el pong de los pings) durante RealtimeReadTimeout se cierra la conexion
+func (rs *RealtimeService) readLoop(session *realtimeSession) {
+	for {
+		select {
+		case <-session.done:
+			return
+		default:
+		}
+
+		session.conn.SetReadDeadline(time.Now().Add(RealtimeReadTimeout))
+
+		message, err := session.conn.Receive()
+		if err != nil {
+			return
+		}
+
+		var request models.RealtimeRequest
+		if err := json.Unmarshal([]byte(message), &request); err != nil {
+			session.sendEvent(models.RealtimeEvent{Type: "error", Error: "Invalid message"})
+			continue
+		}
+
+		rs.handleRequest(session, request)
+	}
+}
+
+func (rs *RealtimeService) handleRequest(session *realtimeSession, request models.RealtimeRequest) {
+	var eventType string
+	var err error
+
+	switch request.Action {
+	case "pong":
+		// Solo renueva el deadline de lectura
+		return
+	case "subscribe":
+		eventType = "subscribed"
+		err = rs.subscribe(session, request.Channel)
+	case "unsubscribe":
+		eventType = "unsubscribed"
+		err = rs.unsubscribe(session, request.Channel)
+	default:
+		err = fmt.Errorf("Invalid action")
+	}
+
+	if err != nil {
+		session.sendEvent(models.RealtimeEvent{Type: "error", Channel: request.Channel, Error: err.Error()})
+		return
+	}
+
+	session.sendEvent(models.RealtimeEvent{Type: eventType, Channel: request.Channel})
+}
+
+// resolveChannel valida que el usuario pueda suscribirse al canal solicitado y retorna el canal del Broadcaster que le corresponde
+func (rs *RealtimeService) resolveChannel(userId int64, channel string) (string, error) {
+	if channel == "notifications" {
+		return notificationsChannel(userId), nil
+	}
+
+	kind, idStr, _ := strings.Cut(channel, ":")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+
+	if err != nil || id <= 0 {
+		return "", fmt.Errorf("Invalid channel")
+	}
+
+	switch kind {
+	case "tweet":
+		tweet, err := repositories.GetTweetById(rs.DB, id)
+
+		if err != nil {
+			return "", fmt.Errorf("Nonexistent tweet")
+		}
+
+		err = checkTweetVisibility(rs.DB, tweet.UserID, userId)
+
+		if err != nil {
+			return "", err
+		}
+
+		return tweetChannel(id), nil
+	case "user":
+		_, err := repositories.GetUserById(rs.DB, id)
+
+		if err != nil {
+			return "", fmt.Errorf("Nonexistent user")
+		}
+
+		return userChannel(id), nil
+	}
+
+	return "", fmt.Errorf("Invalid channel")
+}
+
+// subscribe agrega la conexion al canal. La suscripcion al Broadcaster se abre fuera del lock para no bloquear
+// al resto de las conexiones mientras se conecta con Redis
+func (rs *RealtimeService) subscribe(session *realtimeSession, channel string) error {
+	channelKey, err := rs.resolveChannel(session.userId, channel)
+
+	if err != nil {
+		return err
+	}
+
+	rs.mu.Lock()
+	if _, ok := session.channels[channelKey]; ok {
+		rs.mu.Unlock()
+		return nil
+	}
+
+	if len(session.channels) >= RealtimeMaxSubscriptions {
+		rs.mu.Unlock()
+		return fmt.Errorf("Too many subscriptions")
+	}
+
+	if sharedChannel, ok := rs.channels[channelKey]; ok {
+		sharedChannel.sessions[session] = struct{}{}
+		session.channels[channelKey] = channel
+		rs.mu.Unlock()
+		return nil
+	}
+	rs.mu.Unlock()
+
+	subscription, err := rs.Broadcaster.Subscribe(context.Background(), channelKey)
+
+	if err != nil {
+		return fmt.Errorf("Error subscribing to channel: %v", err)
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	sharedChannel, ok := rs.channels[channelKey]
+	if ok {
+		// Otra conexion abrio la suscripcion mientras tanto
+		subscription.Close()
+	} else {
+		sharedChannel = &realtimeChannel{subscription: subscription, sessions: map[*realtimeSession]struct{}{}}
+		rs.channels[channelKey] = sharedChannel
+		go rs.forward(channelKey, sharedChannel)
+	}
+
+	sharedChannel.sessions[session] = struct{}{}
+	session.channels[channelKey] = channel
+
+	return nil
+}
+
+func (rs *RealtimeService) unsubscribe(session *realtimeSession, channel string) error {
+	rs.mu.Lock()
+
+	channelKey := ""
+	for key, requested := range session.channels {
+		if requested == channel {
+			channelKey = key
+		}
+	}
+
+	if channelKey == "" {
+		rs.mu.Unlock()
+		return fmt.Errorf("Not subscribed to channel")
+	}
+
+	subscription := rs.removeFromChannel(session, channelKey)
+	rs.mu.Unlock()
+
+	if subscription != nil {
+		subscription.Close()
+	}
+
+	return nil
+}
+
+// removeFromChannel quita la conexion del canal y, si el canal queda sin conexiones, retorna su suscripcion para cerrarla.
+// Debe llamarse con el lock tomado
+func (rs *RealtimeService) removeFromChannel(session *realtimeSession, channelKey string) cache.Subscription {
+	delete(session.channels, channelKey)
+
+	sharedChannel, ok := rs.channels[channelKey]
+	if !ok {
+		return nil
+	}
+
+	delete(sharedChannel.sessions, session)
+	if len(sharedChannel.sessions) > 0 {
+		return nil
+	}
+
+	delete(rs.channels, channelKey)
+
+	return sharedChannel.subscription
+}
+
+// forward entrega los eventos del canal a cada conexion suscripta. Si la suscripcion se cierra sin que se haya
+// pedido (por ejemplo porque se perdieron mensajes) se cierran las conexiones, para que el cliente se reconecte
+func (rs *RealtimeService) forward(channelKey string, sharedChannel *realtimeChannel) {
+	for message := range sharedChannel.subscription.Messages() {
+		rs.mu.Lock()
+		for session := range sharedChannel.sessions {
+			session.enqueue(message)
+		}
+		rs.mu.Unlock()
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if rs.channels[channelKey] != sharedChannel {
+		return
+	}
+
+	delete(rs.channels, channelKey)
+	for session := range sharedChannel.sessions {
+		delete(session.channels, channelKey)
+		session.close(RealtimeClosePolicyViolation)
+	}
+}
+
+// sendEvent encola la respuesta a un mensaje del cliente
+func (session *realtimeSession) sendEvent(event models.RealtimeEvent) {
+	message, err := json.Marshal(event)
+	if err != nil {
+		fmt.Printf("Error encoding realtime event: %v\n", err)
+		return
+	}
+
+	session.enqueue(string(message))
+}
+
+// enqueue encola el evento sin bloquearse. Si la cola de la conexion esta llena el cliente no consume los eventos
+// a tiempo (backpressure), por lo que se cierra la conexion en lugar de acumular eventos sin limite
+func (session *realtimeSession) enqueue(message string) {
+	select {
+	case session.send <- message:
+	default:
+		session.close(RealtimeClosePolicyViolation)
+	}
+}
+
+func (session *realtimeSession) close(status int) {
+	session.closeOnce.Do(func() {
+		session.status = status
+		close(session.done)
+	})
+}
+
+// writeLoop escribe los eventos encolados y los pings hasta que se cierra la conexion
+func (session *realtimeSession) writeLoop() {
+	defer close(session.writerDone)
+
+	ping := time.NewTicker(RealtimePingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-session.done:
+			session.conn.SetWriteDeadline(time.Now().Add(realtimeWriteTimeout))
+			session.conn.Close(session.status)
+			return
+		case message := <-session.send:
+			session.write(message)
+		case <-ping.C:
+			session.write(pingMessage)
+		}
+	}
+}
+
+func (session *realtimeSession) write(message string) {
+	session.conn.SetWriteDeadline(time.Now().Add(realtimeWriteTimeout))
+
+	if err := session.conn.Send(message); err != nil {
+		session.close(RealtimeCloseNormal)
+	}
+}
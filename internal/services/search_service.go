@@ -0,0 +1,188 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/repositories"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+)
+
+type SearchService struct {
+	DB          *sql.DB     // Conexion a db SQL
+	Cache       cache.Cache // Cache de los contadores de likes, nil si la API funciona sin cache
+	TweetSearch repositories.TweetSearchRepository
+}
+
+func NewSearchService(db *sql.DB, cacheClient cache.Cache) *SearchService {
+	return &SearchService{DB: db, Cache: cacheClient, TweetSearch: repositories.NewTweetSearchRepository(db)}
+}
+
+// SearchTweets obtiene una pagina de los tweets que cumplen la busqueda, del mas reciente al mas antiguo (ver parseSearchQuery)
+func (ss *SearchService) SearchTweets(q string, viewerId int64, limit int64, offset int64, cursor *models.Cursor) ([]models.Tweet, int64, error) {
+	query, err := parseSearchQuery(q)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query.ViewerID = viewerId
+
+	tweets, err := ss.TweetSearch.SearchTweets(query, limit, offset, cursor)
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("Error searching tweets: %v", err)
+	}
+
+	setLikesData(ss.DB, ss.Cache, viewerId, tweets)
+	hideInvisibleOriginals(ss.DB, viewerId, tweets)
+
+	total, err := ss.TweetSearch.CountTweets(query)
+
+	if err != nil {
+		//Por mas que el count rompa, se retornan los tweets
+		fmt.Println(err)
+	}
+
+	return tweets, total, nil
+}
+
+// SearchUsers obtiene una pagina de los usuarios cuyo handle o nombre empieza con q (con o sin '@', sin distinguir mayusculas)
+func (ss *SearchService) SearchUsers(q string, limit int64, offset int64) ([]models.User, int64, error) {
+	prefix := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(q), "@"))
+
+	if prefix == "" {
+		return nil, 0, fmt.Errorf("The search query is required")
+	}
+
+	users, err := repositories.SearchUsers(ss.DB, prefix, limit, offset)
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("Error searching users: %v", err)
+	}
+
+	total, err := repositories.CountSearchUsers(ss.DB, prefix)
+
+	if err != nil {
+		//Por mas que el count rompa, se retornan los usuarios
+		fmt.Println(err)
+	}
+
+	return users, total, nil
+}
+
+// parseSearchQuery interpreta la busqueda. Los elementos se separan por espacios y todos deben cumplirse:
+//   - "una frase": las palabras juntas y en orden
+//   - from:userId: tweets del usuario
+//   - since:YYYY-MM-DD y until:YYYY-MM-DD: tweets creados desde esa fecha (inclusive) y antes de esa fecha (exclusive), en UTC
+//   - #hashtag: tweets que usan el hashtag
+//   - cualquier otra palabra: debe aparecer en el contenido
+func parseSearchQuery(q string) (models.TweetSearchQuery, error) {
+	query := models.TweetSearchQuery{}
+	hasConditions := false
+
+	for _, token := range splitSearchQuery(q) {
+		if token.phrase {
+			if hasSearchableText(token.text) {
+				query.Phrases = append(query.Phrases, token.text)
+				hasConditions = true
+			}
+			continue
+		}
+
+		operator, value, _ := strings.Cut(token.text, ":")
+
+		switch strings.ToLower(operator) {
+		case "from":
+			userId, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || userId <= 0 {
+				return query, fmt.Errorf("Invalid from: user ID")
+			}
+			query.FromUserID = &userId
+		case "since", "until":
+			date, err := time.Parse(time.DateOnly, value)
+			if err != nil {
+				return query, fmt.Errorf("Invalid %s: date", strings.ToLower(operator))
+			}
+
+			if strings.EqualFold(operator, "since") {
+				query.Since = &date
+			} else {
+				query.Until = &date
+			}
+		default:
+			if strings.HasPrefix(token.text, "#") {
+				tag, ok := normalizeHashtag(token.text)
+				if !ok {
+					return query, fmt.Errorf("Invalid hashtag")
+				}
+				query.Hashtags = append(query.Hashtags, tag)
+			} else if hasSearchableText(token.text) {
+				query.Terms = append(query.Terms, token.text)
+			} else {
+				continue
+			}
+		}
+
+		hasConditions = true
+	}
+
+	if !hasConditions {
+		return query, fmt.Errorf("The search query is required")
+	}
+
+	if query.Since != nil && query.Until != nil && !query.Until.After(*query.Since) {
+		return query, fmt.Errorf("The until: date must be after the since: date")
+	}
+
+	return query, nil
+}
+
+type searchToken struct {
+	text   string
+	phrase bool
+}
+
+// splitSearchQuery separa la busqueda por espacios, respetando las frases entre comillas.
+// Si falta la comilla de cierre, la frase llega hasta el final de la busqueda
+func splitSearchQuery(q string) []searchToken {
+	tokens := []searchToken{}
+	runes := []rune(q)
+
+	for i := 0; i < len(runes); i++ {
+		if unicode.IsSpace(runes[i]) {
+			continue
+		}
+
+		if runes[i] == '"' {
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+
+			tokens = append(tokens, searchToken{text: strings.Join(strings.Fields(string(runes[i+1:min(end, len(runes))])), " "), phrase: true})
+			i = end
+			continue
+		}
+
+		end := i
+		for end < len(runes) && !unicode.IsSpace(runes[end]) && runes[end] != '"' {
+			end++
+		}
+
+		tokens = append(tokens, searchToken{text: string(runes[i:end])})
+		i = end - 1
+	}
+
+	return tokens
+}
+
+// hasSearchableText indica si el texto tiene alguna letra o numero, los signos sueltos no se buscan
+func hasSearchableText(text string) bool {
+	return strings.IndexFunc(text, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/repositories"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+)
+
+// Ventanas de tiempo en las que se pueden consultar las tendencias
+var trendWindows = map[string]time.Duration{
+	"1h":  time.Hour,
+	"24h": 24 * time.Hour,
+}
+
+const defaultTrendWindow = "1h"
+
+// Periodo previo a la ventana con el que se calcula el uso esperado de cada hashtag
+const trendBaselinePeriod = 24 * time.Hour
+
+// Los buckets por minuto se conservan mientras formen parte de la ventana mas grande o de su periodo previo
+const trendBucketTTL = 24*time.Hour + trendBaselinePeriod + time.Minute
+
+type TrendService struct {
+	DB    *sql.DB     // Conexion a db SQL
+	Cache cache.Cache // Cache donde se cuentan los usos de los hashtags, nil si la API funciona sin cache
+}
+
+func NewTrendService(db *sql.DB, cacheClient cache.Cache) *TrendService {
+	return &TrendService{DB: db, Cache: cacheClient}
+}
+
+// GetTrends obtiene una pagina de los hashtags en tendencia dentro de la ventana (1h o 24h). El ranking no se basa en la cantidad
+// de usos sino en la velocidad: cuantas veces supera el uso dentro de la ventana al esperado segun las 24hs previas a la ventana,
+// asi un hashtag que siempre se usa mucho no queda por encima de uno que empezo a usarse de golpe
+func (ts *TrendService) GetTrends(window string, limit int64, offset int64) ([]models.Trend, int64, error) {
+	if window == "" {
+		window = defaultTrendWindow
+	}
+
+	windowDuration, ok := trendWindows[window]
+
+	if !ok {
+		return nil, 0, fmt.Errorf("Invalid window")
+	}
+
+	now := time.Now()
+	windowStart := now.Add(-windowDuration)
+	baselineStart := windowStart.Add(-trendBaselinePeriod)
+
+	var usage, baseline map[string]int64
+	var err error
+
+	if ts.Cache != nil {
+		// Los buckets son por minuto, por lo que la ventana incluye el minuto actual completo
+		currentMinute := now.Truncate(time.Minute).Add(time.Minute)
+		windowStart = currentMinute.Add(-windowDuration)
+
+		usage, err = repositories.GetHashtagUsage(ts.Cache, windowStart, currentMinute)
+		if err == nil {
+			baseline, err = repositories.GetHashtagUsage(ts.Cache, windowStart.Add(-trendBaselinePeriod), windowStart)
+		}
+	} else {
+		usage, baseline, err = repositories.GetHashtagUsageFromDB(ts.DB, baselineStart, windowStart)
+	}
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("Error getting trends: %v", err)
+	}
+
+	trends := rankTrends(usage, baseline, windowDuration)
+	total := int64(len(trends))
+
+	if offset >= total {
+		return []models.Trend{}, total, nil
+	}
+
+	return trends[offset:min(offset+limit, total)], total, nil
+}
+
+// rankTrends ordena los hashtags usados dentro de la ventana segun su velocidad. El uso esperado es el del periodo previo
+// proporcional a la duracion de la ventana, y se suma 1 a ambos usos para que un hashtag sin uso previo no tenga velocidad infinita
+func rankTrends(usage map[string]int64, baseline map[string]int64, windowDuration time.Duration) []models.Trend {
+	trends := make([]models.Trend, 0, len(usage))
+
+	for tag, count := range usage {
+		expected := float64(baseline[tag]) * float64(windowDuration) / float64(trendBaselinePeriod)
+
+		trends = append(trends, models.Trend{
+			Tag:           tag,
+			Count:         count,
+			BaselineCount: roundTrendValue(expected),
+			Velocity:      roundTrendValue((float64(count) + 1) / (expected + 1)),
+		})
+	}
+
+	// A igual velocidad se prioriza el hashtag mas usado, y luego el orden alfabetico para que el ranking sea estable
+	sort.Slice(trends, func(i, j int) bool {
+		if trends[i].Velocity != trends[j].Velocity {
+			return trends[i].Velocity > trends[j].Velocity
+		}
+		if trends[i].Count != trends[j].Count {
+			return trends[i].Count > trends[j].Count
+		}
+		return trends[i].Tag < trends[j].Tag
+	})
+
+	return trends
+}
+
+func roundTrendValue(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// addHashtagUsage cuenta (o descuenta, con delta negativo) en el bucket del minuto de creacion del tweet los usos de sus hashtags.
+// Sin cache no es necesario, ya que las tendencias se calculan sobre los hashtags guardados en la db sql
+func addHashtagUsage(cacheClient cache.Cache, tweet *models.Tweet, delta int64) {
+	if cacheClient == nil || len(tweet.Entities.Hashtags) == 0 {
+		return
+	}
+
+	// Si el bucket ya expiro no hay uso que descontar
+	if time.Since(tweet.CreatedAt) >= trendBucketTTL {
+		return
+	}
+
+	tags := []string{}
+	seen := map[string]bool{}
+	for _, hashtag := range tweet.Entities.Hashtags {
+		tag := strings.ToLower(hashtag.Tag)
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	err := repositories.AddHashtagUsage(cacheClient, tags, tweet.CreatedAt, delta, trendBucketTTL)
+	if err != nil {
+		// Las tendencias son aproximadas, por lo que no se interrumpe la operacion sobre el tweet
+		fmt.Printf("Error updating trends: %v\n", err)
+	}
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/repositories"
+)
+
+// parseEntities obtiene los hashtags y menciones del contenido. Se recorre el contenido como runes, igual que al validar
+// el limite de caracteres, por lo que las posiciones se cuentan en caracteres y se aceptan letras de cualquier idioma.
+// Un '#' o '@' solo inicia una entidad al principio del contenido o despues de un caracter que no forma parte de una palabra
+// (asi el '@' de un email no es una mencion), y los hashtags deben tener al menos una letra (#1 no es un hashtag)
+func parseEntities(content string) models.TweetEntities {
+	entities := models.TweetEntities{Hashtags: []models.HashtagEntity{}, Mentions: []models.MentionEntity{}}
+	runes := []rune(content)
+
+	for start := 0; start < len(runes); start++ {
+		symbol := runes[start]
+		if symbol != '#' && symbol != '@' {
+			continue
+		}
+
+		if start > 0 && (isEntityRune(runes[start-1]) || runes[start-1] == '#' || runes[start-1] == '@') {
+			continue
+		}
+
+		end := start + 1
+		for end < len(runes) && isEntityRune(runes[end]) {
+			end++
+		}
+
+		text := string(runes[start+1 : end])
+		if text == "" {
+			continue
+		}
+
+		if symbol == '#' {
+			if strings.IndexFunc(text, unicode.IsLetter) >= 0 {
+				entities.Hashtags = append(entities.Hashtags, models.HashtagEntity{Tag: text, Start: start, End: end})
+			}
+		} else {
+			entities.Mentions = append(entities.Mentions, models.MentionEntity{Username: text, Start: start, End: end})
+		}
+
+		start = end - 1
+	}
+
+	return entities
+}
+
+// isEntityRune indica si el caracter puede formar parte de un hashtag o mencion
+func isEntityRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) || r == '_'
+}
+
+// normalizeHashtag retorna el hashtag en minusculas y sin '#', o false si no es un hashtag valido
+func normalizeHashtag(tag string) (string, bool) {
+	tag = strings.TrimPrefix(tag, "#")
+
+	entities := parseEntities("#" + tag)
+	if len(entities.Hashtags) != 1 || entities.Hashtags[0].Tag != tag {
+		return "", false
+	}
+
+	return strings.ToLower(tag), true
+}
+
+// setEntities calcula los hashtags y menciones del tweet, resolviendo el usuario de cada mencion por su handle
+func (ts *TweetService) setEntities(tweet *models.Tweet) error {
+	tweet.Entities = parseEntities(tweet.Content)
+
+	if len(tweet.Entities.Mentions) == 0 {
+		return nil
+	}
+
+	// Los handles se guardan en minusculas, por lo que las menciones no distinguen mayusculas
+	handles := make([]string, len(tweet.Entities.Mentions))
+	for i, mention := range tweet.Entities.Mentions {
+		handles[i] = strings.ToLower(mention.Username)
+	}
+
+	userIds, err := repositories.GetUserIdsByHandles(ts.DB, handles)
+	if err != nil {
+		return err
+	}
+
+	for i := range tweet.Entities.Mentions {
+		if userId, ok := userIds[handles[i]]; ok {
+			tweet.Entities.Mentions[i].UserID = &userId
+		}
+	}
+
+	return nil
+}
@@ -3,29 +3,51 @@ package services
 import (
 	"database/sql"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
 	"github.com/MauricioGiaconia/uala_backend_challenge/internal/repositories"
-	"github.com/redis/go-redis/v9"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
 )
 
 type TweetService struct {
-	DB  *sql.DB       // Conexion a db SQL
-	RDB *redis.Client // Conexion a db redis
+	DB    *sql.DB     // Conexion a db SQL
+	Cache cache.Cache // Cache de feeds y tweets (Redis o en memoria), nil si la API funciona sin cache
+
+	Broadcaster cache.Broadcaster // Publica los tweets creados a los streams de timeline y los eventos del gateway WebSocket, nil para no publicarlos
 }
 
+const (
+	feedMaxSize   int64 = 800            // Cantidad maxima de tweets que se guardan en el feed precalculado de cada usuario
+	feedTTL             = 24 * time.Hour // Tiempo de vida del feed de un usuario en cache
+	tweetCacheTTL       = 24 * time.Hour // Tiempo de vida del contenido de un tweet en cache
+
+	threadMaxAncestors     int64 = 100 // Cantidad maxima de tweets a los que se sube desde el tweet solicitado en un hilo
+	threadRepliesDepth     int64 = 3   // Niveles de respuestas anidadas que se incluyen debajo de cada respuesta directa
+	threadMaxNestedReplies int64 = 500 // Cantidad maxima de respuestas anidadas que se incluyen en una pagina del hilo
+
+	mutedWordsMaxBatches = 5 // Cantidad maxima de paginas adicionales que se leen para completar una pagina filtrada por palabras silenciadas
+)
+
+// FanOutFollowersThreshold es la cantidad de seguidores a partir de la cual los tweets de un autor no se distribuyen
+// en los feeds de sus seguidores, sino que se mezclan al momento de leer el timeline (evita escribir en miles de feeds por tweet).
+// Se puede configurar con la flag -fanout-threshold
+var FanOutFollowersThreshold int64 = 10000
+
 type TweetServiceRoutine struct {
 	TS TweetService
 	WG *sync.WaitGroup
 }
 
-func NewTweetService(db *sql.DB, rdb *redis.Client) *TweetService {
-	return &TweetService{DB: db, RDB: rdb}
+func NewTweetService(db *sql.DB, cacheClient cache.Cache) *TweetService {
+	return &TweetService{DB: db, Cache: cacheClient}
 }
 
-func (ts *TweetService) GetUserTimeline(followerId *int64, limit *int64, offset *int64) ([]models.Tweet, error) {
+// GetUserTimeline obtiene una pagina del timeline. Si se recibe un cursor se pagina por cursor y se ignora el offset
+func (ts *TweetService) GetUserTimeline(followerId *int64, limit *int64, offset *int64, cursor *models.Cursor) ([]models.Tweet, error) {
 
 	_, err := repositories.GetUserById(ts.DB, *followerId)
 
@@ -33,55 +55,336 @@ func (ts *TweetService) GetUserTimeline(followerId *int64, limit *int64, offset
 		return nil, fmt.Errorf("Nonexistent user")
 	}
 
-	cacheKey := fmt.Sprintf("timeline:%d:%d:%d", *followerId, *limit, *offset)
+	return ts.getFilteredTimeline(models.PaginationWithID{ID: *followerId, Limit: *limit, Offset: *offset, Cursor: cursor})
+}
+
+// getFilteredTimeline obtiene una pagina del timeline sin los tweets que contienen palabras silenciadas por el usuario.
+// El filtro se aplica despues de leer el feed, asi el contenido cacheado no depende de las palabras silenciadas de cada usuario.
+// Para completar la pagina se siguen leyendo tweets a partir del ultimo leido, hasta mutedWordsMaxBatches veces
+func (ts *TweetService) getFilteredTimeline(page models.PaginationWithID) ([]models.Tweet, error) {
+	mutedWords, err := repositories.GetActiveMutedWords(ts.DB, page.ID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("Error getting muted words: %v", err)
+	}
+
+	if len(mutedWords) == 0 {
+		return ts.getTimeline(page)
+	}
+
+	filter := newMutedWordsFilter(mutedWords)
+
+	// Con offset se leen desde el inicio todos los tweets previos a la pagina, porque el offset se cuenta sobre el timeline filtrado
+	skip := page.Offset
+	batch := models.PaginationWithID{ID: page.ID, Limit: page.Limit, Cursor: page.Cursor}
+	if page.Cursor == nil {
+		batch.Limit = page.Offset + page.Limit
+	}
 
-	if ts.RDB != nil {
-		cachedTimeline, err := repositories.GetTweetsFromCache(ts.RDB, cacheKey)
+	timeline := make([]models.Tweet, 0, page.Limit)
+	for i := 0; i <= mutedWordsMaxBatches; i++ {
+		tweets, err := ts.getTimeline(batch)
 		if err != nil {
-			fmt.Println("Error getting timeline from Redis: %v", err) // No detengo la ejecución asi se intenta obtener la data solicitada desde la DB sql
+			return nil, err
 		}
 
-		// Si los datos están en cache, los devolvemos
-		if cachedTimeline != nil {
-			if cachedTimeline.IsFullPage {
-				fmt.Println("[x] Returning data from cache!")
-				return cachedTimeline.Tweets, nil
+		for _, tweet := range tweets {
+			if filter.matches(tweet) {
+				continue
 			}
-			fmt.Println("[x] The timeline consulted may be outdated, searching for information in the sql database...")
+
+			if skip > 0 {
+				skip--
+				continue
+			}
+
+			timeline = append(timeline, tweet)
+			if int64(len(timeline)) == page.Limit {
+				return timeline, nil
+			}
+		}
+
+		if int64(len(tweets)) < batch.Limit {
+			// No hay mas tweets en el timeline
+			break
+		}
+
+		last := tweets[len(tweets)-1]
+		batch = models.PaginationWithID{ID: page.ID, Limit: page.Limit, Cursor: &models.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}}
+	}
+
+	return timeline, nil
+}
+
+// getTimeline obtiene una pagina del timeline priorizando el feed precalculado en cache.
+// La consulta a la db sql solo se utiliza si no hay cache o si la pagina solicitada excede lo guardado en el feed
+func (ts *TweetService) getTimeline(page models.PaginationWithID) ([]models.Tweet, error) {
+	if ts.Cache != nil {
+		timeline, err := ts.getTimelineFromFeed(page)
+		if err != nil {
+			fmt.Printf("Error getting timeline from cache: %v\n", err) // No detengo la ejecución asi se intenta obtener la data solicitada desde la DB sql
 		}
+
+		if timeline != nil {
+			fmt.Println("[x] Returning data from cache!")
+			// Los contadores de likes del contenido guardado en cache pueden estar desactualizados, se completan al leer
+			setLikesData(ts.DB, ts.Cache, page.ID, timeline)
+			hideInvisibleOriginals(ts.DB, page.ID, timeline)
+			return timeline, nil
+		}
+		fmt.Println("[x] The requested page is not in the feed, searching for information in the sql database...")
+	} else {
+		fmt.Println("Without cache")
+	}
+
+	var timeline []models.Tweet
+	var err error
+
+	if page.Cursor != nil {
+		timeline, err = repositories.GetTweetsFromDBByCursor(ts.DB, page.ID, page.Limit, page.Cursor)
 	} else {
-		fmt.Println("Without Redis connection")
+		timeline, err = repositories.GetTweetsFromDB(ts.DB, &page.ID, &page.Limit, &page.Offset)
 	}
 
-	timeline, err := repositories.GetTweetsFromDB(ts.DB, followerId, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("Error getting timeline: %v", err)
 	}
 
-	//Se guarda unicamente en redis si hay informacion
-	if int64(len(timeline)) > 0 {
-		if ts.RDB != nil {
-			isFullPage := int64(len(timeline)) == *limit
+	if timeline == nil {
+		timeline = []models.Tweet{}
+	}
 
-			timelineCache := models.TimelineCache{
-				Tweets:     timeline,
-				IsFullPage: isFullPage,
-			}
+	setLikesData(ts.DB, ts.Cache, page.ID, timeline)
+	hideInvisibleOriginals(ts.DB, page.ID, timeline)
 
-			ttl := 30 * time.Minute
+	return timeline, nil
+}
 
-			if !isFullPage {
-				//En caso que la pagina NO este completa, se mantiene un time to live menor
-				ttl = 10 * time.Minute
-			}
+// getTimelineFromFeed retorna nil (sin error) cuando la pagina no puede resolverse con el feed
+func (ts *TweetService) getTimelineFromFeed(page models.PaginationWithID) ([]models.Tweet, error) {
+	// Con cursor alcanza con los tweets previos al cursor, con offset se necesitan todos los previos a la pagina
+	celebrityLimit := page.Offset + page.Limit
+	if page.Cursor != nil {
+		celebrityLimit = page.Limit
+	}
 
-			err = repositories.SaveTweetsToCache(ts.RDB, cacheKey, &timelineCache, ttl)
-			if err != nil {
-				fmt.Println("Error saving timeline to Redis: %v", err) // Si no se pudo guardar la data en cache, retorno de todas formas la informacion obtenida de la db sql
+	celebrityTweets, err := ts.getCelebrityTweets(page.ID, celebrityLimit, page.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(celebrityTweets) == 0 {
+		return ts.getFeedPage(page)
+	}
+
+	// Para mezclar los tweets de las cuentas grandes se necesitan todos los tweets del feed previos a la pagina solicitada
+	feedPage := page
+	mergePage := models.PaginationWithID{Limit: page.Limit}
+	if page.Cursor == nil {
+		feedPage = models.PaginationWithID{ID: page.ID, Limit: page.Offset + page.Limit}
+		mergePage.Offset = page.Offset
+	}
+
+	feedTweets, err := ts.getFeedPage(feedPage)
+	if err != nil || feedTweets == nil {
+		return nil, err
+	}
+
+	return mergeTimelines(feedTweets, celebrityTweets, mergePage), nil
+}
+
+// getCelebrityTweets obtiene los tweets mas recientes de las cuentas grandes que sigue el usuario
+func (ts *TweetService) getCelebrityTweets(followerId int64, limit int64, cursor *models.Cursor) ([]models.Tweet, error) {
+	celebrityIds, err := ts.getCelebrities()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(celebrityIds) == 0 {
+		return []models.Tweet{}, nil
+	}
+
+	return repositories.GetTweetsFromDBByAuthors(ts.DB, followerId, celebrityIds, limit, cursor)
+}
+
+// getCelebrities obtiene el set de cuentas grandes. Si no esta en cache (nunca se leyo o fue desalojado) se vuelve a leer
+// de la db sql, donde se guarda la clase de cada cuenta: asi el set recuperado es el mismo que se uso al distribuir
+// los tweets y los feeds siguen siendo validos
+func (ts *TweetService) getCelebrities() ([]int64, error) {
+	celebrityIds, err := repositories.GetCelebrities(ts.Cache)
+	if err != cache.ErrCacheMiss {
+		return celebrityIds, err
+	}
+
+	celebrityIds, err = repositories.GetCelebrityUserIds(ts.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	err = repositories.SaveCelebrities(ts.Cache, celebrityIds)
+	if err != nil {
+		return nil, err
+	}
+
+	return celebrityIds, nil
+}
+
+// mergeTimelines une los tweets del feed con los de las cuentas grandes, eliminando duplicados
+// (el feed reconstruido desde la db sql ya puede contenerlos) y retorna la pagina solicitada
+func mergeTimelines(feedTweets []models.Tweet, celebrityTweets []models.Tweet, page models.PaginationWithID) []models.Tweet {
+	seen := map[int64]bool{}
+	merged := make([]models.Tweet, 0, len(feedTweets)+len(celebrityTweets))
+
+	for _, tweets := range [][]models.Tweet{feedTweets, celebrityTweets} {
+		for _, tweet := range tweets {
+			if !seen[tweet.ID] {
+				seen[tweet.ID] = true
+				merged = append(merged, tweet)
 			}
 		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		if merged[i].CreatedAt.Equal(merged[j].CreatedAt) {
+			return merged[i].ID > merged[j].ID
+		}
+		return merged[i].CreatedAt.After(merged[j].CreatedAt)
+	})
+
+	return paginateTweets(merged, page, false)
+}
+
+// paginateTweets obtiene la pagina solicitada de un listado de tweets ordenado del mas nuevo al mas viejo.
+// Si el listado esta truncado (pueden existir tweets mas viejos fuera de el) y no alcanza para completar la pagina, retorna nil
+func paginateTweets(tweets []models.Tweet, page models.PaginationWithID, isTruncated bool) []models.Tweet {
+	total := int64(len(tweets))
+	start := page.Offset
+
+	if page.Cursor != nil {
+		start = int64(sort.Search(len(tweets), func(i int) bool {
+			return isBeforeCursor(tweets[i], page.Cursor)
+		}))
+	}
+
+	end := start + page.Limit
+	if isTruncated && end > total {
+		return nil
+	}
+
+	if start >= total {
+		return []models.Tweet{}
+	}
+
+	return tweets[start:min(end, total)]
+}
+
+// isBeforeCursor indica si el tweet va despues del cursor en el timeline, es decir si su (fecha, ID) es menor
+func isBeforeCursor(tweet models.Tweet, cursor *models.Cursor) bool {
+	if tweet.CreatedAt.Equal(cursor.CreatedAt) {
+		return tweet.ID < cursor.ID
+	}
+	return tweet.CreatedAt.Before(cursor.CreatedAt)
+}
+
+// getFeedPage obtiene una pagina del feed precalculado. Retorna nil (sin error) cuando la pagina excede lo guardado en el feed
+func (ts *TweetService) getFeedPage(page models.PaginationWithID) ([]models.Tweet, error) {
+	feedSize, err := repositories.GetFeedSize(ts.Cache, page.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if feedSize == 0 {
+		// El feed no existe (expiro o nunca se consulto), se reconstruye a partir de la db sql
+		tweets, err := ts.rebuildFeed(page.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		return paginateTweets(tweets, page, int64(len(tweets)) >= feedMaxSize), nil
+	}
+
+	// Si el feed llego al maximo, puede haber tweets mas viejos que solo estan en la db sql
+	isTruncated := feedSize >= feedMaxSize
+
+	var tweetIds []int64
+	if page.Cursor != nil {
+		tweetIds, err = repositories.GetFeedTweetIdsByCursor(ts.Cache, page.ID, page.Limit, page.Cursor)
+		if err == nil && isTruncated && int64(len(tweetIds)) < page.Limit {
+			return nil, nil
+		}
 	} else {
-		timeline = []models.Tweet{}
+		if isTruncated && page.Offset+page.Limit > feedSize {
+			return nil, nil
+		}
+		tweetIds, err = repositories.GetFeedTweetIds(ts.Cache, page.ID, page.Limit, page.Offset)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ts.getTweetsContent(tweetIds)
+}
+
+// rebuildFeed guarda en cache el feed de un usuario con los tweets mas recientes de la db sql
+func (ts *TweetService) rebuildFeed(followerId int64) ([]models.Tweet, error) {
+	limit := feedMaxSize
+	var offset int64 = 0
+
+	tweets, err := repositories.GetTweetsFromDB(ts.DB, &followerId, &limit, &offset)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting timeline: %v", err)
+	}
+
+	err = repositories.SaveTweetsToCache(ts.Cache, tweets, tweetCacheTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	err = repositories.SaveFeed(ts.Cache, followerId, tweets, feedTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return tweets, nil
+}
+
+// getTweetsContent obtiene el contenido de los tweets respetando el orden de los IDs recibidos.
+// Los tweets que no estan en cache se obtienen de la db sql y se guardan en cache
+func (ts *TweetService) getTweetsContent(tweetIds []int64) ([]models.Tweet, error) {
+	cachedTweets, err := repositories.GetTweetsFromCache(ts.Cache, tweetIds)
+	if err != nil {
+		return nil, err
+	}
+
+	missingIds := []int64{}
+	for _, id := range tweetIds {
+		if _, ok := cachedTweets[id]; !ok {
+			missingIds = append(missingIds, id)
+		}
+	}
+
+	if len(missingIds) > 0 {
+		dbTweets, err := repositories.GetTweetsByIds(ts.DB, missingIds)
+		if err != nil {
+			return nil, err
+		}
+
+		err = repositories.SaveTweetsToCache(ts.Cache, dbTweets, tweetCacheTTL)
+		if err != nil {
+			fmt.Printf("Error saving tweets to cache: %v\n", err)
+		}
+
+		for _, tweet := range dbTweets {
+			cachedTweets[tweet.ID] = tweet
+		}
+	}
+
+	timeline := make([]models.Tweet, 0, len(tweetIds))
+	for _, id := range tweetIds {
+		// Si un tweet del feed ya no existe en la db sql se omite
+		if tweet, ok := cachedTweets[id]; ok {
+			timeline = append(timeline, tweet)
+		}
 	}
 
 	return timeline, nil
@@ -98,27 +401,628 @@ func (ts *TweetService) CountTimeline(followerId *int64) (int64, error) {
 }
 
 func (ts *TweetService) PostTweet(tweet *models.Tweet) (bool, error) {
+	// Los retweets y las citas se crean con Retweet y QuoteTweet
+	tweet.RetweetOfTweetID = nil
+	tweet.QuotedTweetID = nil
+	tweet.OriginalTweet = nil
+
+	return ts.postTweet(tweet)
+}
+
+// postTweet valida y guarda un tweet, y lo distribuye en los feeds de los seguidores de su autor
+func (ts *TweetService) postTweet(tweet *models.Tweet) (bool, error) {
 	const maxCharacters int = 280 //Maximos de caracteres permitidos en un tweet
 	//El len se hace sobre rune para tratar de forma correct a los caracteres multibtyes (como acentos, simbolos etc etc)
 	if len([]rune(tweet.Content)) > maxCharacters {
 		return false, fmt.Errorf("The content of the tweet must not exceed 280 characters")
 	}
 
-	_, err := repositories.GetUserById(ts.DB, tweet.UserID)
+	author, err := repositories.GetUserById(ts.DB, tweet.UserID)
 
 	if err != nil {
 		return false, fmt.Errorf("Nonexistent user")
 	}
 
+	err = ts.setReplyData(tweet)
+
+	if err != nil {
+		return false, err
+	}
+
+	err = ts.setEntities(tweet)
+
+	if err != nil {
+		return false, fmt.Errorf("Error getting mentioned users: %v", err)
+	}
+
 	tweetPosted, err := repositories.PostTweet(ts.DB, tweet)
 
 	if err != nil {
 		return false, fmt.Errorf("Error getting followers: %v", err)
 	}
 
+	tweet.AuthorName = &author.Name
+	tweet.AuthorHandle = &author.Handle
+
+	var recipientIds []int64
+	if ts.Cache != nil || ts.Broadcaster != nil {
+		recipientIds, err = ts.getTimelineRecipientIds(tweet)
+		if err != nil {
+			// El tweet ya fue guardado en la db sql, los feeds se reconstruyen desde ahi cuando expiren
+			// y los streams lo obtienen al reconectarse con Last-Event-ID
+			fmt.Printf("Error getting tweet recipients: %v\n", err)
+		}
+	}
+
+	if ts.Cache != nil {
+		err = ts.fanOutTweet(tweet, recipientIds)
+		if err != nil {
+			// El tweet ya fue guardado en la db sql, los feeds se reconstruyen desde ahi cuando expiren
+			fmt.Printf("Error pushing tweet to followers feeds: %v\n", err)
+		}
+	}
+
+	addHashtagUsage(ts.Cache, tweet, 1)
+
+	ts.publishTweet(tweet, recipientIds)
+	ts.notifyTweetEvents(tweet)
+
 	return tweetPosted, nil
 }
 
+// notifyTweetEvents notifica al autor del tweet respondido y a los usuarios mencionados que pueden ver el tweet,
+// y publica por el gateway WebSocket los contadores actualizados del tweet respondido
+func (ts *TweetService) notifyTweetEvents(tweet *models.Tweet) {
+	notifiedIds := map[int64]bool{}
+	notify := func(userId int64, notificationType string) {
+		if notifiedIds[userId] {
+			return
+		}
+		notifiedIds[userId] = true
+
+		canView, err := repositories.CanViewUserTweets(ts.DB, tweet.UserID, userId)
+		if err != nil || !canView {
+			return
+		}
+
+		createNotification(ts.DB, ts.Broadcaster, models.Notification{UserID: userId, ActorID: tweet.UserID, Type: notificationType, TweetID: &tweet.ID})
+	}
+
+	if tweet.InReplyToTweetID != nil {
+		publishTweetCounters(ts.DB, ts.Cache, ts.Broadcaster, *tweet.InReplyToTweetID)
+		notify(*tweet.InReplyToUserID, "reply")
+	}
+
+	for _, mention := range tweet.Entities.Mentions {
+		if mention.UserID != nil {
+			notify(*mention.UserID, "mention")
+		}
+	}
+}
+
+// setReplyData completa la conversacion y el autor respondido de una respuesta a partir del tweet al que responde.
+// Si se informa la conversacion debe coincidir con la del tweet respondido
+func (ts *TweetService) setReplyData(tweet *models.Tweet) error {
+	// El autor respondido siempre lo completa la API
+	tweet.InReplyToUserID = nil
+
+	if tweet.InReplyToTweetID == nil {
+		if tweet.ConversationID != 0 {
+			return fmt.Errorf("The conversation can only be set on replies")
+		}
+		return nil
+	}
+
+	repliedTweet, err := repositories.GetTweetById(ts.DB, *tweet.InReplyToTweetID)
+
+	if err != nil {
+		return fmt.Errorf("Nonexistent replied tweet")
+	}
+
+	err = checkTweetVisibility(ts.DB, repliedTweet.UserID, tweet.UserID)
+
+	if err != nil {
+		return err
+	}
+
+	if tweet.ConversationID != 0 && tweet.ConversationID != repliedTweet.ConversationID {
+		return fmt.Errorf("The conversation does not match the replied tweet")
+	}
+
+	tweet.ConversationID = repliedTweet.ConversationID
+	tweet.InReplyToUserID = &repliedTweet.UserID
+
+	return nil
+}
+
+// getTimelineRecipientIds obtiene los seguidores del autor en cuyo timeline aparece el tweet recien creado
+func (ts *TweetService) getTimelineRecipientIds(tweet *models.Tweet) ([]int64, error) {
+	// Las respuestas solo van a los timelines de quienes las ven (ver timelineReplyCondition)
+	var followerIds []int64
+	var err error
+	if tweet.InReplyToUserID != nil && *tweet.InReplyToUserID != tweet.UserID {
+		followerIds, err = repositories.GetFollowerIdsForReply(ts.DB, tweet.UserID, *tweet.InReplyToUserID)
+	} else {
+		followerIds, err = repositories.GetFollowerIds(ts.DB, tweet.UserID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Los seguidores que silenciaron al autor no reciben el tweet
+	muterIds, err := repositories.GetMuterIds(ts.DB, tweet.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return excludeIds(followerIds, muterIds), nil
+}
+
+// fanOutTweet agrega el tweet recien creado al feed de cada uno de los destinatarios (fan-out on write).
+// Si el autor supera FanOutFollowersThreshold seguidores, el tweet solo se guarda en cache
+func (ts *TweetService) fanOutTweet(tweet *models.Tweet, recipientIds []int64) error {
+	err := repositories.SaveTweetsToCache(ts.Cache, []models.Tweet{*tweet}, tweetCacheTTL)
+	if err != nil {
+		return err
+	}
+
+	followersCount, err := repositories.CountFollows(ts.DB, tweet.UserID, "followers")
+	if err != nil {
+		return err
+	}
+
+	celebrityIds, err := ts.getCelebrities()
+	if err != nil {
+		return err
+	}
+
+	isCelebrity := followersCount > FanOutFollowersThreshold
+	if isCelebrity != containsId(celebrityIds, tweet.UserID) {
+		err = ts.setCelebrity(tweet.UserID, isCelebrity)
+		if err != nil {
+			return err
+		}
+	}
+
+	if isCelebrity {
+		// Los tweets de las cuentas grandes no se distribuyen, se mezclan al leer el timeline de cada seguidor
+		return nil
+	}
+
+	return repositories.PushTweetToFeeds(ts.Cache, tweet, recipientIds, feedMaxSize)
+}
+
+// setCelebrity actualiza la clase del autor. Los feeds de sus seguidores no tienen los tweets que publico mientras
+// era una cuenta grande, por lo que al dejar de serlo se eliminan para que se reconstruyan desde la db sql.
+// Al pasar a ser una cuenta grande no hace falta: sus tweets previos siguen en los feeds y se descartan los duplicados al mezclar
+func (ts *TweetService) setCelebrity(userId int64, isCelebrity bool) error {
+	// Primero la db sql: si falla la actualizacion del cache, el set se corrige al volver a leerlo
+	err := repositories.SetUserCelebrity(ts.DB, userId, isCelebrity)
+	if err != nil {
+		return err
+	}
+
+	err = repositories.SetCelebrity(ts.Cache, userId, isCelebrity)
+	if err != nil {
+		return err
+	}
+
+	if isCelebrity {
+		return nil
+	}
+
+	followerIds, err := repositories.GetFollowerIds(ts.DB, userId)
+	if err != nil {
+		return err
+	}
+
+	return repositories.DeleteFeeds(ts.Cache, followerIds)
+}
+
+// containsId indica si id esta en ids
+func containsId(ids []int64, id int64) bool {
+	for _, current := range ids {
+		if current == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// excludeIds retorna los IDs de ids que no estan en excluded
+func excludeIds(ids []int64, excluded []int64) []int64 {
+	if len(excluded) == 0 {
+		return ids
+	}
+
+	excludedSet := make(map[int64]bool, len(excluded))
+	for _, id := range excluded {
+		excludedSet[id] = true
+	}
+
+	filtered := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if !excludedSet[id] {
+			filtered = append(filtered, id)
+		}
+	}
+
+	return filtered
+}
+
+// DeleteTweet elimina un tweet del autor indicado y lo quita de los timelines cacheados
+func (ts *TweetService) DeleteTweet(tweetId int64, authorId int64) (bool, error) {
+	tweet, err := repositories.GetTweetById(ts.DB, tweetId)
+
+	if err != nil {
+		return false, fmt.Errorf("Nonexistent tweet")
+	}
+
+	if tweet.UserID != authorId {
+		return false, fmt.Errorf("Only the author can delete the tweet")
+	}
+
+	// Los retweets se eliminan junto al tweet y las citas dejan de embeberlo, por lo que tambien se actualizan en cache
+	retweets, quoteIds, err := repositories.GetTweetReferences(ts.DB, tweetId)
+
+	if err != nil {
+		return false, fmt.Errorf("Error deleting tweet: %v", err)
+	}
+
+	tweetDeleted, err := repositories.DeleteTweet(ts.DB, tweetId)
+
+	if err != nil {
+		return false, fmt.Errorf("Error deleting tweet: %v", err)
+	}
+
+	if !tweetDeleted {
+		return false, fmt.Errorf("Nonexistent tweet")
+	}
+
+	if ts.Cache != nil {
+		for _, purgedTweet := range append([]models.Tweet{tweet}, retweets...) {
+			err = ts.purgeTweetFromCache(&purgedTweet)
+			if err != nil {
+				// Aunque quede en algun feed, al no estar en cache ni en la db sql el tweet se omite al leer el timeline
+				fmt.Printf("Error removing tweet from cache: %v\n", err)
+			}
+		}
+
+		err = repositories.DeleteTweetFromCache(ts.Cache, quoteIds...)
+		if err != nil {
+			fmt.Printf("Error removing quotes from cache: %v\n", err)
+		}
+	}
+
+	addHashtagUsage(ts.Cache, &tweet, -1)
+
+	if tweet.InReplyToTweetID != nil {
+		publishTweetCounters(ts.DB, ts.Cache, ts.Broadcaster, *tweet.InReplyToTweetID)
+	}
+
+	return true, nil
+}
+
+// Retweet repostea un tweet en nombre del usuario. Si el tweet es a su vez un retweet, se repostea el tweet original.
+// Retorna el retweet creado, con el tweet original embebido
+func (ts *TweetService) Retweet(tweetId int64, userId int64) (models.Tweet, error) {
+	original, err := getRetweetableTweet(ts.DB, tweetId)
+
+	if err != nil {
+		return models.Tweet{}, err
+	}
+
+	err = checkRepostable(ts.DB, &original, userId)
+
+	if err != nil {
+		return models.Tweet{}, err
+	}
+
+	_, err = repositories.GetRetweetByUser(ts.DB, userId, original.ID)
+
+	if err == nil {
+		return models.Tweet{}, fmt.Errorf("Tweet already retweeted")
+	}
+
+	retweet := models.Tweet{UserID: userId, RetweetOfTweetID: &original.ID, OriginalTweet: &original}
+
+	_, err = ts.postTweet(&retweet)
+
+	if err != nil {
+		return models.Tweet{}, err
+	}
+
+	// Se obtiene de la db para devolver el nombre del autor y la fecha de creacion
+	created, err := repositories.GetTweetById(ts.DB, retweet.ID)
+
+	if err != nil {
+		return retweet, nil
+	}
+
+	return created, nil
+}
+
+// UndoRetweet elimina el retweet que hizo el usuario del tweet indicado (o del original, si el tweet es un retweet)
+func (ts *TweetService) UndoRetweet(tweetId int64, userId int64) (bool, error) {
+	original, err := getRetweetableTweet(ts.DB, tweetId)
+
+	if err != nil {
+		return false, err
+	}
+
+	retweet, err := repositories.GetRetweetByUser(ts.DB, userId, original.ID)
+
+	if err != nil {
+		return false, fmt.Errorf("Retweet not found")
+	}
+
+	return ts.DeleteTweet(retweet.ID, userId)
+}
+
+// QuoteTweet crea un tweet del usuario que cita al tweet indicado con un comentario propio.
+// Si el tweet es un retweet, se cita el tweet original
+func (ts *TweetService) QuoteTweet(tweetId int64, quote *models.Tweet) (bool, error) {
+	if strings.TrimSpace(quote.Content) == "" {
+		return false, fmt.Errorf("The content of the quote is required")
+	}
+
+	original, err := getRetweetableTweet(ts.DB, tweetId)
+
+	if err != nil {
+		return false, err
+	}
+
+	err = checkRepostable(ts.DB, &original, quote.UserID)
+
+	if err != nil {
+		return false, err
+	}
+
+	quote.RetweetOfTweetID = nil
+	quote.QuotedTweetID = &original.ID
+	quote.OriginalTweet = &original
+
+	return ts.postTweet(quote)
+}
+
+// getRetweetableTweet obtiene el tweet a retweetear, citar o likear: el propio tweet o, si es un retweet, el tweet original
+func getRetweetableTweet(db *sql.DB, tweetId int64) (models.Tweet, error) {
+	tweet, err := repositories.GetTweetById(db, tweetId)
+
+	if err != nil {
+		return models.Tweet{}, fmt.Errorf("Nonexistent tweet")
+	}
+
+	if tweet.RetweetOfTweetID == nil {
+		return tweet, nil
+	}
+
+	original, err := repositories.GetTweetById(db, *tweet.RetweetOfTweetID)
+
+	if err != nil {
+		return models.Tweet{}, fmt.Errorf("Nonexistent tweet")
+	}
+
+	return original, nil
+}
+
+// checkTweetVisibility retorna un error si el usuario que consulta (0 si no hay usuario autenticado) no puede ver los tweets
+// del autor, porque su cuenta es privada y no la sigue
+func checkTweetVisibility(db *sql.DB, authorId int64, viewerId int64) error {
+	visible, err := repositories.CanViewUserTweets(db, authorId, viewerId)
+
+	if err != nil {
+		return err
+	}
+
+	if !visible {
+		return fmt.Errorf("This account is private")
+	}
+
+	return nil
+}
+
+// hideInvisibleOriginals quita el tweet retweeteado o citado de los tweets cuyo original es de una cuenta privada que el usuario que
+// consulta no puede ver. Esos tweets no se pueden retweetear ni citar, pero la cuenta pudo pasar a privada despues. Se aplica al leer,
+// asi el contenido guardado en cache no depende del usuario que consulta. Si falla la consulta se quitan todos los originales ajenos
+func hideInvisibleOriginals(db *sql.DB, viewerId int64, tweets []models.Tweet) {
+	authorIds := []int64{}
+	for _, tweet := range tweets {
+		if tweet.OriginalTweet != nil && tweet.OriginalTweet.UserID != viewerId {
+			authorIds = append(authorIds, tweet.OriginalTweet.UserID)
+		}
+	}
+
+	if len(authorIds) == 0 {
+		return
+	}
+
+	visible, err := repositories.GetVisibleUserIds(db, viewerId, authorIds)
+	if err != nil {
+		fmt.Printf("Error checking original tweets visibility: %v\n", err)
+		visible = map[int64]bool{}
+	}
+
+	for i := range tweets {
+		original := tweets[i].OriginalTweet
+		if original != nil && original.UserID != viewerId && !visible[original.UserID] {
+			tweets[i].OriginalTweet = nil
+		}
+	}
+}
+
+// checkRepostable retorna un error si el tweet es de una cuenta privada y el usuario no es su autor.
+// Los tweets de las cuentas privadas no se retweetean ni se citan, ni siquiera sus seguidores, para no mostrarlos a quienes no la siguen
+func checkRepostable(db *sql.DB, original *models.Tweet, userId int64) error {
+	if original.UserID == userId {
+		return nil
+	}
+
+	author, err := repositories.GetUserById(db, original.UserID)
+
+	if err != nil {
+		return fmt.Errorf("Nonexistent tweet")
+	}
+
+	if author.IsPrivate {
+		return fmt.Errorf("Cannot repost a tweet from a private account")
+	}
+
+	return nil
+}
+
+// purgeTweetFromCache elimina el contenido cacheado del tweet y lo quita de los feeds de los seguidores del autor
+func (ts *TweetService) purgeTweetFromCache(tweet *models.Tweet) error {
+	err := repositories.DeleteTweetFromCache(ts.Cache, tweet.ID)
+	if err != nil {
+		return err
+	}
+
+	followerIds, err := repositories.GetFollowerIds(ts.DB, tweet.UserID)
+	if err != nil {
+		return err
+	}
+
+	return repositories.RemoveTweetFromFeeds(ts.Cache, tweet.ID, followerIds)
+}
+
+// GetThread obtiene el hilo de un tweet: los tweets a los que responde y una pagina de sus respuestas directas,
+// cada una con sus respuestas anidadas. Tambien retorna la cantidad total de respuestas directas.
+// Los tweets de cuentas privadas que el usuario que consulta no sigue no se incluyen
+func (ts *TweetService) GetThread(tweetId int64, viewerId int64, limit int64, offset int64, cursor *models.Cursor) (models.TweetThread, int64, error) {
+	tweet, err := repositories.GetTweetById(ts.DB, tweetId)
+
+	if err != nil {
+		return models.TweetThread{}, 0, fmt.Errorf("Nonexistent tweet")
+	}
+
+	err = checkTweetVisibility(ts.DB, tweet.UserID, viewerId)
+
+	if err != nil {
+		return models.TweetThread{}, 0, err
+	}
+
+	ancestors, err := repositories.GetTweetAncestors(ts.DB, tweetId, viewerId, threadMaxAncestors)
+
+	if err != nil {
+		return models.TweetThread{}, 0, fmt.Errorf("Error getting thread: %v", err)
+	}
+
+	replies, err := repositories.GetTweetReplies(ts.DB, tweetId, viewerId, limit, offset, cursor)
+
+	if err != nil {
+		return models.TweetThread{}, 0, fmt.Errorf("Error getting thread: %v", err)
+	}
+
+	replyIds := make([]int64, len(replies))
+	for i, reply := range replies {
+		replyIds[i] = reply.ID
+	}
+
+	nestedReplies, err := repositories.GetTweetRepliesDescendants(ts.DB, replyIds, viewerId, threadRepliesDepth, threadMaxNestedReplies)
+
+	if err != nil {
+		return models.TweetThread{}, 0, fmt.Errorf("Error getting thread: %v", err)
+	}
+
+	totalReplies, err := repositories.CountTweetReplies(ts.DB, tweetId, viewerId)
+
+	if err != nil {
+		// Igual que en el timeline, si el count falla se retorna el hilo de todas formas
+		fmt.Println(err)
+	}
+
+	tweets := []models.Tweet{tweet}
+	hideInvisibleOriginals(ts.DB, viewerId, tweets)
+	hideInvisibleOriginals(ts.DB, viewerId, ancestors)
+	hideInvisibleOriginals(ts.DB, viewerId, replies)
+	hideInvisibleOriginals(ts.DB, viewerId, nestedReplies)
+
+	thread := models.TweetThread{
+		Ancestors: ancestors,
+		Tweet:     tweets[0],
+		Replies:   buildRepliesTree(replies, nestedReplies),
+	}
+
+	return thread, totalReplies, nil
+}
+
+// buildRepliesTree arma el arbol de respuestas: cada respuesta directa con sus respuestas anidadas,
+// manteniendo en cada nivel el orden en que se recibieron los tweets
+func buildRepliesTree(replies []models.Tweet, nestedReplies []models.Tweet) []models.TweetReply {
+	repliesByParent := map[int64][]models.Tweet{}
+	for _, reply := range nestedReplies {
+		parentId := *reply.InReplyToTweetID
+		repliesByParent[parentId] = append(repliesByParent[parentId], reply)
+	}
+
+	var buildLevel func(tweets []models.Tweet) []models.TweetReply
+	buildLevel = func(tweets []models.Tweet) []models.TweetReply {
+		level := make([]models.TweetReply, len(tweets))
+		for i, tweet := range tweets {
+			level[i] = models.TweetReply{Tweet: tweet, Replies: buildLevel(repliesByParent[tweet.ID])}
+		}
+		return level
+	}
+
+	return buildLevel(replies)
+}
+
+// GetHashtagTweets obtiene una pagina de los tweets que usan el hashtag y que el usuario que consulta puede ver, del mas reciente al mas antiguo
+func (ts *TweetService) GetHashtagTweets(tag string, viewerId int64, limit int64, offset int64, cursor *models.Cursor) ([]models.Tweet, int64, error) {
+	normalizedTag, ok := normalizeHashtag(tag)
+
+	if !ok {
+		return nil, 0, fmt.Errorf("Invalid hashtag")
+	}
+
+	tweets, err := repositories.GetTweetsByHashtag(ts.DB, normalizedTag, viewerId, limit, offset, cursor)
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("Error getting hashtag tweets: %v", err)
+	}
+
+	setLikesData(ts.DB, ts.Cache, viewerId, tweets)
+	hideInvisibleOriginals(ts.DB, viewerId, tweets)
+
+	total, err := repositories.CountTweetsByHashtag(ts.DB, normalizedTag, viewerId)
+
+	if err != nil {
+		//Por mas que el count rompa, se retornan los tweets
+		fmt.Println(err)
+	}
+
+	return tweets, total, nil
+}
+
+// GetUserMentions obtiene una pagina de los tweets que mencionan al usuario y que el usuario que consulta puede ver, del mas reciente al mas antiguo
+func (ts *TweetService) GetUserMentions(userId int64, viewerId int64, limit int64, offset int64, cursor *models.Cursor) ([]models.Tweet, int64, error) {
+	_, err := repositories.GetUserById(ts.DB, userId)
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("Nonexistent user")
+	}
+
+	tweets, err := repositories.GetMentionTweets(ts.DB, userId, viewerId, limit, offset, cursor)
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("Error getting mentions: %v", err)
+	}
+
+	setLikesData(ts.DB, ts.Cache, viewerId, tweets)
+	hideInvisibleOriginals(ts.DB, viewerId, tweets)
+
+	total, err := repositories.CountMentionTweets(ts.DB, userId, viewerId)
+
+	if err != nil {
+		//Por mas que el count rompa, se retornan los tweets
+		fmt.Println(err)
+	}
+
+	return tweets, total, nil
+}
+
 // Esta funcion, a diferencia del timeline, solo obtiene los tweets del usuario que los posteo (osea, los propios)
 func (ts *TweetService) GetTweetsByUserId(userId *int64) ([]models.Tweet, error) {
 
@@ -159,65 +1063,17 @@ func GetUserTimelineRoutine(requestData models.PaginationWithID, tsr TweetServic
 		return
 	}
 
-	cacheKey := fmt.Sprintf("timeline:%d:%d:%d", requestData.ID, requestData.Limit, requestData.Offset)
-
-	if tsr.TS.RDB != nil {
-		cachedTimeline, err := repositories.GetTweetsFromCache(tsr.TS.RDB, cacheKey)
-		if err != nil {
-			// No detengo la ejecución asi se intenta obtener la data solicitada desde la DB sql
-			fmt.Println("Error getting timeline from Redis: %v", err)
-		}
-
-		// Si los datos están en cache, los devolvemos
-		if cachedTimeline != nil {
-			if cachedTimeline.IsFullPage {
-				fmt.Println("[x] Returning data from cache!")
-				responseCn <- cachedTimeline.Tweets
-				return
-			}
-			fmt.Println("[x] The timeline consulted may be outdated, searching for information in the sql database...")
-		}
-	} else {
-		fmt.Println("Without Redis connection")
-	}
-
-	timeline, err := repositories.GetTweetsFromDB(tsr.TS.DB, &requestData.ID, &requestData.Limit, &requestData.Offset)
+	timeline, err := tsr.TS.getFilteredTimeline(requestData)
 
 	if err != nil {
-		errorCn <- "Error getting timeline: " + err.Error()
+		errorCn <- err.Error()
 		return
 	}
 
-	//Se guarda unicamente en redis si hay informacion
-	if int64(len(timeline)) > 0 {
-		if tsr.TS.RDB != nil {
-			isFullPage := int64(len(timeline)) == requestData.Limit
-
-			timelineCache := models.TimelineCache{
-				Tweets:     timeline,
-				IsFullPage: isFullPage,
-			}
-
-			ttl := 30 * time.Minute
-
-			if !isFullPage {
-				//En caso que la pagina NO este completa, se mantiene un time to live menor
-				ttl = 10 * time.Minute
-			}
-
-			err = repositories.SaveTweetsToCache(tsr.TS.RDB, cacheKey, &timelineCache, ttl)
-			if err != nil {
-				fmt.Println("Error saving timeline to Redis: %v", err) // Si no se pudo guardar la data en cache, retorno de todas formas la informacion obtenida de la db sql
-			}
-		}
-	} else {
-		timeline = []models.Tweet{}
-	}
-
 	responseCn <- timeline
 }
 
-func (ts *TweetService) GetUserTimelineDataWithRoutine(followerId *int64, limit *int64, offset *int64) ([]models.Tweet, int64, error) {
+func (ts *TweetService) GetUserTimelineDataWithRoutine(followerId *int64, limit *int64, offset *int64, cursor *models.Cursor) ([]models.Tweet, int64, error) {
 
 	var timeline []models.Tweet
 	var totalTweets int64
@@ -229,7 +1085,7 @@ func (ts *TweetService) GetUserTimelineDataWithRoutine(followerId *int64, limit
 
 	wg.Add(2)
 
-	go GetUserTimelineRoutine(models.PaginationWithID{ID: *followerId, Limit: *limit, Offset: *offset}, TweetServiceRoutine{TS: *ts, WG: wg}, timelineCn, errorCn)
+	go GetUserTimelineRoutine(models.PaginationWithID{ID: *followerId, Limit: *limit, Offset: *offset, Cursor: cursor}, TweetServiceRoutine{TS: *ts, WG: wg}, timelineCn, errorCn)
 	go CountTimelineRoutine(followerId, ts.DB, wg, countCn)
 
 	wg.Wait()
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/repositories"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCelebritiesReloadsFromSQL(t *testing.T) {
+	conn := setupTestDB(t)
+	cacheClient := cache.NewMemoryCache(cache.DefaultMemoryCacheSize)
+	userIds := createTestUsers(t, conn, 2)
+	celebrity, follower := userIds[0], userIds[1]
+
+	tweet := createTestTweet(t, conn, models.Tweet{UserID: follower, Content: "Tweet en el feed"})
+	assert.NoError(t, repositories.SaveFeed(cacheClient, follower, []models.Tweet{tweet}, feedTTL))
+
+	tweetService := NewTweetService(conn, cacheClient)
+	assert.NoError(t, tweetService.setCelebrity(celebrity, true))
+
+	// Si el set se desaloja se vuelve a leer de la db sql, sin eliminar los feeds
+	assert.NoError(t, cacheClient.Delete(context.Background(), "celebrities"))
+
+	celebrityIds, err := tweetService.getCelebrities()
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{celebrity}, celebrityIds)
+
+	feedSize, err := repositories.GetFeedSize(cacheClient, follower)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), feedSize)
+
+	assert.NoError(t, tweetService.setCelebrity(celebrity, false))
+	assert.NoError(t, cacheClient.Delete(context.Background(), "celebrities"))
+
+	celebrityIds, err = tweetService.getCelebrities()
+	assert.NoError(t, err)
+	assert.Empty(t, celebrityIds)
+}
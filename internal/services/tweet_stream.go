@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/repositories"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+)
+
+const (
+	streamReplayMaxTweets int64 = 100 // Cantidad maxima de tweets que se reenvian al reconectar un stream con Last-Event-ID
+
+	streamFilterRefreshInterval = time.Minute // Cada cuanto un stream abierto vuelve a leer las palabras silenciadas
+)
+
+// timelineStreamChannel es el canal del Broadcaster donde se publica el ID de cada tweet creado que forma parte del timeline del usuario
+func timelineStreamChannel(followerId int64) string {
+	return fmt.Sprintf("tweets:timeline:%d", followerId)
+}
+
+// TimelineStream recibe los tweets que se crean y forman parte del timeline de un usuario, mientras su suscripcion esta abierta
+type TimelineStream struct {
+	Replay []models.Tweet // Tweets creados despues del ultimo evento recibido por el cliente, del mas antiguo al mas reciente
+
+	ts           *TweetService
+	followerId   int64
+	replayedId   int64 // ID del ultimo tweet incluido en el replay, los mensajes de tweets previos ya fueron enviados
+	subscription cache.Subscription
+
+	filter   *mutedWordsFilter // Palabras silenciadas vigentes del usuario
+	loadedAt time.Time         // Momento en que se leyo filter
+}
+
+// publishTweet avisa a los streams de timeline abiertos (en esta y en las demas instancias de la API) de los usuarios recibidos
+// que se creo un tweet de su timeline. Se publica solo el ID en el canal de cada usuario: un stream recibe unicamente
+// los tweets de quienes seguia al crearse el tweet, y vuelve a validar contra la db sql que el tweet siga en su timeline
+func (ts *TweetService) publishTweet(tweet *models.Tweet, followerIds []int64) {
+	if ts.Broadcaster == nil || len(followerIds) == 0 {
+		return
+	}
+
+	channels := make([]string, len(followerIds))
+	for i, followerId := range followerIds {
+		channels[i] = timelineStreamChannel(followerId)
+	}
+
+	err := ts.Broadcaster.PublishMany(context.Background(), channels, strconv.FormatInt(tweet.ID, 10))
+	if err != nil {
+		// El tweet ya fue guardado, los clientes lo obtienen al reconectarse con Last-Event-ID o al consultar el timeline
+		fmt.Printf("Error publishing tweet to timeline streams: %v\n", err)
+	}
+}
+
+// OpenTimelineStream suscribe al usuario a los tweets nuevos de su timeline. Si lastEventId es mayor a 0 tambien se obtienen
+// los tweets del timeline creados despues de ese tweet (como maximo los streamReplayMaxTweets mas recientes).
+// La suscripcion se abre antes de leer el replay para no perder los tweets creados mientras tanto
+func (ts *TweetService) OpenTimelineStream(ctx context.Context, followerId int64, lastEventId int64) (*TimelineStream, error) {
+	_, err := repositories.GetUserById(ts.DB, followerId)
+
+	if err != nil {
+		return nil, fmt.Errorf("Nonexistent user")
+	}
+
+	subscription, err := ts.Broadcaster.Subscribe(ctx, timelineStreamChannel(followerId))
+
+	if err != nil {
+		return nil, fmt.Errorf("Error subscribing to new tweets: %v", err)
+	}
+
+	stream := &TimelineStream{Replay: []models.Tweet{}, ts: ts, followerId: followerId, subscription: subscription}
+
+	if err := stream.loadFilter(); err != nil {
+		subscription.Close()
+		return nil, err
+	}
+
+	if lastEventId <= 0 {
+		return stream, nil
+	}
+
+	tweets, err := repositories.GetTimelineTweetsAfter(ts.DB, followerId, lastEventId, streamReplayMaxTweets)
+
+	if err != nil {
+		subscription.Close()
+		return nil, err
+	}
+
+	// Los tweets se obtienen del mas reciente al mas antiguo y se envian en el orden en que se crearon
+	for i := len(tweets) - 1; i >= 0; i-- {
+		if !stream.filter.matches(tweets[i]) {
+			stream.Replay = append(stream.Replay, tweets[i])
+		}
+	}
+
+	if len(tweets) > 0 {
+		stream.replayedId = tweets[0].ID
+	}
+
+	setLikesData(ts.DB, ts.Cache, followerId, stream.Replay)
+	hideInvisibleOriginals(ts.DB, followerId, stream.Replay)
+
+	return stream, nil
+}
+
+// Messages retorna los mensajes de los tweets creados, que se convierten con TweetFromMessage.
+// Se cierra si el stream no consume los mensajes a tiempo o si se pierde la conexion con Redis, en ese caso el cliente debe reconectarse con Last-Event-ID
+func (stream *TimelineStream) Messages() <-chan string {
+	return stream.subscription.Messages()
+}
+
+// loadFilter lee las palabras silenciadas vigentes del usuario del stream
+func (stream *TimelineStream) loadFilter() error {
+	filter, err := stream.ts.getMutedWordsFilter(stream.followerId)
+
+	if err != nil {
+		return err
+	}
+
+	stream.filter = filter
+	stream.loadedAt = time.Now()
+
+	return nil
+}
+
+// TweetFromMessage obtiene el tweet del mensaje recibido. Retorna nil (sin error) si el tweet ya no corresponde al timeline del usuario
+// (por ejemplo porque dejo de seguir al autor), tiene palabras silenciadas, ya se envio en el replay o fue eliminado
+func (stream *TimelineStream) TweetFromMessage(message string) (*models.Tweet, error) {
+	tweetId, err := strconv.ParseInt(message, 10, 64)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid stream message: %s", message)
+	}
+
+	if tweetId <= stream.replayedId {
+		return nil, nil
+	}
+
+	// Las palabras silenciadas se releen periodicamente para reflejar los silencios vencidos
+	if time.Since(stream.loadedAt) >= streamFilterRefreshInterval {
+		if err := stream.loadFilter(); err != nil {
+			return nil, err
+		}
+	}
+
+	tweet, err := repositories.GetTimelineTweet(stream.ts.DB, stream.followerId, tweetId)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("Error getting stream tweet: %v", err)
+	}
+
+	if stream.filter.matches(tweet) {
+		return nil, nil
+	}
+
+	tweets := []models.Tweet{tweet}
+	setLikesData(stream.ts.DB, stream.ts.Cache, stream.followerId, tweets)
+	hideInvisibleOriginals(stream.ts.DB, stream.followerId, tweets)
+
+	return &tweets[0], nil
+}
+
+func (stream *TimelineStream) Close() error {
+	return stream.subscription.Close()
+}
+
+// getMutedWordsFilter obtiene el filtro de las palabras silenciadas vigentes del usuario
+func (ts *TweetService) getMutedWordsFilter(userId int64) (*mutedWordsFilter, error) {
+	mutedWords, err := repositories.GetActiveMutedWords(ts.DB, userId, time.Now())
+
+	if err != nil {
+		return nil, fmt.Errorf("Error getting muted words: %v", err)
+	}
+
+	return newMutedWordsFilter(mutedWords), nil
+}
@@ -7,21 +7,23 @@ import (
 
 	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
 	"github.com/MauricioGiaconia/uala_backend_challenge/internal/repositories"
-	"github.com/redis/go-redis/v9"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
 )
 
 type FollowService struct {
-	DB  *sql.DB
-	RDB *redis.Client
+	DB    *sql.DB
+	Cache cache.Cache
+
+	Broadcaster cache.Broadcaster // Publica la cantidad de seguidores y las notificaciones del gateway WebSocket, nil para no publicarlas
 }
 
-func NewFollowService(db *sql.DB, rdb *redis.Client) *FollowService {
-	return &FollowService{DB: db, RDB: rdb}
+func NewFollowService(db *sql.DB, cacheClient cache.Cache) *FollowService {
+	return &FollowService{DB: db, Cache: cacheClient}
 }
 
 func (ufs *FollowService) FollowUser(follow *models.UserFollow) (bool, error) {
 
-	_, err := repositories.GetUserById(ufs.DB, follow.FollowedID)
+	followed, err := repositories.GetUserById(ufs.DB, follow.FollowedID)
 
 	if err != nil {
 		return false, fmt.Errorf("Nonexistent followed ID user")
@@ -33,22 +35,182 @@ func (ufs *FollowService) FollowUser(follow *models.UserFollow) (bool, error) {
 		return false, fmt.Errorf("Nonexistent follower ID user")
 	}
 
+	// Si alguno de los dos bloqueo al otro no pueden seguirse
+	blocked, err := repositories.IsBlockedBetween(ufs.DB, follow.FollowerID, follow.FollowedID)
+
+	if err != nil {
+		return false, err
+	}
+
+	if blocked {
+		return false, fmt.Errorf("Cannot follow this user")
+	}
+
 	_, err = repositories.GetFollowByFollowerAndFollowed(ufs.DB, follow.FollowerID, follow.FollowedID)
 
 	if err == nil {
 		return false, fmt.Errorf("Follow already exists")
 	}
 
+	// Las cuentas privadas deben aprobar a sus seguidores, hasta entonces queda una solicitud pendiente
+	if followed.IsPrivate {
+		return ufs.requestFollow(follow)
+	}
+
 	userFollow, err := repositories.FollowUser(ufs.DB, follow)
 
 	if err != nil {
 		return userFollow, fmt.Errorf("Error followed user: %v", err)
 	}
 
+	// El feed precalculado del seguidor no tiene los tweets del nuevo usuario seguido, se elimina para que se reconstruya
+	ufs.invalidateFollowCache(follow.FollowerID, follow.FollowedID)
+
+	publishFollowersCount(ufs.DB, ufs.Broadcaster, follow.FollowedID)
+	createNotification(ufs.DB, ufs.Broadcaster, models.Notification{UserID: follow.FollowedID, ActorID: follow.FollowerID, Type: "follow"})
+
 	return userFollow, nil
 }
 
-func (ufs *FollowService) GetFollows(userId *int64, relationType *string, limit *int64, offset *int64) (models.UserFollows, error) {
+// UnfollowUser elimina el seguimiento e invalida el cache afectado, asi los tweets del usuario
+// que se dejo de seguir desaparecen del timeline inmediatamente
+func (ufs *FollowService) UnfollowUser(follow *models.UserFollow) (bool, error) {
+	_, err := repositories.GetFollowByFollowerAndFollowed(ufs.DB, follow.FollowerID, follow.FollowedID)
+
+	if err != nil {
+		// Si no hay follow, se cancela la solicitud pendiente en caso de existir
+		canceled, err := repositories.DeleteFollowRequest(ufs.DB, follow.FollowerID, follow.FollowedID)
+		if err != nil {
+			return false, fmt.Errorf("Error canceling follow request: %v", err)
+		}
+
+		if !canceled {
+			return false, fmt.Errorf("Follow not found")
+		}
+
+		return true, nil
+	}
+
+	unfollowed, err := repositories.UnfollowUser(ufs.DB, follow.FollowerID, follow.FollowedID)
+
+	if err != nil {
+		return false, fmt.Errorf("Error unfollowing user: %v", err)
+	}
+
+	if !unfollowed {
+		return false, fmt.Errorf("Follow not found")
+	}
+
+	ufs.invalidateFollowCache(follow.FollowerID, follow.FollowedID)
+
+	publishFollowersCount(ufs.DB, ufs.Broadcaster, follow.FollowedID)
+
+	return true, nil
+}
+
+// requestFollow crea la solicitud de seguimiento a una cuenta privada y marca el follow como pendiente
+func (ufs *FollowService) requestFollow(follow *models.UserFollow) (bool, error) {
+	exists, err := repositories.FollowRequestExists(ufs.DB, follow.FollowerID, follow.FollowedID)
+
+	if err != nil {
+		return false, err
+	}
+
+	if exists {
+		return false, fmt.Errorf("Follow request already exists")
+	}
+
+	created, err := repositories.CreateFollowRequest(ufs.DB, follow)
+
+	if err != nil {
+		return created, fmt.Errorf("Error requesting follow: %v", err)
+	}
+
+	follow.Pending = true
+
+	return created, nil
+}
+
+// GetFollowRequests obtiene las solicitudes de seguimiento pendientes de una cuenta junto a su total
+func (ufs *FollowService) GetFollowRequests(userId int64, limit int64, offset int64) ([]models.FollowRequestInfo, int64, error) {
+	_, err := repositories.GetUserById(ufs.DB, userId)
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("Nonexistent ID user")
+	}
+
+	requests, err := repositories.GetFollowRequests(ufs.DB, userId, limit, offset)
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("Error getting follow requests: %v", err)
+	}
+
+	total, err := repositories.CountFollowRequests(ufs.DB, userId)
+
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	return requests, total, nil
+}
+
+// ApproveFollowRequest convierte la solicitud en un follow e invalida el cache de ambos usuarios
+func (ufs *FollowService) ApproveFollowRequest(followerId int64, followedId int64) error {
+	approved, err := repositories.ApproveFollowRequest(ufs.DB, followerId, followedId)
+
+	if err != nil {
+		return fmt.Errorf("Error approving follow request: %v", err)
+	}
+
+	if !approved {
+		return fmt.Errorf("Follow request not found")
+	}
+
+	ufs.invalidateFollowCache(followerId, followedId)
+
+	publishFollowersCount(ufs.DB, ufs.Broadcaster, followedId)
+	createNotification(ufs.DB, ufs.Broadcaster, models.Notification{UserID: followedId, ActorID: followerId, Type: "follow"})
+
+	return nil
+}
+
+// RejectFollowRequest elimina la solicitud de seguimiento sin crear el follow
+func (ufs *FollowService) RejectFollowRequest(followerId int64, followedId int64) error {
+	rejected, err := repositories.DeleteFollowRequest(ufs.DB, followerId, followedId)
+
+	if err != nil {
+		return fmt.Errorf("Error rejecting follow request: %v", err)
+	}
+
+	if !rejected {
+		return fmt.Errorf("Follow request not found")
+	}
+
+	return nil
+}
+
+// invalidateFollowCache elimina el feed del seguidor y las paginas de follows cacheadas de ambos usuarios.
+// Los errores solo se informan, el cache expira de todas formas por su time to live
+func (ufs *FollowService) invalidateFollowCache(followerId int64, followedId int64) {
+	if ufs.Cache == nil {
+		return
+	}
+
+	err := repositories.DeleteFeed(ufs.Cache, followerId)
+	if err != nil {
+		fmt.Printf("Error deleting feed from cache: %v\n", err)
+	}
+
+	for _, userId := range []int64{followerId, followedId} {
+		err = repositories.InvalidateFollowsCache(ufs.Cache, userId)
+		if err != nil {
+			fmt.Printf("Error invalidating follows cache: %v\n", err)
+		}
+	}
+}
+
+// GetFollows obtiene los seguidores o seguidos de un usuario. Si se recibe un cursor se pagina por cursor y se ignora el offset
+func (ufs *FollowService) GetFollows(userId *int64, relationType *string, limit *int64, offset *int64, cursor *models.Cursor) (models.UserFollows, error) {
 
 	// Validar que el relationType sea el adecuado segun la logica implementada en el repository
 	if *relationType != "followers" && *relationType != "following" {
@@ -62,12 +224,27 @@ func (ufs *FollowService) GetFollows(userId *int64, relationType *string, limit
 		return models.UserFollows{}, fmt.Errorf("Nonexistent ID user")
 	}
 
-	cacheKey := fmt.Sprintf("follows:%d:%d:%d:%d", *userId, *relationType, *limit, *offset)
+	useCache := ufs.Cache != nil
+	cacheKey := ""
+
+	if useCache {
+		// La version forma parte de la clave: al seguir o dejar de seguir se cambia y las paginas anteriores dejan de leerse
+		version, err := repositories.GetFollowsCacheVersion(ufs.Cache, *userId)
+		if err != nil {
+			fmt.Printf("Error getting follows version from cache: %v\n", err) // Sin version no se puede armar una clave segura, se consulta solo la DB sql
+			useCache = false
+		}
+
+		cacheKey = fmt.Sprintf("follows:%d:v%s:%s:%d:%d", *userId, version, *relationType, *limit, *offset)
+		if cursor != nil {
+			cacheKey = fmt.Sprintf("follows:%d:v%s:%s:%d:cursor:%d:%d", *userId, version, *relationType, *limit, cursor.CreatedAt.UnixNano(), cursor.ID)
+		}
+	}
 
-	if ufs.RDB != nil {
-		cachedFollows, err := repositories.GetFollowsFromCache(ufs.RDB, cacheKey)
+	if useCache {
+		cachedFollows, err := repositories.GetFollowsFromCache(ufs.Cache, cacheKey)
 		if err != nil {
-			fmt.Println("Error getting follows from Redis: %v", err) // No detengo la ejecución asi se intenta obtener la data solicitada desde la DB sql
+			fmt.Printf("Error getting follows from cache: %v\n", err) // No detengo la ejecución asi se intenta obtener la data solicitada desde la DB sql
 		}
 
 		// Si los datos están en cache, los devolvemos
@@ -80,13 +257,13 @@ func (ufs *FollowService) GetFollows(userId *int64, relationType *string, limit
 		}
 	}
 
-	userFollows, err := repositories.GetFollows(ufs.DB, *userId, *relationType, limit, offset)
+	userFollows, err := repositories.GetFollows(ufs.DB, *userId, *relationType, limit, offset, cursor)
 
 	if err != nil {
 		return models.UserFollows{}, fmt.Errorf("Error getting follows: %v", err)
 	}
 
-	if ufs.RDB != nil {
+	if useCache {
 		isFullPage := int64(len(userFollows.Follows)) == *limit
 
 		followsCache := models.FollowsCache{
@@ -101,9 +278,9 @@ func (ufs *FollowService) GetFollows(userId *int64, relationType *string, limit
 			ttl = 10 * time.Minute
 		}
 
-		err = repositories.SaveFollowsToCache(ufs.RDB, cacheKey, &followsCache, ttl)
+		err = repositories.SaveFollowsToCache(ufs.Cache, cacheKey, &followsCache, ttl)
 		if err != nil {
-			fmt.Println("Error saving timeline to Redis: %v", err) // Si no se pudo guardar la data en cache, retorno de todas formas la informacion obtenida de la db sql
+			fmt.Printf("Error saving timeline to cache: %v\n", err) // Si no se pudo guardar la data en cache, retorno de todas formas la informacion obtenida de la db sql
 		}
 	}
 	return *userFollows, nil
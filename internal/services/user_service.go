@@ -3,22 +3,73 @@ package services
 import (
 	"database/sql"
 	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
 	"github.com/MauricioGiaconia/uala_backend_challenge/internal/repositories"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"golang.org/x/text/unicode/norm"
 )
 
 type UserService struct {
-	DB *sql.DB
+	DB    *sql.DB     // Conexion a db SQL
+	Cache cache.Cache // Cache de feeds, tweets y follows, nil si la API funciona sin cache
+
+	Broadcaster cache.Broadcaster // Publica la cantidad de seguidores del gateway WebSocket, nil para no publicarla
 }
 
-func NewUserService(db *sql.DB) *UserService {
-	return &UserService{DB: db}
+func NewUserService(db *sql.DB, cacheClient cache.Cache) *UserService {
+	return &UserService{DB: db, Cache: cacheClient}
 }
 
+// Un handle tiene entre 3 y 15 letras (sin acentos), numeros o guiones bajos
+var handlePattern = regexp.MustCompile(`^[a-z0-9_]{3,15}$`)
+
+const (
+	maxHandleLength   = 15
+	maxNameLength     = 50
+	maxBioLength      = 160
+	maxLocationLength = 30
+	maxURLLength      = 200
+)
+
+// CreateUser crea el usuario con el handle indicado o, si no se indica, con uno generado a partir del nombre
 func (us *UserService) CreateUser(user models.User) (int64, error) {
+	if user.Handle != "" {
+		handle, ok := NormalizeHandle(user.Handle)
+		if !ok {
+			return 0, fmt.Errorf("Invalid handle")
+		}
+
+		taken, err := repositories.HandleExists(us.DB, handle)
+		if err != nil {
+			return 0, err
+		}
+
+		if taken {
+			return 0, fmt.Errorf("Handle already in use")
+		}
+
+		user.Handle = handle
+	} else {
+		handle, err := us.generateHandle(user.Name)
+		if err != nil {
+			return 0, err
+		}
+
+		user.Handle = handle
+	}
+
 	userID, err := repositories.CreateUser(us.DB, user)
 	if err != nil {
+		// Otro usuario pudo tomar el handle entre la validacion y la creacion, lo rechaza el indice unico
+		if strings.Contains(err.Error(), "handle") {
+			return 0, fmt.Errorf("Handle already in use")
+		}
 		return 0, fmt.Errorf(err.Error())
 	}
 	return userID, nil
@@ -33,3 +84,336 @@ func (us *UserService) GetUserById(id int64) (models.User, error) {
 
 	return user, nil
 }
+
+// GetUserByHandle obtiene un usuario por su handle, con o sin '@' y sin distinguir mayusculas
+func (us *UserService) GetUserByHandle(handle string) (models.User, error) {
+	normalizedHandle, ok := NormalizeHandle(handle)
+
+	if !ok {
+		return models.User{}, fmt.Errorf("Invalid handle")
+	}
+
+	user, err := repositories.GetUserByHandle(us.DB, normalizedHandle)
+
+	if err != nil {
+		return models.User{}, fmt.Errorf("Error fetching user: %v", err)
+	}
+
+	return user, nil
+}
+
+// UpdateUser modifica los campos del perfil recibidos y retorna el usuario actualizado.
+// Si cambia el nombre se invalidan los tweets y follows cacheados donde aparece el usuario
+func (us *UserService) UpdateUser(userId int64, update models.UserUpdate) (models.User, error) {
+	user, err := repositories.GetUserWithEmailById(us.DB, userId)
+
+	if err != nil {
+		return models.User{}, fmt.Errorf("Nonexistent user")
+	}
+
+	err = validateUserUpdate(&update)
+
+	if err != nil {
+		return models.User{}, err
+	}
+
+	if update.Email != nil && *update.Email != user.Email {
+		_, err = repositories.GetUserByEmail(us.DB, *update.Email)
+
+		if err == nil {
+			return models.User{}, fmt.Errorf("Email already in use")
+		}
+	}
+
+	updated, err := repositories.UpdateUser(us.DB, userId, update)
+
+	if err != nil {
+		// Otro usuario pudo tomar el email entre la validacion y la modificacion, lo rechaza el indice unico
+		if strings.Contains(err.Error(), "email") {
+			return models.User{}, fmt.Errorf("Email already in use")
+		}
+		return models.User{}, fmt.Errorf("Error updating user: %v", err)
+	}
+
+	if !updated {
+		return models.User{}, fmt.Errorf("Nonexistent user")
+	}
+
+	// Al pasar a ser una cuenta publica ya no hace falta aprobar a los seguidores, se aprueban las solicitudes pendientes
+	if user.IsPrivate && update.IsPrivate != nil && !*update.IsPrivate {
+		us.approvePendingFollowRequests(userId)
+	}
+
+	if us.Cache != nil && update.Name != nil && *update.Name != user.Name {
+		entries, err := us.getUserCacheEntries(userId)
+		if err != nil {
+			// El contenido cacheado expira de todas formas por su time to live
+			fmt.Printf("Error getting user cache entries: %v\n", err)
+		} else {
+			us.purgeUserCache(userId, entries, false)
+		}
+	}
+
+	// El perfil actualizado se retorna al propio usuario, por lo que incluye su email
+	return repositories.GetUserWithEmailById(us.DB, userId)
+}
+
+// approvePendingFollowRequests convierte las solicitudes pendientes en follows e invalida el cache de los nuevos seguidores.
+// Los errores solo se informan, el perfil ya fue modificado
+func (us *UserService) approvePendingFollowRequests(userId int64) {
+	followerIds, err := repositories.ApproveAllFollowRequests(us.DB, userId)
+	if err != nil {
+		fmt.Printf("Error approving pending follow requests: %v\n", err)
+		return
+	}
+
+	if len(followerIds) == 0 {
+		return
+	}
+
+	followService := NewFollowService(us.DB, us.Cache)
+	for _, followerId := range followerIds {
+		followService.invalidateFollowCache(followerId, userId)
+		createNotification(us.DB, us.Broadcaster, models.Notification{UserID: userId, ActorID: followerId, Type: "follow"})
+	}
+
+	publishFollowersCount(us.DB, us.Broadcaster, userId)
+}
+
+// validateUserUpdate valida y normaliza (quitando los espacios de los extremos) los campos a modificar
+func validateUserUpdate(update *models.UserUpdate) error {
+	fields := []*string{update.Name, update.Email, update.Bio, update.Location, update.Website, update.AvatarURL}
+
+	hasFields := update.IsPrivate != nil
+	for _, field := range fields {
+		if field != nil {
+			*field = strings.TrimSpace(*field)
+			hasFields = true
+		}
+	}
+
+	if !hasFields {
+		return fmt.Errorf("No fields to update")
+	}
+
+	if update.Name != nil && (*update.Name == "" || len([]rune(*update.Name)) > maxNameLength) {
+		return fmt.Errorf("Invalid name: must have between 1 and %d characters", maxNameLength)
+	}
+
+	if update.Email != nil {
+		address, err := mail.ParseAddress(*update.Email)
+		if err != nil || address.Address != *update.Email {
+			return fmt.Errorf("Invalid email")
+		}
+	}
+
+	if update.Bio != nil && len([]rune(*update.Bio)) > maxBioLength {
+		return fmt.Errorf("Invalid bio: must not exceed %d characters", maxBioLength)
+	}
+
+	if update.Location != nil && len([]rune(*update.Location)) > maxLocationLength {
+		return fmt.Errorf("Invalid location: must not exceed %d characters", maxLocationLength)
+	}
+
+	if update.Website != nil && !isProfileURL(*update.Website) {
+		return fmt.Errorf("Invalid website: must be an http or https URL of up to %d characters", maxURLLength)
+	}
+
+	if update.AvatarURL != nil && !isProfileURL(*update.AvatarURL) {
+		return fmt.Errorf("Invalid avatarUrl: must be an http or https URL of up to %d characters", maxURLLength)
+	}
+
+	return nil
+}
+
+// isProfileURL indica si el valor es una URL http o https valida. El valor vacio es valido porque borra el dato
+func isProfileURL(value string) bool {
+	if value == "" {
+		return true
+	}
+
+	if len(value) > maxURLLength {
+		return false
+	}
+
+	parsed, err := url.ParseRequestURI(value)
+
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// DeleteUser elimina al usuario junto a sus tweets, follows, likes y bookmarks, y lo quita de todo lo cacheado:
+// sus tweets salen de los feeds de sus seguidores y se descuentan sus likes y el uso de sus hashtags
+func (us *UserService) DeleteUser(userId int64) (bool, error) {
+	_, err := repositories.GetUserById(us.DB, userId)
+
+	if err != nil {
+		return false, fmt.Errorf("Nonexistent user")
+	}
+
+	// Lo que hay que quitar del cache se obtiene antes de eliminar al usuario
+	var entries userCacheEntries
+	var likedTweetIds []int64
+
+	if us.Cache != nil {
+		entries, err = us.getUserCacheEntries(userId)
+		if err != nil {
+			return false, fmt.Errorf("Error deleting user: %v", err)
+		}
+
+		likedTweetIds, err = repositories.GetLikedTweetIds(us.DB, userId)
+		if err != nil {
+			return false, fmt.Errorf("Error deleting user: %v", err)
+		}
+	}
+
+	deleted, err := repositories.DeleteUser(us.DB, userId, us.Cache == nil)
+
+	if err != nil {
+		return false, fmt.Errorf("Error deleting user: %v", err)
+	}
+
+	if !deleted {
+		return false, fmt.Errorf("Nonexistent user")
+	}
+
+	if us.Cache != nil {
+		us.purgeUserCache(userId, entries, true)
+
+		likeService := NewLikeService(us.DB, us.Cache)
+		for _, tweetId := range likedTweetIds {
+			likeService.addPendingLike(tweetId, -1)
+		}
+
+		err = repositories.SetCelebrity(us.Cache, userId, false)
+		if err != nil {
+			fmt.Printf("Error removing user from celebrities: %v\n", err)
+		}
+
+		for i := range entries.tweets {
+			addHashtagUsage(us.Cache, &entries.tweets[i], -1)
+		}
+	}
+
+	return true, nil
+}
+
+// userCacheEntries son los datos de la db sql que indican donde aparece el usuario en el cache
+type userCacheEntries struct {
+	tweets      []models.Tweet // Tweets y retweets del usuario
+	retweets    []models.Tweet // Retweets de otros usuarios a los tweets del usuario
+	quoteIds    []int64        // Citas de otros usuarios a los tweets del usuario
+	followerIds []int64
+	followedIds []int64
+}
+
+func (us *UserService) getUserCacheEntries(userId int64) (userCacheEntries, error) {
+	var entries userCacheEntries
+	var err error
+
+	entries.tweets, err = repositories.GetTweetsByUserId(us.DB, &userId)
+	if err != nil {
+		return entries, err
+	}
+
+	entries.retweets, entries.quoteIds, err = repositories.GetUserTweetReferences(us.DB, userId)
+	if err != nil {
+		return entries, err
+	}
+
+	entries.followerIds, err = repositories.GetFollowerIds(us.DB, userId)
+	if err != nil {
+		return entries, err
+	}
+
+	entries.followedIds, err = repositories.GetFollowedIds(us.DB, userId)
+	if err != nil {
+		return entries, err
+	}
+
+	return entries, nil
+}
+
+// purgeUserCache elimina del cache el contenido de los tweets del usuario y de los que lo retweetean o citan, y las paginas
+// de follows donde aparece. Si removeFromFeeds es true tambien quita esos tweets de los feeds y elimina el feed del usuario.
+// Los errores solo se informan, el cache expira de todas formas por su time to live
+func (us *UserService) purgeUserCache(userId int64, entries userCacheEntries, removeFromFeeds bool) {
+	tweetIds := append([]int64{}, entries.quoteIds...)
+	for _, tweet := range append(entries.tweets, entries.retweets...) {
+		tweetIds = append(tweetIds, tweet.ID)
+	}
+
+	err := repositories.DeleteTweetFromCache(us.Cache, tweetIds...)
+	if err != nil {
+		fmt.Printf("Error deleting user tweets from cache: %v\n", err)
+	}
+
+	if removeFromFeeds {
+		for _, tweet := range entries.tweets {
+			err = repositories.RemoveTweetFromFeeds(us.Cache, tweet.ID, entries.followerIds)
+			if err != nil {
+				fmt.Printf("Error removing tweet from feeds: %v\n", err)
+			}
+		}
+
+		tweetService := NewTweetService(us.DB, us.Cache)
+		for i := range entries.retweets {
+			err = tweetService.purgeTweetFromCache(&entries.retweets[i])
+			if err != nil {
+				fmt.Printf("Error removing retweet from cache: %v\n", err)
+			}
+		}
+
+		err = repositories.DeleteFeed(us.Cache, userId)
+		if err != nil {
+			fmt.Printf("Error deleting feed from cache: %v\n", err)
+		}
+	}
+
+	for _, id := range append(append([]int64{userId}, entries.followerIds...), entries.followedIds...) {
+		err = repositories.InvalidateFollowsCache(us.Cache, id)
+		if err != nil {
+			fmt.Printf("Error invalidating follows cache: %v\n", err)
+		}
+	}
+}
+
+// NormalizeHandle retorna el handle sin '@' y en minusculas, o false si no es un handle valido
+func NormalizeHandle(handle string) (string, bool) {
+	handle = strings.ToLower(strings.TrimPrefix(handle, "@"))
+
+	return handle, handlePattern.MatchString(handle)
+}
+
+// generateHandle genera un handle libre a partir del nombre del usuario, sin acentos, espacios ni otros caracteres no validos.
+// No se usa el email para no exponerlo en el perfil. Si ya esta en uso se le agrega un numero (juanperez, juanperez1, juanperez2...)
+func (us *UserService) generateHandle(name string) (string, error) {
+	// Con la forma NFD las letras acentuadas se separan en la letra y el acento, que luego se descarta
+	base := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return -1
+	}, norm.NFD.String(strings.ToLower(name)))
+
+	if len(base) < 3 {
+		base = "user" + base
+	}
+
+	for i := 0; ; i++ {
+		suffix := ""
+		if i > 0 {
+			suffix = strconv.Itoa(i)
+		}
+
+		handle := base[:min(len(base), maxHandleLength-len(suffix))] + suffix
+
+		taken, err := repositories.HandleExists(us.DB, handle)
+		if err != nil {
+			return "", err
+		}
+
+		if !taken {
+			return handle, nil
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AccessTokenType  = "access"
+	RefreshTokenType = "refresh"
+
+	AccessTokenTTL  = 15 * time.Minute   // Tiempo de vida del token de acceso
+	RefreshTokenTTL = 7 * 24 * time.Hour // Tiempo de vida del token para renovar el acceso
+)
+
+type Claims struct {
+	TokenType string `json:"typ"` // Tipo de token (access o refresh), evita que se use un refresh token como token de acceso
+	jwt.RegisteredClaims
+}
+
+var (
+	secretOnce sync.Once
+	secret     []byte
+)
+
+// getSecret obtiene la clave para firmar los tokens de la variable de entorno JWT_SECRET.
+// Si no esta configurada se genera una clave aleatoria, por lo que los tokens dejan de ser validos al reiniciar la API
+func getSecret() []byte {
+	secretOnce.Do(func() {
+		envSecret := os.Getenv("JWT_SECRET")
+		if envSecret != "" {
+			secret = []byte(envSecret)
+			return
+		}
+
+		log.Println("[x] JWT_SECRET not set, using a random secret (tokens will not survive a restart)")
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			log.Fatalf("[x] Error generating JWT secret: %v", err)
+		}
+	})
+
+	return secret
+}
+
+// GenerateToken firma un token del tipo indicado para el usuario
+func GenerateToken(userId int64, tokenType string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		TokenType: tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatInt(userId, 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(getSecret())
+	if err != nil {
+		return "", fmt.Errorf("Error signing token: %v", err)
+	}
+
+	return token, nil
+}
+
+// ValidateToken verifica la firma, la expiracion y el tipo del token, y retorna el ID del usuario
+func ValidateToken(tokenString string, tokenType string) (int64, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return getSecret(), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+
+	if err != nil {
+		return 0, fmt.Errorf("Invalid or expired token")
+	}
+
+	if claims.TokenType != tokenType {
+		return 0, fmt.Errorf("Invalid or expired token")
+	}
+
+	userId, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil || userId <= 0 {
+		return 0, fmt.Errorf("Invalid or expired token")
+	}
+
+	return userId, nil
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateAndValidateToken(t *testing.T) {
+	token, err := GenerateToken(7, AccessTokenType, AccessTokenTTL)
+	assert.NoError(t, err)
+
+	userId, err := ValidateToken(token, AccessTokenType)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), userId)
+
+	// Un refresh token no se acepta como token de acceso ni al reves
+	_, err = ValidateToken(token, RefreshTokenType)
+	assert.EqualError(t, err, "Invalid or expired token")
+
+	refreshToken, err := GenerateToken(7, RefreshTokenType, RefreshTokenTTL)
+	assert.NoError(t, err)
+	_, err = ValidateToken(refreshToken, AccessTokenType)
+	assert.EqualError(t, err, "Invalid or expired token")
+}
+
+func TestValidateTokenRejectsInvalidTokens(t *testing.T) {
+	expired, err := GenerateToken(7, AccessTokenType, -time.Minute)
+	assert.NoError(t, err)
+
+	valid, err := GenerateToken(7, AccessTokenType, AccessTokenTTL)
+	assert.NoError(t, err)
+
+	// Token firmado con otra clave
+	otherSecret, err := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+		TokenType: AccessTokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "7",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}).SignedString([]byte("otra clave"))
+	assert.NoError(t, err)
+
+	// Token sin expiracion
+	withoutExpiration, err := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+		TokenType:        AccessTokenType,
+		RegisteredClaims: jwt.RegisteredClaims{Subject: "7"},
+	}).SignedString(getSecret())
+	assert.NoError(t, err)
+
+	// Token sin firma
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, Claims{
+		TokenType: AccessTokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "7",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	assert.NoError(t, err)
+
+	// Token con un usuario invalido
+	invalidUser, err := GenerateToken(0, AccessTokenType, AccessTokenTTL)
+	assert.NoError(t, err)
+
+	tokens := map[string]string{
+		"expired":            expired,
+		"tampered":           valid[:len(valid)-2] + "xx",
+		"other secret":       otherSecret,
+		"without expiration": withoutExpiration,
+		"unsigned":           unsigned,
+		"invalid user":       invalidUser,
+		"malformed":          "abc",
+	}
+
+	for name, token := range tokens {
+		_, err := ValidateToken(token, AccessTokenType)
+		assert.EqualError(t, err, "Invalid or expired token", name)
+	}
+}
@@ -0,0 +1,22 @@
+package auth
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// HashPassword genera el hash bcrypt de la contraseña para que nunca se guarde en texto plano
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("Error hashing password: %v", err)
+	}
+
+	return string(hash), nil
+}
+
+// CheckPassword indica si la contraseña corresponde al hash guardado
+func CheckPassword(hash string, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
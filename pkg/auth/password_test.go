@@ -0,0 +1,17 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashAndCheckPassword(t *testing.T) {
+	hash, err := HashPassword("1234")
+	assert.NoError(t, err)
+	assert.NotEqual(t, "1234", hash)
+
+	assert.True(t, CheckPassword(hash, "1234"))
+	assert.False(t, CheckPassword(hash, "12345"))
+	assert.False(t, CheckPassword("no es un hash", "1234"))
+}
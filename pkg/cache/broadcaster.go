@@ -0,0 +1,126 @@
+package cache
+
+import (
+	"context"
+	"sync"
+)
+
+// Broadcaster publica mensajes en un canal y los entrega a todos los suscriptores de ese canal en ese momento.
+// Los mensajes no se guardan: un suscriptor solo recibe los publicados mientras esta suscripto
+type Broadcaster interface {
+	Publish(ctx context.Context, channel string, message string) error
+	PublishMany(ctx context.Context, channels []string, message string) error // Publica el mismo mensaje en cada uno de los canales
+	Subscribe(ctx context.Context, channel string) (Subscription, error)      // Al retornar la suscripcion ya esta activa
+}
+
+// Subscription es la suscripcion a un canal de un Broadcaster
+type Subscription interface {
+	Messages() <-chan string // Se cierra al cerrar la suscripcion, si el suscriptor no consume los mensajes a tiempo o si se pierde la conexion
+	Close() error
+}
+
+// subscriptionBufferSize es la cantidad de mensajes que se encolan para un suscriptor que todavia no los consumio
+const subscriptionBufferSize = 100
+
+// NewBroadcaster retorna el broadcaster a utilizar con el cache recibido: con Redis se usa su Pub/Sub para que los mensajes
+// lleguen a los suscriptores de todas las instancias de la API; con el cache en memoria o sin cache, un broadcaster en memoria
+func NewBroadcaster(cacheClient Cache) Broadcaster {
+	if broadcaster, ok := cacheClient.(Broadcaster); ok {
+		return broadcaster
+	}
+
+	return NewMemoryBroadcaster()
+}
+
+// MemoryBroadcaster implementa Broadcaster dentro del proceso de la API, por lo que solo sirve para una unica instancia
+type MemoryBroadcaster struct {
+	mu          sync.Mutex
+	subscribers map[string]map[*memorySubscription]struct{}
+}
+
+type memorySubscription struct {
+	broadcaster *MemoryBroadcaster
+	channel     string
+	messages    chan string
+	closed      bool
+}
+
+func NewMemoryBroadcaster() *MemoryBroadcaster {
+	return &MemoryBroadcaster{subscribers: map[string]map[*memorySubscription]struct{}{}}
+}
+
+// Publish no se bloquea esperando a los suscriptores: si la cola de un suscriptor esta llena, se cierra su suscripcion
+// para que detecte que perdio mensajes (en lugar de descartarlos sin avisar)
+func (mb *MemoryBroadcaster) Publish(ctx context.Context, channel string, message string) error {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+
+	mb.publishLocked(channel, message)
+
+	return nil
+}
+
+func (mb *MemoryBroadcaster) PublishMany(ctx context.Context, channels []string, message string) error {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+
+	for _, channel := range channels {
+		mb.publishLocked(channel, message)
+	}
+
+	return nil
+}
+
+// publishLocked entrega el mensaje a los suscriptores del canal. Debe llamarse con el lock del broadcaster tomado
+func (mb *MemoryBroadcaster) publishLocked(channel string, message string) {
+	for subscription := range mb.subscribers[channel] {
+		select {
+		case subscription.messages <- message:
+		default:
+			subscription.closeLocked()
+		}
+	}
+}
+
+func (mb *MemoryBroadcaster) Subscribe(ctx context.Context, channel string) (Subscription, error) {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+
+	subscription := &memorySubscription{broadcaster: mb, channel: channel, messages: make(chan string, subscriptionBufferSize)}
+
+	if mb.subscribers[channel] == nil {
+		mb.subscribers[channel] = map[*memorySubscription]struct{}{}
+	}
+	mb.subscribers[channel][subscription] = struct{}{}
+
+	return subscription, nil
+}
+
+func (ms *memorySubscription) Messages() <-chan string {
+	return ms.messages
+}
+
+func (ms *memorySubscription) Close() error {
+	ms.broadcaster.mu.Lock()
+	defer ms.broadcaster.mu.Unlock()
+
+	ms.closeLocked()
+
+	return nil
+}
+
+// closeLocked quita la suscripcion del canal y cierra su cola. Debe llamarse con el lock del broadcaster tomado
+func (ms *memorySubscription) closeLocked() {
+	if ms.closed {
+		return
+	}
+
+	ms.closed = true
+	close(ms.messages)
+
+	subscribers := ms.broadcaster.subscribers[ms.channel]
+	delete(subscribers, ms)
+	if len(subscribers) == 0 {
+		delete(ms.broadcaster.subscribers, ms.channel)
+	}
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryBroadcasterPublishMany(t *testing.T) {
+	ctx := context.Background()
+	mb := NewMemoryBroadcaster()
+
+	first, err := mb.Subscribe(ctx, "a")
+	assert.NoError(t, err)
+	second, err := mb.Subscribe(ctx, "b")
+	assert.NoError(t, err)
+	other, err := mb.Subscribe(ctx, "c")
+	assert.NoError(t, err)
+
+	assert.NoError(t, mb.PublishMany(ctx, []string{"a", "b"}, "1"))
+
+	assert.Equal(t, "1", <-first.Messages())
+	assert.Equal(t, "1", <-second.Messages())
+	assert.Len(t, other.Messages(), 0)
+
+	// Si el suscriptor no consume los mensajes a tiempo se cierra su suscripcion
+	for i := 0; i <= subscriptionBufferSize; i++ {
+		assert.NoError(t, mb.Publish(ctx, "c", "2"))
+	}
+
+	received := 0
+	for range other.Messages() {
+		received++
+	}
+	assert.Equal(t, subscriptionBufferSize, received)
+}
+
+// TestRedisSubscriptionClosesOnDisconnect requiere un servidor Redis en localhost:6379, si no esta disponible se omite
+func TestRedisSubscriptionClosesOnDisconnect(t *testing.T) {
+	ctx := context.Background()
+
+	// Se guardan las conexiones abiertas por el cliente para poder cortarlas
+	var mu sync.Mutex
+	conns := []net.Conn{}
+	client := redis.NewClient(&redis.Options{
+		Addr:     "localhost:6379",
+		Protocol: 2,
+		Dialer: func(ctx context.Context, network string, addr string) (net.Conn, error) {
+			conn, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+			if err == nil {
+				mu.Lock()
+				conns = append(conns, conn)
+				mu.Unlock()
+			}
+			return conn, err
+		},
+	})
+	defer client.Close()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Redis no disponible: %v", err)
+	}
+
+	rc := NewRedisCache(client)
+
+	subscription, err := rc.Subscribe(ctx, "test:subscription")
+	assert.NoError(t, err)
+	defer subscription.Close()
+
+	assert.NoError(t, rc.PublishMany(ctx, []string{"test:subscription", "test:other"}, "1"))
+	assert.Equal(t, "1", <-subscription.Messages())
+
+	// Al perderse la conexion se cierra la cola, en lugar de resuscribirse sin avisar que se perdieron mensajes
+	mu.Lock()
+	for _, conn := range conns {
+		conn.Close()
+	}
+	mu.Unlock()
+
+	select {
+	case _, ok := <-subscription.Messages():
+		assert.False(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for the subscription to close")
+	}
+}
@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+// ErrCacheMiss se retorna cuando la clave solicitada no existe (o expiro)
+var ErrCacheMiss = errors.New("cache miss")
+
+// Z es un miembro de un sorted set junto a su score
+type Z struct {
+	Score  float64
+	Member string
+}
+
+// ScoreRange delimita los miembros de un sorted set segun su score
+type ScoreRange struct {
+	Min          float64 // Score minimo (inclusive), usar math.Inf(-1) para no tener limite
+	Max          float64 // Score maximo, usar math.Inf(1) para no tener limite
+	MaxExclusive bool    // Si es true se excluyen los miembros con score igual a Max
+	Count        int64   // Cantidad maxima de miembros a retornar, 0 para no tener limite
+}
+
+// FullScoreRange retorna un rango que incluye todos los scores
+func FullScoreRange() ScoreRange {
+	return ScoreRange{Min: math.Inf(-1), Max: math.Inf(1)}
+}
+
+// Cache define las operaciones que la API necesita del cache, de esta forma los services no dependen de Redis.
+// Un ttl igual a 0 indica que la clave no expira
+type Cache interface {
+	// Operaciones sobre valores simples
+	Get(ctx context.Context, key string) (string, error)                 // Retorna ErrCacheMiss si la clave no existe
+	MGet(ctx context.Context, keys ...string) (map[string]string, error) // El mapa solo contiene las claves encontradas
+	Set(ctx context.Context, key string, value string, ttl time.Duration) error
+	SetNX(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) // Guarda el valor solo si la clave no existe y retorna si se guardo
+	MSet(ctx context.Context, values map[string]string, ttl time.Duration) error
+	IncrBy(ctx context.Context, key string, value int64) (int64, error) // Suma value al contador (0 si no existe) y retorna el nuevo valor, sin modificar su expiracion
+	Delete(ctx context.Context, keys ...string) error
+	DeleteIfEquals(ctx context.Context, key string, value string) (bool, error) // Elimina la clave solo si su valor es value y retorna si se elimino
+	DeleteByPrefix(ctx context.Context, prefix string) error
+
+	// Operaciones sobre sorted sets (feeds). Los miembros con el mismo score se ordenan de forma lexicografica
+	ZReplace(ctx context.Context, key string, members []Z, ttl time.Duration) error       // Reemplaza el sorted set completo
+	ZAddIfExists(ctx context.Context, keys []string, member Z, maxSize int64) error       // Agrega el miembro solo a los sorted sets existentes, conservando los maxSize de mayor score
+	ZRemFromAll(ctx context.Context, keys []string, member string) error                  // Quita el miembro de cada sorted set
+	ZCard(ctx context.Context, key string) (int64, error)                                 // Cantidad de miembros, 0 si no existe
+	ZRevRange(ctx context.Context, key string, start int64, stop int64) ([]string, error) // Miembros por posicion, de mayor a menor score
+	ZRevRangeByScore(ctx context.Context, key string, scoreRange ScoreRange) ([]string, error)
+	ZIncrBy(ctx context.Context, key string, member string, increment float64, ttl time.Duration) error // Suma increment al score del miembro (lo crea si no existe) y renueva la expiracion
+	ZSumScores(ctx context.Context, keys ...string) (map[string]float64, error)                         // Suma los scores de cada miembro en todos los sorted sets
+
+	// Operaciones sobre sets (sin orden)
+	SAdd(ctx context.Context, key string, members ...string) error
+	SRem(ctx context.Context, key string, members ...string) error
+	SMembers(ctx context.Context, key string) ([]string, error)
+
+	Close() error
+}
@@ -0,0 +1,532 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryCache implementa Cache dentro del proceso de la API. Guarda como maximo maxEntries claves y, al superarlas,
+// descarta las usadas hace mas tiempo (LRU). Las claves vencidas se eliminan al consultarlas o al hacer lugar.
+// Solo sirve para deployments de una unica instancia, ya que el cache no se comparte entre procesos
+type MemoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	lru        *list.List // El frente de la lista es la clave usada mas recientemente
+}
+
+type memoryEntry struct {
+	key       string
+	value     interface{} // string, sortedSet o map[string]struct{} segun el tipo de dato
+	expiresAt time.Time   // Valor cero si la clave no expira
+}
+
+// sortedSet guarda el score de cada miembro; el orden se calcula al leerlo
+type sortedSet map[string]float64
+
+// DefaultMemoryCacheSize es la cantidad de claves que guarda el cache en memoria si no se indica otra
+const DefaultMemoryCacheSize = 100000
+
+var errWrongType = fmt.Errorf("Operation against a key holding the wrong kind of value")
+
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMemoryCacheSize
+	}
+
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		entries:    map[string]*list.Element{},
+		lru:        list.New(),
+	}
+}
+
+// getEntry obtiene la entrada de una clave vigente, marcandola como usada recientemente. Debe llamarse con el lock tomado
+func (mc *MemoryCache) getEntry(key string) *memoryEntry {
+	element, ok := mc.entries[key]
+	if !ok {
+		return nil
+	}
+
+	entry := element.Value.(*memoryEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		mc.removeElement(element)
+		return nil
+	}
+
+	mc.lru.MoveToFront(element)
+	return entry
+}
+
+// setEntry crea o reemplaza el valor de una clave, descartando las claves menos usadas si se supera el maximo.
+// Debe llamarse con el lock tomado
+func (mc *MemoryCache) setEntry(key string, value interface{}, ttl time.Duration) {
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	if element, ok := mc.entries[key]; ok {
+		entry := element.Value.(*memoryEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		mc.lru.MoveToFront(element)
+		return
+	}
+
+	mc.entries[key] = mc.lru.PushFront(&memoryEntry{key: key, value: value, expiresAt: expiresAt})
+
+	for mc.lru.Len() > mc.maxEntries {
+		mc.removeElement(mc.lru.Back())
+	}
+}
+
+func (mc *MemoryCache) removeElement(element *list.Element) {
+	mc.lru.Remove(element)
+	delete(mc.entries, element.Value.(*memoryEntry).key)
+}
+
+// getSortedSet retorna nil (sin error) si la clave no existe
+func (mc *MemoryCache) getSortedSet(key string) (sortedSet, error) {
+	entry := mc.getEntry(key)
+	if entry == nil {
+		return nil, nil
+	}
+
+	set, ok := entry.value.(sortedSet)
+	if !ok {
+		return nil, errWrongType
+	}
+
+	return set, nil
+}
+
+// getSet retorna nil (sin error) si la clave no existe
+func (mc *MemoryCache) getSet(key string) (map[string]struct{}, error) {
+	entry := mc.getEntry(key)
+	if entry == nil {
+		return nil, nil
+	}
+
+	set, ok := entry.value.(map[string]struct{})
+	if !ok {
+		return nil, errWrongType
+	}
+
+	return set, nil
+}
+
+func (mc *MemoryCache) Get(ctx context.Context, key string) (string, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	entry := mc.getEntry(key)
+	if entry == nil {
+		return "", ErrCacheMiss
+	}
+
+	value, ok := entry.value.(string)
+	if !ok {
+		return "", errWrongType
+	}
+
+	return value, nil
+}
+
+func (mc *MemoryCache) MGet(ctx context.Context, keys ...string) (map[string]string, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	values := map[string]string{}
+	for _, key := range keys {
+		entry := mc.getEntry(key)
+		if entry == nil {
+			continue
+		}
+
+		if value, ok := entry.value.(string); ok {
+			values[key] = value
+		}
+	}
+
+	return values, nil
+}
+
+func (mc *MemoryCache) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.setEntry(key, value, ttl)
+	return nil
+}
+
+func (mc *MemoryCache) SetNX(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	if mc.getEntry(key) != nil {
+		return false, nil
+	}
+
+	mc.setEntry(key, value, ttl)
+	return true, nil
+}
+
+func (mc *MemoryCache) MSet(ctx context.Context, values map[string]string, ttl time.Duration) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	for key, value := range values {
+		mc.setEntry(key, value, ttl)
+	}
+
+	return nil
+}
+
+func (mc *MemoryCache) IncrBy(ctx context.Context, key string, value int64) (int64, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	var current int64
+	var ttl time.Duration
+
+	if entry := mc.getEntry(key); entry != nil {
+		stored, ok := entry.value.(string)
+		if !ok {
+			return 0, errWrongType
+		}
+
+		parsed, err := strconv.ParseInt(stored, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("Value is not an integer or out of range")
+		}
+		current = parsed
+
+		// Igual que INCRBY en Redis, se conserva la expiracion de la clave
+		if !entry.expiresAt.IsZero() {
+			ttl = time.Until(entry.expiresAt)
+		}
+	}
+
+	current += value
+	mc.setEntry(key, strconv.FormatInt(current, 10), ttl)
+
+	return current, nil
+}
+
+func (mc *MemoryCache) Delete(ctx context.Context, keys ...string) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	for _, key := range keys {
+		if element, ok := mc.entries[key]; ok {
+			mc.removeElement(element)
+		}
+	}
+
+	return nil
+}
+
+func (mc *MemoryCache) DeleteIfEquals(ctx context.Context, key string, value string) (bool, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	entry := mc.getEntry(key)
+	if entry == nil {
+		return false, nil
+	}
+
+	if stored, ok := entry.value.(string); !ok || stored != value {
+		return false, nil
+	}
+
+	mc.removeElement(mc.entries[key])
+	return true, nil
+}
+
+func (mc *MemoryCache) DeleteByPrefix(ctx context.Context, prefix string) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	for key, element := range mc.entries {
+		if strings.HasPrefix(key, prefix) {
+			mc.removeElement(element)
+		}
+	}
+
+	return nil
+}
+
+func (mc *MemoryCache) ZReplace(ctx context.Context, key string, members []Z, ttl time.Duration) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	if len(members) == 0 {
+		// Igual que en Redis, un sorted set vacio equivale a que la clave no exista
+		if element, ok := mc.entries[key]; ok {
+			mc.removeElement(element)
+		}
+		return nil
+	}
+
+	set := make(sortedSet, len(members))
+	for _, member := range members {
+		set[member.Member] = member.Score
+	}
+
+	mc.setEntry(key, set, ttl)
+	return nil
+}
+
+func (mc *MemoryCache) ZAddIfExists(ctx context.Context, keys []string, member Z, maxSize int64) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	for _, key := range keys {
+		set, err := mc.getSortedSet(key)
+		if err != nil {
+			return err
+		}
+
+		if set == nil {
+			continue
+		}
+
+		set[member.Member] = member.Score
+
+		// Se descartan los miembros de menor score hasta respetar el tamaño maximo
+		if excess := int64(len(set)) - maxSize; excess > 0 {
+			members := set.sortedMembers()
+			for _, removed := range members[int64(len(members))-excess:] {
+				delete(set, removed.Member)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (mc *MemoryCache) ZRemFromAll(ctx context.Context, keys []string, member string) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	for _, key := range keys {
+		set, err := mc.getSortedSet(key)
+		if err != nil {
+			return err
+		}
+
+		if set == nil {
+			continue
+		}
+
+		delete(set, member)
+		if len(set) == 0 {
+			mc.removeElement(mc.entries[key])
+		}
+	}
+
+	return nil
+}
+
+func (mc *MemoryCache) ZCard(ctx context.Context, key string) (int64, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	set, err := mc.getSortedSet(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(set)), nil
+}
+
+func (mc *MemoryCache) ZRevRange(ctx context.Context, key string, start int64, stop int64) ([]string, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	set, err := mc.getSortedSet(key)
+	if err != nil {
+		return nil, err
+	}
+
+	members := set.sortedMembers()
+	total := int64(len(members))
+
+	// Igual que en Redis, los indices negativos se cuentan desde el final
+	if start < 0 {
+		start = max(total+start, 0)
+	}
+	if stop < 0 {
+		stop = total + stop
+	}
+	stop = min(stop, total-1)
+
+	result := []string{}
+	for i := start; i <= stop; i++ {
+		result = append(result, members[i].Member)
+	}
+
+	return result, nil
+}
+
+func (mc *MemoryCache) ZRevRangeByScore(ctx context.Context, key string, scoreRange ScoreRange) ([]string, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	set, err := mc.getSortedSet(key)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []string{}
+	for _, member := range set.sortedMembers() {
+		if scoreRange.Count > 0 && int64(len(result)) >= scoreRange.Count {
+			break
+		}
+
+		if member.Score > scoreRange.Max || (scoreRange.MaxExclusive && member.Score == scoreRange.Max) {
+			continue
+		}
+
+		if member.Score < scoreRange.Min {
+			break
+		}
+
+		result = append(result, member.Member)
+	}
+
+	return result, nil
+}
+
+func (mc *MemoryCache) ZIncrBy(ctx context.Context, key string, member string, increment float64, ttl time.Duration) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	set, err := mc.getSortedSet(key)
+	if err != nil {
+		return err
+	}
+
+	if set == nil {
+		set = sortedSet{}
+	}
+
+	set[member] += increment
+	mc.setEntry(key, set, ttl)
+	return nil
+}
+
+func (mc *MemoryCache) ZSumScores(ctx context.Context, keys ...string) (map[string]float64, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	scores := map[string]float64{}
+	for _, key := range keys {
+		set, err := mc.getSortedSet(key)
+		if err != nil {
+			return nil, err
+		}
+
+		for member, score := range set {
+			scores[member] += score
+		}
+	}
+
+	return scores, nil
+}
+
+// sortedMembers retorna los miembros de mayor a menor score; los que tienen el mismo score se ordenan
+// de forma lexicografica descendente, igual que ZREVRANGE en Redis
+func (set sortedSet) sortedMembers() []Z {
+	members := make([]Z, 0, len(set))
+	for member, score := range set {
+		members = append(members, Z{Score: score, Member: member})
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].Score == members[j].Score {
+			return members[i].Member > members[j].Member
+		}
+		return members[i].Score > members[j].Score
+	})
+
+	return members
+}
+
+func (mc *MemoryCache) SAdd(ctx context.Context, key string, members ...string) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	set, err := mc.getSet(key)
+	if err != nil {
+		return err
+	}
+
+	if set == nil {
+		set = map[string]struct{}{}
+		mc.setEntry(key, set, 0)
+	}
+
+	for _, member := range members {
+		set[member] = struct{}{}
+	}
+
+	return nil
+}
+
+func (mc *MemoryCache) SRem(ctx context.Context, key string, members ...string) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	set, err := mc.getSet(key)
+	if err != nil {
+		return err
+	}
+
+	if set == nil {
+		return nil
+	}
+
+	for _, member := range members {
+		delete(set, member)
+	}
+
+	if len(set) == 0 {
+		mc.removeElement(mc.entries[key])
+	}
+
+	return nil
+}
+
+func (mc *MemoryCache) SMembers(ctx context.Context, key string) ([]string, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	set, err := mc.getSet(key)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]string, 0, len(set))
+	for member := range set {
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+func (mc *MemoryCache) Close() error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.entries = map[string]*list.Element{}
+	mc.lru.Init()
+	return nil
+}
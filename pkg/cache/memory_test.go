@@ -0,0 +1,231 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	mc := NewMemoryCache(2)
+
+	assert.NoError(t, mc.Set(ctx, "a", "1", 0))
+	assert.NoError(t, mc.Set(ctx, "b", "2", 0))
+
+	// Al leer "a" pasa a ser la clave usada mas recientemente, por lo que se descarta "b"
+	value, err := mc.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", value)
+
+	assert.NoError(t, mc.Set(ctx, "c", "3", 0))
+
+	_, err = mc.Get(ctx, "b")
+	assert.Equal(t, ErrCacheMiss, err)
+
+	values, err := mc.MGet(ctx, "a", "b", "c")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "1", "c": "3"}, values)
+
+	// Reemplazar el valor de una clave existente no descarta ninguna otra
+	assert.NoError(t, mc.Set(ctx, "a", "4", 0))
+	values, err = mc.MGet(ctx, "a", "c")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "4", "c": "3"}, values)
+
+	// Los sets tambien cuentan como claves. MGet leyo "c" despues de "a", por lo que se descarta "a"
+	assert.NoError(t, mc.SAdd(ctx, "set", "x"))
+	_, err = mc.Get(ctx, "a")
+	assert.Equal(t, ErrCacheMiss, err)
+}
+
+func TestMemoryCacheExpiresKeys(t *testing.T) {
+	ctx := context.Background()
+	mc := NewMemoryCache(0)
+
+	assert.NoError(t, mc.Set(ctx, "expira", "1", 50*time.Millisecond))
+	assert.NoError(t, mc.Set(ctx, "permanente", "2", 0))
+	assert.NoError(t, mc.Set(ctx, "contador", "10", 50*time.Millisecond))
+	assert.NoError(t, mc.ZIncrBy(ctx, "ranking", "golang", 1, 50*time.Millisecond))
+
+	// Igual que INCRBY en Redis, sumar al contador no renueva su expiracion
+	counter, err := mc.IncrBy(ctx, "contador", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(15), counter)
+
+	value, err := mc.Get(ctx, "expira")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", value)
+
+	time.Sleep(100 * time.Millisecond)
+
+	for _, key := range []string{"expira", "contador"} {
+		_, err = mc.Get(ctx, key)
+		assert.Equal(t, ErrCacheMiss, err, key)
+	}
+
+	value, err = mc.Get(ctx, "permanente")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", value)
+
+	count, err := mc.ZCard(ctx, "ranking")
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+
+	// Un contador vencido vuelve a empezar desde 0
+	counter, err = mc.IncrBy(ctx, "contador", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), counter)
+}
+
+func TestMemoryCacheSortedSets(t *testing.T) {
+	ctx := context.Background()
+	mc := NewMemoryCache(0)
+
+	members := []Z{{Score: 1, Member: "1"}, {Score: 3, Member: "3"}, {Score: 2, Member: "2b"}, {Score: 2, Member: "2a"}}
+	assert.NoError(t, mc.ZReplace(ctx, "feed", members, 0))
+
+	// Se ordenan de mayor a menor score y, con el mismo score, de forma lexicografica descendente
+	result, err := mc.ZRevRange(ctx, "feed", 0, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"3", "2b", "2a", "1"}, result)
+
+	result, err = mc.ZRevRange(ctx, "feed", 1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2b", "2a"}, result)
+
+	result, err = mc.ZRevRange(ctx, "feed", -2, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2a", "1"}, result)
+
+	result, err = mc.ZRevRangeByScore(ctx, "feed", ScoreRange{Min: 1, Max: 3, MaxExclusive: true, Count: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2b", "2a"}, result)
+
+	result, err = mc.ZRevRangeByScore(ctx, "feed", ScoreRange{Min: 2, Max: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"3", "2b", "2a"}, result)
+
+	// Solo se agrega a los sorted sets existentes, descartando los miembros de menor score
+	assert.NoError(t, mc.ZAddIfExists(ctx, []string{"feed", "inexistente"}, Z{Score: 4, Member: "4"}, 3))
+
+	result, err = mc.ZRevRange(ctx, "feed", 0, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"4", "3", "2b"}, result)
+
+	count, err := mc.ZCard(ctx, "inexistente")
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+
+	// Al quitar el ultimo miembro la clave deja de existir
+	assert.NoError(t, mc.ZReplace(ctx, "otro", []Z{{Score: 1, Member: "4"}}, 0))
+	assert.NoError(t, mc.ZRemFromAll(ctx, []string{"feed", "otro"}, "4"))
+
+	result, err = mc.ZRevRange(ctx, "feed", 0, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"3", "2b"}, result)
+
+	count, err = mc.ZCard(ctx, "otro")
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+
+	// Reemplazar con un sorted set vacio elimina la clave
+	assert.NoError(t, mc.ZReplace(ctx, "feed", []Z{}, 0))
+	count, err = mc.ZCard(ctx, "feed")
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+
+	assert.NoError(t, mc.ZIncrBy(ctx, "minuto:1", "golang", 2, 0))
+	assert.NoError(t, mc.ZIncrBy(ctx, "minuto:1", "golang", 1, 0))
+	assert.NoError(t, mc.ZIncrBy(ctx, "minuto:2", "golang", 1, 0))
+	assert.NoError(t, mc.ZIncrBy(ctx, "minuto:2", "go", 5, 0))
+
+	scores, err := mc.ZSumScores(ctx, "minuto:1", "minuto:2", "minuto:3")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"golang": 4, "go": 5}, scores)
+}
+
+func TestMemoryCacheSets(t *testing.T) {
+	ctx := context.Background()
+	mc := NewMemoryCache(0)
+
+	assert.NoError(t, mc.SAdd(ctx, "set", "a", "b", "a"))
+
+	members, err := mc.SMembers(ctx, "set")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a", "b"}, members)
+
+	// Al quitar el ultimo miembro la clave deja de existir
+	assert.NoError(t, mc.SRem(ctx, "set", "a", "b"))
+
+	members, err = mc.SMembers(ctx, "set")
+	assert.NoError(t, err)
+	assert.Empty(t, members)
+
+	// Las operaciones sobre una clave de otro tipo fallan sin modificarla
+	assert.NoError(t, mc.Set(ctx, "valor", "1", 0))
+	assert.Equal(t, errWrongType, mc.SAdd(ctx, "valor", "a"))
+	assert.Equal(t, errWrongType, mc.ZIncrBy(ctx, "valor", "a", 1, 0))
+
+	value, err := mc.Get(ctx, "valor")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", value)
+}
+
+func TestMemoryCacheDeleteByPrefix(t *testing.T) {
+	ctx := context.Background()
+	mc := NewMemoryCache(0)
+
+	assert.NoError(t, mc.Set(ctx, "tweet:1", "1", 0))
+	assert.NoError(t, mc.Set(ctx, "tweet:2", "2", 0))
+	assert.NoError(t, mc.ZReplace(ctx, "timeline:1", []Z{{Score: 1, Member: "1"}}, 0))
+
+	assert.NoError(t, mc.DeleteByPrefix(ctx, "tweet:"))
+
+	values, err := mc.MGet(ctx, "tweet:1", "tweet:2")
+	assert.NoError(t, err)
+	assert.Empty(t, values)
+
+	count, err := mc.ZCard(ctx, "timeline:1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestMemoryCacheConditionalWrites(t *testing.T) {
+	ctx := context.Background()
+	mc := NewMemoryCache(0)
+
+	// SetNX solo guarda el valor si la clave no existe o ya vencio
+	stored, err := mc.SetNX(ctx, "lock", "a", 50*time.Millisecond)
+	assert.NoError(t, err)
+	assert.True(t, stored)
+
+	stored, err = mc.SetNX(ctx, "lock", "b", 0)
+	assert.NoError(t, err)
+	assert.False(t, stored)
+
+	time.Sleep(60 * time.Millisecond)
+
+	stored, err = mc.SetNX(ctx, "lock", "b", 0)
+	assert.NoError(t, err)
+	assert.True(t, stored)
+
+	// DeleteIfEquals no elimina la clave si su valor cambio desde que se leyo
+	assert.NoError(t, mc.Set(ctx, "counter", "2", 0))
+	_, err = mc.IncrBy(ctx, "counter", 1)
+	assert.NoError(t, err)
+
+	deleted, err := mc.DeleteIfEquals(ctx, "counter", "2")
+	assert.NoError(t, err)
+	assert.False(t, deleted)
+
+	deleted, err = mc.DeleteIfEquals(ctx, "counter", "3")
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+
+	deleted, err = mc.DeleteIfEquals(ctx, "counter", "3")
+	assert.NoError(t, err)
+	assert.False(t, deleted)
+}
@@ -0,0 +1,510 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisCache implementa Cache sobre un servidor Redis, permitiendo compartir el cache entre varias instancias de la API
+type RedisCache struct {
+	client redis.UniversalClient
+}
+
+// Script para agregar un miembro a un sorted set unicamente si ya existe, recortandolo a los ARGV[3] miembros de mayor score.
+// Si el sorted set no existe no se crea, ya que un feed con solo el ultimo tweet se interpretaria como un timeline completo
+var zAddIfExistsScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+redis.call('ZADD', KEYS[1], ARGV[1], ARGV[2])
+redis.call('ZREMRANGEBYRANK', KEYS[1], 0, -(tonumber(ARGV[3]) + 1))
+return 1
+`)
+
+// Script para eliminar una clave solo si todavia tiene el valor leido previamente (compare-and-delete)
+var deleteIfEqualsScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+func NewRedisCache(client redis.UniversalClient) *RedisCache {
+	return &RedisCache{client: client}
+}
+
+func (rc *RedisCache) Get(ctx context.Context, key string) (string, error) {
+	value, err := rc.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return "", ErrCacheMiss
+	} else if err != nil {
+		return "", fmt.Errorf("Error getting data from Redis: %v", err)
+	}
+
+	return value, nil
+}
+
+// MGet usa un pipeline de GET en lugar de MGET para que las claves puedan estar en distintos nodos de un cluster
+func (rc *RedisCache) MGet(ctx context.Context, keys ...string) (map[string]string, error) {
+	values := map[string]string{}
+	if len(keys) == 0 {
+		return values, nil
+	}
+
+	pipe := rc.client.Pipeline()
+	cmds := make([]*redis.StringCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.Get(ctx, key)
+	}
+
+	_, err := pipe.Exec(ctx)
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("Error getting data from Redis: %v", err)
+	}
+
+	for i, cmd := range cmds {
+		value, err := cmd.Result()
+		if err == redis.Nil {
+			continue // No hay datos en cache para esa clave
+		} else if err != nil {
+			return nil, fmt.Errorf("Error getting data from Redis: %v", err)
+		}
+		values[keys[i]] = value
+	}
+
+	return values, nil
+}
+
+func (rc *RedisCache) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	err := rc.client.Set(ctx, key, value, ttl).Err()
+	if err != nil {
+		return fmt.Errorf("Error setting value in Redis: %v", err)
+	}
+
+	return nil
+}
+
+func (rc *RedisCache) SetNX(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	stored, err := rc.client.SetNX(ctx, key, value, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("Error setting value in Redis: %v", err)
+	}
+
+	return stored, nil
+}
+
+func (rc *RedisCache) MSet(ctx context.Context, values map[string]string, ttl time.Duration) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	pipe := rc.client.Pipeline()
+	for key, value := range values {
+		pipe.Set(ctx, key, value, ttl)
+	}
+
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("Error setting value in Redis: %v", err)
+	}
+
+	return nil
+}
+
+func (rc *RedisCache) IncrBy(ctx context.Context, key string, value int64) (int64, error) {
+	result, err := rc.client.IncrBy(ctx, key, value).Result()
+	if err != nil {
+		return 0, fmt.Errorf("Error incrementing value in Redis: %v", err)
+	}
+
+	return result, nil
+}
+
+func (rc *RedisCache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	pipe := rc.client.Pipeline()
+	for _, key := range keys {
+		pipe.Del(ctx, key)
+	}
+
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("Error deleting data from Redis: %v", err)
+	}
+
+	return nil
+}
+
+func (rc *RedisCache) DeleteIfEquals(ctx context.Context, key string, value string) (bool, error) {
+	deleted, err := deleteIfEqualsScript.Run(ctx, rc.client, []string{key}, value).Int64()
+	if err != nil {
+		return false, fmt.Errorf("Error deleting data from Redis: %v", err)
+	}
+
+	return deleted == 1, nil
+}
+
+// DeleteByPrefix recorre las claves con SCAN. En un cluster cada nodo master tiene solo una parte de las claves,
+// por lo que se recorre cada uno de ellos
+func (rc *RedisCache) DeleteByPrefix(ctx context.Context, prefix string) error {
+	if clusterClient, ok := rc.client.(*redis.ClusterClient); ok {
+		return clusterClient.ForEachMaster(ctx, func(ctx context.Context, master *redis.Client) error {
+			return deleteByPrefix(ctx, master, prefix)
+		})
+	}
+
+	return deleteByPrefix(ctx, rc.client, prefix)
+}
+
+func deleteByPrefix(ctx context.Context, client redis.UniversalClient, prefix string) error {
+	iter := client.Scan(ctx, 0, prefix+"*", 100).Iterator()
+	keys := []string{}
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("Error scanning keys in Redis: %v", err)
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	// Se eliminan de a una para no mezclar claves de distintos slots en un mismo DEL
+	pipe := client.Pipeline()
+	for _, key := range keys {
+		pipe.Del(ctx, key)
+	}
+
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("Error deleting data from Redis: %v", err)
+	}
+
+	return nil
+}
+
+func (rc *RedisCache) ZReplace(ctx context.Context, key string, members []Z, ttl time.Duration) error {
+	redisMembers := make([]redis.Z, len(members))
+	for i, member := range members {
+		redisMembers[i] = redis.Z{Score: member.Score, Member: member.Member}
+	}
+
+	pipe := rc.client.TxPipeline()
+	pipe.Del(ctx, key)
+	if len(redisMembers) > 0 {
+		pipe.ZAdd(ctx, key, redisMembers...)
+		if ttl > 0 {
+			pipe.Expire(ctx, key, ttl)
+		}
+	}
+
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("Error saving sorted set in Redis: %v", err)
+	}
+
+	return nil
+}
+
+// ZAddIfExists ejecuta el script por cada clave dentro de un pipeline (EVALSHA), de esta forma cada
+// ejecucion involucra una unica clave y funciona aunque las claves esten en distintos nodos de un cluster
+func (rc *RedisCache) ZAddIfExists(ctx context.Context, keys []string, member Z, maxSize int64) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	// Se asegura que el script este cargado para que el pipeline pueda usar EVALSHA
+	err := zAddIfExistsScript.Load(ctx, rc.client).Err()
+	if err != nil {
+		return fmt.Errorf("Error loading script in Redis: %v", err)
+	}
+
+	pipe := rc.client.Pipeline()
+	for _, key := range keys {
+		zAddIfExistsScript.EvalSha(ctx, pipe, []string{key}, member.Score, member.Member, maxSize)
+	}
+
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("Error adding member to sorted sets in Redis: %v", err)
+	}
+
+	return nil
+}
+
+func (rc *RedisCache) ZRemFromAll(ctx context.Context, keys []string, member string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	pipe := rc.client.Pipeline()
+	for _, key := range keys {
+		pipe.ZRem(ctx, key, member)
+	}
+
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("Error removing member from sorted sets in Redis: %v", err)
+	}
+
+	return nil
+}
+
+func (rc *RedisCache) ZCard(ctx context.Context, key string) (int64, error) {
+	size, err := rc.client.ZCard(ctx, key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("Error getting sorted set size from Redis: %v", err)
+	}
+
+	return size, nil
+}
+
+func (rc *RedisCache) ZRevRange(ctx context.Context, key string, start int64, stop int64) ([]string, error) {
+	members, err := rc.client.ZRevRange(ctx, key, start, stop).Result()
+	if err != nil {
+		return nil, fmt.Errorf("Error getting sorted set from Redis: %v", err)
+	}
+
+	return members, nil
+}
+
+func (rc *RedisCache) ZRevRangeByScore(ctx context.Context, key string, scoreRange ScoreRange) ([]string, error) {
+	max := formatScore(scoreRange.Max)
+	if scoreRange.MaxExclusive {
+		max = "(" + max
+	}
+
+	members, err := rc.client.ZRevRangeByScore(ctx, key, &redis.ZRangeBy{
+		Min:   formatScore(scoreRange.Min),
+		Max:   max,
+		Count: scoreRange.Count,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("Error getting sorted set from Redis: %v", err)
+	}
+
+	return members, nil
+}
+
+func (rc *RedisCache) ZIncrBy(ctx context.Context, key string, member string, increment float64, ttl time.Duration) error {
+	pipe := rc.client.TxPipeline()
+	pipe.ZIncrBy(ctx, key, increment, member)
+	if ttl > 0 {
+		pipe.Expire(ctx, key, ttl)
+	}
+
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("Error incrementing score in Redis: %v", err)
+	}
+
+	return nil
+}
+
+// ZSumScores usa un pipeline de ZRANGE en lugar de ZUNION para que las claves puedan estar en distintos nodos de un cluster
+func (rc *RedisCache) ZSumScores(ctx context.Context, keys ...string) (map[string]float64, error) {
+	scores := map[string]float64{}
+	if len(keys) == 0 {
+		return scores, nil
+	}
+
+	pipe := rc.client.Pipeline()
+	cmds := make([]*redis.ZSliceCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.ZRangeWithScores(ctx, key, 0, -1)
+	}
+
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting sorted sets from Redis: %v", err)
+	}
+
+	for _, cmd := range cmds {
+		for _, member := range cmd.Val() {
+			scores[member.Member.(string)] += member.Score
+		}
+	}
+
+	return scores, nil
+}
+
+// formatScore convierte el score al formato que espera Redis, incluyendo los infinitos
+func formatScore(score float64) string {
+	if math.IsInf(score, 1) {
+		return "+inf"
+	}
+	if math.IsInf(score, -1) {
+		return "-inf"
+	}
+	return strconv.FormatFloat(score, 'f', -1, 64)
+}
+
+func (rc *RedisCache) SAdd(ctx context.Context, key string, members ...string) error {
+	err := rc.client.SAdd(ctx, key, toInterfaces(members)...).Err()
+	if err != nil {
+		return fmt.Errorf("Error adding members to set in Redis: %v", err)
+	}
+
+	return nil
+}
+
+func (rc *RedisCache) SRem(ctx context.Context, key string, members ...string) error {
+	err := rc.client.SRem(ctx, key, toInterfaces(members)...).Err()
+	if err != nil {
+		return fmt.Errorf("Error removing members from set in Redis: %v", err)
+	}
+
+	return nil
+}
+
+func (rc *RedisCache) SMembers(ctx context.Context, key string) ([]string, error) {
+	members, err := rc.client.SMembers(ctx, key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("Error getting set from Redis: %v", err)
+	}
+
+	return members, nil
+}
+
+// Publish y Subscribe implementan Broadcaster con el Pub/Sub de Redis, los mensajes llegan a las suscripciones de todas las instancias
+func (rc *RedisCache) Publish(ctx context.Context, channel string, message string) error {
+	err := rc.client.Publish(ctx, channel, message).Err()
+	if err != nil {
+		return fmt.Errorf("Error publishing message to Redis: %v", err)
+	}
+
+	return nil
+}
+
+// PublishMany publica en un pipeline, cada PUBLISH involucra un unico canal
+func (rc *RedisCache) PublishMany(ctx context.Context, channels []string, message string) error {
+	if len(channels) == 0 {
+		return nil
+	}
+
+	pipe := rc.client.Pipeline()
+	for _, channel := range channels {
+		pipe.Publish(ctx, channel, message)
+	}
+
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("Error publishing messages to Redis: %v", err)
+	}
+
+	return nil
+}
+
+func (rc *RedisCache) Subscribe(ctx context.Context, channel string) (Subscription, error) {
+	pubsub := rc.client.Subscribe(ctx, channel)
+
+	// Se espera la confirmacion de Redis para no perder los mensajes publicados apenas retorna la suscripcion
+	_, err := pubsub.Receive(ctx)
+	if err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("Error subscribing to Redis channel: %v", err)
+	}
+
+	subscription := &redisSubscription{
+		pubsub:   pubsub,
+		messages: make(chan string, subscriptionBufferSize),
+	}
+
+	go subscription.forward()
+
+	return subscription, nil
+}
+
+// redisSubscriptionHealthCheckInterval es cada cuanto se envia un PING si no llegaron mensajes, para detectar conexiones caidas
+const redisSubscriptionHealthCheckInterval = 30 * time.Second
+
+type redisSubscription struct {
+	pubsub    *redis.PubSub
+	messages  chan string
+	closeOnce sync.Once
+}
+
+// forward entrega el contenido de los mensajes de Redis hasta que se cierra la suscripcion. No se usa pubsub.Channel porque
+// al caerse la conexion se resuscribe sin avisar (perdiendo los mensajes publicados mientras tanto) y descarta los mensajes
+// si el suscriptor no los consume: en ambos casos se cierra la cola para que el suscriptor lo detecte
+func (rs *redisSubscription) forward() {
+	defer close(rs.messages)
+
+	ctx := context.Background()
+	pingPending := false
+
+	for {
+		received, err := rs.pubsub.ReceiveTimeout(ctx, redisSubscriptionHealthCheckInterval)
+
+		if isTimeout(err) {
+			// Si tampoco llego la respuesta al PING anterior, la conexion se considera caida
+			if pingPending || rs.pubsub.Ping(ctx) != nil {
+				return
+			}
+			pingPending = true
+			continue
+		}
+
+		if err != nil {
+			return
+		}
+
+		pingPending = false
+
+		message, ok := received.(*redis.Message)
+		if !ok {
+			continue
+		}
+
+		select {
+		case rs.messages <- message.Payload:
+		default:
+			return
+		}
+	}
+}
+
+// isTimeout indica si el error es un timeout de lectura de la conexion
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func (rs *redisSubscription) Messages() <-chan string {
+	return rs.messages
+}
+
+func (rs *redisSubscription) Close() error {
+	var err error
+	rs.closeOnce.Do(func() {
+		err = rs.pubsub.Close()
+	})
+
+	return err
+}
+
+func (rc *RedisCache) Close() error {
+	return rc.client.Close()
+}
+
+func toInterfaces(values []string) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, value := range values {
+		result[i] = value
+	}
+
+	return result
+}
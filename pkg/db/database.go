@@ -11,6 +11,7 @@ import (
 
 type Database interface {
 	Connect() (*sql.DB, error) // Connect realiza la conexión a la base de datos.
+	Dialect() string           // Dialect indica el dialecto SQL, define que migraciones se aplican (ver migrations.go)
 }
 
 // CloseDatabase cierra la conexión a la base de datos
@@ -0,0 +1,339 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Las migraciones de cada dialecto se guardan en migrations/<dialecto>/ con el formato
+// <version>_<nombre>.up.sql y <version>_<nombre>.down.sql, y se embeben en el binario
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+const (
+	DialectSQLite   = "sqlite"
+	DialectPostgres = "postgres"
+)
+
+// Clave del advisory lock de PostgreSQL que evita que dos procesos migren la db al mismo tiempo
+const migrationsLockKey = 7311840211
+
+// Migration es un cambio versionado del esquema de la db
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string // Script que aplica el cambio
+	Down    string // Script que revierte el cambio, vacio si la migracion no se puede revertir
+}
+
+// MigrationStatus indica si una migracion fue aplicada y cuando
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+// LoadMigrations obtiene las migraciones del dialecto ordenadas por version
+func LoadMigrations(dialect string) ([]Migration, error) {
+	dir := path.Join("migrations", dialect)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("[x] No migrations for dialect %s", dialect)
+	}
+
+	migrationsByVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		fileName := entry.Name()
+
+		var direction string
+		switch {
+		case strings.HasSuffix(fileName, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(fileName, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		baseName := strings.TrimSuffix(fileName, "."+direction+".sql")
+		versionPart, name, found := strings.Cut(baseName, "_")
+		version, err := strconv.ParseInt(versionPart, 10, 64)
+		if !found || err != nil {
+			return nil, fmt.Errorf("[x] Invalid migration file name: %s", fileName)
+		}
+
+		content, err := migrationFiles.ReadFile(path.Join(dir, fileName))
+		if err != nil {
+			return nil, fmt.Errorf("[x] Error reading migration %s: %v", fileName, err)
+		}
+
+		migration, ok := migrationsByVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			migrationsByVersion[version] = migration
+		} else if migration.Name != name {
+			return nil, fmt.Errorf("[x] Duplicated migration version %d", version)
+		}
+
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(migrationsByVersion))
+	for _, migration := range migrationsByVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("[x] Migration %d has no up script", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// MigrateUp aplica las migraciones pendientes en orden. Con steps > 0 aplica como maximo esa cantidad.
+// Retorna las migraciones aplicadas
+func MigrateUp(db *sql.DB, dialect string, steps int) ([]Migration, error) {
+	migrations, err := LoadMigrations(dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := []Migration{}
+	err = withMigrationsLock(db, dialect, func(conn *sql.Conn) error {
+		appliedVersions, err := getAppliedVersions(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			if steps > 0 && len(applied) >= steps {
+				break
+			}
+
+			if _, ok := appliedVersions[migration.Version]; ok {
+				continue
+			}
+
+			err = runMigration(conn, migration.Up, func(tx *sql.Tx) error {
+				_, err := tx.Exec(`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, migration.Version, migration.Name)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("[x] Error applying migration %d_%s: %v", migration.Version, migration.Name, err)
+			}
+
+			applied = append(applied, migration)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// MigrateDown revierte las ultimas steps migraciones aplicadas, de la mas nueva a la mas vieja.
+// Retorna las migraciones revertidas
+func MigrateDown(db *sql.DB, dialect string, steps int) ([]Migration, error) {
+	migrations, err := LoadMigrations(dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	reverted := []Migration{}
+	err = withMigrationsLock(db, dialect, func(conn *sql.Conn) error {
+		appliedVersions, err := getAppliedVersions(conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := migrations[i]
+			if _, ok := appliedVersions[migration.Version]; !ok {
+				continue
+			}
+
+			if migration.Down == "" {
+				return fmt.Errorf("[x] Migration %d_%s cannot be reverted", migration.Version, migration.Name)
+			}
+
+			err = runMigration(conn, migration.Down, func(tx *sql.Tx) error {
+				_, err := tx.Exec(`DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("[x] Error reverting migration %d_%s: %v", migration.Version, migration.Name, err)
+			}
+
+			reverted = append(reverted, migration)
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// GetMigrationsStatus retorna todas las migraciones del dialecto indicando cuales fueron aplicadas.
+// Solo lee la db: no toma el lock de las migraciones ni crea la tabla schema_migrations (si no existe, ninguna fue aplicada)
+func GetMigrationsStatus(db *sql.DB, dialect string) ([]MigrationStatus, error) {
+	migrations, err := LoadMigrations(dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	appliedVersions := map[int64]time.Time{}
+
+	exists, err := schemaMigrationsExists(db, dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	if exists {
+		appliedVersions, err = getAppliedVersions(db)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		appliedAt, applied := appliedVersions[migration.Version]
+		status := MigrationStatus{Migration: migration, Applied: applied}
+		if applied {
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// CountPendingMigrations retorna la cantidad de migraciones del dialecto que todavia no se aplicaron
+func CountPendingMigrations(db *sql.DB, dialect string) (int, error) {
+	statuses, err := GetMigrationsStatus(db, dialect)
+	if err != nil {
+		return 0, err
+	}
+
+	pending := 0
+	for _, status := range statuses {
+		if !status.Applied {
+			pending++
+		}
+	}
+
+	return pending, nil
+}
+
+// schemaMigrationsExists indica si la tabla schema_migrations fue creada
+func schemaMigrationsExists(db *sql.DB, dialect string) (bool, error) {
+	query := `SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'`
+	if dialect == DialectPostgres {
+		query = `SELECT to_regclass('schema_migrations') IS NOT NULL`
+	}
+
+	var exists bool
+	err := db.QueryRow(query).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("[x] Error checking schema_migrations table: %v", err)
+	}
+
+	return exists, nil
+}
+
+// withMigrationsLock ejecuta fn sobre una unica conexion, con la tabla schema_migrations creada.
+// En PostgreSQL ademas toma un advisory lock para que dos instancias no apliquen las mismas migraciones
+func withMigrationsLock(db *sql.DB, dialect string, fn func(conn *sql.Conn) error) error {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("[x] Error getting connection to migrate: %v", err)
+	}
+	defer conn.Close()
+
+	if dialect == DialectPostgres {
+		_, err = conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationsLockKey)
+		if err != nil {
+			return fmt.Errorf("[x] Error locking migrations: %v", err)
+		}
+		defer conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, migrationsLockKey)
+	}
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+	if err != nil {
+		return fmt.Errorf("[x] Error creating schema_migrations table: %v", err)
+	}
+
+	return fn(conn)
+}
+
+// queryer lo implementan *sql.DB y *sql.Conn
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// getAppliedVersions retorna las versiones aplicadas junto a su fecha de aplicacion
+func getAppliedVersions(conn queryer) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(context.Background(), `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("[x] Error getting applied migrations: %v", err)
+	}
+	defer rows.Close()
+
+	versions := map[int64]time.Time{}
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("[x] Error scanning applied migrations: %v", err)
+		}
+		versions[version] = appliedAt
+	}
+
+	return versions, rows.Err()
+}
+
+// runMigration ejecuta el script y registra el cambio en schema_migrations dentro de una misma transaccion,
+// de esta forma si el script falla la db queda como estaba
+func runMigration(conn *sql.Conn, script string, record func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(script)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = record(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
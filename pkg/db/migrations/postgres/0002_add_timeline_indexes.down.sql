@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_follows_followed_id;
+DROP INDEX IF EXISTS idx_tweets_user_created_at;
@@ -0,0 +1,10 @@
+-- in_reply_to_user_id guarda el autor del tweet respondido para decidir sin joins si la respuesta aparece en un timeline
+ALTER TABLE tweets ADD COLUMN IF NOT EXISTS in_reply_to_tweet_id INTEGER REFERENCES tweets(id) ON DELETE SET NULL;
+ALTER TABLE tweets ADD COLUMN IF NOT EXISTS in_reply_to_user_id INTEGER REFERENCES users(id);
+ALTER TABLE tweets ADD COLUMN IF NOT EXISTS conversation_id INTEGER;
+
+-- Los tweets existentes no son respuestas, cada uno inicia su propia conversacion
+UPDATE tweets SET conversation_id = id WHERE conversation_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tweets_in_reply_to_created_at ON tweets (in_reply_to_tweet_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_tweets_conversation_id ON tweets (conversation_id);
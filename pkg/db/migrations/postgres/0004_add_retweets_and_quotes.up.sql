@@ -0,0 +1,8 @@
+-- Un retweet es un tweet sin contenido propio que referencia al tweet original; una cita tiene contenido y referencia al tweet citado
+ALTER TABLE tweets ADD COLUMN IF NOT EXISTS retweet_of_tweet_id INTEGER REFERENCES tweets(id) ON DELETE CASCADE;
+ALTER TABLE tweets ADD COLUMN IF NOT EXISTS quoted_tweet_id INTEGER REFERENCES tweets(id) ON DELETE SET NULL;
+
+-- Un usuario solo puede retweetear una vez cada tweet
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tweets_user_retweet ON tweets (user_id, retweet_of_tweet_id);
+CREATE INDEX IF NOT EXISTS idx_tweets_retweet_of ON tweets (retweet_of_tweet_id);
+CREATE INDEX IF NOT EXISTS idx_tweets_quoted ON tweets (quoted_tweet_id);
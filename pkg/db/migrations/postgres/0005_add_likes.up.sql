@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS likes (
+	user_id INTEGER NOT NULL REFERENCES users(id),
+	tweet_id INTEGER NOT NULL REFERENCES tweets(id) ON DELETE CASCADE,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY(user_id, tweet_id)
+);
+
+-- Para listar los usuarios que dieron like a un tweet, del mas reciente al mas antiguo
+CREATE INDEX IF NOT EXISTS idx_likes_tweet_created_at ON likes (tweet_id, created_at DESC);
+-- Para listar los tweets a los que un usuario dio like
+CREATE INDEX IF NOT EXISTS idx_likes_user_created_at ON likes (user_id, created_at DESC);
+
+-- Contador de likes del tweet. Con cache, los likes se acumulan en contadores del cache y se vuelcan periodicamente a esta columna
+ALTER TABLE tweets ADD COLUMN IF NOT EXISTS like_count INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS bookmarks (
+	user_id INTEGER NOT NULL REFERENCES users(id),
+	tweet_id INTEGER NOT NULL REFERENCES tweets(id) ON DELETE CASCADE,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY(user_id, tweet_id)
+);
+
+-- Para listar los bookmarks de un usuario del mas reciente al mas antiguo, paginando por cursor
+CREATE INDEX IF NOT EXISTS idx_bookmarks_user_created_at ON bookmarks (user_id, created_at DESC, tweet_id DESC);
+-- Para eliminar los bookmarks de un tweet eliminado
+CREATE INDEX IF NOT EXISTS idx_bookmarks_tweet ON bookmarks (tweet_id);
@@ -0,0 +1,22 @@
+-- Hashtags y menciones del contenido del tweet (JSON con sus posiciones), se calculan al crear el tweet
+ALTER TABLE tweets ADD COLUMN IF NOT EXISTS entities TEXT;
+
+-- Hashtags de cada tweet, normalizados en minusculas. Se guarda la fecha del tweet para listar los tweets de un hashtag usando solo el indice
+CREATE TABLE IF NOT EXISTS tweet_hashtags (
+	tweet_id INTEGER NOT NULL REFERENCES tweets(id) ON DELETE CASCADE,
+	tag TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY(tweet_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tweet_hashtags_tag_created_at ON tweet_hashtags (tag, created_at DESC, tweet_id DESC);
+
+-- Usuarios mencionados en cada tweet (solo las menciones que corresponden a un usuario)
+CREATE TABLE IF NOT EXISTS tweet_mentions (
+	tweet_id INTEGER NOT NULL REFERENCES tweets(id) ON DELETE CASCADE,
+	user_id INTEGER NOT NULL REFERENCES users(id),
+	created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY(tweet_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tweet_mentions_user_created_at ON tweet_mentions (user_id, created_at DESC, tweet_id DESC);
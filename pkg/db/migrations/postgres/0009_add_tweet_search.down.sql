@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_tweets_search_vector;
+ALTER TABLE tweets DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,6 @@
+-- Vector de busqueda de texto del contenido de cada tweet, se calcula al guardar el tweet.
+-- Se usa la configuracion 'simple' (sin stemming) porque los tweets pueden estar en cualquier idioma
+ALTER TABLE tweets ADD COLUMN IF NOT EXISTS search_vector tsvector
+	GENERATED ALWAYS AS (to_tsvector('simple', coalesce(content, ''))) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_tweets_search_vector ON tweets USING GIN (search_vector);
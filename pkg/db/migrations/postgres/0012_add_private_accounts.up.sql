@@ -0,0 +1,12 @@
+-- Los tweets de una cuenta privada solo los ven sus seguidores, y para seguirla hay que enviar una solicitud que la cuenta aprueba
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_private BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS follow_requests (
+	follower_id INTEGER NOT NULL REFERENCES users(id),
+	followed_id INTEGER NOT NULL REFERENCES users(id),
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY(follower_id, followed_id)
+);
+
+-- Para listar las solicitudes pendientes de una cuenta, de la mas reciente a la mas antigua
+CREATE INDEX IF NOT EXISTS idx_follow_requests_followed_created_at ON follow_requests (followed_id, created_at DESC);
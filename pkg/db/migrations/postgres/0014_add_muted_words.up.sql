@@ -0,0 +1,9 @@
+-- Palabras, frases y hashtags silenciados por cada usuario. Los tweets que los contienen se ocultan de su timeline hasta expires_at (NULL si no expiran)
+CREATE TABLE IF NOT EXISTS muted_words (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id),
+	word TEXT NOT NULL,
+	expires_at TIMESTAMP,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE(user_id, word)
+);
@@ -0,0 +1,16 @@
+-- Notificaciones de las acciones de otros usuarios sobre la cuenta o los tweets del usuario (follow, like, reply y mention).
+-- tweet_id es el tweet likeado, la respuesta o el tweet con la mencion, NULL en los follows
+-- group_bucket es la ventana de tiempo de los follows (los de distintas ventanas no se agrupan entre si), 0 en las demas notificaciones
+CREATE TABLE IF NOT EXISTS notifications (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id),
+	actor_id INTEGER NOT NULL REFERENCES users(id),
+	type TEXT NOT NULL,
+	tweet_id INTEGER REFERENCES tweets(id) ON DELETE CASCADE,
+	is_read BOOLEAN NOT NULL DEFAULT FALSE,
+	group_bucket BIGINT NOT NULL DEFAULT 0,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Para listar las notificaciones del usuario y contar las no leidas
+CREATE INDEX IF NOT EXISTS idx_notifications_user_read ON notifications (user_id, is_read, created_at DESC);
@@ -0,0 +1,8 @@
+-- Cuentas cuyos tweets no se distribuyen en los feeds por tener demasiados seguidores (ver FanOutFollowersThreshold).
+-- Se guarda en la db sql porque el set cacheado puede ser desalojado y sin saber que cuentas cambiaron de clase no se
+-- puede recalcular sin eliminar todos los feeds. Se inicializa con el umbral por defecto: con otro -fanout-threshold
+-- cada cuenta se corrige la proxima vez que publica
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_celebrity BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE users SET is_celebrity = TRUE
+WHERE id IN (SELECT followed_id FROM follows GROUP BY followed_id HAVING COUNT(*) > 10000);
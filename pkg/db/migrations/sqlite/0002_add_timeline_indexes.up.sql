@@ -0,0 +1,5 @@
+-- El timeline se filtra por autor y se ordena por fecha e ID
+CREATE INDEX IF NOT EXISTS idx_tweets_user_created_at ON tweets (user_id, created_at DESC, id DESC);
+
+-- La clave primaria de follows cubre las busquedas por seguidor, este indice cubre las busquedas por seguido
+CREATE INDEX IF NOT EXISTS idx_follows_followed_id ON follows (followed_id);
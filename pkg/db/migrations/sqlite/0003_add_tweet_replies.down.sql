@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_tweets_conversation_id;
+DROP INDEX IF EXISTS idx_tweets_in_reply_to_created_at;
+
+ALTER TABLE tweets DROP COLUMN conversation_id;
+ALTER TABLE tweets DROP COLUMN in_reply_to_user_id;
+ALTER TABLE tweets DROP COLUMN in_reply_to_tweet_id;
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_tweets_quoted;
+DROP INDEX IF EXISTS idx_tweets_retweet_of;
+DROP INDEX IF EXISTS idx_tweets_user_retweet;
+
+DELETE FROM tweets WHERE retweet_of_tweet_id IS NOT NULL;
+
+ALTER TABLE tweets DROP COLUMN quoted_tweet_id;
+ALTER TABLE tweets DROP COLUMN retweet_of_tweet_id;
@@ -0,0 +1,5 @@
+ALTER TABLE tweets DROP COLUMN like_count;
+
+DROP INDEX IF EXISTS idx_likes_user_created_at;
+DROP INDEX IF EXISTS idx_likes_tweet_created_at;
+DROP TABLE IF EXISTS likes;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS likes (
+	user_id INTEGER NOT NULL,
+	tweet_id INTEGER NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY(user_id, tweet_id),
+	FOREIGN KEY(user_id) REFERENCES users(id),
+	FOREIGN KEY(tweet_id) REFERENCES tweets(id) ON DELETE CASCADE
+);
+
+-- Para listar los usuarios que dieron like a un tweet, del mas reciente al mas antiguo
+CREATE INDEX IF NOT EXISTS idx_likes_tweet_created_at ON likes (tweet_id, created_at DESC);
+-- Para listar los tweets a los que un usuario dio like
+CREATE INDEX IF NOT EXISTS idx_likes_user_created_at ON likes (user_id, created_at DESC);
+
+-- Contador de likes del tweet. Con cache, los likes se acumulan en contadores del cache y se vuelcan periodicamente a esta columna
+ALTER TABLE tweets ADD COLUMN like_count INTEGER NOT NULL DEFAULT 0;
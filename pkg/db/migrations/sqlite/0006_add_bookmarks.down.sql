@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_bookmarks_tweet;
+DROP INDEX IF EXISTS idx_bookmarks_user_created_at;
+DROP TABLE IF EXISTS bookmarks;
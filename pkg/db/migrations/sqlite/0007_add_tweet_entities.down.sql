@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_tweet_mentions_user_created_at;
+DROP TABLE IF EXISTS tweet_mentions;
+
+DROP INDEX IF EXISTS idx_tweet_hashtags_tag_created_at;
+DROP TABLE IF EXISTS tweet_hashtags;
+
+ALTER TABLE tweets DROP COLUMN entities;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_tweet_hashtags_created_at;
@@ -0,0 +1,2 @@
+-- Para calcular las tendencias sin cache, agregando los hashtags usados en las ultimas horas
+CREATE INDEX IF NOT EXISTS idx_tweet_hashtags_created_at ON tweet_hashtags (created_at);
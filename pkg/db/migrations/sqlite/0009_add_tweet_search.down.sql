@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS tweets_search_after_update;
+DROP TRIGGER IF EXISTS tweets_search_before_update;
+DROP TRIGGER IF EXISTS tweets_search_delete;
+DROP TRIGGER IF EXISTS tweets_search_insert;
+DROP TABLE IF EXISTS tweets_search;
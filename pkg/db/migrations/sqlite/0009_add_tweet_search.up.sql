@@ -0,0 +1,28 @@
+-- Indice de texto del contenido de cada tweet. Se usa FTS4 en lugar de FTS5 porque go-sqlite3 solo incluye FTS5 con el build tag
+-- sqlite_fts5, y sin el la migracion fallaria en las compilaciones y tests que no lo usan. FTS4 se incluye en todas las compilaciones.
+-- Se usa el tokenizer unicode61 sin acentos: la busqueda coincide con palabras completas, sin distinguir mayusculas ni acentos
+CREATE VIRTUAL TABLE IF NOT EXISTS tweets_search USING fts4(
+	content="tweets",
+	content,
+	tokenize=unicode61 "remove_diacritics=2"
+);
+
+INSERT INTO tweets_search (tweets_search) VALUES ('rebuild');
+
+-- La tabla FTS no guarda el contenido (lo lee de tweets), por lo que se mantiene sincronizada con triggers.
+-- Las filas se quitan del indice antes de modificar el tweet, ya que FTS4 lee el contenido anterior desde tweets
+CREATE TRIGGER IF NOT EXISTS tweets_search_insert AFTER INSERT ON tweets BEGIN
+	INSERT INTO tweets_search (docid, content) VALUES (new.id, new.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS tweets_search_delete BEFORE DELETE ON tweets BEGIN
+	DELETE FROM tweets_search WHERE docid = old.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS tweets_search_before_update BEFORE UPDATE OF content ON tweets BEGIN
+	DELETE FROM tweets_search WHERE docid = old.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS tweets_search_after_update AFTER UPDATE OF content ON tweets BEGIN
+	INSERT INTO tweets_search (docid, content) VALUES (new.id, new.content);
+END;
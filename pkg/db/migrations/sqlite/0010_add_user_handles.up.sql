@@ -0,0 +1,7 @@
+-- Nombre de usuario unico (@handle), se guarda sin '@' y en minusculas. A los usuarios existentes se les asigna user_<id>
+ALTER TABLE users ADD COLUMN handle TEXT;
+
+UPDATE users SET handle = 'user_' || id WHERE handle IS NULL;
+
+-- Ademas de garantizar que sea unico, permite buscar usuarios por prefijo del handle
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_handle ON users (handle);
@@ -0,0 +1,4 @@
+ALTER TABLE users DROP COLUMN avatar_url;
+ALTER TABLE users DROP COLUMN website;
+ALTER TABLE users DROP COLUMN location;
+ALTER TABLE users DROP COLUMN bio;
@@ -0,0 +1,14 @@
+-- Los tweets de una cuenta privada solo los ven sus seguidores, y para seguirla hay que enviar una solicitud que la cuenta aprueba
+ALTER TABLE users ADD COLUMN is_private BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS follow_requests (
+	follower_id INTEGER NOT NULL,
+	followed_id INTEGER NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY(follower_id, followed_id),
+	FOREIGN KEY(follower_id) REFERENCES users(id),
+	FOREIGN KEY(followed_id) REFERENCES users(id)
+);
+
+-- Para listar las solicitudes pendientes de una cuenta, de la mas reciente a la mas antigua
+CREATE INDEX IF NOT EXISTS idx_follow_requests_followed_created_at ON follow_requests (followed_id, created_at DESC);
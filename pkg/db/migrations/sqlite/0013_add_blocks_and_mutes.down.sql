@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_mutes_muted;
+DROP TABLE IF EXISTS mutes;
+DROP TABLE IF EXISTS blocks;
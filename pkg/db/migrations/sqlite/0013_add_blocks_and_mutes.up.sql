@@ -0,0 +1,22 @@
+-- Un bloqueo elimina los follows entre ambos usuarios e impide que vuelvan a seguirse
+CREATE TABLE IF NOT EXISTS blocks (
+	blocker_id INTEGER NOT NULL,
+	blocked_id INTEGER NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY(blocker_id, blocked_id),
+	FOREIGN KEY(blocker_id) REFERENCES users(id),
+	FOREIGN KEY(blocked_id) REFERENCES users(id)
+);
+
+-- Un silenciamiento oculta los tweets del usuario silenciado en el timeline, sin dejar de seguirlo
+CREATE TABLE IF NOT EXISTS mutes (
+	muter_id INTEGER NOT NULL,
+	muted_id INTEGER NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY(muter_id, muted_id),
+	FOREIGN KEY(muter_id) REFERENCES users(id),
+	FOREIGN KEY(muted_id) REFERENCES users(id)
+);
+
+-- Para no distribuir los tweets de un usuario en los feeds de quienes lo silenciaron
+CREATE INDEX IF NOT EXISTS idx_mutes_muted ON mutes (muted_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_notifications_user_read;
+DROP TABLE IF EXISTS notifications;
@@ -59,3 +59,9 @@ func (p *PostgresDatabase) Connect() (*sql.DB, error) {
 
 	return db, nil
 }
+
+// Dialect de PostgreSQL. A diferencia de SQLite, las migraciones no se aplican al conectar: se aplican con el
+// subcomando "migrate" (o al iniciar la API con -migrate-on-start) para controlar cuando se modifica el esquema
+func (p *PostgresDatabase) Dialect() string {
+	return DialectPostgres
+}
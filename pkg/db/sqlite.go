@@ -1,22 +1,41 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"net/url"
+	"os"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
-// SQLiteDatabase define la conexión para SQLite
-type SQLiteDatabase struct{}
+// SQLiteDatabase define la conexión para SQLite.
+// Si Path esta vacio la db se crea en memoria, si no se guarda en ese archivo y sobrevive a los reinicios
+type SQLiteDatabase struct {
+	Path           string
+	SkipMigrations bool // No aplica las migraciones al conectar, lo usa el subcomando migrate para controlar cuales se aplican
+}
+
+var (
+	ErrSnapshotExists   = errors.New("Snapshot already exists")
+	ErrSnapshotNotFound = errors.New("Snapshot not found")
+)
 
 func (s *SQLiteDatabase) Connect() (*sql.DB, error) {
 	// Usar SQLite en memoria (se perderá al cerrar la aplicación)
 	connStr := "file::memory:?cache=shared" // Esto crea una base de datos en memoria
-	// Intentar abrir la conexión a la base de datos SQLite en memoria
+	if s.Path != "" {
+		// En disco se usa el modo WAL, que permite leer mientras otra conexion escribe, y se espera
+		// a que se libere el lock en lugar de fallar si dos conexiones escriben al mismo tiempo
+		connStr = fmt.Sprintf("file:%s?_journal_mode=WAL&_busy_timeout=5000", url.PathEscape(s.Path))
+	}
+
+	// Intentar abrir la conexión a la base de datos SQLite
 	db, err := sql.Open("sqlite3", connStr)
 	if err != nil {
-		return nil, fmt.Errorf("[x] DB error to open SQLite in memory: %v", err)
+		return nil, fmt.Errorf("[x] DB error to open SQLite: %v", err)
 	}
 
 	ConfigurePoolConnection(db)
@@ -26,11 +45,15 @@ func (s *SQLiteDatabase) Connect() (*sql.DB, error) {
 		return nil, fmt.Errorf("[x] SQLite DB ping error: %v", err)
 	}
 
-	// Una vez validada la conexión, creo las tablas necesarias para que el proyecto funcione
-	err = createTables(db)
+	// Una vez validada la conexión, aplico las migraciones para crear las tablas necesarias para que el proyecto funcione.
+	// En memoria la db siempre arranca vacia y en disco puede haber quedado en una version anterior,
+	// por eso las migraciones se aplican al conectar
+	if !s.SkipMigrations {
+		_, err = MigrateUp(db, s.Dialect(), 0)
 
-	if err != nil {
-		return nil, fmt.Errorf("[x] SQLite Error creating tables: %v", err)
+		if err != nil {
+			return nil, fmt.Errorf("[x] SQLite Error applying migrations: %v", err)
+		}
 	}
 
 	fmt.Printf("[x] SQLiteDB connection success")
@@ -38,47 +61,87 @@ func (s *SQLiteDatabase) Connect() (*sql.DB, error) {
 	return db, nil
 }
 
-// createTables crea las tablas necesarias en SQLite
-// Nota: Esto solo sirve para levantar la DB en memoria. Si se utiliza PostgreSQL, se deben crear las tablas en su respectiva DB
-func createTables(db *sql.DB) error {
-	_, err := db.Exec(`
-		CREATE TABLE IF NOT EXISTS users (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT NOT NULL,
-			email TEXT NOT NULL UNIQUE,
-			password TEXT NOT NULL,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		);
-	`)
+func (s *SQLiteDatabase) Dialect() string {
+	return DialectSQLite
+}
+
+// IsSQLite indica si la conexion es a una db SQLite
+func IsSQLite(db *sql.DB) bool {
+	_, ok := db.Driver().(*sqlite3.SQLiteDriver)
+	return ok
+}
+
+// SnapshotSQLite copia la db completa (en memoria o en disco) al archivo indicado usando VACUUM INTO.
+// La copia es consistente aunque se este escribiendo en la db, y no pisa un archivo existente
+func SnapshotSQLite(db *sql.DB, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return ErrSnapshotExists
+	}
+
+	_, err := db.Exec(`VACUUM INTO $1`, path)
 	if err != nil {
-		return fmt.Errorf("[x] Error creating users table: %v", err)
+		return fmt.Errorf("[x] Error creating SQLite snapshot: %v", err)
 	}
 
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS tweets (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			user_id INTEGER NOT NULL,
-			content TEXT NOT NULL,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			FOREIGN KEY(user_id) REFERENCES users(id)
-		);
-	`)
+	return nil
+}
+
+// RestoreSQLite reemplaza el contenido de la db por el del snapshot indicado usando la API de backup de SQLite.
+// Luego se aplican las migraciones que falten, por si el snapshot se creo con una version anterior del esquema
+func RestoreSQLite(db *sql.DB, path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return ErrSnapshotNotFound
+	}
+
+	snapshotDb, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro", url.PathEscape(path)))
+	if err != nil {
+		return fmt.Errorf("[x] Error opening SQLite snapshot: %v", err)
+	}
+	defer snapshotDb.Close()
+
+	ctx := context.Background()
+	snapshotConn, err := snapshotDb.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("[x] Error opening SQLite snapshot: %v", err)
+	}
+	defer snapshotConn.Close()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("[x] Error getting connection to restore: %v", err)
+	}
+	defer conn.Close()
+
+	err = conn.Raw(func(destDriverConn any) error {
+		return snapshotConn.Raw(func(srcDriverConn any) error {
+			dest, ok := destDriverConn.(*sqlite3.SQLiteConn)
+			src, srcOk := srcDriverConn.(*sqlite3.SQLiteConn)
+			if !ok || !srcOk {
+				return fmt.Errorf("not a SQLite connection")
+			}
+
+			backup, err := dest.Backup("main", src, "main")
+			if err != nil {
+				return err
+			}
+
+			// Con -1 se copian todas las paginas en un solo paso
+			_, err = backup.Step(-1)
+			if err != nil {
+				backup.Finish()
+				return err
+			}
+
+			return backup.Finish()
+		})
+	})
 	if err != nil {
-		return fmt.Errorf("[x] Error creating tweets table: %v", err)
+		return fmt.Errorf("[x] Error restoring SQLite snapshot: %v", err)
 	}
 
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS follows (
-			follower_id INTEGER NOT NULL,
-			followed_id INTEGER NOT NULL,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			PRIMARY KEY(follower_id, followed_id),
-			FOREIGN KEY(follower_id) REFERENCES users(id),
-			FOREIGN KEY(followed_id) REFERENCES users(id)
-		);
-	`)
+	_, err = MigrateUp(db, DialectSQLite, 0)
 	if err != nil {
-		return fmt.Errorf("[x] Error creating follows table: %v", err)
+		return fmt.Errorf("[x] SQLite Error applying migrations: %v", err)
 	}
 
 	return nil
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSQLiteConnectSkipMigrations verifica que una db en disco revertida con MigrateDown siga revertida al reconectar con
+// SkipMigrations (como hace el subcomando migrate), y que sin SkipMigrations se vuelvan a aplicar las migraciones pendientes
+func TestSQLiteConnectSkipMigrations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	migrations, err := LoadMigrations(DialectSQLite)
+	assert.NoError(t, err)
+
+	database := &SQLiteDatabase{Path: path}
+	conn, err := database.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	pending, err := CountPendingMigrations(conn, DialectSQLite)
+	assert.NoError(t, err)
+	assert.Zero(t, pending)
+
+	reverted, err := MigrateDown(conn, DialectSQLite, 2)
+	assert.NoError(t, err)
+	assert.Len(t, reverted, 2)
+	assert.NoError(t, conn.Close())
+
+	database = &SQLiteDatabase{Path: path, SkipMigrations: true}
+	conn, err = database.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	statuses, err := GetMigrationsStatus(conn, DialectSQLite)
+	assert.NoError(t, err)
+	for i, status := range statuses {
+		assert.Equal(t, i < len(migrations)-2, status.Applied, status.Name)
+	}
+
+	// Aplicar un paso solo aplica la primera migracion pendiente
+	applied, err := MigrateUp(conn, DialectSQLite, 1)
+	assert.NoError(t, err)
+	assert.Len(t, applied, 1)
+	assert.Equal(t, migrations[len(migrations)-2].Version, applied[0].Version)
+	assert.NoError(t, conn.Close())
+
+	database = &SQLiteDatabase{Path: path}
+	conn, err = database.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+	defer conn.Close()
+
+	pending, err = CountPendingMigrations(conn, DialectSQLite)
+	assert.NoError(t, err)
+	assert.Zero(t, pending)
+}
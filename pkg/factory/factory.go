@@ -3,16 +3,28 @@ package factory
 import (
 	"fmt"
 
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
 	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/db"
 	redisdb "github.com/MauricioGiaconia/uala_backend_challenge/pkg/redis_db"
-	"github.com/redis/go-redis/v9"
 )
 
+// CacheOptions agrupa la configuracion de los distintos tipos de cache
+type CacheOptions struct {
+	Redis            redisdb.Config // Configuracion de la conexion a Redis
+	MemoryMaxEntries int            // Cantidad maxima de claves del cache en memoria
+}
+
+// DatabaseOptions agrupa la configuracion de los distintos tipos de base de datos
+type DatabaseOptions struct {
+	SQLitePath           string // Archivo de la db SQLite, vacio para usar una db en memoria
+	SQLiteSkipMigrations bool   // No aplica las migraciones de SQLite al conectar
+}
+
 // GetDatabase crea una instancia de la base de datos que se solicite (SQLite o PostgreSQL)
-func GetDatabase(dbType string) (db.Database, error) {
+func GetDatabase(dbType string, options DatabaseOptions) (db.Database, error) {
 	switch dbType {
 	case "sqlite":
-		return &db.SQLiteDatabase{}, nil
+		return &db.SQLiteDatabase{Path: options.SQLitePath, SkipMigrations: options.SQLiteSkipMigrations}, nil
 	case "postgres":
 		return &db.PostgresDatabase{}, nil
 	default:
@@ -20,15 +32,17 @@ func GetDatabase(dbType string) (db.Database, error) {
 	}
 }
 
-// GetCache crea una instancia del cache solicitado (Redis)
-func GetCache(cacheType string) (*redis.Client, error) {
+// GetCache crea una instancia del cache solicitado (Redis o en memoria)
+func GetCache(cacheType string, options CacheOptions) (cache.Cache, error) {
 	switch cacheType {
 	case "redis":
-		client, err := redisdb.NewRedisClient("tes", "test", 0)
+		client, err := redisdb.NewRedisClient(options.Redis)
 		if err != nil {
 			return nil, err
 		}
-		return client, nil
+		return cache.NewRedisCache(client), nil
+	case "memory":
+		return cache.NewMemoryCache(options.MemoryMaxEntries), nil
 	default:
 		return nil, fmt.Errorf("[x] Invalid cache type: %s", cacheType)
 	}
@@ -0,0 +1,258 @@
+package redisdb
+
+import (
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultAddr es la direccion que se usa si no se configura ninguna
+const DefaultAddr = "localhost:6379"
+
+// Config agrupa la configuracion de la conexion a Redis. Los campos vacios (valor cero) toman el valor
+// de la URL si se indico una y, si no, el valor por defecto del cliente de Redis
+type Config struct {
+	URL                   string        // redis://, rediss://, redis+sentinel:// o redis+cluster:// (ver parseURL)
+	Addrs                 []string      // host:port del servidor, de los nodos del cluster o de los sentinels
+	MasterName            string        // Nombre del master en Sentinel, si se indica se conecta a traves de Sentinel
+	Cluster               bool          // Fuerza el modo cluster aunque se indique una unica direccion
+	Username              string        // Usuario (ACL de Redis 6+)
+	Password              string        // Password del servidor (o del master en Sentinel)
+	SentinelPassword      string        // Password de los sentinels
+	DB                    int           // Indice de la db, no se admite en Cluster
+	TLS                   bool          // Conectar usando TLS
+	TLSInsecureSkipVerify bool          // No validar el certificado del servidor (solo para pruebas)
+	PoolSize              int           // Cantidad maxima de conexiones por nodo
+	DialTimeout           time.Duration // Tiempo maximo para establecer una conexion
+	ReadTimeout           time.Duration // Tiempo maximo de espera de una respuesta
+	WriteTimeout          time.Duration // Tiempo maximo para enviar un comando
+}
+
+// ConfigFromEnv obtiene la configuracion de las variables de entorno (o del archivo .env si existe):
+// REDIS_URL, REDIS_ADDR (direcciones separadas por coma), REDIS_MASTER_NAME, REDIS_CLUSTER, REDIS_USERNAME,
+// REDIS_PASSWORD, REDIS_SENTINEL_PASSWORD, REDIS_DB, REDIS_TLS, REDIS_TLS_INSECURE_SKIP_VERIFY, REDIS_POOL_SIZE,
+// REDIS_DIAL_TIMEOUT, REDIS_READ_TIMEOUT y REDIS_WRITE_TIMEOUT (los tiempos con el formato de Go, por ejemplo 5s)
+func ConfigFromEnv() (Config, error) {
+	// El archivo .env es opcional, las variables pueden estar definidas en el entorno
+	_ = godotenv.Load()
+
+	config := Config{
+		URL:              os.Getenv("REDIS_URL"),
+		Addrs:            splitAddrs(os.Getenv("REDIS_ADDR")),
+		MasterName:       os.Getenv("REDIS_MASTER_NAME"),
+		Username:         os.Getenv("REDIS_USERNAME"),
+		Password:         os.Getenv("REDIS_PASSWORD"),
+		SentinelPassword: os.Getenv("REDIS_SENTINEL_PASSWORD"),
+	}
+
+	var err error
+	if config.Cluster, err = envBool("REDIS_CLUSTER"); err != nil {
+		return Config{}, err
+	}
+	if config.DB, err = envInt("REDIS_DB"); err != nil {
+		return Config{}, err
+	}
+	if config.TLS, err = envBool("REDIS_TLS"); err != nil {
+		return Config{}, err
+	}
+	if config.TLSInsecureSkipVerify, err = envBool("REDIS_TLS_INSECURE_SKIP_VERIFY"); err != nil {
+		return Config{}, err
+	}
+	if config.PoolSize, err = envInt("REDIS_POOL_SIZE"); err != nil {
+		return Config{}, err
+	}
+	if config.DialTimeout, err = envDuration("REDIS_DIAL_TIMEOUT"); err != nil {
+		return Config{}, err
+	}
+	if config.ReadTimeout, err = envDuration("REDIS_READ_TIMEOUT"); err != nil {
+		return Config{}, err
+	}
+	if config.WriteTimeout, err = envDuration("REDIS_WRITE_TIMEOUT"); err != nil {
+		return Config{}, err
+	}
+
+	return config, nil
+}
+
+// BindFlags registra las flags -redis-* usando como valor por defecto la configuracion recibida
+// (normalmente la de las variables de entorno), de esta forma las flags tienen prioridad sobre el entorno
+func BindFlags(fs *flag.FlagSet, config *Config) {
+	fs.StringVar(&config.URL, "redis-url", config.URL, "URL de conexion a Redis (redis://, rediss://, redis+sentinel://, redis+cluster://)")
+	fs.Var((*addrsValue)(&config.Addrs), "redis-addr", "Direcciones host:port de Redis separadas por coma (varias direcciones = Cluster o Sentinels)")
+	fs.StringVar(&config.MasterName, "redis-master-name", config.MasterName, "Nombre del master de Redis Sentinel")
+	fs.BoolVar(&config.Cluster, "redis-cluster", config.Cluster, "Conectar a Redis Cluster aunque se indique una unica direccion")
+	fs.StringVar(&config.Username, "redis-username", config.Username, "Usuario de Redis")
+	fs.StringVar(&config.Password, "redis-password", config.Password, "Password de Redis")
+	fs.StringVar(&config.SentinelPassword, "redis-sentinel-password", config.SentinelPassword, "Password de Redis Sentinel")
+	fs.IntVar(&config.DB, "redis-db", config.DB, "Indice de la db de Redis")
+	fs.BoolVar(&config.TLS, "redis-tls", config.TLS, "Conectar a Redis usando TLS")
+	fs.BoolVar(&config.TLSInsecureSkipVerify, "redis-tls-insecure-skip-verify", config.TLSInsecureSkipVerify, "No validar el certificado TLS de Redis")
+	fs.IntVar(&config.PoolSize, "redis-pool-size", config.PoolSize, "Cantidad maxima de conexiones a Redis por nodo")
+	fs.DurationVar(&config.DialTimeout, "redis-dial-timeout", config.DialTimeout, "Tiempo maximo para conectar a Redis")
+	fs.DurationVar(&config.ReadTimeout, "redis-read-timeout", config.ReadTimeout, "Tiempo maximo de espera de una respuesta de Redis")
+	fs.DurationVar(&config.WriteTimeout, "redis-write-timeout", config.WriteTimeout, "Tiempo maximo para enviar un comando a Redis")
+}
+
+// addrsValue permite recibir las direcciones por flag como una lista separada por coma
+type addrsValue []string
+
+func (a *addrsValue) String() string {
+	return strings.Join(*a, ",")
+}
+
+func (a *addrsValue) Set(value string) error {
+	*a = splitAddrs(value)
+	return nil
+}
+
+func splitAddrs(value string) []string {
+	addrs := []string{}
+	for _, addr := range strings.Split(value, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+
+	if len(addrs) == 0 {
+		return nil
+	}
+
+	return addrs
+}
+
+// parseURL interpreta las URLs de conexion. Se admiten varias direcciones separadas por coma:
+//
+//	redis://[usuario:password@]host:port[,host:port...][/db]     servidor unico (o cluster si hay varias direcciones)
+//	rediss://...                                                 igual que redis:// pero usando TLS
+//	redis+sentinel://[:password@]sentinel:port[,...][/db]?master=nombre[&sentinel_password=...]
+//	redis+cluster://[usuario:password@]nodo:port[,nodo:port...]
+//
+// Los esquemas rediss+sentinel:// y rediss+cluster:// usan TLS. Tambien se admiten los parametros
+// pool_size, dial_timeout, read_timeout y write_timeout
+func parseURL(rawURL string) (*redis.UniversalOptions, bool, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, false, fmt.Errorf("[x] Invalid Redis URL: %v", err)
+	}
+
+	scheme, mode, _ := strings.Cut(parsedURL.Scheme, "+")
+	if (scheme != "redis" && scheme != "rediss") || (mode != "" && mode != "sentinel" && mode != "cluster") {
+		return nil, false, fmt.Errorf("[x] Invalid Redis URL scheme: %s", parsedURL.Scheme)
+	}
+
+	defaultPort := "6379"
+	if mode == "sentinel" {
+		defaultPort = "26379"
+	}
+
+	options := &redis.UniversalOptions{Protocol: 2}
+	for _, host := range splitAddrs(parsedURL.Host) {
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			host = net.JoinHostPort(host, defaultPort)
+		}
+		options.Addrs = append(options.Addrs, host)
+	}
+
+	if len(options.Addrs) == 0 {
+		return nil, false, fmt.Errorf("[x] Redis URL requires at least one host")
+	}
+
+	if parsedURL.User != nil {
+		options.Username = parsedURL.User.Username()
+		options.Password, _ = parsedURL.User.Password()
+	}
+
+	if db := strings.Trim(parsedURL.Path, "/"); db != "" {
+		options.DB, err = strconv.Atoi(db)
+		if err != nil {
+			return nil, false, fmt.Errorf("[x] Invalid Redis DB in URL: %s", db)
+		}
+	}
+
+	if scheme == "rediss" {
+		hostname, _, _ := net.SplitHostPort(options.Addrs[0])
+		options.TLSConfig = &tls.Config{ServerName: hostname, MinVersion: tls.VersionTLS12}
+	}
+
+	query := parsedURL.Query()
+	options.MasterName = query.Get("master")
+	options.SentinelPassword = query.Get("sentinel_password")
+
+	if mode == "sentinel" && options.MasterName == "" {
+		return nil, false, fmt.Errorf("[x] Redis Sentinel URL requires the master parameter")
+	}
+	if mode != "sentinel" && options.MasterName != "" {
+		return nil, false, fmt.Errorf("[x] The master parameter is only valid for redis+sentinel URLs")
+	}
+
+	if value := query.Get("pool_size"); value != "" {
+		if options.PoolSize, err = strconv.Atoi(value); err != nil {
+			return nil, false, fmt.Errorf("[x] Invalid pool_size in Redis URL: %s", value)
+		}
+	}
+
+	for name, target := range map[string]*time.Duration{
+		"dial_timeout":  &options.DialTimeout,
+		"read_timeout":  &options.ReadTimeout,
+		"write_timeout": &options.WriteTimeout,
+	} {
+		if value := query.Get(name); value != "" {
+			if *target, err = time.ParseDuration(value); err != nil {
+				return nil, false, fmt.Errorf("[x] Invalid %s in Redis URL: %s", name, value)
+			}
+		}
+	}
+
+	return options, mode == "cluster", nil
+}
+
+func envBool(name string) (bool, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return false, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("[x] Invalid %s: %s", name, value)
+	}
+
+	return parsed, nil
+}
+
+func envInt(name string) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("[x] Invalid %s: %s", name, value)
+	}
+
+	return parsed, nil
+}
+
+func envDuration(name string) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("[x] Invalid %s: %s", name, value)
+	}
+
+	return parsed, nil
+}
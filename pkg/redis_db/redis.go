@@ -2,27 +2,110 @@ package redisdb
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
-var ctx = context.Background()
-
-func NewRedisClient(addr string, password string, db int) (*redis.Client, error) {
+// NewRedisClient crea el cliente segun la configuracion recibida: Sentinel si se indica el nombre del master,
+// Cluster si se indican varias direcciones (o se fuerza el modo cluster) y, si no, un servidor unico
+func NewRedisClient(config Config) (redis.UniversalClient, error) {
+	options, isCluster, err := config.clientOptions()
+	if err != nil {
+		return nil, err
+	}
 
-	client := redis.NewClient(&redis.Options{
-		Addr:     "localhost:6379",
-		Password: "",
-		DB:       0,
-		Protocol: 2,
-	})
+	var client redis.UniversalClient
+	if isCluster {
+		client = redis.NewClusterClient(options.Cluster())
+	} else {
+		client = redis.NewUniversalClient(options)
+	}
 
 	// Probar la conexión
-	_, err := client.Ping(ctx).Result()
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout(options))
+	defer cancel()
+
+	_, err = client.Ping(ctx).Result()
 	if err != nil {
+		client.Close()
 		return nil, fmt.Errorf("error conectando a Redis: %v", err)
 	}
 
 	return client, nil
 }
+
+// clientOptions arma las opciones del cliente e indica si se debe usar el modo cluster. Los valores de la URL
+// se usan como base y los campos de la configuracion que no estan vacios los reemplazan
+func (c Config) clientOptions() (*redis.UniversalOptions, bool, error) {
+	options := &redis.UniversalOptions{Protocol: 2}
+
+	if c.URL != "" {
+		urlOptions, isClusterURL, err := parseURL(c.URL)
+		if err != nil {
+			return nil, false, err
+		}
+		options = urlOptions
+		c.Cluster = c.Cluster || isClusterURL
+	}
+
+	if len(c.Addrs) > 0 {
+		options.Addrs = c.Addrs
+	}
+	if len(options.Addrs) == 0 {
+		options.Addrs = []string{DefaultAddr}
+	}
+
+	if c.MasterName != "" {
+		options.MasterName = c.MasterName
+	}
+	if c.Username != "" {
+		options.Username = c.Username
+	}
+	if c.Password != "" {
+		options.Password = c.Password
+	}
+	if c.SentinelPassword != "" {
+		options.SentinelPassword = c.SentinelPassword
+	}
+	if c.DB != 0 {
+		options.DB = c.DB
+	}
+	if c.PoolSize != 0 {
+		options.PoolSize = c.PoolSize
+	}
+	if c.DialTimeout != 0 {
+		options.DialTimeout = c.DialTimeout
+	}
+	if c.ReadTimeout != 0 {
+		options.ReadTimeout = c.ReadTimeout
+	}
+	if c.WriteTimeout != 0 {
+		options.WriteTimeout = c.WriteTimeout
+	}
+
+	if c.TLS && options.TLSConfig == nil {
+		options.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if c.TLSInsecureSkipVerify && options.TLSConfig != nil {
+		options.TLSConfig.InsecureSkipVerify = true
+	}
+
+	// Con Sentinel se ignora el modo cluster, el cliente se conecta al master que indiquen los sentinels
+	isCluster := c.Cluster && options.MasterName == ""
+	if isCluster && options.DB != 0 {
+		return nil, false, fmt.Errorf("[x] Redis Cluster only supports DB 0")
+	}
+
+	return options, isCluster, nil
+}
+
+// pingTimeout es el tiempo maximo para validar la conexion al iniciar la API
+func pingTimeout(options *redis.UniversalOptions) time.Duration {
+	if options.DialTimeout > 0 {
+		return 2 * options.DialTimeout
+	}
+	return 10 * time.Second
+}
@@ -15,13 +15,14 @@ type SuccessResponse struct {
 }
 
 type SuccessListResponse struct {
-	Code     int64       `json:"code"`               // Status http
-	Data     interface{} `json:"data"`               // Informacion de la respuesta
-	Count    int64       `json:"count"`              // Total de elementos existentes en la BD
-	Limit    int64       `json:"limit"`              // Maximo de elementos que se obtiene por pagina
-	Offset   int64       `json:"offset"`             // Paginado
-	Next     string      `json:"next,omitempty"`     // Campo para saber cual es el siguiente indice a consultar, sirve para el paginado
-	Previous string      `json:"previous,omitempty"` // Campo para saber el indice anterior a consultar, sirve para paginado
+	Code       int64       `json:"code"`                  // Status http
+	Data       interface{} `json:"data"`                  // Informacion de la respuesta
+	Count      int64       `json:"count"`                 // Total de elementos existentes en la BD
+	Limit      int64       `json:"limit"`                 // Maximo de elementos que se obtiene por pagina
+	Offset     int64       `json:"offset"`                // Paginado
+	Next       string      `json:"next,omitempty"`        // Campo para saber cual es el siguiente indice a consultar, sirve para el paginado
+	Previous   string      `json:"previous,omitempty"`    // Campo para saber el indice anterior a consultar, sirve para paginado
+	NextCursor string      `json:"next_cursor,omitempty"` // Cursor opaco para obtener la siguiente pagina con paginado por cursor
 }
 
 type ErrorResponse struct {
@@ -117,6 +118,31 @@ func ResponseToApi(code int64, data interface{}, isAList bool, count int64, limi
 	return successResponse
 }
 
+// Metodo encargado de construir la respuesta de los listados que soportan paginado por cursor.
+// Si "cursor" viene vacio, la pagina se obtuvo por offset y se mantienen los campos next/previous de siempre;
+// en ambos casos se informa el cursor de la siguiente pagina para que el cliente pueda pasar a paginar por cursor
+func ResponseCursorListToApi(code int64, data interface{}, count int64, limit int64, offset int64, cursor string, nextCursor string) interface{} {
+	response := ResponseToApi(code, data, true, count, limit, offset)
+
+	listResponse, ok := response.(SuccessListResponse)
+	if !ok {
+		return response
+	}
+
+	listResponse.NextCursor = nextCursor
+
+	if cursor != "" {
+		listResponse.Offset = 0
+		listResponse.Previous = ""
+		listResponse.Next = ""
+		if nextCursor != "" {
+			listResponse.Next = fmt.Sprintf("?limit=%d&cursor=%s", limit, nextCursor)
+		}
+	}
+
+	return listResponse
+}
+
 func buildErrorResponse(code int64, data interface{}) ErrorResponse {
 	if str, ok := data.(string); ok {
 		return ErrorResponse{
@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EncodeCursor genera un cursor opaco a partir de la fecha y el ID del ultimo elemento de una pagina.
+// El cliente solo debe reenviar el cursor recibido, sin interpretar su contenido
+func EncodeCursor(createdAt time.Time, id int64) string {
+	raw := fmt.Sprintf("%d:%d", createdAt.UnixNano(), id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor obtiene la fecha y el ID codificados en un cursor generado por EncodeCursor
+func DecodeCursor(cursor string) (time.Time, int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("Invalid cursor")
+	}
+
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 2 {
+		return time.Time{}, 0, fmt.Errorf("Invalid cursor")
+	}
+
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("Invalid cursor")
+	}
+
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || id <= 0 {
+		return time.Time{}, 0, fmt.Errorf("Invalid cursor")
+	}
+
+	return time.Unix(0, nanos).UTC(), id, nil
+}
@@ -7,4 +7,4 @@ redis-server &
 sleep 2
 
 # Iniciar API
-/bin/api --db sqlite
\ No newline at end of file
+/bin/api --db sqlite --cache redis
\ No newline at end of file
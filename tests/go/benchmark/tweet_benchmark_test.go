@@ -11,6 +11,8 @@ import (
 	"testing"
 
 	"github.com/MauricioGiaconia/uala_backend_challenge/internal/routes"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/auth"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
 	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/factory"
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
@@ -23,12 +25,12 @@ type CreateTweetRequest struct {
 
 func BenchmarkTweetCreation(b *testing.B) {
 	// Configuración de la base de datos y el mock de Redis
-	db, err := factory.GetDatabase("postgres")
+	db, err := factory.GetDatabase("postgres", factory.DatabaseOptions{})
 	if err != nil {
 		b.Fatalf("failed to create DB: %v", err)
 	}
 
-	rdb := getMockBenchmarkRedis()
+	cacheClient := getMockBenchmarkCache()
 
 	conn, err := db.Connect()
 	if err != nil {
@@ -36,7 +38,7 @@ func BenchmarkTweetCreation(b *testing.B) {
 	}
 	defer conn.Close()
 
-	router := setupTweetRouter(conn, rdb)
+	router := setupTweetRouter(conn, cacheClient)
 
 	// Crear un usuario de prueba
 	userPayload := map[string]interface{}{
@@ -45,22 +47,23 @@ func BenchmarkTweetCreation(b *testing.B) {
 		"password": "1223",
 	}
 	makeRequest(b, "POST", "/users/create", userPayload, router)
+	token := loginUser(b, "maurigiaconia@hotmail.com", "1223", router)
 
 	// Benchmarking del endpoint de creación de tweets
 	b.ResetTimer() // Restablece el temporizador para evitar medir el tiempo de configuración
 	for i := 0; i < b.N; i++ {
 		tweetPayload := CreateTweetRequest{Content: "Tweet para benchmark", UserID: 1}
-		makeRequest(b, "POST", "/tweets/create", tweetPayload, router)
+		makeAuthRequest(b, "POST", "/tweets/create", tweetPayload, token, router)
 	}
 }
 
 func BenchmarkGetTimeline(b *testing.B) {
 	// Configuración de la base de datos y el mock de Redis
-	db, err := factory.GetDatabase("postgres")
+	db, err := factory.GetDatabase("postgres", factory.DatabaseOptions{})
 	if err != nil {
 		b.Fatalf("failed to create DB: %v", err)
 	}
-	rdb := getMockBenchmarkRedis()
+	cacheClient := getMockBenchmarkCache()
 
 	conn, err := db.Connect()
 	if err != nil {
@@ -68,7 +71,7 @@ func BenchmarkGetTimeline(b *testing.B) {
 	}
 	defer conn.Close()
 
-	router := setupTweetRouter(conn, rdb)
+	router := setupTweetRouter(conn, cacheClient)
 
 	// Crear un usuario de prueba
 	userPayload := map[string]interface{}{
@@ -77,19 +80,31 @@ func BenchmarkGetTimeline(b *testing.B) {
 		"password": "1223",
 	}
 	makeRequest(b, "POST", "/users/create", userPayload, router)
+	token := loginUser(b, "maurigiaconia@hotmail.com", "1223", router)
 
 	// Crear un tweet
 	tweetPayload := CreateTweetRequest{Content: "Tweet para obtener timeline y realizar el benchmark", UserID: 1}
-	makeRequest(b, "POST", "/tweets/create", tweetPayload, router)
+	makeAuthRequest(b, "POST", "/tweets/create", tweetPayload, token, router)
+
+	// El timeline solo puede consultarlo su propio usuario
+	followerToken, err := auth.GenerateToken(3, auth.AccessTokenType, auth.AccessTokenTTL)
+	if err != nil {
+		b.Fatalf("failed to generate token: %v", err)
+	}
 
 	// Benchmarking del endpoint de obtención de timeline
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		makeRequest(b, "GET", "/tweets/3/timeline", nil, router)
+		makeAuthRequest(b, "GET", "/tweets/3/timeline", nil, followerToken, router)
 	}
 }
 
 func makeRequest(b *testing.B, method, url string, body interface{}, router *gin.Engine) *httptest.ResponseRecorder {
+	return makeAuthRequest(b, method, url, body, "", router)
+}
+
+// Realiza una request enviando el token de acceso en el header Authorization (si no es vacio)
+func makeAuthRequest(b *testing.B, method, url string, body interface{}, token string, router *gin.Engine) *httptest.ResponseRecorder {
 	var requestBody []byte
 	if body != nil {
 		var err error
@@ -104,6 +119,9 @@ func makeRequest(b *testing.B, method, url string, body interface{}, router *gin
 		b.Fatalf("Error creating request: %v", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
@@ -111,7 +129,27 @@ func makeRequest(b *testing.B, method, url string, body interface{}, router *gin
 	return w
 }
 
-func getMockBenchmarkRedis() *redis.Client {
+// Inicia sesion con el usuario indicado y devuelve su access token
+func loginUser(b *testing.B, email, password string, router *gin.Engine) string {
+	w := makeRequest(b, "POST", "/auth/login", map[string]interface{}{"email": email, "password": password}, router)
+	if w.Code != http.StatusOK {
+		b.Fatalf("Error logging in: %s", w.Body.String())
+	}
+
+	var response struct {
+		Data struct {
+			AccessToken string `json:"accessToken"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		b.Fatalf("Error decoding login response: %v", err)
+	}
+
+	return response.Data.AccessToken
+}
+
+// getMockBenchmarkCache usa Redis si hay un servidor disponible y, si no, el cache en memoria
+func getMockBenchmarkCache() cache.Cache {
 
 	var ctx = context.Background()
 
@@ -124,15 +162,15 @@ func getMockBenchmarkRedis() *redis.Client {
 
 	_, err := client.Ping(ctx).Result()
 	if err != nil {
-		fmt.Printf("error conectando a Redis: %v\n", err)
-		return nil
+		fmt.Printf("error conectando a Redis, se usa el cache en memoria: %v\n", err)
+		return cache.NewMemoryCache(cache.DefaultMemoryCacheSize)
 	}
 
-	return client
+	return cache.NewRedisCache(client)
 }
 
-func setupTweetRouter(db *sql.DB, rdb *redis.Client) *gin.Engine {
+func setupTweetRouter(db *sql.DB, cacheClient cache.Cache) *gin.Engine {
 	router := gin.Default()
-	routes.SetupRoutes(router, db, rdb)
+	routes.SetupRoutes(router, db, cacheClient)
 	return router
 }
@@ -3,6 +3,7 @@ package benchmark
 import (
 	"testing"
 
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/auth"
 	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/factory"
 )
 
@@ -13,11 +14,11 @@ type CreateTweetRoutineRequest struct {
 
 func BenchmarkGetRoutineTimeline(b *testing.B) {
 	// Configuración de la base de datos y el mock de Redis
-	db, err := factory.GetDatabase("postgres")
+	db, err := factory.GetDatabase("postgres", factory.DatabaseOptions{})
 	if err != nil {
 		b.Fatalf("failed to create DB: %v", err)
 	}
-	rdb := getMockBenchmarkRedis()
+	cacheClient := getMockBenchmarkCache()
 
 	conn, err := db.Connect()
 	if err != nil {
@@ -25,7 +26,7 @@ func BenchmarkGetRoutineTimeline(b *testing.B) {
 	}
 	defer conn.Close()
 
-	router := setupTweetRouter(conn, rdb)
+	router := setupTweetRouter(conn, cacheClient)
 
 	// Crear un usuario de prueba
 	userPayload := map[string]interface{}{
@@ -34,14 +35,21 @@ func BenchmarkGetRoutineTimeline(b *testing.B) {
 		"password": "1223",
 	}
 	makeRequest(b, "POST", "/users/create", userPayload, router)
+	token := loginUser(b, "maurigiaconia@hotmail.com", "1223", router)
 
 	// Crear un tweet
 	tweetPayload := CreateTweetRoutineRequest{Content: "Tweet para obtener timeline y realizar el benchmark", UserID: 1}
-	makeRequest(b, "POST", "/tweets/create", tweetPayload, router)
+	makeAuthRequest(b, "POST", "/tweets/create", tweetPayload, token, router)
+
+	// El timeline solo puede consultarlo su propio usuario
+	followerToken, err := auth.GenerateToken(3, auth.AccessTokenType, auth.AccessTokenTTL)
+	if err != nil {
+		b.Fatalf("failed to generate token: %v", err)
+	}
 
 	// Benchmarking del endpoint de obtención de timeline
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		makeRequest(b, "GET", "/tweets/3/routine_timeline", nil, router)
+		makeAuthRequest(b, "GET", "/tweets/3/routine_timeline", nil, followerToken, router)
 	}
 }
@@ -0,0 +1,139 @@
+package functional
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/factory"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type LoginResponse struct {
+	Code int               `json:"code"`
+	Data models.AuthTokens `json:"data"`
+}
+
+func TestLoginAndProtectedEndpoints(t *testing.T) {
+	db, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{})
+	if err != nil {
+		t.Fatalf("failed to create DB: %v", err)
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	defer conn.Close()
+
+	router := setupTweetRouter(conn, nil)
+
+	w := makeRequest(t, "POST", "/users/create", map[string]interface{}{"name": "Mauricio Giaconia", "email": "maurigiaconia@hotmail.com", "password": "1223"}, router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	// La contraseña no se guarda en texto plano
+	var storedPassword string
+	err = conn.QueryRow(`SELECT password FROM users WHERE id = 1`).Scan(&storedPassword)
+	assert.NoError(t, err)
+	assert.NotEqual(t, "1223", storedPassword)
+
+	requests := []struct {
+		payload  map[string]interface{}
+		expected int
+		message  string
+	}{
+		{map[string]interface{}{"email": "maurigiaconia@hotmail.com", "password": "incorrecta"}, http.StatusUnauthorized, "Invalid email or password"},
+		{map[string]interface{}{"email": "inexistente@hotmail.com", "password": "1223"}, http.StatusUnauthorized, "Invalid email or password"},
+		{map[string]interface{}{"email": "maurigiaconia@hotmail.com"}, http.StatusBadRequest, "Email and password are required"},
+	}
+
+	for _, tc := range requests {
+		w = makeRequest(t, "POST", "/auth/login", tc.payload, router)
+		assert.Equal(t, int64(tc.expected), int64(w.Code))
+
+		var errorResponse utils.ErrorResponse
+		err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.message, errorResponse.Error)
+	}
+
+	w = makeRequest(t, "POST", "/auth/login", map[string]interface{}{"email": "maurigiaconia@hotmail.com", "password": "1223"}, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	var loginResponse LoginResponse
+	err = json.Unmarshal(w.Body.Bytes(), &loginResponse)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, loginResponse.Data.AccessToken)
+	assert.NotEmpty(t, loginResponse.Data.RefreshToken)
+
+	// Sin token, con un token invalido o con el refresh token no se puede postear
+	for _, token := range []string{"", "invalido", loginResponse.Data.RefreshToken} {
+		w = makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Tweet sin autenticar"}, token, router)
+		assert.Equal(t, int64(http.StatusUnauthorized), int64(w.Code))
+	}
+
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Tweet autenticado"}, loginResponse.Data.AccessToken, router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	// El refresh token permite obtener un nuevo token de acceso, pero el token de acceso no sirve para renovar
+	w = makeRequest(t, "POST", "/auth/refresh", map[string]interface{}{"refreshToken": loginResponse.Data.AccessToken}, router)
+	assert.Equal(t, int64(http.StatusUnauthorized), int64(w.Code))
+
+	w = makeRequest(t, "POST", "/auth/refresh", map[string]interface{}{"refreshToken": loginResponse.Data.RefreshToken}, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	var refreshResponse LoginResponse
+	err = json.Unmarshal(w.Body.Bytes(), &refreshResponse)
+	assert.NoError(t, err)
+
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Tweet con token renovado"}, refreshResponse.Data.AccessToken, router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+}
+
+// loginUser obtiene el token de acceso de un usuario ya creado
+func loginUser(t *testing.T, email string, password string, router *gin.Engine) string {
+	w := makeRequest(t, "POST", "/auth/login", map[string]interface{}{"email": email, "password": password}, router)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Error logging in %s: %s", email, w.Body.String())
+	}
+
+	var response LoginResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error decoding login response: %v", err)
+	}
+
+	return response.Data.AccessToken
+}
+
+// makeAuthRequest realiza una request enviando el token de acceso en el header Authorization
+func makeAuthRequest(t *testing.T, method, url string, body interface{}, token string, router *gin.Engine) *httptest.ResponseRecorder {
+	var requestBody []byte
+	if body != nil {
+		var err error
+		requestBody, err = json.Marshal(body)
+		if err != nil {
+			t.Fatalf("Error marshalling request body: %v", err)
+		}
+	}
+
+	req, err := http.NewRequest(method, url, bytes.NewBuffer(requestBody))
+	if err != nil {
+		t.Fatalf("Error creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	return w
+}
@@ -0,0 +1,154 @@
+package functional
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/factory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlocksAndMutes(t *testing.T) {
+	caches := []struct {
+		name        string
+		cacheClient cache.Cache
+	}{
+		{"default cache", getMockCache()},
+		{"without cache", nil},
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			testBlocksAndMutes(t, tc.cacheClient)
+		})
+	}
+}
+
+func testBlocksAndMutes(t *testing.T, cacheClient cache.Cache) {
+	db, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{})
+	if err != nil {
+		t.Fatalf("failed to create DB: %v", err)
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	defer conn.Close()
+
+	router := setupTweetRouter(conn, cacheClient)
+
+	users := []map[string]interface{}{
+		{"name": "Mauricio Giaconia", "email": "maurigiaconia@hotmail.com", "password": "1223"},
+		{"name": "Juan Perez", "email": "juanperez@hotmail.com", "password": "4567"},
+		{"name": "Ana Lopez", "email": "analopez@hotmail.com", "password": "8910"},
+	}
+	tokens := []string{}
+	for _, user := range users {
+		w := makeRequest(t, "POST", "/users/create", user, router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+		tokens = append(tokens, loginUser(t, user["email"].(string), user["password"].(string), router))
+	}
+
+	// Los usuarios 1 y 2 se siguen entre si y el usuario 3 sigue a ambos
+	follows := []struct {
+		token      string
+		followedId int64
+	}{
+		{tokens[0], 2},
+		{tokens[1], 1},
+		{tokens[2], 1},
+		{tokens[2], 2},
+	}
+	for _, follow := range follows {
+		w := makeAuthRequest(t, "POST", "/users_follow/create", map[string]interface{}{"followedId": follow.followedId}, follow.token, router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+	}
+
+	w := makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Tweet de Mauricio"}, tokens[0], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Tweet de Juan"}, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	// Se consulta el timeline antes de silenciar para que quede en cache
+	assert.Equal(t, []int{2, 1}, getTimelineTweetIds(t, "/tweets/3/timeline", tokens[2], router))
+
+	invalidRequests := []struct {
+		method   string
+		url      string
+		token    string
+		expected int
+	}{
+		{"POST", "/users/3/mutes/1", "", http.StatusUnauthorized},
+		{"POST", "/users/3/mutes/1", tokens[0], http.StatusForbidden},
+		{"POST", "/users/3/mutes/3", tokens[2], http.StatusBadRequest},
+		{"POST", "/users/3/mutes/abc", tokens[2], http.StatusBadRequest},
+		{"POST", "/users/3/mutes/99", tokens[2], http.StatusNotFound},
+		{"DELETE", "/users/3/mutes/1", tokens[2], http.StatusNotFound},
+		{"POST", "/users/1/blocks/1", tokens[0], http.StatusBadRequest},
+		{"POST", "/users/1/blocks/2", tokens[1], http.StatusForbidden},
+		{"POST", "/users/1/blocks/99", tokens[0], http.StatusNotFound},
+		{"DELETE", "/users/1/blocks/2", tokens[0], http.StatusNotFound},
+	}
+
+	for _, tc := range invalidRequests {
+		w = makeAuthRequest(t, tc.method, tc.url, nil, tc.token, router)
+		assert.Equal(t, int64(tc.expected), int64(w.Code), tc.method+" "+tc.url)
+	}
+
+	// Silenciar oculta los tweets del usuario en el timeline (aunque estuviera cacheado) sin dejar de seguirlo
+	w = makeAuthRequest(t, "POST", "/users/3/mutes/1", nil, tokens[2], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/users/3/mutes/1", nil, tokens[2], router)
+	assert.Equal(t, int64(http.StatusConflict), int64(w.Code))
+
+	assert.Equal(t, []int{2}, getTimelineTweetIds(t, "/tweets/3/timeline", tokens[2], router))
+	assert.Equal(t, []string{"Juan Perez", "Mauricio Giaconia"}, getFollowNames(t, "/users_follow/3/follows/following", router))
+
+	// Los nuevos tweets del usuario silenciado tampoco llegan al timeline, pero si al de sus otros seguidores
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Otro tweet de Mauricio"}, tokens[0], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	assert.Equal(t, []int{2}, getTimelineTweetIds(t, "/tweets/3/timeline", tokens[2], router))
+	assert.Equal(t, []int{3, 1}, getTimelineTweetIds(t, "/tweets/2/timeline", tokens[1], router))
+
+	w = makeAuthRequest(t, "DELETE", "/users/3/mutes/1", nil, tokens[2], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	assert.Equal(t, []int{3, 2, 1}, getTimelineTweetIds(t, "/tweets/3/timeline", tokens[2], router))
+
+	// Bloquear elimina los follows en ambos sentidos y ninguno de los dos puede volver a seguir al otro
+	w = makeAuthRequest(t, "POST", "/users/1/blocks/2", nil, tokens[0], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/users/1/blocks/2", nil, tokens[0], router)
+	assert.Equal(t, int64(http.StatusConflict), int64(w.Code))
+
+	assert.Empty(t, getFollowNames(t, "/users_follow/1/follows/following", router))
+	assert.Empty(t, getFollowNames(t, "/users_follow/2/follows/following", router))
+	assert.Equal(t, []string{"Ana Lopez"}, getFollowNames(t, "/users_follow/1/follows/followers", router))
+	assert.Empty(t, getTimelineTweetIds(t, "/tweets/2/timeline", tokens[1], router))
+
+	for _, follow := range []struct {
+		token      string
+		followedId int64
+	}{{tokens[1], 1}, {tokens[0], 2}} {
+		w = makeAuthRequest(t, "POST", "/users_follow/create", map[string]interface{}{"followedId": follow.followedId}, follow.token, router)
+		assert.Equal(t, int64(http.StatusForbidden), int64(w.Code))
+	}
+
+	// Al desbloquear pueden volver a seguirse, pero los follows eliminados no se restauran
+	w = makeAuthRequest(t, "DELETE", "/users/1/blocks/2", nil, tokens[0], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	assert.Empty(t, getFollowNames(t, "/users_follow/2/follows/following", router))
+
+	w = makeAuthRequest(t, "POST", "/users_follow/create", map[string]interface{}{"followedId": 1}, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	assert.Equal(t, []int{3, 1}, getTimelineTweetIds(t, "/tweets/2/timeline", tokens[1], router))
+}
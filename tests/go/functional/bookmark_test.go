@@ -0,0 +1,144 @@
+package functional
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/factory"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type BookmarksResponse struct {
+	Code int `json:"code"`
+	Data []struct {
+		Tweet        TimelineTweet `json:"tweet"`
+		BookmarkDate string        `json:"bookmarkDate"`
+	} `json:"data"`
+	Count      int    `json:"count"`
+	NextCursor string `json:"next_cursor"`
+}
+
+func TestBookmarks(t *testing.T) {
+	caches := []struct {
+		name        string
+		cacheClient cache.Cache
+	}{
+		{"default cache", getMockCache()},
+		{"without cache", nil},
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			testBookmarks(t, tc.cacheClient)
+		})
+	}
+}
+
+func testBookmarks(t *testing.T, cacheClient cache.Cache) {
+	db, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{})
+	if err != nil {
+		t.Fatalf("failed to create DB: %v", err)
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	defer conn.Close()
+
+	router := setupTweetRouter(conn, cacheClient)
+
+	users := []map[string]interface{}{
+		{"name": "Mauricio Giaconia", "email": "maurigiaconia@hotmail.com", "password": "1223"},
+		{"name": "Juan Perez", "email": "juanperez@hotmail.com", "password": "4567"},
+	}
+	tokens := []string{}
+	for _, user := range users {
+		w := makeRequest(t, "POST", "/users/create", user, router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+		tokens = append(tokens, loginUser(t, user["email"].(string), user["password"].(string), router))
+	}
+
+	for _, content := range []string{"Primer tweet", "Segundo tweet", "Tercer tweet"} {
+		w := makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: content}, tokens[0], router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+	}
+
+	// El usuario 2 guarda los tres tweets del usuario 1
+	for _, url := range []string{"/tweets/1/bookmark", "/tweets/2/bookmark", "/tweets/3/bookmark"} {
+		w := makeAuthRequest(t, "POST", url, nil, tokens[1], router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+	}
+
+	w := makeAuthRequest(t, "POST", "/tweets/1/bookmark", nil, tokens[1], router)
+	assert.Equal(t, int64(http.StatusConflict), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/tweets/99/bookmark", nil, tokens[1], router)
+	assert.Equal(t, int64(http.StatusNotFound), int64(w.Code))
+
+	// Se pagina por cursor, del bookmark mas reciente al mas antiguo
+	response := getBookmarks(t, "/users/2/bookmarks?limit=2", tokens[1], router)
+	assert.Equal(t, 3, response.Count)
+	assert.Equal(t, []int{3, 2}, bookmarkedTweetIds(response))
+	assert.NotEmpty(t, response.NextCursor)
+
+	response = getBookmarks(t, "/users/2/bookmarks?limit=2&cursor="+response.NextCursor, tokens[1], router)
+	assert.Equal(t, []int{1}, bookmarkedTweetIds(response))
+	assert.Empty(t, response.NextCursor)
+
+	// Los bookmarks son privados
+	w = makeAuthRequest(t, "GET", "/users/2/bookmarks", nil, tokens[0], router)
+	assert.Equal(t, int64(http.StatusForbidden), int64(w.Code))
+
+	w = makeRequest(t, "GET", "/users/2/bookmarks", nil, router)
+	assert.Equal(t, int64(http.StatusUnauthorized), int64(w.Code))
+
+	// Los bookmarks se guardan en la db sql, por lo que no se pierden al vaciar el cache
+	if cacheClient != nil {
+		err = cacheClient.DeleteByPrefix(context.Background(), "")
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, []int{3, 2, 1}, bookmarkedTweetIds(getBookmarks(t, "/users/2/bookmarks", tokens[1], router)))
+
+	w = makeAuthRequest(t, "DELETE", "/tweets/2/bookmark", nil, tokens[1], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	w = makeAuthRequest(t, "DELETE", "/tweets/2/bookmark", nil, tokens[1], router)
+	assert.Equal(t, int64(http.StatusNotFound), int64(w.Code))
+
+	assert.Equal(t, []int{3, 1}, bookmarkedTweetIds(getBookmarks(t, "/users/2/bookmarks", tokens[1], router)))
+
+	// Al eliminar un tweet se elimina de los bookmarks
+	w = makeAuthRequest(t, "DELETE", "/tweets/3", nil, tokens[0], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	response = getBookmarks(t, "/users/2/bookmarks", tokens[1], router)
+	assert.Equal(t, 1, response.Count)
+	assert.Equal(t, []int{1}, bookmarkedTweetIds(response))
+}
+
+func getBookmarks(t *testing.T, url string, token string, router *gin.Engine) BookmarksResponse {
+	w := makeAuthRequest(t, "GET", url, nil, token, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	var response BookmarksResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	return response
+}
+
+func bookmarkedTweetIds(response BookmarksResponse) []int {
+	ids := []int{}
+	for _, bookmark := range response.Data {
+		ids = append(ids, bookmark.Tweet.TweetID)
+	}
+
+	return ids
+}
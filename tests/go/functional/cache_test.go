@@ -0,0 +1,34 @@
+package functional
+
+import (
+	"testing"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/factory"
+	redisdb "github.com/MauricioGiaconia/uala_backend_challenge/pkg/redis_db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRedisCacheWithConfig(t *testing.T) {
+	// Configuraciones invalidas se rechazan antes de intentar conectar
+	invalidConfigs := []redisdb.Config{
+		{URL: "http://localhost:6379"},
+		{URL: "redis+sentinel://localhost:26379"},
+		{URL: "redis://localhost:6379/abc"},
+		{URL: "redis+cluster://localhost:7000", DB: 1},
+	}
+
+	for _, config := range invalidConfigs {
+		_, err := factory.GetCache("redis", factory.CacheOptions{Redis: config})
+		assert.Error(t, err, config.URL)
+	}
+
+	// Con un servidor Redis disponible, la URL y los campos de la configuracion se combinan
+	cacheClient, err := factory.GetCache("redis", factory.CacheOptions{Redis: redisdb.Config{URL: "redis://localhost/0?dial_timeout=1s", Addrs: []string{"localhost:6379"}}})
+	if err != nil {
+		t.Skipf("Redis not available: %v", err)
+	}
+	defer cacheClient.Close()
+
+	_, err = factory.GetCache("redis", factory.CacheOptions{Redis: redisdb.Config{Addrs: []string{"localhost:6379"}, Password: "wrong-password", Username: "nobody"}})
+	assert.Error(t, err)
+}
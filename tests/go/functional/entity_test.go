@@ -0,0 +1,228 @@
+package functional
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/factory"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type EntitiesTweet struct {
+	TimelineTweet
+	Entities struct {
+		Hashtags []struct {
+			Tag   string `json:"tag"`
+			Start int    `json:"start"`
+			End   int    `json:"end"`
+		} `json:"hashtags"`
+		Mentions []struct {
+			Username string `json:"username"`
+			UserID   *int64 `json:"userId"`
+			Start    int    `json:"start"`
+			End      int    `json:"end"`
+		} `json:"mentions"`
+	} `json:"entities"`
+}
+
+type EntitiesTweetsResponse struct {
+	Code  int             `json:"code"`
+	Data  []EntitiesTweet `json:"data"`
+	Count int             `json:"count"`
+}
+
+func TestHashtagsAndMentions(t *testing.T) {
+	caches := []struct {
+		name        string
+		cacheClient cache.Cache
+	}{
+		{"default cache", getMockCache()},
+		{"without cache", nil},
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			testHashtagsAndMentions(t, tc.cacheClient)
+		})
+	}
+}
+
+func testHashtagsAndMentions(t *testing.T, cacheClient cache.Cache) {
+	db, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{})
+	if err != nil {
+		t.Fatalf("failed to create DB: %v", err)
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	defer conn.Close()
+
+	router := setupTweetRouter(conn, cacheClient)
+
+	users := []map[string]interface{}{
+		{"name": "Mauricio Giaconia", "email": "maurigiaconia@hotmail.com", "password": "1223"},
+		{"name": "Juan Perez", "email": "juanperez@hotmail.com", "password": "4567"},
+		{"name": "Ana Lopez", "email": "analopez@hotmail.com", "password": "8910"},
+	}
+	tokens := []string{}
+	for _, user := range users {
+		w := makeRequest(t, "POST", "/users/create", user, router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+		tokens = append(tokens, loginUser(t, user["email"].(string), user["password"].(string), router))
+	}
+
+	w := makeAuthRequest(t, "POST", "/users_follow/create", map[string]interface{}{"followedId": 1}, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	assert.Empty(t, getTimelineTweetIds(t, "/tweets/2/timeline", tokens[1], router))
+
+	// El '@' de un email no es una mencion, #1 no es un hashtag y @desconocido no corresponde a ningun usuario
+	content := "¡Hola @juanperez y @AnaLopez! Mirá #GoLang y #café, escribí a test@juanperez.com #1 @desconocido"
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: content}, tokens[0], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Otro tweet de #golang"}, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	// Las entidades se incluyen en el timeline, tanto desde el cache como desde la db sql
+	w = makeAuthRequest(t, "GET", "/tweets/2/timeline", nil, tokens[1], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	var timelineResponse EntitiesTweetsResponse
+	err = json.Unmarshal(w.Body.Bytes(), &timelineResponse)
+	assert.NoError(t, err)
+
+	timeline := timelineResponse.Data
+	if assert.Len(t, timeline, 1) {
+		tweet := timeline[0]
+
+		if assert.Len(t, tweet.Entities.Hashtags, 2) {
+			assert.Equal(t, "GoLang", tweet.Entities.Hashtags[0].Tag)
+			assert.Equal(t, "café", tweet.Entities.Hashtags[1].Tag)
+
+			// Las posiciones se cuentan en caracteres, no en bytes
+			start := runeIndex(content, "#café")
+			assert.Equal(t, start, tweet.Entities.Hashtags[1].Start)
+			assert.Equal(t, start+5, tweet.Entities.Hashtags[1].End)
+		}
+
+		if assert.Len(t, tweet.Entities.Mentions, 3) {
+			expectedMentions := []struct {
+				username string
+				userId   *int64
+			}{
+				{"juanperez", int64Pointer(2)},
+				{"AnaLopez", int64Pointer(3)},
+				{"desconocido", nil},
+			}
+
+			for i, expected := range expectedMentions {
+				mention := tweet.Entities.Mentions[i]
+				assert.Equal(t, expected.username, mention.Username)
+				assert.Equal(t, expected.userId, mention.UserID)
+				assert.Equal(t, runeIndex(content, "@"+expected.username), mention.Start)
+				assert.Equal(t, mention.Start+len([]rune(expected.username))+1, mention.End)
+			}
+		}
+	}
+
+	// Los hashtags no distinguen mayusculas y se pueden buscar con o sin '#'
+	for _, tag := range []string{"golang", "GOLANG", "#GoLang"} {
+		response := getEntitiesTweetsResponse(t, "/hashtags/"+url.PathEscape(tag)+"/tweets", router)
+		assert.Equal(t, 2, response.Count)
+		assert.Equal(t, []int{2, 1}, entitiesTweetIds(response.Data))
+	}
+
+	assert.Equal(t, []int{1}, entitiesTweetIds(getEntitiesTweets(t, "/hashtags/"+url.PathEscape("café")+"/tweets", router)))
+	assert.Empty(t, getEntitiesTweets(t, "/hashtags/desconocido/tweets", router))
+
+	for _, userId := range []string{"2", "3"} {
+		assert.Equal(t, []int{1}, entitiesTweetIds(getEntitiesTweets(t, "/users/"+userId+"/mentions", router)))
+	}
+	assert.Empty(t, getEntitiesTweets(t, "/users/1/mentions", router))
+
+	invalidRequests := []struct {
+		url      string
+		expected int
+	}{
+		{"/hashtags/123/tweets", http.StatusBadRequest},
+		{"/hashtags/" + url.PathEscape("go lang") + "/tweets", http.StatusBadRequest},
+		{"/users/99/mentions", http.StatusNotFound},
+		{"/users/abc/mentions", http.StatusBadRequest},
+	}
+
+	for _, tc := range invalidRequests {
+		w = makeRequest(t, "GET", tc.url, nil, router)
+		assert.Equal(t, int64(tc.expected), int64(w.Code), tc.url)
+	}
+
+	// likedByViewer indica si el usuario que consulta dio like al tweet, no el usuario mencionado
+	w = makeAuthRequest(t, "POST", "/tweets/1/like", nil, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	likedRequests := []struct {
+		url           string
+		token         string
+		likedByViewer bool
+	}{
+		{"/hashtags/" + url.PathEscape("café") + "/tweets", tokens[1], true},
+		{"/hashtags/" + url.PathEscape("café") + "/tweets", tokens[2], false},
+		{"/users/3/mentions", tokens[1], true},
+		{"/users/2/mentions", tokens[2], false},
+	}
+
+	for _, tc := range likedRequests {
+		tweets := getLikedTweets(t, tc.url, tc.token, router)
+		if assert.Len(t, tweets, 1, tc.url) {
+			assert.Equal(t, tc.likedByViewer, tweets[0].LikedByViewer, tc.url)
+		}
+	}
+
+	// Al eliminar el tweet se eliminan sus hashtags y menciones
+	w = makeAuthRequest(t, "DELETE", "/tweets/1", nil, tokens[0], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	assert.Equal(t, []int{2}, entitiesTweetIds(getEntitiesTweets(t, "/hashtags/golang/tweets", router)))
+	assert.Empty(t, getEntitiesTweets(t, "/users/2/mentions", router))
+}
+
+func getEntitiesTweetsResponse(t *testing.T, url string, router *gin.Engine) EntitiesTweetsResponse {
+	w := makeRequest(t, "GET", url, nil, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code), url)
+
+	var response EntitiesTweetsResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	return response
+}
+
+func getEntitiesTweets(t *testing.T, url string, router *gin.Engine) []EntitiesTweet {
+	return getEntitiesTweetsResponse(t, url, router).Data
+}
+
+func entitiesTweetIds(tweets []EntitiesTweet) []int {
+	ids := []int{}
+	for _, tweet := range tweets {
+		ids = append(ids, tweet.TweetID)
+	}
+
+	return ids
+}
+
+// runeIndex retorna la posicion en caracteres (no en bytes) de la primera aparicion de substr
+func runeIndex(s string, substr string) int {
+	return len([]rune(s[:strings.Index(s, substr)]))
+}
+
+func int64Pointer(value int64) *int64 {
+	return &value
+}
@@ -0,0 +1,185 @@
+package functional
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/factory"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type UserResponse struct {
+	Code int         `json:"code"`
+	Data models.User `json:"data"`
+}
+
+type UsersResponse struct {
+	Code  int           `json:"code"`
+	Data  []models.User `json:"data"`
+	Count int           `json:"count"`
+}
+
+func TestUserHandles(t *testing.T) {
+	db, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{})
+	if err != nil {
+		t.Fatalf("failed to create DB: %v", err)
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	defer conn.Close()
+
+	router := setupTweetRouter(conn, nil)
+
+	// Los usuarios sin handle reciben uno generado a partir del nombre, sin acentos ni espacios y con un numero si ya esta en uso
+	users := []struct {
+		user     map[string]interface{}
+		expected string
+	}{
+		{map[string]interface{}{"name": "Mauricio Giaconia", "email": "maurigiaconia@hotmail.com", "password": "1223", "handle": "@MauriG"}, "maurig"},
+		{map[string]interface{}{"name": "Juan Perez", "email": "juan.perez@hotmail.com", "password": "4567"}, "juanperez"},
+		{map[string]interface{}{"name": "Juan Pérez", "email": "juanperez@gmail.com", "password": "8910"}, "juanperez1"},
+		{map[string]interface{}{"name": "Al", "email": "a@hotmail.com", "password": "1112"}, "useral"},
+		{map[string]interface{}{"name": "Pedro Gomez", "email": "pedro@hotmail.com", "password": "1314", "handle": "pedro_gomez"}, "pedro_gomez"},
+		{map[string]interface{}{"name": "Laura Martinez Rodriguez", "email": "laura@hotmail.com", "password": "1516"}, "lauramartinezro"},
+		{map[string]interface{}{"name": "Laura Martinez Rodriguez", "email": "laura@gmail.com", "password": "1718"}, "lauramartinezr1"},
+	}
+	tokens := []string{}
+	for i, tc := range users {
+		w := makeRequest(t, "POST", "/users/create", tc.user, router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+		tokens = append(tokens, loginUser(t, tc.user["email"].(string), tc.user["password"].(string), router))
+
+		user := getUser(t, "/users/"+fmt.Sprint(i+1), router)
+		assert.Equal(t, tc.expected, user.Handle)
+		assert.Empty(t, user.Email)
+	}
+
+	invalidUsers := []struct {
+		handle   string
+		expected int
+		message  string
+	}{
+		{"MAURIG", http.StatusConflict, "Handle already in use"},
+		{"ab", http.StatusBadRequest, "Invalid handle"},
+		{"juan perez", http.StatusBadRequest, "Invalid handle"},
+		{"ñandu", http.StatusBadRequest, "Invalid handle"},
+		{"handlemuylargo123", http.StatusBadRequest, "Invalid handle"},
+	}
+
+	for _, tc := range invalidUsers {
+		w := makeRequest(t, "POST", "/users/create", map[string]interface{}{"name": "Otro", "email": "otro@hotmail.com", "password": "1", "handle": tc.handle}, router)
+		assert.Equal(t, int64(tc.expected), int64(w.Code), tc.handle)
+
+		var errorResponse utils.ErrorResponse
+		err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.message, errorResponse.Error)
+	}
+
+	// Busqueda por handle, con o sin '@' y sin distinguir mayusculas
+	for _, handle := range []string{"juanperez", "@JuanPerez"} {
+		user := getUser(t, "/users/by-handle/"+url.PathEscape(handle), router)
+		assert.Equal(t, 2, user.ID)
+		assert.Equal(t, "Juan Perez", user.Name)
+		assert.Empty(t, user.Email)
+	}
+
+	w := makeRequest(t, "GET", "/users/by-handle/nadie", nil, router)
+	assert.Equal(t, int64(http.StatusNotFound), int64(w.Code))
+
+	w = makeRequest(t, "GET", "/users/by-handle/a!", nil, router)
+	assert.Equal(t, int64(http.StatusBadRequest), int64(w.Code))
+
+	// Busqueda de usuarios por prefijo: primero el handle exacto, luego los que coinciden por handle y por ultimo por nombre
+	searches := []struct {
+		q        string
+		expected []int
+	}{
+		{"juan", []int{2, 3}},
+		{"@JUANPEREZ", []int{2, 3}},
+		{"gom", []int{5}},
+		{"pedro_", []int{5}},
+		{"pedro%", []int{}},
+		{"martinez", []int{7, 6}},
+		{"xyz", []int{}},
+	}
+
+	for _, search := range searches {
+		response := searchUsers(t, url.Values{"q": {search.q}}, router)
+		assert.Equal(t, search.expected, userIds(response.Data), search.q)
+		assert.Equal(t, len(search.expected), response.Count, search.q)
+	}
+
+	response := searchUsers(t, url.Values{"q": {"juan"}, "limit": {"1"}, "offset": {"1"}}, router)
+	assert.Equal(t, []int{3}, userIds(response.Data))
+	assert.Equal(t, 2, response.Count)
+
+	// Los perfiles publicos no incluyen el email
+	for _, user := range searchUsers(t, url.Values{"q": {"juan"}}, router).Data {
+		assert.Empty(t, user.Email)
+	}
+
+	for _, query := range []string{"q=", "q=%20", "q=juan&cursor=abc"} {
+		w = makeRequest(t, "GET", "/search/users?"+query, nil, router)
+		assert.Equal(t, int64(http.StatusBadRequest), int64(w.Code), query)
+	}
+
+	// Las menciones se resuelven por handle y los tweets incluyen el handle del autor
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Hola @JuanPerez1 y @useral, pero no @juan"}, tokens[0], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	mentions := getEntitiesTweets(t, "/users/3/mentions", router)
+	if assert.Len(t, mentions, 1) {
+		assert.Equal(t, "maurig", mentions[0].AuthorHandle)
+
+		mentionedIds := []*int64{}
+		for _, mention := range mentions[0].Entities.Mentions {
+			mentionedIds = append(mentionedIds, mention.UserID)
+		}
+		assert.Equal(t, []*int64{int64Pointer(3), int64Pointer(4), nil}, mentionedIds)
+	}
+
+	assert.Len(t, getEntitiesTweets(t, "/users/4/mentions", router), 1)
+	assert.Empty(t, getEntitiesTweets(t, "/users/2/mentions", router))
+}
+
+func getUser(t *testing.T, url string, router *gin.Engine) models.User {
+	w := makeRequest(t, "GET", url, nil, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code), url)
+
+	var response UserResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	return response.Data
+}
+
+func searchUsers(t *testing.T, params url.Values, router *gin.Engine) UsersResponse {
+	w := makeRequest(t, "GET", "/search/users?"+params.Encode(), nil, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code), params.Encode())
+
+	var response UsersResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	return response
+}
+
+func userIds(users []models.User) []int {
+	ids := []int{}
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+
+	return ids
+}
@@ -0,0 +1,316 @@
+package functional
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/repositories"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/services"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/factory"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type LikedTweet struct {
+	TimelineTweet
+	LikeCount     int64       `json:"likeCount"`
+	LikedByViewer bool        `json:"likedByViewer"`
+	OriginalTweet *LikedTweet `json:"originalTweet"`
+}
+
+type LikedTweetsResponse struct {
+	Code  int          `json:"code"`
+	Data  []LikedTweet `json:"data"`
+	Count int          `json:"count"`
+}
+
+type TweetLikesResponse struct {
+	Code int `json:"code"`
+	Data []struct {
+		LikeUserData struct {
+			ID    int    `json:"id"`
+			Name  string `json:"name"`
+			Email string `json:"email"`
+		} `json:"likeUserData"`
+		LikeDate string `json:"likeDate"`
+	} `json:"data"`
+	Count int `json:"count"`
+}
+
+func TestLikes(t *testing.T) {
+	caches := []struct {
+		name        string
+		cacheClient cache.Cache
+	}{
+		{"default cache", getMockCache()},
+		{"without cache", nil},
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			testLikes(t, tc.cacheClient)
+		})
+	}
+}
+
+func testLikes(t *testing.T, cacheClient cache.Cache) {
+	db, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{})
+	if err != nil {
+		t.Fatalf("failed to create DB: %v", err)
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	defer conn.Close()
+
+	router := setupTweetRouter(conn, cacheClient)
+
+	// El usuario 3 sigue a los usuarios 1 y 2
+	users := []map[string]interface{}{
+		{"name": "Mauricio Giaconia", "email": "maurigiaconia@hotmail.com", "password": "1223"},
+		{"name": "Juan Perez", "email": "juanperez@hotmail.com", "password": "4567"},
+		{"name": "Ana Lopez", "email": "analopez@hotmail.com", "password": "8910"},
+	}
+	tokens := []string{}
+	for _, user := range users {
+		w := makeRequest(t, "POST", "/users/create", user, router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+		tokens = append(tokens, loginUser(t, user["email"].(string), user["password"].(string), router))
+	}
+
+	for _, followedId := range []int64{1, 2} {
+		w := makeAuthRequest(t, "POST", "/users_follow/create", map[string]interface{}{"followedId": followedId}, tokens[2], router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+	}
+
+	// Tweet 1 del usuario 1 y tweet 2, retweet del usuario 2
+	w := makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Tweet original"}, tokens[0], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/tweets/1/retweet", nil, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	// Se consulta el timeline antes de los likes para que el contenido de los tweets quede en cache
+	timeline := getLikedTweets(t, "/tweets/3/timeline", tokens[2], router)
+	if assert.Len(t, timeline, 2) {
+		assert.Zero(t, timeline[1].LikeCount)
+		assert.False(t, timeline[1].LikedByViewer)
+	}
+
+	w = makeAuthRequest(t, "POST", "/tweets/1/like", nil, tokens[2], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/tweets/1/like", nil, tokens[2], router)
+	assert.Equal(t, int64(http.StatusConflict), int64(w.Code))
+
+	// El like a un retweet es un like al tweet original
+	w = makeAuthRequest(t, "POST", "/tweets/2/like", nil, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/tweets/99/like", nil, tokens[1], router)
+	assert.Equal(t, int64(http.StatusNotFound), int64(w.Code))
+
+	w = makeRequest(t, "POST", "/tweets/1/like", nil, router)
+	assert.Equal(t, int64(http.StatusUnauthorized), int64(w.Code))
+
+	assertTimelineLikes := func(likeCount int64, likedByViewer bool) {
+		timeline := getLikedTweets(t, "/tweets/3/timeline", tokens[2], router)
+		if assert.Len(t, timeline, 2) {
+			assert.Equal(t, 2, timeline[0].TweetID)
+			if assert.NotNil(t, timeline[0].OriginalTweet) {
+				assert.Equal(t, likeCount, timeline[0].OriginalTweet.LikeCount)
+				assert.Equal(t, likedByViewer, timeline[0].OriginalTweet.LikedByViewer)
+			}
+
+			assert.Equal(t, 1, timeline[1].TweetID)
+			assert.Equal(t, likeCount, timeline[1].LikeCount)
+			assert.Equal(t, likedByViewer, timeline[1].LikedByViewer)
+		}
+	}
+
+	assertTimelineLikes(2, true)
+
+	w = makeRequest(t, "GET", "/tweets/1/likes", nil, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	var likesResponse TweetLikesResponse
+	err = json.Unmarshal(w.Body.Bytes(), &likesResponse)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, likesResponse.Count)
+
+	likers := []int{}
+	for _, like := range likesResponse.Data {
+		likers = append(likers, like.LikeUserData.ID)
+		assert.Empty(t, like.LikeUserData.Email)
+	}
+	assert.ElementsMatch(t, []int{2, 3}, likers)
+
+	w = makeRequest(t, "GET", "/tweets/1/likes?limit=1&offset=1", nil, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	err = json.Unmarshal(w.Body.Bytes(), &likesResponse)
+	assert.NoError(t, err)
+	assert.Len(t, likesResponse.Data, 1)
+
+	// likedByViewer indica si el usuario que consulta dio like, no el dueño de la lista
+	for _, tc := range []struct {
+		token         string
+		likedByViewer bool
+	}{
+		{"", false},
+		{tokens[0], false},
+		{tokens[1], true},
+		{tokens[2], true},
+	} {
+		userLikes := getLikedTweets(t, "/users/3/likes", tc.token, router)
+		if assert.Len(t, userLikes, 1) {
+			assert.Equal(t, 1, userLikes[0].TweetID)
+			assert.Equal(t, int64(2), userLikes[0].LikeCount)
+			assert.Equal(t, tc.likedByViewer, userLikes[0].LikedByViewer)
+		}
+	}
+
+	// Con cache los likes se acumulan en cache hasta que se vuelcan a la db sql; sin cache se actualiza directamente
+	var storedLikeCount int64
+	if cacheClient != nil {
+		err = conn.QueryRow(`SELECT like_count FROM tweets WHERE id = 1`).Scan(&storedLikeCount)
+		assert.NoError(t, err)
+		assert.Zero(t, storedLikeCount)
+
+		err = services.FlushLikeCounts(conn, cacheClient)
+		assert.NoError(t, err)
+	}
+
+	err = conn.QueryRow(`SELECT like_count FROM tweets WHERE id = 1`).Scan(&storedLikeCount)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), storedLikeCount)
+
+	assertTimelineLikes(2, true)
+
+	w = makeAuthRequest(t, "DELETE", "/tweets/1/like", nil, tokens[2], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	w = makeAuthRequest(t, "DELETE", "/tweets/1/like", nil, tokens[2], router)
+	assert.Equal(t, int64(http.StatusNotFound), int64(w.Code))
+
+	assertTimelineLikes(1, false)
+	assert.Empty(t, getLikedTweets(t, "/users/3/likes", "", router))
+
+	invalidRequests := []struct {
+		url      string
+		expected int
+	}{
+		{"/tweets/99/likes", http.StatusNotFound},
+		{"/users/99/likes", http.StatusNotFound},
+		{"/tweets/abc/likes", http.StatusBadRequest},
+		{"/users/3/likes?cursor=abc", http.StatusBadRequest},
+	}
+
+	for _, tc := range invalidRequests {
+		w = makeRequest(t, "GET", tc.url, nil, router)
+		assert.Equal(t, int64(tc.expected), int64(w.Code), tc.url)
+	}
+}
+
+func TestReconcileLikeCountsWithLostCacheCounters(t *testing.T) {
+	db, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{})
+	if err != nil {
+		t.Fatalf("failed to get DB: %v", err)
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	defer conn.Close()
+
+	cacheClient := getMockCache()
+	router := setupTweetRouter(conn, cacheClient)
+
+	users := []map[string]interface{}{
+		{"name": "Mauricio Giaconia", "email": "maurigiaconia@hotmail.com", "password": "1223"},
+		{"name": "Juan Perez", "email": "juanperez@hotmail.com", "password": "4567"},
+		{"name": "Ana Lopez", "email": "analopez@hotmail.com", "password": "8910"},
+	}
+	tokens := []string{}
+	for _, user := range users {
+		w := makeRequest(t, "POST", "/users/create", user, router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+		tokens = append(tokens, loginUser(t, user["email"].(string), user["password"].(string), router))
+	}
+
+	w := makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Tweet original"}, tokens[0], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	like := func(method string, token string) {
+		w := makeAuthRequest(t, method, "/tweets/1/like", nil, token, router)
+		assert.Contains(t, []int{http.StatusCreated, http.StatusOK}, w.Code)
+	}
+
+	assertLikeCounts := func(stored int64, shown int64) {
+		var storedLikeCount int64
+		err := conn.QueryRow(`SELECT like_count FROM tweets WHERE id = 1`).Scan(&storedLikeCount)
+		assert.NoError(t, err)
+		assert.Equal(t, stored, storedLikeCount)
+
+		userLikes := getLikedTweets(t, "/users/3/likes", "", router)
+		if assert.Len(t, userLikes, 1) {
+			assert.Equal(t, shown, userLikes[0].LikeCount)
+		}
+	}
+
+	ctx := context.Background()
+
+	// Si se pierde el set de tweets con likes pendientes, el contador nunca se vuelca y queda huerfano
+	like("POST", tokens[1])
+	like("POST", tokens[2])
+	assert.NoError(t, cacheClient.Delete(ctx, "pending_likes"))
+	assert.NoError(t, services.FlushLikeCounts(conn, cacheClient))
+	assertLikeCounts(0, 2)
+
+	// El recalculo corrige la db sql y elimina el contador huerfano para no contar dos veces los likes
+	assert.NoError(t, services.ReconcileLikeCounts(conn, cacheClient))
+	assertLikeCounts(2, 2)
+
+	// Si se pierde el contador, el volcado no encuentra likes pendientes
+	like("POST", tokens[0])
+	assert.NoError(t, cacheClient.Delete(ctx, repositories.PendingLikesKey(1)))
+	assert.NoError(t, services.FlushLikeCounts(conn, cacheClient))
+	assertLikeCounts(2, 2)
+
+	assert.NoError(t, services.ReconcileLikeCounts(conn, cacheClient))
+	assertLikeCounts(3, 3)
+
+	// Los tweets con likes pendientes no se recalculan, ya que sus likes todavia no se volcaron
+	like("DELETE", tokens[1])
+	assert.NoError(t, services.ReconcileLikeCounts(conn, cacheClient))
+	assertLikeCounts(3, 2)
+
+	assert.NoError(t, services.FlushLikeCounts(conn, cacheClient))
+	assertLikeCounts(2, 2)
+
+	// Un contador que cambia despues de leerlo (por ejemplo por un like durante el recalculo) no se elimina
+	like("POST", tokens[1])
+	changedIds, err := repositories.DeletePendingLikesIfUnchanged(cacheClient, map[int64]int64{1: 0})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1}, changedIds)
+	assertLikeCounts(2, 3)
+}
+
+func getLikedTweets(t *testing.T, url string, token string, router *gin.Engine) []LikedTweet {
+	w := makeAuthRequest(t, "GET", url, nil, token, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	var response LikedTweetsResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	return response.Data
+}
@@ -0,0 +1,101 @@
+package functional
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/db"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/factory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrationsUpAndDown(t *testing.T) {
+	database, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{})
+	if err != nil {
+		t.Fatalf("failed to create DB: %v", err)
+	}
+
+	// Al conectar SQLite se aplican todas las migraciones
+	conn, err := database.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	defer conn.Close()
+
+	migrations, err := db.LoadMigrations(database.Dialect())
+	assert.NoError(t, err)
+	assert.NotEmpty(t, migrations)
+
+	statuses, err := db.GetMigrationsStatus(conn, database.Dialect())
+	assert.NoError(t, err)
+	assert.Len(t, statuses, len(migrations))
+	for _, status := range statuses {
+		assert.True(t, status.Applied, status.Name)
+	}
+
+	// Volver a aplicar no hace nada
+	applied, err := db.MigrateUp(conn, database.Dialect(), 0)
+	assert.NoError(t, err)
+	assert.Empty(t, applied)
+
+	// Se revierten todas las migraciones, de la mas nueva a la mas vieja
+	reverted, err := db.MigrateDown(conn, database.Dialect(), len(migrations))
+	assert.NoError(t, err)
+	assert.Len(t, reverted, len(migrations))
+	assert.Equal(t, migrations[len(migrations)-1].Version, reverted[0].Version)
+
+	_, err = conn.Exec(`INSERT INTO users (name, email, password) VALUES ('Mauricio', 'mauri@hotmail.com', '1234')`)
+	assert.Error(t, err, "The users table should not exist after reverting the migrations")
+
+	// Se aplican de a una
+	applied, err = db.MigrateUp(conn, database.Dialect(), 1)
+	assert.NoError(t, err)
+	assert.Len(t, applied, 1)
+	assert.Equal(t, migrations[0].Version, applied[0].Version)
+
+	applied, err = db.MigrateUp(conn, database.Dialect(), 0)
+	assert.NoError(t, err)
+	assert.Len(t, applied, len(migrations)-1)
+
+	_, err = conn.Exec(`INSERT INTO users (name, email, password) VALUES ('Mauricio', 'mauri@hotmail.com', '1234')`)
+	assert.NoError(t, err)
+}
+
+func TestMigrationsStatusIsReadOnly(t *testing.T) {
+	// Una db sin migraciones: el driver de SQLite lo registra el paquete db
+	conn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open DB: %v", err)
+	}
+	conn.SetMaxOpenConns(1)
+
+	defer conn.Close()
+
+	migrations, err := db.LoadMigrations(db.DialectSQLite)
+	assert.NoError(t, err)
+
+	statuses, err := db.GetMigrationsStatus(conn, db.DialectSQLite)
+	assert.NoError(t, err)
+	assert.Len(t, statuses, len(migrations))
+	for _, status := range statuses {
+		assert.False(t, status.Applied, status.Name)
+	}
+
+	pending, err := db.CountPendingMigrations(conn, db.DialectSQLite)
+	assert.NoError(t, err)
+	assert.Equal(t, len(migrations), pending)
+
+	// Consultar el estado no crea la tabla schema_migrations
+	var tables int
+	err = conn.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'schema_migrations'`).Scan(&tables)
+	assert.NoError(t, err)
+	assert.Zero(t, tables)
+
+	_, err = db.MigrateUp(conn, db.DialectSQLite, 1)
+	assert.NoError(t, err)
+
+	pending, err = db.CountPendingMigrations(conn, db.DialectSQLite)
+	assert.NoError(t, err)
+	assert.Equal(t, len(migrations)-1, pending)
+}
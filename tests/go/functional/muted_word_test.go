@@ -0,0 +1,192 @@
+package functional
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/factory"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type MutedWordResponse struct {
+	Code int              `json:"code"`
+	Data models.MutedWord `json:"data"`
+}
+
+type MutedWordsResponse struct {
+	Code  int                `json:"code"`
+	Data  []models.MutedWord `json:"data"`
+	Count int                `json:"count"`
+}
+
+func TestMutedWords(t *testing.T) {
+	caches := []struct {
+		name        string
+		cacheClient cache.Cache
+	}{
+		{"default cache", getMockCache()},
+		{"without cache", nil},
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			testMutedWords(t, tc.cacheClient)
+		})
+	}
+}
+
+func testMutedWords(t *testing.T, cacheClient cache.Cache) {
+	db, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{})
+	if err != nil {
+		t.Fatalf("failed to create DB: %v", err)
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	defer conn.Close()
+
+	router := setupTweetRouter(conn, cacheClient)
+
+	users := []map[string]interface{}{
+		{"name": "Mauricio Giaconia", "email": "maurigiaconia@hotmail.com", "password": "1223"},
+		{"name": "Juan Perez", "email": "juanperez@hotmail.com", "password": "4567"},
+	}
+	tokens := []string{}
+	for _, user := range users {
+		w := makeRequest(t, "POST", "/users/create", user, router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+		tokens = append(tokens, loginUser(t, user["email"].(string), user["password"].(string), router))
+	}
+
+	w := makeAuthRequest(t, "POST", "/users_follow/create", map[string]interface{}{"followedId": 1}, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	contents := []string{
+		"Hola #golang",
+		"Partido de futbol",
+		"Buenos Aires hoy",
+		"Los futbolistas",
+		"Otro tweet de FUTBOL!",
+		"Tweet normal",
+	}
+	for _, content := range contents {
+		w = makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: content}, tokens[0], router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+	}
+
+	// Se consulta el timeline antes de silenciar palabras para que quede en cache
+	assert.Equal(t, []int{6, 5, 4, 3, 2, 1}, getTimelineTweetIds(t, "/tweets/2/timeline", tokens[1], router))
+
+	invalidRequests := []struct {
+		url      string
+		body     map[string]interface{}
+		token    string
+		expected int
+	}{
+		{"/users/2/muted_words", map[string]interface{}{"word": "futbol"}, "", http.StatusUnauthorized},
+		{"/users/2/muted_words", map[string]interface{}{"word": "futbol"}, tokens[0], http.StatusForbidden},
+		{"/users/2/muted_words", map[string]interface{}{"word": "  "}, tokens[1], http.StatusBadRequest},
+		{"/users/2/muted_words", map[string]interface{}{"word": "#"}, tokens[1], http.StatusBadRequest},
+		{"/users/2/muted_words", map[string]interface{}{"word": "futbol", "expiresAt": time.Now().Add(-time.Hour)}, tokens[1], http.StatusBadRequest},
+	}
+
+	for _, tc := range invalidRequests {
+		w = makeAuthRequest(t, "POST", tc.url, tc.body, tc.token, router)
+		assert.Equal(t, int64(tc.expected), int64(w.Code), tc.body)
+	}
+
+	mutedWords := []struct {
+		body     map[string]interface{}
+		expected string
+	}{
+		{map[string]interface{}{"word": " Futbol "}, "futbol"},
+		{map[string]interface{}{"word": "#GoLang"}, "#golang"},
+		{map[string]interface{}{"word": "buenos   aires", "expiresAt": time.Now().Add(time.Hour)}, "buenos aires"},
+	}
+
+	mutedWordIds := map[string]int64{}
+	for _, tc := range mutedWords {
+		w = makeAuthRequest(t, "POST", "/users/2/muted_words", tc.body, tokens[1], router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+		var response MutedWordResponse
+		err = json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, response.Data.Word)
+		mutedWordIds[tc.expected] = response.Data.ID
+	}
+
+	w = makeAuthRequest(t, "POST", "/users/2/muted_words", map[string]interface{}{"word": "FUTBOL"}, tokens[1], router)
+	assert.Equal(t, int64(http.StatusConflict), int64(w.Code))
+
+	assert.Equal(t, []string{"buenos aires", "#golang", "futbol"}, getMutedWords(t, "/users/2/muted_words", tokens[1], router))
+
+	// Las palabras se silencian completas y sin distinguir mayusculas, tambien sobre el feed cacheado.
+	// Las paginas se completan con los tweets siguientes del timeline
+	assert.Equal(t, []int{6, 4}, getTimelineTweetIds(t, "/tweets/2/timeline", tokens[1], router))
+	assert.Equal(t, []int{6, 4}, getTimelineTweetIds(t, "/tweets/2/timeline?limit=2", tokens[1], router))
+	assert.Equal(t, []int{4}, getTimelineTweetIds(t, "/tweets/2/timeline?limit=2&offset=1", tokens[1], router))
+	assert.Equal(t, []int{6, 4}, getTimelineTweetIds(t, "/tweets/2/routine_timeline?limit=2", tokens[1], router))
+
+	firstPage := getTimelineResponse(t, "/tweets/2/timeline?limit=1", tokens[1], router)
+	assert.Equal(t, []int{6}, timelineTweetIds(firstPage.Data))
+	assert.Equal(t, []int{4}, getTimelineTweetIds(t, "/tweets/2/timeline?limit=1&cursor="+firstPage.NextCursor, tokens[1], router))
+
+	// Las palabras silenciadas solo se aplican al timeline
+	assert.Equal(t, []int{1}, getVisibleTweetIds(t, "/hashtags/golang/tweets", tokens[1], router))
+
+	// Al expirar, la palabra deja de silenciarse
+	_, err = conn.Exec(`UPDATE muted_words SET expires_at = $1 WHERE id = $2`, time.Now().Add(-time.Minute).UTC().Format("2006-01-02 15:04:05"), mutedWordIds["buenos aires"])
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"#golang", "futbol"}, getMutedWords(t, "/users/2/muted_words", tokens[1], router))
+	assert.Equal(t, []int{6, 4, 3}, getTimelineTweetIds(t, "/tweets/2/timeline", tokens[1], router))
+
+	// Otro usuario no puede eliminar las palabras silenciadas de un usuario
+	w = makeAuthRequest(t, "DELETE", fmt.Sprintf("/users/1/muted_words/%d", mutedWordIds["futbol"]), nil, tokens[0], router)
+	assert.Equal(t, int64(http.StatusNotFound), int64(w.Code))
+
+	w = makeAuthRequest(t, "DELETE", fmt.Sprintf("/users/2/muted_words/%d", mutedWordIds["futbol"]), nil, tokens[1], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	w = makeAuthRequest(t, "DELETE", fmt.Sprintf("/users/2/muted_words/%d", mutedWordIds["futbol"]), nil, tokens[1], router)
+	assert.Equal(t, int64(http.StatusNotFound), int64(w.Code))
+
+	assert.Equal(t, []int{6, 5, 4, 3, 2}, getTimelineTweetIds(t, "/tweets/2/timeline", tokens[1], router))
+}
+
+func getTimelineResponse(t *testing.T, url string, token string, router *gin.Engine) TimelineResponse {
+	w := makeAuthRequest(t, "GET", url, nil, token, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	var response TimelineResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	return response
+}
+
+func getMutedWords(t *testing.T, url string, token string, router *gin.Engine) []string {
+	w := makeAuthRequest(t, "GET", url, nil, token, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	var response MutedWordsResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	words := []string{}
+	for _, mutedWord := range response.Data {
+		words = append(words, mutedWord.Word)
+	}
+
+	return words
+}
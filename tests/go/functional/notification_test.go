@@ -0,0 +1,266 @@
+package functional
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/factory"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type NotificationsResponse struct {
+	Code  int                      `json:"code"`
+	Data  models.NotificationsPage `json:"data"`
+	Count int                      `json:"count"`
+}
+
+func TestNotifications(t *testing.T) {
+	caches := []struct {
+		name        string
+		cacheClient cache.Cache
+	}{
+		{"default cache", getMockCache()},
+		{"without cache", nil},
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			testNotifications(t, tc.cacheClient)
+		})
+	}
+}
+
+func testNotifications(t *testing.T, cacheClient cache.Cache) {
+	db, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{})
+	if err != nil {
+		t.Fatalf("failed to create DB: %v", err)
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	defer conn.Close()
+
+	router := setupTweetRouter(conn, cacheClient)
+
+	users := []map[string]interface{}{
+		{"name": "Mauricio Giaconia", "email": "maurigiaconia@hotmail.com", "password": "1223", "handle": "mauri"},
+		{"name": "Juan Perez", "email": "juanperez@hotmail.com", "password": "4567"},
+		{"name": "Ana Lopez", "email": "analopez@hotmail.com", "password": "8910"},
+		{"name": "Pedro Gomez", "email": "pedrogomez@hotmail.com", "password": "1112"},
+		{"name": "Lucia Diaz", "email": "luciadiaz@hotmail.com", "password": "1314"},
+		{"name": "Sofia Ruiz", "email": "sofiaruiz@hotmail.com", "password": "1516", "isPrivate": true},
+	}
+	tokens := []string{}
+	for _, user := range users {
+		w := makeRequest(t, "POST", "/users/create", user, router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+		tokens = append(tokens, loginUser(t, user["email"].(string), user["password"].(string), router))
+	}
+
+	invalidRequests := []struct {
+		method   string
+		url      string
+		token    string
+		expected int
+	}{
+		{"GET", "/users/1/notifications", "", http.StatusUnauthorized},
+		{"GET", "/users/1/notifications", tokens[1], http.StatusForbidden},
+		{"GET", "/users/abc/notifications", tokens[0], http.StatusBadRequest},
+		{"GET", "/users/1/notifications?limit=0", tokens[0], http.StatusBadRequest},
+		{"POST", "/users/1/notifications/read", "", http.StatusUnauthorized},
+		{"POST", "/users/1/notifications/read", tokens[1], http.StatusForbidden},
+	}
+
+	for _, tc := range invalidRequests {
+		w := makeAuthRequest(t, tc.method, tc.url, nil, tc.token, router)
+		assert.Equal(t, int64(tc.expected), int64(w.Code), tc.method+" "+tc.url)
+	}
+
+	notifications, count := getNotifications(t, "/users/1/notifications", tokens[0], router)
+	assert.Equal(t, 0, count)
+	assert.Equal(t, int64(0), notifications.UnreadCount)
+	assert.Empty(t, notifications.Notifications)
+
+	// Los follows, likes, respuestas y menciones de otros usuarios generan notificaciones
+	for _, token := range tokens[1:5] {
+		w := makeAuthRequest(t, "POST", "/users_follow/create", map[string]interface{}{"followedId": 1}, token, router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+	}
+
+	w := makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Tweet de Mauricio"}, tokens[0], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	for _, token := range tokens[:3] {
+		w = makeAuthRequest(t, "POST", "/tweets/1/like", nil, token, router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+	}
+
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateReplyRequest{Content: "Respuesta de Juan", InReplyToTweetID: 1}, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Hola @mauri"}, tokens[2], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	// Mauricio no puede ver los tweets de la cuenta privada, por lo que no se le notifica la mencion
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Hola @mauri desde una cuenta privada"}, tokens[5], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	notifications, count = getNotifications(t, "/users/1/notifications", tokens[0], router)
+	assert.Equal(t, 4, count)
+	assert.Equal(t, int64(4), notifications.UnreadCount)
+
+	expected := []struct {
+		notificationType string
+		tweetId          int64
+		actorNames       []string
+		actorsCount      int64
+		message          string
+	}{
+		{"mention", 3, []string{"Ana Lopez"}, 1, "Ana Lopez mentioned you in a tweet"},
+		{"reply", 2, []string{"Juan Perez"}, 1, "Juan Perez replied to your tweet"},
+		{"like", 1, []string{"Ana Lopez", "Juan Perez"}, 2, "Ana Lopez and 1 other liked your tweet"},
+		{"follow", 0, []string{"Lucia Diaz", "Pedro Gomez", "Ana Lopez"}, 4, "Lucia Diaz and 3 others followed you"},
+	}
+
+	if assert.Len(t, notifications.Notifications, len(expected)) {
+		for i, tc := range expected {
+			group := notifications.Notifications[i]
+			assert.Equal(t, tc.notificationType, group.Type)
+			assert.Equal(t, tc.actorNames, getNotificationActorNames(group))
+			assert.Equal(t, tc.actorsCount, group.ActorsCount)
+			assert.Equal(t, tc.message, group.Message)
+			assert.False(t, group.Read)
+
+			if tc.tweetId == 0 {
+				assert.Nil(t, group.TweetID)
+			} else if assert.NotNil(t, group.TweetID) {
+				assert.Equal(t, tc.tweetId, *group.TweetID)
+			}
+		}
+	}
+
+	// De los actores solo se retornan sus datos publicos
+	w = makeAuthRequest(t, "GET", "/users/1/notifications", nil, tokens[0], router)
+	assert.NotContains(t, w.Body.String(), "@hotmail.com")
+
+	notifications, count = getNotifications(t, "/users/1/notifications?limit=2&offset=1", tokens[0], router)
+	assert.Equal(t, 4, count)
+	assert.Equal(t, []string{"reply", "like"}, getNotificationTypes(notifications))
+
+	// Las notificaciones de los usuarios silenciados no se listan
+	w = makeAuthRequest(t, "POST", "/users/1/mutes/3", nil, tokens[0], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	notifications, count = getNotifications(t, "/users/1/notifications", tokens[0], router)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, int64(3), notifications.UnreadCount)
+	assert.Equal(t, []string{"reply", "like", "follow"}, getNotificationTypes(notifications))
+	assert.Equal(t, "Juan Perez liked your tweet", notifications.Notifications[1].Message)
+	assert.Equal(t, "Lucia Diaz and 2 others followed you", notifications.Notifications[2].Message)
+
+	w = makeAuthRequest(t, "DELETE", "/users/1/mutes/3", nil, tokens[0], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	// Al marcarlas como leidas no quedan notificaciones sin leer, y las nuevas forman otro grupo
+	w = makeAuthRequest(t, "POST", "/users/1/notifications/read", nil, tokens[0], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	notifications, count = getNotifications(t, "/users/1/notifications", tokens[0], router)
+	assert.Equal(t, 4, count)
+	assert.Equal(t, int64(0), notifications.UnreadCount)
+	for _, group := range notifications.Notifications {
+		assert.True(t, group.Read)
+	}
+
+	w = makeAuthRequest(t, "POST", "/users_follow/create", map[string]interface{}{"followedId": 1}, tokens[5], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	notifications, count = getNotifications(t, "/users/1/notifications", tokens[0], router)
+	assert.Equal(t, 5, count)
+	assert.Equal(t, int64(1), notifications.UnreadCount)
+	assert.Equal(t, []string{"follow", "mention", "reply", "like", "follow"}, getNotificationTypes(notifications))
+	assert.Equal(t, "Sofia Ruiz followed you", notifications.Notifications[0].Message)
+	assert.False(t, notifications.Notifications[0].Read)
+
+	// Al eliminar un tweet se eliminan sus notificaciones
+	w = makeAuthRequest(t, "DELETE", "/tweets/1", nil, tokens[0], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	notifications, count = getNotifications(t, "/users/1/notifications", tokens[0], router)
+	assert.Equal(t, 4, count)
+	assert.Equal(t, []string{"follow", "mention", "reply", "follow"}, getNotificationTypes(notifications))
+
+	// Los demas usuarios no tienen notificaciones
+	notifications, count = getNotifications(t, "/users/2/notifications", tokens[1], router)
+	assert.Equal(t, 0, count)
+	assert.Equal(t, int64(0), notifications.UnreadCount)
+
+	// Aprobar una solicitud de seguimiento notifica el follow a la cuenta privada
+	w = makeAuthRequest(t, "POST", "/users_follow/create", map[string]interface{}{"followedId": 6}, tokens[1], router)
+	assert.Equal(t, int64(http.StatusAccepted), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/users/6/follow_requests/2/approve", nil, tokens[5], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	notifications, count = getNotifications(t, "/users/6/notifications", tokens[5], router)
+	assert.Equal(t, 1, count)
+	if assert.Len(t, notifications.Notifications, 1) {
+		assert.Equal(t, "Juan Perez followed you", notifications.Notifications[0].Message)
+	}
+
+	// Los follows de distintas ventanas de tiempo no se agrupan entre si
+	_, err = conn.Exec(`UPDATE notifications SET group_bucket = group_bucket - 1 WHERE user_id = 6 AND type = 'follow'`)
+	assert.NoError(t, err)
+
+	w = makeAuthRequest(t, "POST", "/users_follow/create", map[string]interface{}{"followedId": 6}, tokens[2], router)
+	assert.Equal(t, int64(http.StatusAccepted), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/users/6/follow_requests/3/approve", nil, tokens[5], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	notifications, count = getNotifications(t, "/users/6/notifications", tokens[5], router)
+	assert.Equal(t, 2, count)
+	if assert.Len(t, notifications.Notifications, 2) {
+		assert.Equal(t, []string{"Ana Lopez"}, getNotificationActorNames(notifications.Notifications[0]))
+		assert.Equal(t, "Ana Lopez followed you", notifications.Notifications[0].Message)
+		assert.Equal(t, []string{"Juan Perez"}, getNotificationActorNames(notifications.Notifications[1]))
+		assert.Equal(t, "Juan Perez followed you", notifications.Notifications[1].Message)
+	}
+}
+
+func getNotifications(t *testing.T, url string, token string, router *gin.Engine) (models.NotificationsPage, int) {
+	w := makeAuthRequest(t, "GET", url, nil, token, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	var response NotificationsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error decoding notifications: %v", err)
+	}
+
+	return response.Data, response.Count
+}
+
+func getNotificationTypes(notifications models.NotificationsPage) []string {
+	types := []string{}
+	for _, group := range notifications.Notifications {
+		types = append(types, group.Type)
+	}
+
+	return types
+}
+
+func getNotificationActorNames(group models.NotificationGroup) []string {
+	names := []string{}
+	for _, actor := range group.Actors {
+		names = append(names, actor.Name)
+	}
+
+	return names
+}
@@ -0,0 +1,344 @@
+package functional
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/factory"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type FollowRequestsResponse struct {
+	Code  int                        `json:"code"`
+	Data  []models.FollowRequestInfo `json:"data"`
+	Count int                        `json:"count"`
+}
+
+func TestPrivateAccounts(t *testing.T) {
+	caches := []struct {
+		name        string
+		cacheClient cache.Cache
+	}{
+		{"default cache", getMockCache()},
+		{"without cache", nil},
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			testPrivateAccounts(t, tc.cacheClient)
+		})
+	}
+}
+
+func testPrivateAccounts(t *testing.T, cacheClient cache.Cache) {
+	db, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{})
+	if err != nil {
+		t.Fatalf("failed to create DB: %v", err)
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	defer conn.Close()
+
+	router := setupTweetRouter(conn, cacheClient)
+
+	// El usuario 1 tiene una cuenta privada
+	users := []map[string]interface{}{
+		{"name": "Mauricio Giaconia", "email": "maurigiaconia@hotmail.com", "password": "1223", "isPrivate": true},
+		{"name": "Juan Perez", "email": "juanperez@hotmail.com", "password": "4567"},
+		{"name": "Ana Lopez", "email": "analopez@hotmail.com", "password": "8910"},
+	}
+	tokens := []string{}
+	for _, user := range users {
+		w := makeRequest(t, "POST", "/users/create", user, router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+		tokens = append(tokens, loginUser(t, user["email"].(string), user["password"].(string), router))
+	}
+
+	assert.True(t, getUser(t, "/users/1", router).IsPrivate)
+
+	// Tweet 1 de la cuenta privada y tweet 2 del usuario 2
+	w := makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Hola #privado"}, tokens[0], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Tweet de Juan #privado"}, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	// Seguir a la cuenta privada crea una solicitud pendiente en lugar del follow
+	for _, token := range []string{tokens[1], tokens[2]} {
+		w = makeAuthRequest(t, "POST", "/users_follow/create", map[string]interface{}{"followedId": 1}, token, router)
+		assert.Equal(t, int64(http.StatusAccepted), int64(w.Code))
+	}
+
+	w = makeAuthRequest(t, "POST", "/users_follow/create", map[string]interface{}{"followedId": 1}, tokens[1], router)
+	assert.Equal(t, int64(http.StatusBadRequest), int64(w.Code))
+
+	var errorResponse utils.ErrorResponse
+	err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	assert.NoError(t, err)
+	assert.Equal(t, "Follow request already exists", errorResponse.Error)
+
+	assert.Empty(t, getFollowNames(t, "/users_follow/2/follows/following", router))
+
+	// Solo la propia cuenta ve sus solicitudes, de la mas reciente a la mas antigua
+	w = makeAuthRequest(t, "GET", "/users/1/follow_requests", nil, tokens[1], router)
+	assert.Equal(t, int64(http.StatusForbidden), int64(w.Code))
+
+	requests := getFollowRequests(t, "/users/1/follow_requests", tokens[0], router)
+	assert.Equal(t, 2, requests.Count)
+	assert.Equal(t, []int{3, 2}, followRequestUserIds(requests.Data))
+
+	// Los tweets de la cuenta privada solo los ve la propia cuenta
+	assert.Equal(t, []int{2}, getVisibleTweetIds(t, "/hashtags/privado/tweets", "", router))
+	assert.Equal(t, []int{2}, getVisibleTweetIds(t, "/hashtags/privado/tweets", tokens[1], router))
+	assert.Equal(t, []int{2, 1}, getVisibleTweetIds(t, "/hashtags/privado/tweets", tokens[0], router))
+	assert.Equal(t, []int{2}, getVisibleTweetIds(t, "/search/tweets?q=privado", "", router))
+	assert.Equal(t, []int{2, 1}, getVisibleTweetIds(t, "/search/tweets?q=privado", tokens[0], router))
+	assert.NotContains(t, getTimelineTweetIds(t, "/tweets/2/timeline", tokens[1], router), 1)
+
+	w = makeRequest(t, "GET", "/tweets/1/thread", nil, router)
+	assert.Equal(t, int64(http.StatusForbidden), int64(w.Code))
+
+	w = makeAuthRequest(t, "GET", "/tweets/1/thread", nil, tokens[0], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	forbiddenActions := []struct {
+		method string
+		url    string
+		body   interface{}
+	}{
+		{"POST", "/tweets/1/like", nil},
+		{"POST", "/tweets/1/bookmark", nil},
+		{"POST", "/tweets/1/retweet", nil},
+		{"POST", "/tweets/1/quote", CreateTweetRequest{Content: "Cita"}},
+		{"POST", "/tweets/create", CreateReplyRequest{Content: "Respuesta", InReplyToTweetID: 1}},
+	}
+
+	for _, tc := range forbiddenActions {
+		w = makeAuthRequest(t, tc.method, tc.url, tc.body, tokens[1], router)
+		assert.Equal(t, int64(http.StatusForbidden), int64(w.Code), tc.url)
+	}
+
+	// Solo la cuenta privada resuelve sus solicitudes
+	w = makeAuthRequest(t, "POST", "/users/1/follow_requests/2/approve", nil, tokens[1], router)
+	assert.Equal(t, int64(http.StatusForbidden), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/users/1/follow_requests/2/approve", nil, tokens[0], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/users/1/follow_requests/2/approve", nil, tokens[0], router)
+	assert.Equal(t, int64(http.StatusNotFound), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/users/1/follow_requests/3/reject", nil, tokens[0], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/users/1/follow_requests/3/reject", nil, tokens[0], router)
+	assert.Equal(t, int64(http.StatusNotFound), int64(w.Code))
+
+	assert.Empty(t, getFollowRequests(t, "/users/1/follow_requests", tokens[0], router).Data)
+
+	// El seguidor aprobado ve los tweets de la cuenta privada, el rechazado no
+	assert.Equal(t, []string{"Mauricio Giaconia"}, getFollowNames(t, "/users_follow/2/follows/following", router))
+	assert.Contains(t, getTimelineTweetIds(t, "/tweets/2/timeline", tokens[1], router), 1)
+	assert.Equal(t, []int{2, 1}, getVisibleTweetIds(t, "/hashtags/privado/tweets", tokens[1], router))
+	assert.Equal(t, []int{2}, getVisibleTweetIds(t, "/hashtags/privado/tweets", tokens[2], router))
+	assert.Equal(t, []int{2, 1}, getVisibleTweetIds(t, "/search/tweets?q=privado", tokens[1], router))
+
+	w = makeAuthRequest(t, "GET", "/tweets/1/thread", nil, tokens[1], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/tweets/1/like", nil, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	// Los tweets de una cuenta privada no se pueden repostear ni siquiera por sus seguidores
+	w = makeAuthRequest(t, "POST", "/tweets/1/retweet", nil, tokens[1], router)
+	assert.Equal(t, int64(http.StatusForbidden), int64(w.Code))
+
+	// El seguidor puede cancelar su solicitud pendiente
+	w = makeAuthRequest(t, "POST", "/users_follow/create", map[string]interface{}{"followedId": 1}, tokens[2], router)
+	assert.Equal(t, int64(http.StatusAccepted), int64(w.Code))
+
+	w = makeAuthRequest(t, "DELETE", "/users_follow/3/1", nil, tokens[2], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	w = makeAuthRequest(t, "DELETE", "/users_follow/3/1", nil, tokens[2], router)
+	assert.Equal(t, int64(http.StatusNotFound), int64(w.Code))
+
+	// Al pasar a ser una cuenta publica se aprueban las solicitudes pendientes y sus tweets quedan visibles para todos
+	w = makeAuthRequest(t, "POST", "/users_follow/create", map[string]interface{}{"followedId": 1}, tokens[2], router)
+	assert.Equal(t, int64(http.StatusAccepted), int64(w.Code))
+
+	w = makeAuthRequest(t, "PATCH", "/users/1", map[string]interface{}{"isPrivate": false}, tokens[0], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+	assert.False(t, getUser(t, "/users/1", router).IsPrivate)
+
+	assert.Equal(t, []string{"Mauricio Giaconia"}, getFollowNames(t, "/users_follow/3/follows/following", router))
+	assert.Empty(t, getFollowRequests(t, "/users/1/follow_requests", tokens[0], router).Data)
+	assert.Equal(t, []int{2, 1}, getVisibleTweetIds(t, "/hashtags/privado/tweets", "", router))
+
+	w = makeAuthRequest(t, "POST", "/tweets/1/retweet", nil, tokens[2], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	// Una cuenta publica vuelve a aceptar follows inmediatos
+	w = makeAuthRequest(t, "POST", "/users_follow/create", map[string]interface{}{"followedId": 1}, tokens[2], router)
+	assert.Equal(t, int64(http.StatusBadRequest), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/users_follow/create", map[string]interface{}{"followedId": 2}, tokens[2], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+}
+
+func TestPrivateOriginalTweets(t *testing.T) {
+	caches := []struct {
+		name        string
+		cacheClient cache.Cache
+	}{
+		{"default cache", getMockCache()},
+		{"without cache", nil},
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			testPrivateOriginalTweets(t, tc.cacheClient)
+		})
+	}
+}
+
+func testPrivateOriginalTweets(t *testing.T, cacheClient cache.Cache) {
+	db, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{})
+	if err != nil {
+		t.Fatalf("failed to create DB: %v", err)
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	defer conn.Close()
+
+	router := setupTweetRouter(conn, cacheClient)
+
+	users := []map[string]interface{}{
+		{"name": "Mauricio Giaconia", "email": "maurigiaconia@hotmail.com", "password": "1223"},
+		{"name": "Juan Perez", "email": "juanperez@hotmail.com", "password": "4567"},
+		{"name": "Ana Lopez", "email": "analopez@hotmail.com", "password": "8910"},
+	}
+	tokens := []string{}
+	for _, user := range users {
+		w := makeRequest(t, "POST", "/users/create", user, router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+		tokens = append(tokens, loginUser(t, user["email"].(string), user["password"].(string), router))
+	}
+
+	// El usuario 2 sigue al usuario 1 y el usuario 3 solo sigue al usuario 2
+	follows := []struct {
+		token      string
+		followedId int64
+	}{
+		{tokens[1], 1},
+		{tokens[2], 2},
+	}
+	for _, follow := range follows {
+		w := makeAuthRequest(t, "POST", "/users_follow/create", map[string]interface{}{"followedId": follow.followedId}, follow.token, router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+	}
+
+	// Tweet 1 del usuario 1 (todavia publico), tweet 2 retweet y tweet 3 cita del usuario 2. El usuario 3 guarda el tweet 1
+	w := makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Hola #original"}, tokens[0], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/tweets/1/retweet", nil, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/tweets/1/quote", CreateTweetRequest{Content: "Cita #original"}, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/tweets/1/bookmark", nil, tokens[2], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	// originalTweetIds obtiene el ID del tweet original de cada tweet, 0 si no lo incluye
+	originalTweetIds := func(tweets []LikedTweet) []int {
+		ids := []int{}
+		for _, tweet := range tweets {
+			id := 0
+			if tweet.OriginalTweet != nil {
+				id = tweet.OriginalTweet.TweetID
+			}
+			ids = append(ids, id)
+		}
+		return ids
+	}
+
+	// Se consulta el timeline antes del cambio para que el contenido de los tweets quede en cache
+	assert.Equal(t, []int{1, 1}, originalTweetIds(getLikedTweets(t, "/tweets/3/timeline", tokens[2], router)))
+
+	w = makeAuthRequest(t, "PATCH", "/users/1", map[string]interface{}{"isPrivate": true}, tokens[0], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	// Quien no sigue a la cuenta privada ve los retweets y citas sin el tweet original; sus seguidores lo siguen viendo
+	timeline := getLikedTweets(t, "/tweets/3/timeline", tokens[2], router)
+	assert.Equal(t, []int{3, 2}, likedTweetIds(timeline))
+	assert.Equal(t, []int{0, 0}, originalTweetIds(timeline))
+	assert.Equal(t, []int{1, 0}, originalTweetIds(getLikedTweets(t, "/hashtags/original/tweets", tokens[1], router)))
+
+	assert.Equal(t, []int{0}, originalTweetIds(getLikedTweets(t, "/hashtags/original/tweets", "", router)))
+	assert.Equal(t, []int{0}, originalTweetIds(getLikedTweets(t, "/search/tweets?q=original", tokens[2], router)))
+
+	w = makeAuthRequest(t, "GET", "/tweets/3/thread", nil, tokens[2], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	var thread struct {
+		Data struct {
+			Tweet LikedTweet `json:"tweet"`
+		} `json:"data"`
+	}
+	err = json.Unmarshal(w.Body.Bytes(), &thread)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, thread.Data.Tweet.TweetID)
+	assert.Nil(t, thread.Data.Tweet.OriginalTweet)
+
+	// Los tweets guardados de la cuenta privada dejan de aparecer en los bookmarks de quien no la sigue
+	bookmarks := getBookmarks(t, "/users/3/bookmarks", tokens[2], router)
+	assert.Empty(t, bookmarks.Data)
+	assert.Zero(t, bookmarks.Count)
+}
+
+// getVisibleTweetIds obtiene los IDs de los tweets que ve el usuario del token (sin token, un usuario anonimo)
+func getVisibleTweetIds(t *testing.T, url string, token string, router *gin.Engine) []int {
+	w := makeAuthRequest(t, "GET", url, nil, token, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code), url)
+
+	var response TimelineResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	return timelineTweetIds(response.Data)
+}
+
+func getFollowRequests(t *testing.T, url string, token string, router *gin.Engine) FollowRequestsResponse {
+	w := makeAuthRequest(t, "GET", url, nil, token, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	var response FollowRequestsResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	return response
+}
+
+func followRequestUserIds(requests []models.FollowRequestInfo) []int {
+	ids := []int{}
+	for _, request := range requests {
+		ids = append(ids, int(request.RequesterUserData.ID))
+	}
+
+	return ids
+}
@@ -0,0 +1,238 @@
+package functional
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/factory"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateAndDeleteUser(t *testing.T) {
+	caches := []struct {
+		name        string
+		cacheClient cache.Cache
+	}{
+		{"default cache", getMockCache()},
+		{"without cache", nil},
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			testUpdateAndDeleteUser(t, tc.cacheClient)
+		})
+	}
+}
+
+func testUpdateAndDeleteUser(t *testing.T, cacheClient cache.Cache) {
+	db, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{})
+	if err != nil {
+		t.Fatalf("failed to create DB: %v", err)
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	defer conn.Close()
+
+	router := setupTweetRouter(conn, cacheClient)
+
+	// El usuario 3 sigue a los usuarios 1 y 2
+	users := []map[string]interface{}{
+		{"name": "Mauricio Giaconia", "email": "maurigiaconia@hotmail.com", "password": "1223"},
+		{"name": "Juan Perez", "email": "juanperez@hotmail.com", "password": "4567"},
+		{"name": "Ana Lopez", "email": "analopez@hotmail.com", "password": "8910"},
+	}
+	tokens := []string{}
+	for _, user := range users {
+		w := makeRequest(t, "POST", "/users/create", user, router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+		tokens = append(tokens, loginUser(t, user["email"].(string), user["password"].(string), router))
+	}
+
+	for _, followedId := range []int64{1, 2} {
+		w := makeAuthRequest(t, "POST", "/users_follow/create", map[string]interface{}{"followedId": followedId}, tokens[2], router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+	}
+
+	// Tweet 1 del usuario 1, tweet 2 del usuario 2, tweet 3 retweet del usuario 2 al tweet 1 y tweet 4 cita del usuario 2 al tweet 1
+	w := makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Hola #golang"}, tokens[0], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Tweet de Juan"}, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/tweets/1/retweet", nil, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/tweets/1/quote", CreateTweetRequest{Content: "Cita de Juan"}, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/tweets/2/like", nil, tokens[0], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/tweets/1/bookmark", nil, tokens[2], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	// Se consultan el timeline y los follows antes de modificar al usuario para que queden en cache
+	assert.Equal(t, []int{4, 3, 2, 1}, getTimelineTweetIds(t, "/tweets/3/timeline", tokens[2], router))
+	assert.Equal(t, []string{"Juan Perez", "Mauricio Giaconia"}, getFollowNames(t, "/users_follow/3/follows/following", router))
+
+	// Solo el propio usuario autenticado puede modificar su perfil
+	w = makeRequest(t, "PATCH", "/users/1", map[string]interface{}{"name": "Otro"}, router)
+	assert.Equal(t, int64(http.StatusUnauthorized), int64(w.Code))
+
+	w = makeAuthRequest(t, "PATCH", "/users/1", map[string]interface{}{"name": "Otro"}, tokens[1], router)
+	assert.Equal(t, int64(http.StatusForbidden), int64(w.Code))
+
+	w = makeAuthRequest(t, "PATCH", "/users/abc", map[string]interface{}{"name": "Otro"}, tokens[0], router)
+	assert.Equal(t, int64(http.StatusBadRequest), int64(w.Code))
+
+	invalidUpdates := []struct {
+		update   map[string]interface{}
+		expected int
+		message  string
+	}{
+		{map[string]interface{}{}, http.StatusBadRequest, "No fields to update"},
+		{map[string]interface{}{"name": "  "}, http.StatusBadRequest, "Invalid name: must have between 1 and 50 characters"},
+		{map[string]interface{}{"bio": strings.Repeat("a", 161)}, http.StatusBadRequest, "Invalid bio: must not exceed 160 characters"},
+		{map[string]interface{}{"location": strings.Repeat("a", 31)}, http.StatusBadRequest, "Invalid location: must not exceed 30 characters"},
+		{map[string]interface{}{"website": "ftp://example.com"}, http.StatusBadRequest, "Invalid website: must be an http or https URL of up to 200 characters"},
+		{map[string]interface{}{"avatarUrl": "avatar.png"}, http.StatusBadRequest, "Invalid avatarUrl: must be an http or https URL of up to 200 characters"},
+		{map[string]interface{}{"email": "mauricio"}, http.StatusBadRequest, "Invalid email"},
+		{map[string]interface{}{"email": "juanperez@hotmail.com"}, http.StatusConflict, "Email already in use"},
+	}
+
+	for _, tc := range invalidUpdates {
+		w = makeAuthRequest(t, "PATCH", "/users/1", tc.update, tokens[0], router)
+		assert.Equal(t, int64(tc.expected), int64(w.Code), tc.message)
+
+		var errorResponse utils.ErrorResponse
+		err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.message, errorResponse.Error)
+	}
+
+	update := map[string]interface{}{
+		"name":      " Mauricio G ",
+		"email":     "mauri@example.com",
+		"bio":       "Desarrollador backend",
+		"location":  "Buenos Aires",
+		"website":   "https://example.com",
+		"avatarUrl": "https://example.com/avatar.png",
+	}
+	w = makeAuthRequest(t, "PATCH", "/users/1", update, tokens[0], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	var updateResponse UserResponse
+	err = json.Unmarshal(w.Body.Bytes(), &updateResponse)
+	assert.NoError(t, err)
+	assert.Equal(t, "Mauricio G", updateResponse.Data.Name)
+	assert.Equal(t, "mauri@example.com", updateResponse.Data.Email)
+	assert.Equal(t, "mauriciogiaconi", updateResponse.Data.Handle)
+	assert.Empty(t, updateResponse.Data.Password)
+
+	// Un texto vacio borra el dato y los campos no enviados no se modifican
+	w = makeAuthRequest(t, "PATCH", "/users/1", map[string]interface{}{"website": ""}, tokens[0], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	user := getUser(t, "/users/1", router)
+	assert.Equal(t, "Mauricio G", user.Name)
+	assert.Equal(t, "Desarrollador backend", user.Bio)
+	assert.Equal(t, "Buenos Aires", user.Location)
+	assert.Empty(t, user.Website)
+	assert.Equal(t, "https://example.com/avatar.png", user.AvatarURL)
+
+	// El login pasa a usar el nuevo email
+	loginUser(t, "mauri@example.com", "1223", router)
+
+	w = makeRequest(t, "POST", "/auth/login", map[string]interface{}{"email": "maurigiaconia@hotmail.com", "password": "1223"}, router)
+	assert.Equal(t, int64(http.StatusUnauthorized), int64(w.Code))
+
+	// El nuevo nombre se ve en el timeline y en los follows aunque estuvieran cacheados
+	timeline := getLikedTweets(t, "/tweets/3/timeline", tokens[2], router)
+	if assert.Len(t, timeline, 4) {
+		assert.Equal(t, "Mauricio G", timeline[3].AuthorName)
+		if assert.NotNil(t, timeline[1].OriginalTweet) {
+			assert.Equal(t, "Mauricio G", timeline[1].OriginalTweet.AuthorName)
+		}
+		assert.Equal(t, int64(1), timeline[2].LikeCount)
+	}
+	assert.Equal(t, []string{"Juan Perez", "Mauricio G"}, getFollowNames(t, "/users_follow/3/follows/following", router))
+
+	// Tweet 5: respuesta del usuario 2 al tweet 1, que se conserva al eliminar al usuario 1
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateReplyRequest{Content: "Respuesta de Juan", InReplyToTweetID: 1}, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	// Solo el propio usuario autenticado puede eliminar su cuenta
+	w = makeAuthRequest(t, "DELETE", "/users/1", nil, tokens[1], router)
+	assert.Equal(t, int64(http.StatusForbidden), int64(w.Code))
+
+	w = makeAuthRequest(t, "DELETE", "/users/1", nil, tokens[0], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	w = makeAuthRequest(t, "DELETE", "/users/1", nil, tokens[0], router)
+	assert.Equal(t, int64(http.StatusNotFound), int64(w.Code))
+
+	w = makeRequest(t, "GET", "/users/1", nil, router)
+	assert.Equal(t, int64(http.StatusNotFound), int64(w.Code))
+
+	w = makeRequest(t, "POST", "/auth/login", map[string]interface{}{"email": "mauri@example.com", "password": "1223"}, router)
+	assert.Equal(t, int64(http.StatusUnauthorized), int64(w.Code))
+
+	// Se eliminan sus tweets y el retweet del usuario 2; la cita queda sin el tweet citado, la respuesta deja de ser
+	// respuesta a un tweet o a un usuario y el like del usuario se descuenta
+	timeline = getLikedTweets(t, "/tweets/3/timeline", tokens[2], router)
+	assert.Equal(t, []int{5, 4, 2}, likedTweetIds(timeline))
+	if assert.Len(t, timeline, 3) {
+		assert.Nil(t, timeline[1].OriginalTweet)
+		assert.Zero(t, timeline[2].LikeCount)
+	}
+
+	var inReplyToTweetId, inReplyToUserId sql.NullInt64
+	err = conn.QueryRow(`SELECT in_reply_to_tweet_id, in_reply_to_user_id FROM tweets WHERE id = 5`).Scan(&inReplyToTweetId, &inReplyToUserId)
+	assert.NoError(t, err)
+	assert.False(t, inReplyToTweetId.Valid)
+	assert.False(t, inReplyToUserId.Valid)
+
+	assert.Equal(t, []string{"Juan Perez"}, getFollowNames(t, "/users_follow/3/follows/following", router))
+	assert.Empty(t, getBookmarks(t, "/users/3/bookmarks", tokens[2], router).Data)
+	assert.Empty(t, getEntitiesTweets(t, "/hashtags/golang/tweets", router))
+
+	// El email y el handle quedan libres
+	w = makeRequest(t, "POST", "/users/create", map[string]interface{}{"name": "Mauricio", "email": "mauri@example.com", "password": "1", "handle": "mauriciogiaconi"}, router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+}
+
+func getFollowNames(t *testing.T, url string, router *gin.Engine) []string {
+	w := makeRequest(t, "GET", url, nil, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	var response FollowsResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	names := []string{}
+	for _, follow := range response.Data.Follows {
+		names = append(names, follow.FollowUserData.Name)
+	}
+
+	return names
+}
+
+func likedTweetIds(tweets []LikedTweet) []int {
+	ids := []int{}
+	for _, tweet := range tweets {
+		ids = append(ids, tweet.TweetID)
+	}
+
+	return ids
+}
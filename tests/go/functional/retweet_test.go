@@ -0,0 +1,173 @@
+package functional
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/factory"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type RepostTweet struct {
+	TimelineTweet
+	RetweetOfTweetID int64          `json:"retweetOfTweetId"`
+	QuotedTweetID    int64          `json:"quotedTweetId"`
+	OriginalTweet    *TimelineTweet `json:"originalTweet"`
+}
+
+type RepostTimelineResponse struct {
+	Code int           `json:"code"`
+	Data []RepostTweet `json:"data"`
+}
+
+type RetweetResponse struct {
+	Code int         `json:"code"`
+	Data RepostTweet `json:"data"`
+}
+
+func TestRetweetsAndQuotes(t *testing.T) {
+	caches := []struct {
+		name        string
+		cacheClient cache.Cache
+	}{
+		{"default cache", getMockCache()},
+		{"without cache", nil},
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			testRetweetsAndQuotes(t, tc.cacheClient)
+		})
+	}
+}
+
+func testRetweetsAndQuotes(t *testing.T, cacheClient cache.Cache) {
+	db, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{})
+	if err != nil {
+		t.Fatalf("failed to create DB: %v", err)
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	defer conn.Close()
+
+	router := setupTweetRouter(conn, cacheClient)
+
+	// El usuario 3 sigue al usuario 2, que retweetea y cita los tweets del usuario 1
+	users := []map[string]interface{}{
+		{"name": "Mauricio Giaconia", "email": "maurigiaconia@hotmail.com", "password": "1223"},
+		{"name": "Juan Perez", "email": "juanperez@hotmail.com", "password": "4567"},
+		{"name": "Ana Lopez", "email": "analopez@hotmail.com", "password": "8910"},
+	}
+	tokens := []string{}
+	for _, user := range users {
+		w := makeRequest(t, "POST", "/users/create", user, router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+		tokens = append(tokens, loginUser(t, user["email"].(string), user["password"].(string), router))
+	}
+
+	w := makeAuthRequest(t, "POST", "/users_follow/create", map[string]interface{}{"followedId": 2}, tokens[2], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	// Se consulta el timeline para que se construya el feed antes de retweetear
+	assert.Empty(t, getRepostTimeline(t, "/tweets/3/timeline", tokens[2], router))
+
+	// Tweet 1: tweet original del usuario 1
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Tweet original"}, tokens[0], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	// Tweet 2: retweet del usuario 2
+	w = makeAuthRequest(t, "POST", "/tweets/1/retweet", nil, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	var retweetResponse RetweetResponse
+	err = json.Unmarshal(w.Body.Bytes(), &retweetResponse)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, retweetResponse.Data.TweetID)
+	assert.Equal(t, 2, retweetResponse.Data.AuthorID)
+	assert.Equal(t, int64(1), retweetResponse.Data.RetweetOfTweetID)
+	if assert.NotNil(t, retweetResponse.Data.OriginalTweet) {
+		assert.Equal(t, "Tweet original", retweetResponse.Data.OriginalTweet.Content)
+	}
+
+	// Retweetear de nuevo el tweet, o su retweet, no crea otro retweet
+	for _, url := range []string{"/tweets/1/retweet", "/tweets/2/retweet"} {
+		w = makeAuthRequest(t, "POST", url, nil, tokens[1], router)
+		assert.Equal(t, int64(http.StatusConflict), int64(w.Code))
+	}
+
+	w = makeAuthRequest(t, "POST", "/tweets/99/retweet", nil, tokens[1], router)
+	assert.Equal(t, int64(http.StatusNotFound), int64(w.Code))
+
+	// Tweet 3: cita del usuario 2
+	w = makeAuthRequest(t, "POST", "/tweets/1/quote", CreateTweetRequest{Content: "Comentario"}, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/tweets/1/quote", CreateTweetRequest{Content: " "}, tokens[1], router)
+	assert.Equal(t, int64(http.StatusBadRequest), int64(w.Code))
+
+	var errorResponse utils.ErrorResponse
+	err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	assert.NoError(t, err)
+	assert.Equal(t, "The content of the quote is required", errorResponse.Error)
+
+	// El timeline muestra el retweet y la cita como tweets del usuario 2, con el tweet original embebido
+	timeline := getRepostTimeline(t, "/tweets/3/timeline", tokens[2], router)
+	if assert.Len(t, timeline, 2) {
+		assert.Equal(t, 3, timeline[0].TweetID)
+		assert.Equal(t, "Comentario", timeline[0].Content)
+		assert.Equal(t, int64(1), timeline[0].QuotedTweetID)
+		assert.Equal(t, 2, timeline[1].TweetID)
+		assert.Equal(t, "Juan Perez", timeline[1].AuthorName)
+		assert.Equal(t, int64(1), timeline[1].RetweetOfTweetID)
+
+		for _, tweet := range timeline {
+			if assert.NotNil(t, tweet.OriginalTweet) {
+				assert.Equal(t, 1, tweet.OriginalTweet.TweetID)
+				assert.Equal(t, "Mauricio Giaconia", tweet.OriginalTweet.AuthorName)
+			}
+		}
+	}
+
+	// Deshacer el retweet lo quita del timeline
+	w = makeAuthRequest(t, "DELETE", "/tweets/1/retweet", nil, tokens[1], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	w = makeAuthRequest(t, "DELETE", "/tweets/1/retweet", nil, tokens[1], router)
+	assert.Equal(t, int64(http.StatusNotFound), int64(w.Code))
+
+	assert.Equal(t, []int{3}, getTimelineTweetIds(t, "/tweets/3/timeline", tokens[2], router))
+
+	// Tweet 4: se retweetea otra vez. Al eliminar el original se eliminan sus retweets y la cita queda sin el tweet embebido
+	w = makeAuthRequest(t, "POST", "/tweets/1/retweet", nil, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+	assert.Equal(t, []int{4, 3}, getTimelineTweetIds(t, "/tweets/3/timeline", tokens[2], router))
+
+	w = makeAuthRequest(t, "DELETE", "/tweets/1", nil, tokens[0], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	timeline = getRepostTimeline(t, "/tweets/3/timeline", tokens[2], router)
+	if assert.Len(t, timeline, 1) {
+		assert.Equal(t, 3, timeline[0].TweetID)
+		assert.Zero(t, timeline[0].QuotedTweetID)
+		assert.Nil(t, timeline[0].OriginalTweet)
+	}
+}
+
+func getRepostTimeline(t *testing.T, url string, token string, router *gin.Engine) []RepostTweet {
+	w := makeAuthRequest(t, "GET", url, nil, token, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	var response RepostTimelineResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	return response.Data
+}
@@ -0,0 +1,178 @@
+package functional
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/factory"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchTweets(t *testing.T) {
+	caches := []struct {
+		name        string
+		cacheClient cache.Cache
+	}{
+		{"default cache", getMockCache()},
+		{"without cache", nil},
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			testSearchTweets(t, tc.cacheClient)
+		})
+	}
+}
+
+func testSearchTweets(t *testing.T, cacheClient cache.Cache) {
+	db, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{})
+	if err != nil {
+		t.Fatalf("failed to create DB: %v", err)
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	defer conn.Close()
+
+	router := setupTweetRouter(conn, cacheClient)
+
+	users := []map[string]interface{}{
+		{"name": "Mauricio Giaconia", "email": "maurigiaconia@hotmail.com", "password": "1223"},
+		{"name": "Juan Perez", "email": "juanperez@hotmail.com", "password": "4567"},
+	}
+	tokens := []string{}
+	for _, user := range users {
+		w := makeRequest(t, "POST", "/users/create", user, router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+		tokens = append(tokens, loginUser(t, user["email"].(string), user["password"].(string), router))
+	}
+
+	tweets := []struct {
+		token   string
+		content string
+	}{
+		{tokens[0], "Hoy juega la seleccion #Mundial"},
+		{tokens[1], "El partido estuvo aburrido"},
+		{tokens[0], "Aprendiendo el lenguaje de Google #golang"},
+		{tokens[1], "Me encanta este lenguaje #golang"},
+	}
+	for _, tweet := range tweets {
+		w := makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: tweet.content}, tweet.token, router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+	}
+
+	// Tweet 5: retweet del tweet 3, no aparece en la busqueda ya que no tiene contenido propio
+	w := makeAuthRequest(t, "POST", "/tweets/3/retweet", nil, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	today := time.Now().UTC().Format("2006-01-02")
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format("2006-01-02")
+
+	searches := []struct {
+		q        string
+		expected []int
+	}{
+		{"lenguaje", []int{4, 3}},
+		{"LENGUAJE", []int{4, 3}},
+		// Se buscan palabras completas, sin distinguir acentos
+		{"lengua", []int{}},
+		{"selección", []int{1}},
+		{"partido aburrido", []int{2}},
+		{"partido divertido", []int{}},
+		{`"lenguaje de Google"`, []int{3}},
+		{`"Google de lenguaje"`, []int{}},
+		{"lenguaje from:1", []int{3}},
+		{"from:2", []int{4, 2}},
+		{"#golang", []int{4, 3}},
+		{"#GOLANG from:2", []int{4}},
+		{`#golang "de google"`, []int{3}},
+		{"#mundial", []int{1}},
+		{"lenguaje since:" + today, []int{4, 3}},
+		{"lenguaje since:" + tomorrow, []int{}},
+		{"lenguaje until:" + today, []int{}},
+		{"since:" + today + " until:" + tomorrow, []int{4, 3, 2, 1}},
+	}
+
+	for _, search := range searches {
+		response := searchTweets(t, url.Values{"q": {search.q}}, router)
+		assert.Equal(t, search.expected, timelineTweetIds(response.Data), search.q)
+		assert.Equal(t, len(search.expected), response.Count, search.q)
+	}
+
+	// Paginado por cursor
+	response := searchTweets(t, url.Values{"q": {"lenguaje"}, "limit": {"1"}}, router)
+	assert.Equal(t, []int{4}, timelineTweetIds(response.Data))
+	assert.NotEmpty(t, response.NextCursor)
+
+	response = searchTweets(t, url.Values{"q": {"lenguaje"}, "limit": {"1"}, "cursor": {response.NextCursor}}, router)
+	assert.Equal(t, []int{3}, timelineTweetIds(response.Data))
+
+	invalidSearches := []struct {
+		q       string
+		message string
+	}{
+		{"", "The search query is required"},
+		{` "" - `, "The search query is required"},
+		{"lenguaje from:abc", "Invalid from: user ID"},
+		{"since:2024-13-01", "Invalid since: date"},
+		{"until:ayer", "Invalid until: date"},
+		{"#123", "Invalid hashtag"},
+		{"since:2024-01-02 until:2024-01-01", "The until: date must be after the since: date"},
+	}
+
+	for _, search := range invalidSearches {
+		w = makeRequest(t, "GET", "/search/tweets?"+url.Values{"q": {search.q}}.Encode(), nil, router)
+		assert.Equal(t, int64(http.StatusBadRequest), int64(w.Code), search.q)
+
+		var errorResponse utils.ErrorResponse
+		err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+		assert.NoError(t, err)
+		assert.Equal(t, search.message, errorResponse.Error)
+	}
+
+	// likedByViewer indica si el usuario que consulta dio like al tweet
+	w = makeAuthRequest(t, "POST", "/tweets/2/like", nil, tokens[0], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	for i, likedByViewer := range []bool{true, false} {
+		tweets := getLikedTweets(t, "/search/tweets?"+url.Values{"q": {"aburrido"}}.Encode(), tokens[i], router)
+		if assert.Len(t, tweets, 1) {
+			assert.Equal(t, likedByViewer, tweets[0].LikedByViewer)
+		}
+	}
+
+	// Los tweets eliminados dejan de aparecer en la busqueda
+	w = makeAuthRequest(t, "DELETE", "/tweets/3", nil, tokens[0], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	assert.Equal(t, []int{4}, timelineTweetIds(searchTweets(t, url.Values{"q": {"lenguaje"}}, router).Data))
+}
+
+func searchTweets(t *testing.T, params url.Values, router *gin.Engine) TimelineResponse {
+	w := makeRequest(t, "GET", "/search/tweets?"+params.Encode(), nil, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code), params.Encode())
+
+	var response TimelineResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	return response
+}
+
+func timelineTweetIds(tweets []TimelineTweet) []int {
+	ids := []int{}
+	for _, tweet := range tweets {
+		ids = append(ids, tweet.TweetID)
+	}
+
+	return ids
+}
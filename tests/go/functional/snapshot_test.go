@@ -0,0 +1,161 @@
+package functional
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/services"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/factory"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLiteFileModePersistsData(t *testing.T) {
+	database, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{SQLitePath: filepath.Join(t.TempDir(), "tweets.db")})
+	if err != nil {
+		t.Fatalf("failed to create DB: %v", err)
+	}
+
+	conn, err := database.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	var journalMode string
+	err = conn.QueryRow(`PRAGMA journal_mode`).Scan(&journalMode)
+	assert.NoError(t, err)
+	assert.Equal(t, "wal", journalMode)
+
+	router := setupTweetRouter(conn, nil)
+	w := makeRequest(t, "POST", "/users/create", map[string]interface{}{"name": "Mauricio Giaconia", "email": "maurigiaconia@hotmail.com", "password": "1223"}, router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	conn.Close()
+
+	// Al volver a conectar los datos siguen estando
+	conn, err = database.Connect()
+	if err != nil {
+		t.Fatalf("failed to reconnect DB: %v", err)
+	}
+
+	defer conn.Close()
+
+	router = setupTweetRouter(conn, nil)
+	w = makeRequest(t, "GET", "/users/1", nil, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+}
+
+func TestAdminSnapshotAndRestore(t *testing.T) {
+	t.Setenv("ADMIN_TOKEN", "admin-secret")
+
+	snapshotDir := services.SQLiteSnapshotDir
+	services.SQLiteSnapshotDir = t.TempDir()
+	defer func() { services.SQLiteSnapshotDir = snapshotDir }()
+
+	db, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{})
+	if err != nil {
+		t.Fatalf("failed to create DB: %v", err)
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	defer conn.Close()
+
+	cacheClient := cache.NewMemoryCache(cache.DefaultMemoryCacheSize)
+	router := setupTweetRouter(conn, cacheClient)
+
+	// Sin el token de administrador no se puede crear un snapshot
+	for _, token := range []string{"", "incorrecto"} {
+		w := makeAdminRequest(t, "POST", "/admin/db/snapshot", nil, token, router)
+		assert.Equal(t, int64(http.StatusUnauthorized), int64(w.Code))
+	}
+
+	w := makeRequest(t, "POST", "/users/create", map[string]interface{}{"name": "Mauricio Giaconia", "email": "maurigiaconia@hotmail.com", "password": "1223"}, router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeAdminRequest(t, "POST", "/admin/db/snapshot", map[string]interface{}{"name": "demo.db"}, "admin-secret", router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	requests := []struct {
+		url      string
+		name     string
+		expected int
+		message  string
+	}{
+		{"/admin/db/snapshot", "demo.db", http.StatusConflict, "Snapshot already exists"},
+		{"/admin/db/snapshot", "../demo.db", http.StatusBadRequest, "Invalid snapshot name"},
+		{"/admin/db/restore", "", http.StatusBadRequest, "Snapshot name is required"},
+		{"/admin/db/restore", "inexistente.db", http.StatusNotFound, "Snapshot not found"},
+		{"/admin/db/restore", "../demo.db", http.StatusBadRequest, "Invalid snapshot name"},
+	}
+
+	for _, tc := range requests {
+		w = makeAdminRequest(t, "POST", tc.url, map[string]interface{}{"name": tc.name}, "admin-secret", router)
+		assert.Equal(t, int64(tc.expected), int64(w.Code), tc.url+" "+tc.name)
+
+		var errorResponse utils.ErrorResponse
+		err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.message, errorResponse.Error)
+	}
+
+	// Sin nombre se genera uno a partir de la fecha
+	w = makeAdminRequest(t, "POST", "/admin/db/snapshot", nil, "admin-secret", router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeRequest(t, "POST", "/users/create", map[string]interface{}{"name": "Usuario Nuevo", "email": "nuevo@hotmail.com", "password": "1223"}, router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	err = cacheClient.Set(context.Background(), "tweet:1", "cacheado", time.Hour)
+	assert.NoError(t, err)
+
+	// Al restaurar el snapshot se pierde el usuario creado despues y se vacia el cache
+	w = makeAdminRequest(t, "POST", "/admin/db/restore", map[string]interface{}{"name": "demo.db"}, "admin-secret", router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	w = makeRequest(t, "GET", "/users/1", nil, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	w = makeRequest(t, "GET", "/users/2", nil, router)
+	assert.Equal(t, int64(http.StatusNotFound), int64(w.Code))
+
+	_, err = cacheClient.Get(context.Background(), "tweet:1")
+	assert.ErrorIs(t, err, cache.ErrCacheMiss)
+}
+
+func makeAdminRequest(t *testing.T, method, url string, body interface{}, adminToken string, router *gin.Engine) *httptest.ResponseRecorder {
+	var requestBody []byte
+	if body != nil {
+		var err error
+		requestBody, err = json.Marshal(body)
+		if err != nil {
+			t.Fatalf("Error marshalling request body: %v", err)
+		}
+	}
+
+	req, err := http.NewRequest(method, url, bytes.NewBuffer(requestBody))
+	if err != nil {
+		t.Fatalf("Error creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if adminToken != "" {
+		req.Header.Set("X-Admin-Token", adminToken)
+	}
+
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	return w
+}
@@ -0,0 +1,240 @@
+package functional
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/auth"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/factory"
+	"github.com/stretchr/testify/assert"
+)
+
+type streamEvent struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+func TestTimelineStream(t *testing.T) {
+	caches := []struct {
+		name        string
+		cacheClient cache.Cache
+	}{
+		{"default cache", getMockCache()},
+		{"without cache", nil},
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			testTimelineStream(t, tc.cacheClient)
+		})
+	}
+}
+
+func testTimelineStream(t *testing.T, cacheClient cache.Cache) {
+	db, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{})
+	if err != nil {
+		t.Fatalf("failed to create DB: %v", err)
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	defer conn.Close()
+
+	router := setupTweetRouter(conn, cacheClient)
+
+	// El stream necesita un servidor real para mantener la conexion abierta mientras se crean tweets
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	users := []map[string]interface{}{
+		{"name": "Mauricio Giaconia", "email": "maurigiaconia@hotmail.com", "password": "1223"},
+		{"name": "Juan Perez", "email": "juanperez@hotmail.com", "password": "4567"},
+		{"name": "Ana Lopez", "email": "analopez@hotmail.com", "password": "8910"},
+	}
+	tokens := []string{}
+	for _, user := range users {
+		w := makeRequest(t, "POST", "/users/create", user, router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+		tokens = append(tokens, loginUser(t, user["email"].(string), user["password"].(string), router))
+	}
+
+	w := makeAuthRequest(t, "POST", "/users_follow/create", map[string]interface{}{"followedId": 1}, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	postTweet := func(token string, content string) {
+		w := makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: content}, token, router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+	}
+
+	postTweet(tokens[0], "Tweet previo al stream")
+
+	nonexistentUserToken, err := auth.GenerateToken(99, auth.AccessTokenType, auth.AccessTokenTTL)
+	assert.NoError(t, err)
+
+	invalidRequests := []struct {
+		url         string
+		token       string
+		lastEventId string
+		expected    int
+	}{
+		{"/tweets/2/stream", "", "", http.StatusUnauthorized},
+		{"/tweets/2/stream", tokens[0], "", http.StatusForbidden},
+		{"/tweets/abc/stream", tokens[1], "", http.StatusBadRequest},
+		{"/tweets/99/stream", nonexistentUserToken, "", http.StatusNotFound},
+		{"/tweets/2/stream", tokens[1], "abc", http.StatusBadRequest},
+	}
+
+	for _, tc := range invalidRequests {
+		req, err := http.NewRequest("GET", tc.url, nil)
+		assert.NoError(t, err)
+		if tc.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+		if tc.lastEventId != "" {
+			req.Header.Set("Last-Event-ID", tc.lastEventId)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, int64(tc.expected), int64(w.Code), tc.url)
+	}
+
+	// Las palabras silenciadas se leen al abrir el stream
+	w = makeAuthRequest(t, "POST", "/users/2/muted_words", map[string]interface{}{"word": "futbol"}, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	// Solo llegan los tweets nuevos del timeline: no los de usuarios no seguidos ni los que tienen palabras silenciadas
+	ctx, cancel := context.WithCancel(context.Background())
+	events := openTimelineStream(t, ctx, server.URL+"/tweets/2/stream", tokens[1], "")
+
+	postTweet(tokens[0], "Primer tweet en vivo")
+	assert.Equal(t, 2, nextStreamTweetId(t, events))
+
+	postTweet(tokens[2], "Tweet de un usuario no seguido")
+
+	postTweet(tokens[0], "Hoy hay futbol")
+	postTweet(tokens[0], "Segundo tweet en vivo")
+	assert.Equal(t, 5, nextStreamTweetId(t, events))
+
+	cancel()
+
+	// Al reconectarse con Last-Event-ID se reciben primero los tweets creados desde ese evento y luego los nuevos
+	postTweet(tokens[0], "Tweet mientras el stream esta cerrado")
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	events = openTimelineStream(t, ctx, server.URL+"/tweets/2/stream", tokens[1], "2")
+
+	assert.Equal(t, 5, nextStreamTweetId(t, events))
+	assert.Equal(t, 6, nextStreamTweetId(t, events))
+
+	postTweet(tokens[0], "Tweet despues de reconectar")
+	assert.Equal(t, 7, nextStreamTweetId(t, events))
+
+	// Los follows y unfollows hechos con el stream abierto se reflejan de inmediato, sin reconectar
+	w = makeAuthRequest(t, "POST", "/users_follow/create", map[string]interface{}{"followedId": 3}, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	postTweet(tokens[2], "Tweet de un usuario seguido con el stream abierto")
+	assert.Equal(t, 8, nextStreamTweetId(t, events))
+
+	w = makeAuthRequest(t, "DELETE", "/users_follow/2/1", nil, tokens[1], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	postTweet(tokens[0], "Tweet de un usuario que se dejo de seguir")
+	postTweet(tokens[2], "Otro tweet del usuario seguido")
+	assert.Equal(t, 10, nextStreamTweetId(t, events))
+}
+
+// openTimelineStream abre el stream y retorna sus eventos. La conexion se cierra al cancelar ctx
+func openTimelineStream(t *testing.T, ctx context.Context, url string, token string, lastEventId string) <-chan streamEvent {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		t.Fatalf("Error creating request: %v", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	if lastEventId != "" {
+		req.Header.Set("Last-Event-ID", lastEventId)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Error opening stream: %v", err)
+	}
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	events := make(chan streamEvent, 10)
+
+	go func() {
+		defer resp.Body.Close()
+		defer close(events)
+
+		event := streamEvent{}
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+
+			if line == "" {
+				if event.Event != "" {
+					events <- event
+				}
+				event = streamEvent{}
+				continue
+			}
+
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+
+			switch field {
+			case "id":
+				event.ID = value
+			case "event":
+				event.Event = value
+			case "data":
+				event.Data = value
+			}
+		}
+	}()
+
+	return events
+}
+
+// nextStreamTweetId espera el siguiente evento del stream y retorna el ID del tweet recibido
+func nextStreamTweetId(t *testing.T, events <-chan streamEvent) int {
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatalf("Stream closed")
+		}
+
+		assert.Equal(t, "tweet", event.Event)
+
+		var tweet models.Tweet
+		err := json.Unmarshal([]byte(event.Data), &tweet)
+		assert.NoError(t, err)
+		assert.Equal(t, strconv.FormatInt(tweet.ID, 10), event.ID)
+
+		return int(tweet.ID)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timeout waiting for stream event")
+	}
+
+	return 0
+}
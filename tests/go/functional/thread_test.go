@@ -0,0 +1,214 @@
+package functional
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/factory"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type CreateReplyRequest struct {
+	Content          string `json:"content"`
+	InReplyToTweetID int64  `json:"inReplyToTweetId"`
+	ConversationID   int64  `json:"conversationId,omitempty"`
+}
+
+type ThreadTweet struct {
+	TimelineTweet
+	InReplyToTweetID int64         `json:"inReplyToTweetId"`
+	ConversationID   int64         `json:"conversationId"`
+	Replies          []ThreadTweet `json:"replies"`
+}
+
+type ThreadResponse struct {
+	Code int `json:"code"`
+	Data struct {
+		Ancestors []ThreadTweet `json:"ancestors"`
+		Tweet     ThreadTweet   `json:"tweet"`
+		Replies   []ThreadTweet `json:"replies"`
+	} `json:"data"`
+	Count      int    `json:"count"`
+	NextCursor string `json:"next_cursor"`
+}
+
+func TestRepliesAndThreads(t *testing.T) {
+	caches := []struct {
+		name        string
+		cacheClient cache.Cache
+	}{
+		{"default cache", getMockCache()},
+		{"without cache", nil},
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			testRepliesAndThreads(t, tc.cacheClient)
+		})
+	}
+}
+
+func testRepliesAndThreads(t *testing.T, cacheClient cache.Cache) {
+	db, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{})
+	if err != nil {
+		t.Fatalf("failed to create DB: %v", err)
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	defer conn.Close()
+
+	router := setupTweetRouter(conn, cacheClient)
+
+	// El usuario 3 solo sigue al 1, el usuario 4 sigue al 1 y al 2
+	users := []map[string]interface{}{
+		{"name": "Mauricio Giaconia", "email": "maurigiaconia@hotmail.com", "password": "1223"},
+		{"name": "Juan Perez", "email": "juanperez@hotmail.com", "password": "4567"},
+		{"name": "Ana Lopez", "email": "analopez@hotmail.com", "password": "8910"},
+		{"name": "Pedro Gomez", "email": "pedrogomez@hotmail.com", "password": "1112"},
+	}
+	tokens := []string{}
+	for _, user := range users {
+		w := makeRequest(t, "POST", "/users/create", user, router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+		tokens = append(tokens, loginUser(t, user["email"].(string), user["password"].(string), router))
+	}
+
+	follows := []struct {
+		token      string
+		followedId int64
+	}{
+		{tokens[2], 1},
+		{tokens[3], 1},
+		{tokens[3], 2},
+	}
+	for _, follow := range follows {
+		w := makeAuthRequest(t, "POST", "/users_follow/create", map[string]interface{}{"followedId": follow.followedId}, follow.token, router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+	}
+
+	// Se consultan los timelines para que se construyan los feeds antes de responder
+	for i, url := range []string{"/tweets/3/timeline", "/tweets/4/timeline"} {
+		w := makeAuthRequest(t, "GET", url, nil, tokens[i+2], router)
+		assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+	}
+
+	// Tweet 1: tweet original del usuario 1
+	w := makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Tweet original"}, tokens[0], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	// Tweet 2: el usuario 2 responde al tweet 1
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateReplyRequest{Content: "Respuesta", InReplyToTweetID: 1}, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	// Tweet 3: el usuario 1 responde a la respuesta del usuario 2
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateReplyRequest{Content: "Respuesta a la respuesta", InReplyToTweetID: 2, ConversationID: 1}, tokens[0], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	// Tweet 4: el usuario 1 continua su propio hilo
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateReplyRequest{Content: "Continuacion del hilo", InReplyToTweetID: 1}, tokens[0], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	invalidReplies := []struct {
+		payload  interface{}
+		expected int
+		message  string
+	}{
+		{CreateReplyRequest{Content: "Respuesta", InReplyToTweetID: 99}, http.StatusNotFound, "Nonexistent replied tweet"},
+		{CreateReplyRequest{Content: "Respuesta", InReplyToTweetID: 2, ConversationID: 2}, http.StatusBadRequest, "The conversation does not match the replied tweet"},
+		{map[string]interface{}{"content": "Tweet", "conversationId": 1}, http.StatusBadRequest, "The conversation can only be set on replies"},
+	}
+
+	for _, tc := range invalidReplies {
+		w = makeAuthRequest(t, "POST", "/tweets/create", tc.payload, tokens[1], router)
+		assert.Equal(t, int64(tc.expected), int64(w.Code))
+
+		var errorResponse utils.ErrorResponse
+		err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.message, errorResponse.Error)
+	}
+
+	// El usuario 3 no sigue al usuario 2: no ve su respuesta ni la respuesta que le dio el usuario 1,
+	// pero si el hilo propio del usuario 1. El usuario 4 sigue a ambos y ve toda la conversacion
+	assert.ElementsMatch(t, []int{1, 4}, getTimelineTweetIds(t, "/tweets/3/timeline", tokens[2], router))
+	assert.ElementsMatch(t, []int{1, 2, 3, 4}, getTimelineTweetIds(t, "/tweets/4/timeline", tokens[3], router))
+
+	var count TimelineResponse
+	w = makeAuthRequest(t, "GET", "/tweets/3/timeline", nil, tokens[2], router)
+	err = json.Unmarshal(w.Body.Bytes(), &count)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count.Count)
+
+	// El hilo del tweet 2 incluye el tweet al que responde y su respuesta
+	thread := getThread(t, "/tweets/2/thread", router)
+	assert.Equal(t, 2, thread.Data.Tweet.TweetID)
+	assert.Equal(t, int64(1), thread.Data.Tweet.InReplyToTweetID)
+	assert.Equal(t, int64(1), thread.Data.Tweet.ConversationID)
+	assert.Len(t, thread.Data.Ancestors, 1)
+	assert.Equal(t, 1, thread.Data.Ancestors[0].TweetID)
+	assert.Len(t, thread.Data.Replies, 1)
+	assert.Equal(t, 3, thread.Data.Replies[0].TweetID)
+	assert.Equal(t, 1, thread.Count)
+
+	// El hilo del tweet 1 tiene sus respuestas directas (de la mas nueva a la mas vieja) con las respuestas anidadas
+	thread = getThread(t, "/tweets/1/thread", router)
+	assert.Empty(t, thread.Data.Ancestors)
+	assert.Equal(t, 2, thread.Count)
+	assert.Len(t, thread.Data.Replies, 2)
+	assert.Equal(t, 4, thread.Data.Replies[0].TweetID)
+	assert.Empty(t, thread.Data.Replies[0].Replies)
+	assert.Equal(t, 2, thread.Data.Replies[1].TweetID)
+	assert.Len(t, thread.Data.Replies[1].Replies, 1)
+	assert.Equal(t, 3, thread.Data.Replies[1].Replies[0].TweetID)
+
+	// Paginado de las respuestas por cursor
+	thread = getThread(t, "/tweets/1/thread?limit=1", router)
+	assert.Len(t, thread.Data.Replies, 1)
+	assert.Equal(t, 4, thread.Data.Replies[0].TweetID)
+	assert.NotEmpty(t, thread.NextCursor)
+
+	thread = getThread(t, "/tweets/1/thread?limit=1&cursor="+thread.NextCursor, router)
+	assert.Len(t, thread.Data.Replies, 1)
+	assert.Equal(t, 2, thread.Data.Replies[0].TweetID)
+
+	w = makeRequest(t, "GET", "/tweets/99/thread", nil, router)
+	assert.Equal(t, int64(http.StatusNotFound), int64(w.Code))
+
+	w = makeRequest(t, "GET", "/tweets/abc/thread", nil, router)
+	assert.Equal(t, int64(http.StatusBadRequest), int64(w.Code))
+}
+
+func getTimelineTweetIds(t *testing.T, url string, token string, router *gin.Engine) []int {
+	w := makeAuthRequest(t, "GET", url, nil, token, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	var response TimelineResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	ids := []int{}
+	for _, tweet := range response.Data {
+		ids = append(ids, tweet.TweetID)
+	}
+
+	return ids
+}
+
+func getThread(t *testing.T, url string, router *gin.Engine) ThreadResponse {
+	w := makeRequest(t, "GET", url, nil, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	var response ThreadResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	return response
+}
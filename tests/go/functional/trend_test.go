@@ -0,0 +1,199 @@
+package functional
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/repositories"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/factory"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type Trend struct {
+	Tag           string  `json:"tag"`
+	Count         int64   `json:"count"`
+	BaselineCount float64 `json:"baselineCount"`
+	Velocity      float64 `json:"velocity"`
+}
+
+type TrendsResponse struct {
+	Code  int     `json:"code"`
+	Data  []Trend `json:"data"`
+	Count int     `json:"count"`
+}
+
+func TestTrends(t *testing.T) {
+	caches := []struct {
+		name        string
+		cacheClient cache.Cache
+	}{
+		{"default cache", getMockCache()},
+		{"without cache", nil},
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			testTrends(t, tc.cacheClient)
+		})
+	}
+}
+
+func testTrends(t *testing.T, cacheClient cache.Cache) {
+	db, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{})
+	if err != nil {
+		t.Fatalf("failed to create DB: %v", err)
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	defer conn.Close()
+
+	router := setupTweetRouter(conn, cacheClient)
+
+	users := []map[string]interface{}{
+		{"name": "Mauricio Giaconia", "email": "maurigiaconia@hotmail.com", "password": "1223"},
+		{"name": "Juan Perez", "email": "juanperez@hotmail.com", "password": "4567"},
+	}
+	tokens := []string{}
+	for _, user := range users {
+		w := makeRequest(t, "POST", "/users/create", user, router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+		tokens = append(tokens, loginUser(t, user["email"].(string), user["password"].(string), router))
+	}
+
+	assert.Empty(t, getTrends(t, "/trends", router).Data)
+
+	// #futbol se usa siempre (24 veces hace 3 horas) y #viejo se uso hace 20 horas
+	addPastHashtagUsage(t, conn, cacheClient, "futbol", 24, time.Now().Add(-3*time.Hour))
+	addPastHashtagUsage(t, conn, cacheClient, "viejo", 5, time.Now().Add(-20*time.Hour))
+
+	tweets := []struct {
+		token   string
+		content string
+	}{
+		{tokens[0], "#Nuevo #nuevo: un hashtag repetido en un tweet cuenta una vez"},
+		{tokens[1], "Otra vez #NUEVO"},
+		{tokens[0], "Aprendiendo #golang"},
+		{tokens[1], "Partido de #futbol"},
+		{tokens[0], "Mas #futbol"},
+	}
+	for _, tweet := range tweets {
+		w := makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: tweet.content}, tweet.token, router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+	}
+
+	// En la ultima hora #futbol se uso tanto como #nuevo, pero se esperaba que se use una vez (24 usos en las 24hs previas),
+	// por lo que queda ultimo. #viejo no se uso en la ventana y no es tendencia
+	for _, url := range []string{"/trends", "/trends?window=1h"} {
+		response := getTrends(t, url, router)
+		assert.Equal(t, 3, response.Count)
+		assert.Equal(t, []Trend{
+			{Tag: "nuevo", Count: 2, BaselineCount: 0, Velocity: 3},
+			{Tag: "golang", Count: 1, BaselineCount: 0, Velocity: 2},
+			{Tag: "futbol", Count: 2, BaselineCount: 1, Velocity: 1.5},
+		}, response.Data)
+	}
+
+	// En las ultimas 24hs ningun hashtag tiene uso previo, por lo que el ranking sigue la cantidad de usos
+	response := getTrends(t, "/trends?window=24h", router)
+	assert.Equal(t, 4, response.Count)
+	assert.Equal(t, []string{"futbol", "viejo", "nuevo", "golang"}, trendTags(response.Data))
+	assert.Equal(t, int64(26), response.Data[0].Count)
+
+	response = getTrends(t, "/trends?window=24h&limit=2&offset=1", router)
+	assert.Equal(t, 4, response.Count)
+	assert.Equal(t, []string{"viejo", "nuevo"}, trendTags(response.Data))
+
+	w := makeRequest(t, "GET", "/trends?window=7d", nil, router)
+	assert.Equal(t, int64(http.StatusBadRequest), int64(w.Code))
+
+	// Al eliminar un tweet se descuentan sus hashtags
+	w = makeAuthRequest(t, "DELETE", "/tweets/3", nil, tokens[0], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	assert.Equal(t, []string{"nuevo", "futbol"}, trendTags(getTrends(t, "/trends", router).Data))
+}
+
+func TestHashtagUsageCombinesHourAndMinuteBuckets(t *testing.T) {
+	cacheClient := getMockCache()
+	hour := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	usages := []struct {
+		tag   string
+		count int64
+		at    time.Time
+	}{
+		{"golang", 1, hour.Add(-90 * time.Minute)},
+		{"golang", 2, hour.Add(-30 * time.Minute)},
+		{"golang", 4, hour.Add(15 * time.Minute)},
+		{"golang", 8, hour.Add(75 * time.Minute)},
+		{"futbol", 16, hour.Add(2*time.Hour + 10*time.Minute)},
+	}
+
+	for _, usage := range usages {
+		err := repositories.AddHashtagUsage(cacheClient, []string{usage.tag}, usage.at, usage.count, 48*time.Hour)
+		assert.NoError(t, err)
+	}
+
+	ranges := []struct {
+		from     time.Time
+		to       time.Time
+		expected map[string]int64
+	}{
+		// Horas completas: solo se leen los buckets por hora
+		{hour.Add(-time.Hour), hour.Add(2 * time.Hour), map[string]int64{"golang": 14}},
+		// Extremos dentro de una hora: los minutos fuera de las horas completas se leen de los buckets por minuto
+		{hour.Add(-45 * time.Minute), hour.Add(80 * time.Minute), map[string]int64{"golang": 14}},
+		{hour.Add(-29 * time.Minute), hour.Add(75 * time.Minute), map[string]int64{"golang": 4}},
+		{hour.Add(75 * time.Minute), hour.Add(2*time.Hour + 11*time.Minute), map[string]int64{"golang": 8, "futbol": 16}},
+		{hour.Add(2*time.Hour + 11*time.Minute), hour.Add(4 * time.Hour), map[string]int64{}},
+	}
+
+	for _, tc := range ranges {
+		usage, err := repositories.GetHashtagUsage(cacheClient, tc.from, tc.to)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, usage, "%s - %s", tc.from, tc.to)
+	}
+}
+
+// addPastHashtagUsage simula usos de un hashtag en el pasado: con cache se suman al bucket del minuto y sin cache se guardan en la db sql
+func addPastHashtagUsage(t *testing.T, conn *sql.DB, cacheClient cache.Cache, tag string, count int64, at time.Time) {
+	if cacheClient != nil {
+		err := repositories.AddHashtagUsage(cacheClient, []string{tag}, at, count, 48*time.Hour)
+		assert.NoError(t, err)
+		return
+	}
+
+	for i := int64(0); i < count; i++ {
+		_, err := conn.Exec(`INSERT INTO tweet_hashtags (tweet_id, tag, created_at) VALUES ($1, $2, $3)`, 1000+i, tag, at.UTC().Format("2006-01-02 15:04:05"))
+		assert.NoError(t, err)
+	}
+}
+
+func getTrends(t *testing.T, url string, router *gin.Engine) TrendsResponse {
+	w := makeRequest(t, "GET", url, nil, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code), url)
+
+	var response TrendsResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	return response
+}
+
+func trendTags(trends []Trend) []string {
+	tags := []string{}
+	for _, trend := range trends {
+		tags = append(tags, trend.Tag)
+	}
+
+	return tags
+}
@@ -11,6 +11,9 @@ import (
 	"testing"
 
 	"github.com/MauricioGiaconia/uala_backend_challenge/internal/routes"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/services"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/auth"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
 	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/factory"
 	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/utils"
 	"github.com/gin-gonic/gin"
@@ -29,29 +32,31 @@ type CreateTweetResponse struct {
 }
 
 type TimelineTweet struct {
-	TweetID    int    `json:"tweetId"`
-	AuthorID   int    `json:"authorId"`
-	AuthorName string `json:"authorName"`
-	Content    string `json:"content"`
-	CreatedAt  string `json:"createdAt"` // O usa `time.Time` si quieres manejar fechas como objetos
+	TweetID      int    `json:"tweetId"`
+	AuthorID     int    `json:"authorId"`
+	AuthorName   string `json:"authorName"`
+	AuthorHandle string `json:"authorHandle"`
+	Content      string `json:"content"`
+	CreatedAt    string `json:"createdAt"` // O usa `time.Time` si quieres manejar fechas como objetos
 }
 
 type TimelineResponse struct {
-	Code   int             `json:"code"`
-	Data   []TimelineTweet `json:"data"`
-	Count  int             `json:"count"`
-	Limit  int             `json:"limit"`
-	Offset int             `json:"offset"`
-	Next   string          `json:"next"`
+	Code       int             `json:"code"`
+	Data       []TimelineTweet `json:"data"`
+	Count      int             `json:"count"`
+	Limit      int             `json:"limit"`
+	Offset     int             `json:"offset"`
+	Next       string          `json:"next"`
+	NextCursor string          `json:"next_cursor"`
 }
 
 func TestTweetCreation(t *testing.T) {
 	// Se reutiliza sqlite en memoria para los tests
-	db, err := factory.GetDatabase("sqlite")
+	db, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{})
 	if err != nil {
 		t.Fatalf("failed to create DB: %v", err)
 	}
-	rdb := getMockRedis()
+	cacheClient := getMockCache()
 
 	conn, err := db.Connect()
 	if err != nil {
@@ -60,7 +65,7 @@ func TestTweetCreation(t *testing.T) {
 
 	defer conn.Close()
 
-	router := setupTweetRouter(conn, rdb)
+	router := setupTweetRouter(conn, cacheClient)
 
 	// Se crea un user para realizar el test de tweetear
 	userPayload := map[string]interface{}{
@@ -73,26 +78,28 @@ func TestTweetCreation(t *testing.T) {
 
 	assert.Equal(t, http.StatusCreated, w.Code)
 
+	token := loginUser(t, "maurigiaconia@hotmail.com", "1223", router)
+
+	// Token valido pero de un usuario que no existe
+	nonexistentUserToken, err := auth.GenerateToken(9999, auth.AccessTokenType, auth.AccessTokenTTL)
+	assert.NoError(t, err)
+
 	// []struct con el lsitado de pruebas a realizar
 	requests := []struct {
 		payload  CreateTweetRequest
+		token    string // Token de acceso del autor
 		expected int    // Código de estado esperado
 		message  string // Mensaje esperado en la respuesta
 	}{
-		{CreateTweetRequest{Content: "test posteado", UserID: 1}, int(http.StatusCreated), "Tweet posted"},
-		{CreateTweetRequest{Content: "Uala ha transformado la experiencia financiera de millones de usuarios al ofrecer una plataforma accesible y completa que les permite gestionar su dinero, realizar pagos, ahorrar, solicitar créditos y acceder a una amplia gama de servicios financieros con solo unos clics, facilitando su día a día.", UserID: 1}, int(http.StatusBadRequest), "The content of the tweet must not exceed 280 characters"},
-		{CreateTweetRequest{Content: "test", UserID: 9999}, int(http.StatusNotFound), "Nonexistent user"},
+		{CreateTweetRequest{Content: "test posteado", UserID: 1}, token, int(http.StatusCreated), "Tweet posted"},
+		{CreateTweetRequest{Content: "Uala ha transformado la experiencia financiera de millones de usuarios al ofrecer una plataforma accesible y completa que les permite gestionar su dinero, realizar pagos, ahorrar, solicitar créditos y acceder a una amplia gama de servicios financieros con solo unos clics, facilitando su día a día.", UserID: 1}, token, int(http.StatusBadRequest), "The content of the tweet must not exceed 280 characters"},
+		{CreateTweetRequest{Content: "test", UserID: 9999}, nonexistentUserToken, int(http.StatusNotFound), "Nonexistent user"},
+		{CreateTweetRequest{Content: "test", UserID: 1}, "", int(http.StatusUnauthorized), "Missing authorization token"},
 	}
 	for i, tc := range requests {
 		t.Run(fmt.Sprintf("Request %d", i+1), func(t *testing.T) {
 
-			requestBody, _ := json.Marshal(tc.payload)
-
-			req, _ := http.NewRequest("POST", "/tweets/create", bytes.NewBuffer(requestBody))
-			req.Header.Set("Content-Type", "application/json")
-			w := httptest.NewRecorder()
-
-			router.ServeHTTP(w, req)
+			w := makeAuthRequest(t, "POST", "/tweets/create", tc.payload, tc.token, router)
 
 			assert.Equal(t, int64(tc.expected), int64(w.Code))
 
@@ -115,11 +122,11 @@ func TestTweetCreation(t *testing.T) {
 
 func TestGetTimeline(t *testing.T) {
 	// Se reutiliza sqlite en memoria para los tests
-	db, err := factory.GetDatabase("sqlite")
+	db, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{})
 	if err != nil {
 		t.Fatalf("failed to create DB: %v", err)
 	}
-	rdb := getMockRedis()
+	cacheClient := getMockCache()
 
 	conn, err := db.Connect()
 	if err != nil {
@@ -128,7 +135,7 @@ func TestGetTimeline(t *testing.T) {
 
 	defer conn.Close()
 
-	router := setupTweetRouter(conn, rdb)
+	router := setupTweetRouter(conn, cacheClient)
 
 	// Se crea un user para realizar el test de tweetear
 	userPayload := map[string]interface{}{
@@ -150,23 +157,25 @@ func TestGetTimeline(t *testing.T) {
 	w = makeRequest(t, "POST", "/users/create", otherUserPayload, router)
 	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
 
-	// El usuario 1 sigue al usuario 2
+	firstUserToken := loginUser(t, "maurigiaconia@hotmail.com", "1223", router)
+	otherUserToken := loginUser(t, "juanperez@hotmail.com", "4567", router)
+
+	// El usuario 2 sigue al usuario 1
 	followPayload := map[string]interface{}{
-		"followerId": 2,
 		"followedId": 1,
 	}
-	w = makeRequest(t, "POST", "/users_follow/create", followPayload, router)
+	w = makeAuthRequest(t, "POST", "/users_follow/create", followPayload, otherUserToken, router)
 	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
 
-	tweetPayload := CreateTweetRequest{Content: "Tweet desde test 1", UserID: 1}
-	w = makeRequest(t, "POST", "/tweets/create", tweetPayload, router)
+	tweetPayload := CreateTweetRequest{Content: "Tweet desde test 1"}
+	w = makeAuthRequest(t, "POST", "/tweets/create", tweetPayload, firstUserToken, router)
 	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
 
-	tweetPayload = CreateTweetRequest{Content: "Tweet Uala desde test 2", UserID: 1}
-	w = makeRequest(t, "POST", "/tweets/create", tweetPayload, router)
+	tweetPayload = CreateTweetRequest{Content: "Tweet Uala desde test 2"}
+	w = makeAuthRequest(t, "POST", "/tweets/create", tweetPayload, firstUserToken, router)
 	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
 
-	w = makeRequest(t, "GET", "/tweets/2/timeline", nil, router)
+	w = makeAuthRequest(t, "GET", "/tweets/2/timeline", nil, otherUserToken, router)
 	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
 
 	// Asegurarse de que los tweets de Mauricio Giaconia aparecen en la timeline de Juan Perez
@@ -175,15 +184,28 @@ func TestGetTimeline(t *testing.T) {
 
 	assert.NoError(t, err)
 	assert.Len(t, response.Data, 2)
-	assert.Contains(t, response.Data[0].Content, "Tweet desde test 1")
-	assert.Contains(t, response.Data[1].Content, "Tweet Uala desde test 2")
+	// El timeline se ordena por (fecha, id) descendente, por lo que el ultimo tweet posteado va primero
+	assert.Contains(t, response.Data[0].Content, "Tweet Uala desde test 2")
+	assert.Contains(t, response.Data[1].Content, "Tweet desde test 1")
 	assert.Equal(t, response.Count, 2)
 	assert.Equal(t, response.Limit, 25)
 	assert.Equal(t, response.Offset, 0)
 
+	// Cada usuario solo puede consultar su propio timeline
+	for _, url := range []string{"/tweets/2/timeline", "/tweets/2/routine_timeline"} {
+		w = makeRequest(t, "GET", url, nil, router)
+		assert.Equal(t, int64(http.StatusUnauthorized), int64(w.Code))
+
+		w = makeAuthRequest(t, "GET", url, nil, firstUserToken, router)
+		assert.Equal(t, int64(http.StatusForbidden), int64(w.Code))
+	}
+
 	var errorResponse utils.ErrorResponse
 	// Obtencion del timeline de un usuario inexistente
-	w = makeRequest(t, "GET", "/tweets/999/timeline", nil, router)
+	nonexistentUserToken, err := auth.GenerateToken(999, auth.AccessTokenType, auth.AccessTokenTTL)
+	assert.NoError(t, err)
+
+	w = makeAuthRequest(t, "GET", "/tweets/999/timeline", nil, nonexistentUserToken, router)
 	assert.Equal(t, int64(http.StatusBadRequest), int64(w.Code))
 
 	err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
@@ -192,7 +214,433 @@ func TestGetTimeline(t *testing.T) {
 	assert.Contains(t, errorResponse.Error, "Nonexistent user")
 }
 
-func getMockRedis() *redis.Client {
+func TestTimelineIncludesNewTweets(t *testing.T) {
+	// Se prueba con el cache disponible, con un cache en memoria que solo admite 2 claves (obliga a descartar
+	// feeds y tweets cacheados) y sin cache, donde el timeline se obtiene siempre de la db sql
+	caches := []struct {
+		name        string
+		cacheClient cache.Cache
+	}{
+		{"default cache", getMockCache()},
+		{"small memory cache", cache.NewMemoryCache(2)},
+		{"without cache", nil},
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			testTimelineIncludesNewTweets(t, tc.cacheClient)
+		})
+	}
+}
+
+func testTimelineIncludesNewTweets(t *testing.T, cacheClient cache.Cache) {
+	db, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{})
+	if err != nil {
+		t.Fatalf("failed to create DB: %v", err)
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	defer conn.Close()
+
+	router := setupTweetRouter(conn, cacheClient)
+
+	w := makeRequest(t, "POST", "/users/create", map[string]interface{}{"name": "Mauricio Giaconia", "email": "maurigiaconia@hotmail.com", "password": "1223"}, router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeRequest(t, "POST", "/users/create", map[string]interface{}{"name": "Juan Perez", "email": "juanperez@hotmail.com", "password": "4567"}, router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	firstUserToken := loginUser(t, "maurigiaconia@hotmail.com", "1223", router)
+	otherUserToken := loginUser(t, "juanperez@hotmail.com", "4567", router)
+
+	w = makeAuthRequest(t, "POST", "/users_follow/create", map[string]interface{}{"followedId": 1}, otherUserToken, router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Primer tweet"}, firstUserToken, router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	// La primera consulta construye el feed del usuario 2
+	w = makeAuthRequest(t, "GET", "/tweets/2/timeline", nil, otherUserToken, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	var response TimelineResponse
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Len(t, response.Data, 1)
+
+	// El tweet posteado luego de consultar el timeline debe aparecer sin esperar a que expire el cache
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Segundo tweet"}, firstUserToken, router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	for _, url := range []string{"/tweets/2/timeline", "/tweets/2/routine_timeline"} {
+		w = makeAuthRequest(t, "GET", url, nil, otherUserToken, router)
+		assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+		response = TimelineResponse{}
+		err = json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Len(t, response.Data, 2)
+
+		contents := []string{}
+		for _, tweet := range response.Data {
+			contents = append(contents, tweet.Content)
+			assert.Equal(t, "Mauricio Giaconia", tweet.AuthorName)
+		}
+		assert.ElementsMatch(t, []string{"Primer tweet", "Segundo tweet"}, contents)
+	}
+}
+
+func TestTimelineMergesHighFollowerAuthors(t *testing.T) {
+	db, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{})
+	if err != nil {
+		t.Fatalf("failed to create DB: %v", err)
+	}
+	cacheClient := getMockCache()
+
+	conn, err := db.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	defer conn.Close()
+
+	// Con un umbral de 1 seguidor, el usuario 1 (con 2 seguidores) no distribuye sus tweets y el usuario 3 (con 1 seguidor) si
+	defaultThreshold := services.FanOutFollowersThreshold
+	services.FanOutFollowersThreshold = 1
+	defer func() { services.FanOutFollowersThreshold = defaultThreshold }()
+
+	router := setupTweetRouter(conn, cacheClient)
+
+	tokens := map[int64]string{}
+	for i, name := range []string{"Mauricio Giaconia", "Juan Perez", "Ana Lopez", "Pedro Gomez"} {
+		email := fmt.Sprintf("user%d@hotmail.com", i+1)
+		w := makeRequest(t, "POST", "/users/create", map[string]interface{}{"name": name, "email": email, "password": "1234"}, router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+		tokens[int64(i+1)] = loginUser(t, email, "1234", router)
+	}
+
+	for _, follow := range []FollowCreationRequest{{FollowerId: 2, FollowedId: 1}, {FollowerId: 4, FollowedId: 1}, {FollowerId: 2, FollowedId: 3}} {
+		w := makeAuthRequest(t, "POST", "/users_follow/create", follow, tokens[follow.FollowerId], router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+	}
+
+	// Se consulta el timeline antes de postear para que el feed del usuario 2 ya exista
+	w := makeAuthRequest(t, "GET", "/tweets/2/timeline", nil, tokens[2], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Tweet de cuenta grande"}, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Tweet de cuenta chica"}, tokens[3], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeAuthRequest(t, "GET", "/tweets/2/timeline", nil, tokens[2], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	var response TimelineResponse
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	contents := []string{}
+	for _, tweet := range response.Data {
+		contents = append(contents, tweet.Content)
+	}
+	assert.ElementsMatch(t, []string{"Tweet de cuenta grande", "Tweet de cuenta chica"}, contents)
+	assert.Equal(t, 2, response.Count)
+
+	// El feed del usuario 2 ya existe, por lo que los siguientes tweets de la cuenta grande solo se mezclan al leer
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Segundo tweet de cuenta grande"}, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	expected := []string{"Segundo tweet de cuenta grande", "Tweet de cuenta grande", "Tweet de cuenta chica"}
+	assert.ElementsMatch(t, expected, getTimelineContents(t, "/tweets/2/timeline", tokens[2], router))
+
+	// Al dejar de ser una cuenta grande, el feed del seguidor se reconstruye con los tweets que no se le distribuyeron
+	services.FanOutFollowersThreshold = 10
+
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Tweet de cuenta que dejo de ser grande"}, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	expected = append([]string{"Tweet de cuenta que dejo de ser grande"}, expected...)
+	assert.ElementsMatch(t, expected, getTimelineContents(t, "/tweets/2/timeline", tokens[2], router))
+
+	// Si el set de cuentas grandes se pierde del cache, se vuelve a leer de la db sql sin eliminar los feeds
+	services.FanOutFollowersThreshold = 1
+
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Tweet de cuenta grande otra vez"}, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	err = cacheClient.Delete(context.Background(), "celebrities")
+	assert.NoError(t, err)
+
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Tweet de cuenta chica sin el set"}, tokens[3], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	feedSize, err := cacheClient.ZCard(context.Background(), "timeline:2")
+	assert.NoError(t, err)
+	assert.Greater(t, feedSize, int64(0), "The feed should not be deleted when the celebrities set is reloaded")
+
+	expected = append([]string{"Tweet de cuenta chica sin el set", "Tweet de cuenta grande otra vez"}, expected...)
+	assert.ElementsMatch(t, expected, getTimelineContents(t, "/tweets/2/timeline", tokens[2], router))
+
+	// La clase de la cuenta se conserva aunque se pierda el set, por lo que al dejar de ser grande se detecta el cambio
+	// y el feed se reconstruye con los tweets que no se le distribuyeron
+	err = cacheClient.Delete(context.Background(), "celebrities")
+	assert.NoError(t, err)
+
+	services.FanOutFollowersThreshold = 10
+
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Tweet de cuenta que dejo de ser grande otra vez"}, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	expected = append([]string{"Tweet de cuenta que dejo de ser grande otra vez"}, expected...)
+	assert.ElementsMatch(t, expected, getTimelineContents(t, "/tweets/2/timeline", tokens[2], router))
+}
+
+// getTimelineContents obtiene el contenido de los tweets de la primera pagina del timeline
+func getTimelineContents(t *testing.T, url string, token string, router *gin.Engine) []string {
+	w := makeAuthRequest(t, "GET", url, nil, token, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	var response TimelineResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	contents := []string{}
+	for _, tweet := range response.Data {
+		contents = append(contents, tweet.Content)
+	}
+
+	return contents
+}
+
+func TestGetTimelineWithCursor(t *testing.T) {
+	db, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{})
+	if err != nil {
+		t.Fatalf("failed to create DB: %v", err)
+	}
+	cacheClient := getMockCache()
+
+	conn, err := db.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	defer conn.Close()
+
+	router := setupTweetRouter(conn, cacheClient)
+
+	w := makeRequest(t, "POST", "/users/create", map[string]interface{}{"name": "Mauricio Giaconia", "email": "maurigiaconia@hotmail.com", "password": "1223"}, router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeRequest(t, "POST", "/users/create", map[string]interface{}{"name": "Juan Perez", "email": "juanperez@hotmail.com", "password": "4567"}, router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	firstUserToken := loginUser(t, "maurigiaconia@hotmail.com", "1223", router)
+	otherUserToken := loginUser(t, "juanperez@hotmail.com", "4567", router)
+
+	w = makeAuthRequest(t, "POST", "/users_follow/create", map[string]interface{}{"followedId": 1}, otherUserToken, router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	for i := 1; i <= 5; i++ {
+		w = makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: fmt.Sprintf("Tweet %d", i)}, firstUserToken, router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+	}
+
+	for _, url := range []string{"/tweets/2/timeline", "/tweets/2/routine_timeline"} {
+		// Se recorre el timeline completo pagina por pagina usando el cursor recibido en cada respuesta
+		contents := []string{}
+		nextUrl := url + "?limit=2"
+		for nextUrl != "" {
+			w = makeAuthRequest(t, "GET", nextUrl, nil, otherUserToken, router)
+			assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+			var response TimelineResponse
+			err = json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+
+			for _, tweet := range response.Data {
+				contents = append(contents, tweet.Content)
+			}
+
+			nextUrl = ""
+			if response.NextCursor != "" {
+				// Mientras haya cursor, el enlace a la siguiente pagina no debe usar offset
+				if len(contents) > 2 {
+					assert.Equal(t, fmt.Sprintf("?limit=2&cursor=%s", response.NextCursor), response.Next)
+				}
+				nextUrl = url + "?limit=2&cursor=" + response.NextCursor
+			}
+		}
+
+		assert.Equal(t, []string{"Tweet 5", "Tweet 4", "Tweet 3", "Tweet 2", "Tweet 1"}, contents)
+	}
+
+	w = makeAuthRequest(t, "GET", "/tweets/2/timeline?cursor=invalido", nil, otherUserToken, router)
+	assert.Equal(t, int64(http.StatusBadRequest), int64(w.Code))
+
+	w = makeAuthRequest(t, "GET", "/tweets/2/timeline?cursor=invalido&offset=2", nil, otherUserToken, router)
+	assert.Equal(t, int64(http.StatusBadRequest), int64(w.Code))
+}
+
+func TestGetTimelineWithCursorMergingHighFollowerAuthors(t *testing.T) {
+	db, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{})
+	if err != nil {
+		t.Fatalf("failed to create DB: %v", err)
+	}
+	cacheClient := getMockCache()
+
+	conn, err := db.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	defer conn.Close()
+
+	// Con un umbral de 1 seguidor, los tweets del usuario 1 (con 2 seguidores) se mezclan al leer el timeline
+	defaultThreshold := services.FanOutFollowersThreshold
+	services.FanOutFollowersThreshold = 1
+	defer func() { services.FanOutFollowersThreshold = defaultThreshold }()
+
+	router := setupTweetRouter(conn, cacheClient)
+
+	tokens := map[int64]string{}
+	for i, name := range []string{"Mauricio Giaconia", "Juan Perez", "Ana Lopez", "Pedro Gomez"} {
+		email := fmt.Sprintf("user%d@hotmail.com", i+1)
+		w := makeRequest(t, "POST", "/users/create", map[string]interface{}{"name": name, "email": email, "password": "1234"}, router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+		tokens[int64(i+1)] = loginUser(t, email, "1234", router)
+	}
+
+	for _, follow := range []FollowCreationRequest{{FollowerId: 2, FollowedId: 1}, {FollowerId: 4, FollowedId: 1}, {FollowerId: 2, FollowedId: 3}} {
+		w := makeAuthRequest(t, "POST", "/users_follow/create", follow, tokens[follow.FollowerId], router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+	}
+
+	// Se consulta el timeline luego del primer tweet de la cuenta chica para que el feed del usuario 2 ya exista,
+	// asi los tweets de la cuenta grande solo se obtienen al mezclarlos
+	w := makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Primer tweet del usuario 3"}, tokens[3], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeAuthRequest(t, "GET", "/tweets/2/timeline", nil, tokens[2], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	// Se intercalan los tweets de la cuenta grande con los de la cuenta chica
+	expected := []string{"Primer tweet del usuario 3"}
+	for i := 1; i <= 3; i++ {
+		for _, authorId := range []int64{1, 3} {
+			content := fmt.Sprintf("Tweet %d del usuario %d", i, authorId)
+			w = makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: content}, tokens[authorId], router)
+			assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+			expected = append([]string{content}, expected...)
+		}
+	}
+
+	contents := []string{}
+	nextUrl := "/tweets/2/timeline?limit=2"
+	for nextUrl != "" {
+		w = makeAuthRequest(t, "GET", nextUrl, nil, tokens[2], router)
+		assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+		var response TimelineResponse
+		err = json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+
+		for _, tweet := range response.Data {
+			contents = append(contents, tweet.Content)
+		}
+
+		nextUrl = ""
+		if response.NextCursor != "" {
+			nextUrl = "/tweets/2/timeline?limit=2&cursor=" + response.NextCursor
+		}
+	}
+
+	assert.Equal(t, expected, contents)
+}
+
+func TestDeleteTweet(t *testing.T) {
+	db, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{})
+	if err != nil {
+		t.Fatalf("failed to create DB: %v", err)
+	}
+	cacheClient := getMockCache()
+
+	conn, err := db.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	defer conn.Close()
+
+	router := setupTweetRouter(conn, cacheClient)
+
+	w := makeRequest(t, "POST", "/users/create", map[string]interface{}{"name": "Mauricio Giaconia", "email": "maurigiaconia@hotmail.com", "password": "1223"}, router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeRequest(t, "POST", "/users/create", map[string]interface{}{"name": "Juan Perez", "email": "juanperez@hotmail.com", "password": "4567"}, router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	firstUserToken := loginUser(t, "maurigiaconia@hotmail.com", "1223", router)
+	otherUserToken := loginUser(t, "juanperez@hotmail.com", "4567", router)
+
+	w = makeAuthRequest(t, "POST", "/users_follow/create", map[string]interface{}{"followedId": 1}, otherUserToken, router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	// Se consulta el timeline para que el feed exista y el tweet se distribuya al postearlo
+	w = makeAuthRequest(t, "GET", "/tweets/2/timeline", nil, otherUserToken, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Tweet a eliminar"}, firstUserToken, router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeAuthRequest(t, "GET", "/tweets/2/timeline", nil, otherUserToken, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	var response TimelineResponse
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Len(t, response.Data, 1)
+
+	requests := []struct {
+		url      string
+		token    string
+		expected int
+		message  string
+	}{
+		{"/tweets/1", "", http.StatusUnauthorized, "Missing authorization token"},
+		{"/tweets/1", otherUserToken, http.StatusForbidden, "Only the author can delete the tweet"},
+		{"/tweets/999", firstUserToken, http.StatusNotFound, "Nonexistent tweet"},
+		{"/tweets/abc", firstUserToken, http.StatusBadRequest, "Invalid tweet ID"},
+		{"/tweets/1", firstUserToken, http.StatusOK, "Tweet deleted"},
+		{"/tweets/1", firstUserToken, http.StatusNotFound, "Nonexistent tweet"},
+	}
+
+	for i, tc := range requests {
+		t.Run(fmt.Sprintf("Request %d", i+1), func(t *testing.T) {
+			w := makeAuthRequest(t, "DELETE", tc.url, nil, tc.token, router)
+			assert.Equal(t, int64(tc.expected), int64(w.Code))
+			assert.Contains(t, w.Body.String(), tc.message)
+		})
+	}
+
+	// El tweet eliminado no debe seguir apareciendo en el timeline cacheado
+	w = makeAuthRequest(t, "GET", "/tweets/2/timeline", nil, otherUserToken, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	response = TimelineResponse{}
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Len(t, response.Data, 0)
+}
+
+// getMockCache usa Redis si hay un servidor disponible y, si no, el cache en memoria.
+// En ambos casos el cache arranca vacio para no mezclar feeds de otras ejecuciones
+func getMockCache() cache.Cache {
 
 	var ctx = context.Background()
 
@@ -205,16 +653,18 @@ func getMockRedis() *redis.Client {
 
 	_, err := client.Ping(ctx).Result()
 	if err != nil {
-		fmt.Printf("error conectando a Redis: %v\n", err)
-		return nil
+		fmt.Printf("error conectando a Redis, se usa el cache en memoria: %v\n", err)
+		return cache.NewMemoryCache(cache.DefaultMemoryCacheSize)
 	}
 
-	return client
+	client.FlushDB(ctx)
+
+	return cache.NewRedisCache(client)
 }
 
-func setupTweetRouter(db *sql.DB, rdb *redis.Client) *gin.Engine {
+func setupTweetRouter(db *sql.DB, cacheClient cache.Cache) *gin.Engine {
 	router := gin.Default()
-	routes.SetupRoutes(router, db, rdb)
+	routes.SetupRoutes(router, db, cacheClient)
 	return router
 }
 
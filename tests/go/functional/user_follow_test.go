@@ -12,6 +12,8 @@ import (
 
 	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
 	"github.com/MauricioGiaconia/uala_backend_challenge/internal/routes"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/auth"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
 	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/factory"
 	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/utils"
 	"github.com/gin-gonic/gin"
@@ -26,12 +28,13 @@ type UserFollows struct {
 }
 
 type FollowsResponse struct {
-	Code   int         `json:"code"`
-	Data   UserFollows `json:"data"`
-	Count  int         `json:"count"`
-	Limit  int         `json:"limit"`
-	Offset int         `json:"offset"`
-	Next   string      `json:"next"`
+	Code       int         `json:"code"`
+	Data       UserFollows `json:"data"`
+	Count      int         `json:"count"`
+	Limit      int         `json:"limit"`
+	Offset     int         `json:"offset"`
+	Next       string      `json:"next"`
+	NextCursor string      `json:"next_cursor"`
 }
 
 type FollowCreationRequest struct {
@@ -45,11 +48,11 @@ type CreateFollowResponse struct {
 }
 
 func TestPostAndGetFollows(t *testing.T) {
-	db, err := factory.GetDatabase("sqlite")
+	db, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{})
 	if err != nil {
 		t.Fatalf("failed to create DB: %v", err)
 	}
-	rdb := getMockFollowRedis()
+	cacheClient := getMockFollowCache()
 
 	conn, err := db.Connect()
 	if err != nil {
@@ -58,7 +61,7 @@ func TestPostAndGetFollows(t *testing.T) {
 
 	defer conn.Close()
 
-	router := setupFollowRouter(conn, rdb)
+	router := setupFollowRouter(conn, cacheClient)
 
 	// Se crea un user para realizar el test de follow
 	userPayload := map[string]interface{}{
@@ -80,6 +83,15 @@ func TestPostAndGetFollows(t *testing.T) {
 	w = makeFollowRequest(t, "POST", "/users/create", otherUserPayload, router)
 	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
 
+	// El follower se toma del token de acceso, por lo que cada caso usa el token del usuario que sigue
+	tokens := map[int64]string{
+		1: loginUser(t, "maurigiaconia@hotmail.com", "1223", router),
+		2: loginUser(t, "juanperez@hotmail.com", "4567", router),
+	}
+	nonexistentUserToken, err := auth.GenerateToken(100, auth.AccessTokenType, auth.AccessTokenTTL)
+	assert.NoError(t, err)
+	tokens[100] = nonexistentUserToken
+
 	requests := []struct {
 		payload  FollowCreationRequest
 		expected int    // Código de estado esperado
@@ -105,18 +117,16 @@ func TestPostAndGetFollows(t *testing.T) {
 			FollowerId: 1,
 			FollowedId: 1,
 		}, http.StatusBadRequest, "Cannot follow yourself"},
+		{FollowCreationRequest{
+			FollowerId: 3,
+			FollowedId: 1,
+		}, http.StatusUnauthorized, "Missing authorization token"},
 	}
 
 	for i, tc := range requests {
 		t.Run(fmt.Sprintf("Request %d", i+1), func(t *testing.T) {
 
-			requestBody, _ := json.Marshal(tc.payload)
-
-			req, _ := http.NewRequest("POST", "/users_follow/create", bytes.NewBuffer(requestBody))
-			req.Header.Set("Content-Type", "application/json")
-			w := httptest.NewRecorder()
-
-			router.ServeHTTP(w, req)
+			w := makeAuthRequest(t, "POST", "/users_follow/create", tc.payload, tokens[tc.payload.FollowerId], router)
 
 			assert.Equal(t, int64(tc.expected), int64(w.Code))
 
@@ -151,15 +161,113 @@ func TestPostAndGetFollows(t *testing.T) {
 	err = json.Unmarshal(w.Body.Bytes(), &followResponse)
 	assert.NoError(t, err, "Error deserializando la respuesta GET")
 	assert.True(t, len(followResponse.Data.Follows) > 0, "Expected at least one following")
+
+	// Paginado por cursor: el usuario 1 tiene un unico seguidor, la pagina siguiente al cursor debe estar vacia
+	w = makeFollowRequest(t, "GET", "/users_follow/1/follows/followers?limit=1", nil, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	followResponse = FollowsResponse{}
+	err = json.Unmarshal(w.Body.Bytes(), &followResponse)
+	assert.NoError(t, err)
+	assert.Len(t, followResponse.Data.Follows, 1)
+	assert.NotEmpty(t, followResponse.NextCursor)
+
+	w = makeFollowRequest(t, "GET", "/users_follow/1/follows/followers?limit=1&cursor="+followResponse.NextCursor, nil, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	followResponse = FollowsResponse{}
+	err = json.Unmarshal(w.Body.Bytes(), &followResponse)
+	assert.NoError(t, err)
+	assert.Len(t, followResponse.Data.Follows, 0)
+	assert.Empty(t, followResponse.NextCursor)
+}
+
+func TestUnfollow(t *testing.T) {
+	db, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{})
+	if err != nil {
+		t.Fatalf("failed to create DB: %v", err)
+	}
+	cacheClient := getMockFollowCache()
+
+	conn, err := db.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	defer conn.Close()
+
+	router := setupFollowRouter(conn, cacheClient)
+
+	w := makeFollowRequest(t, "POST", "/users/create", map[string]interface{}{"name": "Mauricio Giaconia", "email": "maurigiaconia@hotmail.com", "password": "1223"}, router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeFollowRequest(t, "POST", "/users/create", map[string]interface{}{"name": "Juan Perez", "email": "juanperez@hotmail.com", "password": "4567"}, router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	firstUserToken := loginUser(t, "maurigiaconia@hotmail.com", "1223", router)
+	otherUserToken := loginUser(t, "juanperez@hotmail.com", "4567", router)
+
+	w = makeAuthRequest(t, "POST", "/users_follow/create", FollowCreationRequest{FollowedId: 1}, otherUserToken, router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/tweets/create", map[string]interface{}{"content": "Tweet antes del unfollow"}, firstUserToken, router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	// Se consultan el timeline y los seguidores para que queden cacheados antes del unfollow
+	w = makeAuthRequest(t, "GET", "/tweets/2/timeline", nil, otherUserToken, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+	assert.Contains(t, w.Body.String(), "Tweet antes del unfollow")
+
+	w = makeFollowRequest(t, "GET", "/users_follow/1/follows/followers", nil, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	// Con limit=1 la pagina queda completa, que es la unica que se retorna desde el cache
+	w = makeFollowRequest(t, "GET", "/users_follow/1/follows/followers?limit=1", nil, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+	assert.Contains(t, w.Body.String(), "Juan Perez")
+
+	// Solo el propio follower puede dejar de seguir
+	w = makeAuthRequest(t, "DELETE", "/users_follow/2/1", nil, firstUserToken, router)
+	assert.Equal(t, int64(http.StatusForbidden), int64(w.Code))
+
+	w = makeAuthRequest(t, "DELETE", "/users_follow/2/1", nil, otherUserToken, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	w = makeAuthRequest(t, "GET", "/tweets/2/timeline", nil, otherUserToken, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+	assert.NotContains(t, w.Body.String(), "Tweet antes del unfollow")
+
+	var followResponse FollowsResponse
+	w = makeFollowRequest(t, "GET", "/users_follow/1/follows/followers", nil, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+	err = json.Unmarshal(w.Body.Bytes(), &followResponse)
+	assert.NoError(t, err)
+	assert.Len(t, followResponse.Data.Follows, 0)
+
+	w = makeFollowRequest(t, "GET", "/users_follow/1/follows/followers?limit=1", nil, router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+	assert.NotContains(t, w.Body.String(), "Juan Perez")
+
+	var errorResponse utils.ErrorResponse
+	w = makeAuthRequest(t, "DELETE", "/users_follow/2/1", nil, otherUserToken, router)
+	assert.Equal(t, int64(http.StatusNotFound), int64(w.Code))
+	err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	assert.NoError(t, err)
+	assert.Equal(t, "Follow not found", errorResponse.Error)
+
+	w = makeAuthRequest(t, "DELETE", "/users_follow/abc/1", nil, otherUserToken, router)
+	assert.Equal(t, int64(http.StatusBadRequest), int64(w.Code))
 }
 
-func setupFollowRouter(db *sql.DB, rdb *redis.Client) *gin.Engine {
+func setupFollowRouter(db *sql.DB, cacheClient cache.Cache) *gin.Engine {
 	router := gin.Default()
-	routes.SetupRoutes(router, db, rdb)
+	routes.SetupRoutes(router, db, cacheClient)
 	return router
 }
 
-func getMockFollowRedis() *redis.Client {
+// getMockFollowCache usa Redis si hay un servidor disponible y, si no, el cache en memoria.
+// En ambos casos el cache arranca vacio para no mezclar feeds de otras ejecuciones
+func getMockFollowCache() cache.Cache {
 
 	var ctx = context.Background()
 
@@ -172,11 +280,13 @@ func getMockFollowRedis() *redis.Client {
 
 	_, err := client.Ping(ctx).Result()
 	if err != nil {
-		fmt.Printf("error conectando a Redis: %v\n", err)
-		return nil
+		fmt.Printf("error conectando a Redis, se usa el cache en memoria: %v\n", err)
+		return cache.NewMemoryCache(cache.DefaultMemoryCacheSize)
 	}
 
-	return client
+	client.FlushDB(ctx)
+
+	return cache.NewRedisCache(client)
 }
 
 // Funcion utilziada para realizar requests necesarias para el test (por ejemplo, si se necesita crear un usuario para poder testear los endpoints de tweets)
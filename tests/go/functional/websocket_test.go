@@ -0,0 +1,272 @@
+package functional
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/models"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/routes"
+	"github.com/MauricioGiaconia/uala_backend_challenge/internal/services"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/cache"
+	"github.com/MauricioGiaconia/uala_backend_challenge/pkg/factory"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+)
+
+// realtimeEvent es el evento recibido por el WebSocket, con la data sin decodificar
+type realtimeEvent struct {
+	Type    string          `json:"type"`
+	Channel string          `json:"channel"`
+	Data    json.RawMessage `json:"data"`
+	Error   string          `json:"error"`
+}
+
+func TestWebSocketGateway(t *testing.T) {
+	caches := []struct {
+		name        string
+		cacheClient cache.Cache
+	}{
+		{"default cache", getMockCache()},
+		{"without cache", nil},
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			testWebSocketGateway(t, tc.cacheClient)
+		})
+	}
+}
+
+func testWebSocketGateway(t *testing.T, cacheClient cache.Cache) {
+	db, err := factory.GetDatabase("sqlite", factory.DatabaseOptions{})
+	if err != nil {
+		t.Fatalf("failed to create DB: %v", err)
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		t.Fatalf("failed to connect DB: %v", err)
+	}
+
+	defer conn.Close()
+
+	router := gin.Default()
+	shutdown := routes.SetupRoutes(router, conn, cacheClient)
+
+	// El WebSocket necesita un servidor real para mantener la conexion abierta mientras se realizan las acciones
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	users := []map[string]interface{}{
+		{"name": "Mauricio Giaconia", "email": "maurigiaconia@hotmail.com", "password": "1223", "handle": "mauri"},
+		{"name": "Juan Perez", "email": "juanperez@hotmail.com", "password": "4567"},
+		{"name": "Ana Lopez", "email": "analopez@hotmail.com", "password": "8910", "isPrivate": true},
+	}
+	tokens := []string{}
+	for _, user := range users {
+		w := makeRequest(t, "POST", "/users/create", user, router)
+		assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+		tokens = append(tokens, loginUser(t, user["email"].(string), user["password"].(string), router))
+	}
+
+	w := makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Tweet de Mauricio"}, tokens[0], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Tweet de una cuenta privada"}, tokens[2], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	// Sin token no se abre el WebSocket
+	w = makeRequest(t, "GET", "/ws", nil, router)
+	assert.Equal(t, int64(http.StatusUnauthorized), int64(w.Code))
+
+	ws := dialWebSocket(t, server.URL, tokens[0])
+	defer ws.Close()
+
+	subscriptions := []struct {
+		message   string
+		eventType string
+		channel   string
+		error     string
+	}{
+		{`{"action":"subscribe","channel":"notifications"}`, "subscribed", "notifications", ""},
+		{`{"action":"subscribe","channel":"user:1"}`, "subscribed", "user:1", ""},
+		{`{"action":"subscribe","channel":"tweet:1"}`, "subscribed", "tweet:1", ""},
+		{`{"action":"subscribe","channel":"tweet:2"}`, "error", "tweet:2", "This account is private"},
+		{`{"action":"subscribe","channel":"tweet:99"}`, "error", "tweet:99", "Nonexistent tweet"},
+		{`{"action":"subscribe","channel":"user:99"}`, "error", "user:99", "Nonexistent user"},
+		{`{"action":"subscribe","channel":"tweets"}`, "error", "tweets", "Invalid channel"},
+		{`{"action":"unsubscribe","channel":"user:2"}`, "error", "user:2", "Not subscribed to channel"},
+		{`{"action":"follow","channel":"user:2"}`, "error", "user:2", "Invalid action"},
+		{`hola`, "error", "", "Invalid message"},
+	}
+
+	for _, tc := range subscriptions {
+		sendWebSocketMessage(t, ws, tc.message)
+
+		event := nextRealtimeEvent(t, ws)
+		assert.Equal(t, tc.eventType, event.Type, tc.message)
+		assert.Equal(t, tc.channel, event.Channel, tc.message)
+		assert.Equal(t, tc.error, event.Error, tc.message)
+	}
+
+	// Al seguir al usuario se notifica al seguido y se actualiza su cantidad de seguidores
+	w = makeAuthRequest(t, "POST", "/users_follow/create", map[string]interface{}{"followedId": 1}, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	events := nextRealtimeEvents(t, ws, 2)
+
+	var followersCount models.FollowersCount
+	assert.NoError(t, json.Unmarshal(events["followers_count"].Data, &followersCount))
+	assert.Equal(t, models.FollowersCount{UserID: 1, FollowersCount: 1}, followersCount)
+
+	assertRealtimeNotification(t, events["notification"], "follow", 2, 0)
+
+	// Los likes y las respuestas actualizan los contadores del tweet y se notifican a su autor
+	w = makeAuthRequest(t, "POST", "/tweets/1/like", nil, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	events = nextRealtimeEvents(t, ws, 2)
+	assertTweetCounters(t, events["tweet_counters"], models.TweetCounters{TweetID: 1, LikeCount: 1, ReplyCount: 0})
+	assertRealtimeNotification(t, events["notification"], "like", 2, 1)
+
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateReplyRequest{Content: "Respuesta de Juan", InReplyToTweetID: 1}, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	events = nextRealtimeEvents(t, ws, 2)
+	assertTweetCounters(t, events["tweet_counters"], models.TweetCounters{TweetID: 1, LikeCount: 1, ReplyCount: 1})
+	assertRealtimeNotification(t, events["notification"], "reply", 2, 3)
+
+	// Las menciones se notifican, salvo que el usuario mencionado no pueda ver el tweet
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Hola @mauri"}, tokens[1], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	assertRealtimeNotification(t, nextRealtimeEvent(t, ws), "mention", 2, 4)
+
+	w = makeAuthRequest(t, "POST", "/tweets/create", CreateTweetRequest{Content: "Hola @mauri desde una cuenta privada"}, tokens[2], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	// Las acciones sobre los propios tweets no se notifican, y tras desuscribirse no llegan mas contadores del tweet
+	sendWebSocketMessage(t, ws, `{"action":"unsubscribe","channel":"tweet:1"}`)
+	event := nextRealtimeEvent(t, ws)
+	assert.Equal(t, "unsubscribed", event.Type)
+
+	w = makeAuthRequest(t, "POST", "/tweets/1/like", nil, tokens[0], router)
+	assert.Equal(t, int64(http.StatusCreated), int64(w.Code))
+
+	w = makeAuthRequest(t, "DELETE", "/users_follow/2/1", nil, tokens[1], router)
+	assert.Equal(t, int64(http.StatusOK), int64(w.Code))
+
+	event = nextRealtimeEvent(t, ws)
+	assert.Equal(t, "followers_count", event.Type)
+	assert.NoError(t, json.Unmarshal(event.Data, &followersCount))
+	assert.Equal(t, models.FollowersCount{UserID: 1, FollowersCount: 0}, followersCount)
+
+	// El servidor envia pings periodicos que el cliente responde con pong
+	pingInterval := services.RealtimePingInterval
+	services.RealtimePingInterval = 50 * time.Millisecond
+	pingWs := dialWebSocket(t, server.URL, tokens[1])
+	defer pingWs.Close()
+
+	event = nextRealtimeEvent(t, pingWs)
+	services.RealtimePingInterval = pingInterval
+	assert.Equal(t, "ping", event.Type)
+	sendWebSocketMessage(t, pingWs, `{"action":"pong"}`)
+
+	// Al apagar el servidor se cierran las conexiones y no se aceptan nuevas
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, shutdown(ctx))
+
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var message string
+	for websocket.Message.Receive(ws, &message) == nil {
+	}
+
+	w = makeAuthRequest(t, "GET", "/ws", nil, tokens[0], router)
+	assert.Equal(t, int64(http.StatusServiceUnavailable), int64(w.Code))
+}
+
+// dialWebSocket abre el WebSocket autenticado con el token
+func dialWebSocket(t *testing.T, serverUrl string, token string) *websocket.Conn {
+	config, err := websocket.NewConfig(strings.Replace(serverUrl, "http", "ws", 1)+"/ws", "http://localhost/")
+	if err != nil {
+		t.Fatalf("Error creating WebSocket config: %v", err)
+	}
+
+	config.Header.Set("Authorization", "Bearer "+token)
+
+	ws, err := websocket.DialConfig(config)
+	if err != nil {
+		t.Fatalf("Error opening WebSocket: %v", err)
+	}
+
+	return ws
+}
+
+func sendWebSocketMessage(t *testing.T, ws *websocket.Conn, message string) {
+	if err := websocket.Message.Send(ws, message); err != nil {
+		t.Fatalf("Error sending WebSocket message: %v", err)
+	}
+}
+
+// nextRealtimeEvent espera el siguiente evento del WebSocket
+func nextRealtimeEvent(t *testing.T, ws *websocket.Conn) realtimeEvent {
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	var message string
+	if err := websocket.Message.Receive(ws, &message); err != nil {
+		t.Fatalf("Error receiving WebSocket event: %v", err)
+	}
+
+	var event realtimeEvent
+	if err := json.Unmarshal([]byte(message), &event); err != nil {
+		t.Fatalf("Error decoding WebSocket event %s: %v", message, err)
+	}
+
+	return event
+}
+
+// nextRealtimeEvents espera los siguientes eventos de distintos canales, que pueden llegar en cualquier orden, y los retorna por tipo
+func nextRealtimeEvents(t *testing.T, ws *websocket.Conn, count int) map[string]realtimeEvent {
+	events := map[string]realtimeEvent{}
+	for i := 0; i < count; i++ {
+		event := nextRealtimeEvent(t, ws)
+		events[event.Type] = event
+	}
+
+	return events
+}
+
+func assertRealtimeNotification(t *testing.T, event realtimeEvent, notificationType string, actorId int64, tweetId int64) {
+	assert.Equal(t, "notification", event.Type)
+	assert.Equal(t, "notifications", event.Channel)
+
+	var notification models.Notification
+	assert.NoError(t, json.Unmarshal(event.Data, &notification))
+	assert.Equal(t, notificationType, notification.Type)
+	assert.Equal(t, actorId, notification.ActorID)
+
+	if tweetId == 0 {
+		assert.Nil(t, notification.TweetID)
+		return
+	}
+
+	if assert.NotNil(t, notification.TweetID) {
+		assert.Equal(t, tweetId, *notification.TweetID)
+	}
+}
+
+func assertTweetCounters(t *testing.T, event realtimeEvent, expected models.TweetCounters) {
+	assert.Equal(t, "tweet_counters", event.Type)
+	assert.Equal(t, "tweet:1", event.Channel)
+
+	var counters models.TweetCounters
+	assert.NoError(t, json.Unmarshal(event.Data, &counters))
+	assert.Equal(t, expected, counters)
+}